@@ -32,22 +32,36 @@ func (v *checkCommitsCommand) Command() *cobra.Command {
 	v.cmd.Flags().String("report-typos",
 		"",
 		"way to display typos (none, warn, error)")
+	v.cmd.Flags().String("report-punctuation",
+		"",
+		"way to report punctuation and whitespace differences (none, warn, error; default none)")
 	v.cmd.Flags().String("report-file-locations",
 		"",
 		"way to report file-location issues (none, warn, error)")
 	v.cmd.Flags().Bool("no-check-filter",
 		false,
 		"skip PO .gitattributes filter check and msgcat format comparison")
+	v.cmd.Flags().String("output-format",
+		util.ReportFormatText,
+		"write findings to stdout as json or sarif, instead of text to stderr")
 	_ = viper.BindPFlag("check-commits--no-gpg", v.cmd.Flags().Lookup("no-gpg"))
 	_ = viper.BindPFlag("check-commits--force", v.cmd.Flags().Lookup("force"))
 	_ = viper.BindPFlag("check-commits--report-typos", v.cmd.Flags().Lookup("report-typos"))
+	_ = viper.BindPFlag("check-commits--report-punctuation", v.cmd.Flags().Lookup("report-punctuation"))
 	_ = viper.BindPFlag("check-commits--report-file-locations", v.cmd.Flags().Lookup("report-file-locations"))
 	_ = viper.BindPFlag("check-commits--no-check-filter", v.cmd.Flags().Lookup("no-check-filter"))
+	_ = viper.BindPFlag("check-commits--output-format", v.cmd.Flags().Lookup("output-format"))
 	return v.cmd
 }
 
 func (v checkCommitsCommand) Execute(args []string) error {
-	if !util.CmdCheckCommits(args...) {
+	ok, err := runWithOutputFormat("check-commits", viper.GetString("check-commits--output-format"), func() bool {
+		return util.CmdCheckCommits(args...)
+	})
+	if err != nil {
+		return err
+	}
+	if !ok {
 		return NewStandardError("check-commits command failed")
 	}
 	return nil
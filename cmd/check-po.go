@@ -1,6 +1,11 @@
 package cmd
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/git-l10n/git-po-helper/flag"
 	"github.com/git-l10n/git-po-helper/util"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -28,27 +33,114 @@ func (v *checkPoCommand) Command() *cobra.Command {
 	v.cmd.Flags().String("report-typos",
 		"",
 		"way to display typos (none, warn, error)")
+	v.cmd.Flags().String("report-punctuation",
+		"",
+		"way to report punctuation and whitespace differences (none, warn, error; default none)")
 	v.cmd.Flags().String("report-file-locations",
 		"",
 		"way to report file-location issues (none, warn, error)")
+	v.cmd.Flags().Bool("consistency",
+		false,
+		"also report msgids translated differently and msgstrs shared by different msgids")
 	v.cmd.Flags().Bool("no-check-filter",
 		false,
 		"skip PO .gitattributes filter check and msgcat format comparison")
+	v.cmd.Flags().Bool("list-checks",
+		false,
+		"list the checks and their levels (for the language of the optional <XX.po>), and exit")
+	v.cmd.Flags().StringSlice("only",
+		nil,
+		"run only these checks, even if disabled in config (comma-separated names)")
+	v.cmd.Flags().StringSlice("skip",
+		nil,
+		"do not run these checks (comma-separated names)")
+	v.cmd.Flags().String("output-format",
+		util.ReportFormatText,
+		"write findings to stdout as json or sarif, instead of text to stderr")
 	_ = viper.BindPFlag("check-po--core", v.cmd.Flags().Lookup("core"))
 	_ = viper.BindPFlag("check-po--report-typos", v.cmd.Flags().Lookup("report-typos"))
+	_ = viper.BindPFlag("check-po--report-punctuation", v.cmd.Flags().Lookup("report-punctuation"))
 	_ = viper.BindPFlag("check-po--report-file-locations", v.cmd.Flags().Lookup("report-file-locations"))
+	_ = viper.BindPFlag("check-po--consistency", v.cmd.Flags().Lookup("consistency"))
 	_ = viper.BindPFlag("check-po--no-check-filter", v.cmd.Flags().Lookup("no-check-filter"))
+	_ = viper.BindPFlag("check-po--list-checks", v.cmd.Flags().Lookup("list-checks"))
+	_ = viper.BindPFlag("check-po--only", v.cmd.Flags().Lookup("only"))
+	_ = viper.BindPFlag("check-po--skip", v.cmd.Flags().Lookup("skip"))
+	_ = viper.BindPFlag("check-po--output-format", v.cmd.Flags().Lookup("output-format"))
 
 	return v.cmd
 }
 
 func (v checkPoCommand) Execute(args []string) error {
-	if !util.CmdCheckPo(args...) {
+	only := viper.GetStringSlice("check-po--only")
+	skip := viper.GetStringSlice("check-po--skip")
+	for _, names := range [][]string{only, skip} {
+		if err := util.ValidatePoCheckNames(names); err != nil {
+			return NewErrorWithUsage(err.Error())
+		}
+	}
+	for _, name := range only {
+		for _, skipped := range skip {
+			if name == skipped {
+				return NewErrorWithUsageF("check %q is given to both --only and --skip", name)
+			}
+		}
+	}
+
+	if viper.GetBool("check-po--list-checks") {
+		if len(args) > 1 {
+			return NewErrorWithUsage("--list-checks accepts at most one <XX.po>")
+		}
+		locale := ""
+		if len(args) == 1 {
+			locale = strings.TrimSuffix(filepath.Base(args[0]), ".po")
+		}
+		if err := util.ListPoChecks(os.Stdout, locale); err != nil {
+			return NewStandardErrorF("fail to list checks: %s", err)
+		}
+		return nil
+	}
+
+	ok, err := runWithOutputFormat("check-po", viper.GetString("check-po--output-format"), func() bool {
+		return util.CmdCheckPo(args...)
+	})
+	if err != nil {
+		return err
+	}
+	if !ok {
 		return NewStandardError("check-po command failed")
 	}
 	return nil
 }
 
+// runWithOutputFormat runs the checks of command with run, and writes their
+// findings to stdout in format (see --output-format), unless it is text. In
+// GitHub Actions, the text report is followed by workflow annotations and a
+// job summary.
+func runWithOutputFormat(command, format string, run func() bool) (bool, error) {
+	switch format {
+	case "", util.ReportFormatText:
+		if flag.GitHubActionEvent() == "" {
+			return run(), nil
+		}
+		util.CollectReportFindings(true)
+		ok := run()
+		if err := util.WriteGitHubAnnotations(os.Stdout, command, ok); err != nil {
+			return false, NewStandardErrorF("fail to write GitHub annotations: %s", err)
+		}
+		return ok, nil
+	case util.ReportFormatJSON, util.ReportFormatSARIF:
+	default:
+		return false, NewErrorWithUsageF("bad --output-format %q (use text, json or sarif)", format)
+	}
+	util.CollectReportFindings(false)
+	ok := run()
+	if err := util.WriteReportFindings(os.Stdout, format, ok); err != nil {
+		return false, NewStandardErrorF("fail to write findings: %s", err)
+	}
+	return ok, nil
+}
+
 var checkPoCmd = checkPoCommand{}
 
 func init() {
@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/git-l10n/git-po-helper/util"
+	"github.com/mattn/go-isatty"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -24,6 +26,8 @@ type compareCommand struct {
 		Output          string
 		NoHeader        bool
 		JSON            bool
+		Format          string
+		Color           string
 	}
 }
 
@@ -47,7 +51,8 @@ Assert modes (for CI; mutually exclusive with --stat):
 - --assert-changes: fail if there are no new or changed entries
 
 If no po/XX.po argument is given, the PO file is selected from changed files
-(interactive when multiple, auto when single).
+(interactive when multiple, auto when single). Files may also be gettext JSON
+or compiled .mo files (format is auto-detected by content).
 
 Modes:
 - --commit <commit>: compare parent of commit with the specified commit
@@ -55,7 +60,21 @@ Modes:
 - no --commit/--since: compare HEAD with current working tree (local changes)
 
 Exactly one of --range, --commit and --since may be specified.
-Output is empty when there are no new or changed entries.`,
+Output is empty when there are no new or changed entries.
+
+Output formats (--format):
+- po: new or changed entries as PO (default)
+- json: new or changed entries as gettext JSON (same as --json)
+- word-diff: PO lines of each entry with deleted and inserted words marked
+  as [-old-]{+new+}, or colored on a terminal
+- unified: unified diff of the PO lines of each entry; on a terminal the
+  changed words of a line are highlighted
+- html: standalone HTML page with <del> and <ins> for changed words
+
+Diff formats compare msgstr and flags, and msgid too when it changed in the
+template (a fuzzy entry is compared to the entry of its previous msgid).
+CJK text is compared character by character. Colors are used when writing to
+a terminal; use --color=always|never to override.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return v.Execute(args)
 		},
@@ -74,6 +93,10 @@ Output is empty when there are no new or changed entries.`,
 		"write output to file (use - for stdout); empty output overwrites file")
 	v.cmd.Flags().BoolVar(&v.O.NoHeader, "no-header", false, "omit header from output (empty header in PO)")
 	v.cmd.Flags().BoolVar(&v.O.JSON, "json", false, "output JSON instead of PO when there are new or changed entries")
+	v.cmd.Flags().StringVar(&v.O.Format, "format", "",
+		"output format: "+strings.Join(util.CompareFormats, ", ")+" (default: po)")
+	v.cmd.Flags().StringVar(&v.O.Color, "color", "auto",
+		"color diff output: auto, always or never")
 
 	_ = viper.BindPFlag("compare--range", v.cmd.Flags().Lookup("range"))
 	_ = viper.BindPFlag("compare--commit", v.cmd.Flags().Lookup("commit"))
@@ -97,6 +120,26 @@ func (v compareCommand) Execute(args []string) error {
 		return NewErrorWithUsageF("--stat cannot be used with --assert-no-changes or --assert-changes")
 	}
 
+	format := v.O.Format
+	if v.O.JSON {
+		if format != "" && format != util.CompareFormatJSON {
+			return NewErrorWithUsageF("--json cannot be used with --format %s", format)
+		}
+		format = util.CompareFormatJSON
+	}
+	if format == "" {
+		format = util.CompareFormatPO
+	}
+	if !util.IsCompareFormat(format) {
+		return NewErrorWithUsageF("invalid --format %q (use %s)", format, strings.Join(util.CompareFormats, ", "))
+	}
+	switch v.O.Color {
+	case "", "auto", "always", "never":
+	default:
+		return NewErrorWithUsageF("invalid --color %q (use auto, always or never)", v.O.Color)
+	}
+	v.O.Format = format
+
 	if v.O.Stat {
 		return v.executeStat(target.OldCommit, target.OldFile, target.NewCommit, target.NewFile)
 	}
@@ -122,7 +165,14 @@ func (v compareCommand) executeNew(oldCommit, oldFile, newCommit, newFile string
 
 	log.Debugf("outputting new entries from '%s:%s' to '%s:%s'",
 		oldCommit, oldFile, newCommit, newFile)
-	err := util.PrepareReviewData(oldCommit, oldFile, newCommit, newFile, outputDest, v.O.NoHeader, v.O.JSON, v.O.MsgIDOnly)
+	var err error
+	if util.IsCompareDiffFormat(v.O.Format) {
+		color := v.O.Color == "always" ||
+			(v.O.Color != "never" && outputDest == "-" && isatty.IsTerminal(os.Stdout.Fd()))
+		err = util.PrepareReviewDiff(oldCommit, oldFile, newCommit, newFile, outputDest, v.O.Format, v.O.MsgIDOnly, color)
+	} else {
+		err = util.PrepareReviewData(oldCommit, oldFile, newCommit, newFile, outputDest, v.O.NoHeader, v.O.Format == util.CompareFormatJSON, v.O.MsgIDOnly)
+	}
 	if err != nil {
 		return NewStandardErrorF("failed to prepare review data: %v", err)
 	}
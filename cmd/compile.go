@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/git-l10n/git-po-helper/util"
+	"github.com/spf13/cobra"
+)
+
+type compileCommand struct {
+	cmd *cobra.Command
+	O   struct {
+		Output     string
+		UseFuzzy   bool
+		Check      bool
+		Statistics bool
+	}
+}
+
+func (v *compileCommand) Command() *cobra.Command {
+	if v.cmd != nil {
+		return v.cmd
+	}
+
+	v.cmd = &cobra.Command{
+		Use:   "compile [-o <file.mo>] <XX.po>",
+		Short: "Compile PO file to binary .mo file (msgfmt replacement)",
+		Long: `Compile a PO file into a binary .mo message catalog without calling msgfmt.
+
+The PO file is validated by the built-in validator first: syntax errors,
+duplicate message definitions and misplaced plural sections are always fatal.
+Use --check to also check the header and format strings (like msgfmt --check).
+
+Like msgfmt, untranslated and obsolete entries, and plural entries with an
+empty msgstr[0], are not written. Fuzzy entries are skipped unless --use-fuzzy
+is given, but the header entry is written even if it is fuzzy.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return v.Execute(args)
+		},
+	}
+	v.cmd.Flags().StringVarP(&v.O.Output, "output", "o", "messages.mo",
+		"write output to specified file")
+	v.cmd.Flags().BoolVar(&v.O.UseFuzzy, "use-fuzzy", false,
+		"use fuzzy entries in output")
+	v.cmd.Flags().BoolVarP(&v.O.Check, "check", "c", false,
+		"check header and format strings (like msgfmt --check)")
+	v.cmd.Flags().BoolVar(&v.O.Statistics, "statistics", false,
+		"print statistics about translations")
+
+	return v.cmd
+}
+
+func (v compileCommand) Execute(args []string) error {
+	if len(args) != 1 {
+		return NewErrorWithUsage("compile requires exactly one argument: <XX.po>")
+	}
+	opts := util.PoValidateOptions{CheckHeader: v.O.Check, CheckFormat: v.O.Check}
+	result, err := util.CompilePoFileToMo(args[0], v.O.Output, v.O.UseFuzzy, opts)
+	if result != nil {
+		for _, msg := range result.Messages {
+			fmt.Fprintln(os.Stderr, msg)
+		}
+	}
+	if err != nil {
+		return NewStandardErrorF("%v", err)
+	}
+	if v.O.Statistics {
+		fmt.Fprintln(os.Stderr, result.Summary())
+	}
+	return nil
+}
+
+var compileCmd = compileCommand{}
+
+func init() {
+	rootCmd.AddCommand(compileCmd.Command())
+}
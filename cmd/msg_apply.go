@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/git-l10n/git-po-helper/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type msgApplyCommand struct {
+	cmd *cobra.Command
+	O   struct {
+		Output     string
+		KeepLayout bool
+	}
+}
+
+func (v *msgApplyCommand) Command() *cobra.Command {
+	if v.cmd != nil {
+		return v.cmd
+	}
+
+	v.cmd = &cobra.Command{
+		Use:   "msg-apply [-o <output>] <po-file> <table-file>",
+		Short: "Apply translations edited in a CSV/TSV table to a PO file",
+		Long: `Apply the msgstr columns of a CSV or TSV table, as written by
+"msg-select --format csv" or "msg-cat --format tsv" and edited in a spreadsheet,
+back onto a PO file (or gettext JSON/XLIFF file), and write the result as PO.
+
+Rows are matched to entries by msgctxt, msgid and msgid_plural, like msg-cat
+matches duplicate entries, so rows may be sorted, filtered or removed, and
+columns other than msgid and msgstr[N] may be deleted. When the fuzzy column is
+kept, it sets the fuzzy state of the entry.
+
+The table format is detected by extension (.csv, .tsv) or by the header row.
+Rows whose msgid no longer exists in the PO file, changed rows of obsolete
+entries, duplicate rows, and rows with more msgstr forms than the entry are
+rejected and reported; the other rows are still applied, and the command fails.
+
+Write result to the file given by -o; use -o - or omit -o to write to stdout.
+
+Examples:
+  git-po-helper msg-select --fuzzy --format csv -o review.csv po/zh_CN.po
+  git-po-helper msg-apply --keep-layout -o po/zh_CN.po po/zh_CN.po review.csv`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return v.Execute(args)
+		},
+	}
+	fs := v.cmd.Flags()
+	fs.StringVarP(&v.O.Output, "output", "o", "",
+		"write output to file (use - for stdout); default is stdout")
+	fs.BoolVar(&v.O.KeepLayout, "keep-layout", false,
+		"write unmodified entries with their original lines, byte for byte")
+	_ = viper.BindPFlag("msg-apply--keep-layout", fs.Lookup("keep-layout"))
+
+	return v.cmd
+}
+
+func (v msgApplyCommand) Execute(args []string) error {
+	if len(args) != 2 {
+		return NewErrorWithUsage("msg-apply requires exactly two arguments: <po-file> <table-file>")
+	}
+	poFile, tableFile := args[0], args[1]
+	poData, err := os.ReadFile(poFile)
+	if err != nil {
+		return NewStandardErrorF("read %s: %v", poFile, err)
+	}
+	j, err := util.LoadFileToGettextJSON(poData, poFile)
+	if err != nil {
+		return NewStandardErrorF("%v", err)
+	}
+	tableData, err := os.ReadFile(tableFile)
+	if err != nil {
+		return NewStandardErrorF("read %s: %v", tableFile, err)
+	}
+	result, err := util.ApplyTableToGettextJSON(j, tableData, util.TableSeparator(tableFile, tableData))
+	if err != nil {
+		return NewStandardErrorF("%s: %v", tableFile, err)
+	}
+
+	// Read the input before creating the output, which may be the same file.
+	var w io.Writer = os.Stdout
+	if v.O.Output != "" && v.O.Output != "-" {
+		f, err := os.Create(v.O.Output)
+		if err != nil {
+			return NewStandardErrorF("failed to create output file %s: %v", v.O.Output, err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := util.WriteGettextJSONToPO(j, w, false, false); err != nil {
+		return NewStandardErrorF("%v", err)
+	}
+
+	for _, msg := range result.Rejected {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", tableFile, msg)
+	}
+	fmt.Fprintf(os.Stderr, "%d entries updated, %d rows rejected\n", result.Updated, len(result.Rejected))
+	if len(result.Rejected) > 0 {
+		return NewStandardErrorF("%d rows of %s rejected", len(result.Rejected), tableFile)
+	}
+	return nil
+}
+
+var msgApplyCmd = msgApplyCommand{}
+
+func init() {
+	rootCmd.AddCommand(msgApplyCmd.Command())
+}
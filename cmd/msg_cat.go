@@ -1,28 +1,43 @@
 package cmd
 
 import (
+	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/git-l10n/git-po-helper/flag"
 	"github.com/git-l10n/git-po-helper/util"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 type msgCatCommand struct {
 	cmd *cobra.Command
 	O   struct {
-		Output       string
-		JSON         bool
-		NoHeader     bool
-		Translated   bool
-		Untranslated bool
-		Fuzzy        bool
-		WithObsolete bool
-		NoObsolete   bool
-		OnlySame     bool
-		OnlyObsolete bool
-		UnsetFuzzy   bool
-		ClearFuzzy   bool
+		Output         string
+		JSON           bool
+		Format         string
+		XLIFFVersion   string
+		JSONVersion    int
+		NoHeader       bool
+		Translated     bool
+		Untranslated   bool
+		Fuzzy          bool
+		WithObsolete   bool
+		NoObsolete     bool
+		OnlySame       bool
+		OnlyObsolete   bool
+		UnsetFuzzy     bool
+		ClearFuzzy     bool
+		Width          int
+		NoWrap         bool
+		KeepLayout     bool
+		Prefer         string
+		ConflictReport string
+		FailOnConflict bool
+		CheckSplit     bool
 	}
 }
 
@@ -32,19 +47,43 @@ func (v *msgCatCommand) Command() *cobra.Command {
 	}
 
 	v.cmd = &cobra.Command{
-		Use:   "msg-cat -o <output> [--json] [inputfile]...",
-		Short: "Concatenate and merge PO/POT/JSON files",
-		Long: `Merge one or more input files (PO, POT, or gettext JSON) into a single output.
-Input files can have extension .po, .pot, or .json; format is auto-detected by content
-(starts with '{') or by extension. For duplicate msgid (and msgid_plural for plurals),
-the first occurrence by file order is kept.
+		Use:   "msg-cat -o <output> [--json | --format <format>] [inputfile]...",
+		Short: "Concatenate and merge PO/POT/JSON/XLIFF/MO files",
+		Long: `Merge one or more input files (PO, POT, gettext JSON, XLIFF, or .mo) into a single output.
+Input files can have extension .po, .pot, .json, .xlf, or .mo; format is auto-detected by content
+(starts with '{', an <xliff> document, or the .mo magic number) or by extension.
+Entries of .mo files are reconstructed with msgctxt, plural forms and the header. For duplicate msgid (and
+msgid_plural for plurals), the first occurrence by file order is kept; use --prefer to choose another:
+  first       the first occurrence by file order (default)
+  last        the last occurrence by file order
+  translated  the first occurrence with a translation, fuzzy or not
+  non-fuzzy   the first translated occurrence that is not fuzzy, else the first translated one
+  longest     the occurrence with the longest translation
+
+Entries translated differently in the inputs are reported on stderr, or written
+as JSON to the file given by --conflict-report. Inputs where an entry is
+untranslated do not conflict. With --fail-on-conflict, the command fails after
+writing the output if there are conflicts.
+
+Use --check-split to reassemble the chunks of "msg-split": the inputs must be
+all the chunks of one split, and together have exactly the entries that were
+split, or nothing is written. The split fields are removed from the header.
 
 By default, all entries are selected (translated, same, untranslated, fuzzy, obsolete).
 Use --translated, --untranslated, --fuzzy to filter by state (OR relationship).
 Use --no-obsolete to exclude obsolete; --only-same or --only-obsolete for a single state.
 
 Write result to the file given by -o; use -o - or omit -o to write to stdout.
-Use --json to output gettext JSON; otherwise output is PO format.`,
+Use --json to output gettext JSON; otherwise output is PO format. With
+--json-version 2, JSON output has plain text strings and structured comments
+(translator_comments, extracted_comments, references, flags, previous).
+Use --format xliff to output XLIFF (--xliff-version 1.2 or 2.0) for CAT tools.
+Use --format csv or --format tsv to output a table for spreadsheets (see msg-apply).
+
+PO output regenerates each entry from its fields. Use --keep-layout to write
+unmodified entries with their original lines, so that only changed entries show
+up in diffs. Use --width=79 to wrap long lines like msgcat does by default;
+without --width (or with --no-wrap), lines are only broken after embedded newlines.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return v.Execute(args)
 		},
@@ -56,11 +95,35 @@ Use --json to output gettext JSON; otherwise output is PO format.`,
 	// General options
 	fs.StringVarP(&v.O.Output, "output", "o", "",
 		"write output to file (use - for stdout); default is stdout")
-	fs.BoolVar(&v.O.JSON, "json", false, "output JSON instead of PO text")
+	fs.BoolVar(&v.O.JSON, "json", false, "output JSON instead of PO text (same as --format json)")
+	fs.StringVar(&v.O.Format, "format", "", "output format: po (default), json, xliff, csv, or tsv")
+	fs.StringVar(&v.O.XLIFFVersion, "xliff-version", "", "XLIFF version for --format xliff: 1.2 (default) or 2.0")
+	fs.IntVar(&v.O.JSONVersion, "json-version", 0, "gettext JSON schema version for JSON output: 1 (default) or 2")
 	fs.BoolVar(&v.O.NoHeader, "no-header", false, "omit header from output (empty header in PO/JSON)")
 	_ = fs.SetAnnotation("output", "group", []string{"General options"})
 	_ = fs.SetAnnotation("json", "group", []string{"General options"})
+	_ = fs.SetAnnotation("format", "group", []string{"General options"})
+	_ = fs.SetAnnotation("xliff-version", "group", []string{"General options"})
+	_ = fs.SetAnnotation("json-version", "group", []string{"General options"})
 	_ = fs.SetAnnotation("no-header", "group", []string{"General options"})
+	_ = viper.BindPFlag("msg-cat--xliff-version", fs.Lookup("xliff-version"))
+	_ = viper.BindPFlag("msg-cat--json-version", fs.Lookup("json-version"))
+
+	// Conflicts between inputs
+	fs.StringVar(&v.O.Prefer, "prefer", util.MergePreferFirst,
+		"which duplicate entry to keep: "+strings.Join(util.MergePreferStrategies, ", "))
+	fs.StringVar(&v.O.ConflictReport, "conflict-report", "",
+		"write conflicting translations as JSON to this file instead of stderr")
+	fs.BoolVar(&v.O.FailOnConflict, "fail-on-conflict", false,
+		"fail if inputs translate an entry differently")
+	_ = fs.SetAnnotation("prefer", "group", []string{"Conflicts"})
+	_ = fs.SetAnnotation("conflict-report", "group", []string{"Conflicts"})
+	_ = fs.SetAnnotation("fail-on-conflict", "group", []string{"Conflicts"})
+
+	// Chunks of msg-split
+	fs.BoolVar(&v.O.CheckSplit, "check-split", false,
+		"check that the inputs are all the chunks of msg-split, covering the split entries")
+	_ = fs.SetAnnotation("check-split", "group", []string{"Split chunks"})
 
 	// State filter: translated, untranslated, fuzzy (OR when combined)
 	fs.BoolVar(&v.O.Translated, "translated", false, "select translated entries")
@@ -90,6 +153,20 @@ Use --json to output gettext JSON; otherwise output is PO format.`,
 	_ = fs.SetAnnotation("unset-fuzzy", "group", []string{"Others"})
 	_ = fs.SetAnnotation("clear-fuzzy", "group", []string{"Others"})
 
+	// Output layout (PO output only)
+	fs.IntVar(&v.O.Width, "width", 0,
+		"wrap long lines at this page width like msgcat --width (default: no wrapping)")
+	fs.BoolVar(&v.O.NoWrap, "no-wrap", false,
+		"do not wrap long lines; only break after embedded newlines (default)")
+	fs.BoolVar(&v.O.KeepLayout, "keep-layout", false,
+		"write unmodified entries with their original lines, byte for byte")
+	_ = fs.SetAnnotation("width", "group", []string{"Output layout"})
+	_ = fs.SetAnnotation("no-wrap", "group", []string{"Output layout"})
+	_ = fs.SetAnnotation("keep-layout", "group", []string{"Output layout"})
+	_ = viper.BindPFlag("msg-cat--width", fs.Lookup("width"))
+	_ = viper.BindPFlag("msg-cat--no-wrap", fs.Lookup("no-wrap"))
+	_ = viper.BindPFlag("msg-cat--keep-layout", fs.Lookup("keep-layout"))
+
 	// Custom usage template with grouped flags
 	v.cmd.SetUsageTemplate(`Usage:{{if .Runnable}}
   {{.UseLine}}{{end}}{{if .HasAvailableSubCommands}}
@@ -126,10 +203,18 @@ func (v msgCatCommand) Execute(args []string) error {
 	if v.O.UnsetFuzzy && v.O.ClearFuzzy {
 		return NewErrorWithUsage("--unset-fuzzy and --clear-fuzzy are mutually exclusive")
 	}
+	if !util.IsMergePreferStrategy(v.O.Prefer) {
+		return NewErrorWithUsageF("invalid --prefer %q (use %s)",
+			v.O.Prefer, strings.Join(util.MergePreferStrategies, ", "))
+	}
 	filter, err := v.buildFilter()
 	if err != nil {
 		return err
 	}
+	format, err := resolveOutputFormat(v.O.Format, v.O.JSON, v.O.XLIFFVersion, v.O.JSONVersion)
+	if err != nil {
+		return err
+	}
 
 	var w io.Writer = os.Stdout
 	if v.O.Output != "" && v.O.Output != "-" {
@@ -142,15 +227,63 @@ func (v msgCatCommand) Execute(args []string) error {
 	}
 
 	sources := make([]*util.GettextJSON, 0, len(args))
+	// JSON and XLIFF input come from agents and CAT tools, not from gettext.
+	var jsonSources []int
 	for _, path := range args {
-		j, err := util.ReadFileToGettextJSON(path)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return NewStandardErrorF("read %s: %v", path, err)
+		}
+		j, err := util.LoadFileToGettextJSON(data, path)
 		if err != nil {
 			return NewStandardErrorF("%v", err)
 		}
+		if util.IsGettextJSONData(data) || util.IsXLIFFData(data) {
+			jsonSources = append(jsonSources, len(sources))
+		}
 		sources = append(sources, j)
 	}
-	merged := util.MergeGettextJSON(sources)
 
+	// Refuse JSON or XLIFF input with a wrong number of msgstr[] forms.
+	// Plural-Forms comes from the JSON header, or from the first input when absent.
+	for _, i := range jsonSources {
+		var pf *util.PluralForms
+		if i > 0 {
+			if own, err := util.PluralFormsFromHeaderMeta(sources[i].HeaderMeta); own == nil && err == nil {
+				pf, _ = util.PluralFormsFromHeaderMeta(sources[0].HeaderMeta)
+			}
+		}
+		if err := util.CheckGettextJSONPluralForms(sources[i], pf); err != nil {
+			return NewStandardErrorF("%s: %v", args[i], err)
+		}
+	}
+	if v.O.CheckSplit {
+		if problems := util.CheckSplitChunks(sources, args); len(problems) > 0 {
+			for _, p := range problems {
+				fmt.Fprintln(os.Stderr, p)
+			}
+			return NewStandardErrorF("inputs are not the chunks of one msg-split")
+		}
+	}
+	merged, conflicts := util.MergeGettextJSONPrefer(sources, args, v.O.Prefer)
+	if v.O.CheckSplit {
+		merged.HeaderMeta = util.RemoveSplitHeaderFields(merged.HeaderMeta)
+	}
+	if err := v.reportConflicts(conflicts); err != nil {
+		return err
+	}
+	if err := v.write(merged, filter, format, w, args[0]); err != nil {
+		return err
+	}
+	if v.O.FailOnConflict && len(conflicts) > 0 {
+		return NewStandardErrorF("%d entries have conflicting translations", len(conflicts))
+	}
+	return nil
+}
+
+// write filters merged and writes it to w in format.
+func (v msgCatCommand) write(merged *util.GettextJSON, filter *util.EntryStateFilter,
+	format string, w io.Writer, firstInput string) error {
 	// Apply state filter
 	if filter != nil {
 		merged.Entries = util.FilterGettextEntries(merged.Entries, *filter)
@@ -169,7 +302,7 @@ func (v msgCatCommand) Execute(args []string) error {
 		return nil
 	}
 
-	if v.O.JSON {
+	if format != util.OutputFormatPO {
 		out := merged
 		if v.O.NoHeader {
 			out = &util.GettextJSON{
@@ -178,11 +311,46 @@ func (v msgCatCommand) Execute(args []string) error {
 				Entries:       merged.Entries,
 			}
 		}
+		switch format {
+		case util.OutputFormatXLIFF:
+			return util.WriteGettextJSONToXLIFF(out, w, flag.XLIFFVersion(), filepath.Base(firstInput))
+		case util.OutputFormatCSV:
+			return util.WriteGettextJSONToTable(out, w, ',')
+		case util.OutputFormatTSV:
+			return util.WriteGettextJSONToTable(out, w, '\t')
+		}
+		if v.O.JSONVersion == util.GettextJSONVersion2 {
+			return util.WriteGettextJSONToJSONV2(out, w)
+		}
 		return util.WriteGettextJSONToJSON(out, w)
 	}
 	return util.WriteGettextJSONToPO(merged, w, v.O.NoHeader, false)
 }
 
+// reportConflicts writes conflicts to the --conflict-report file as JSON, or
+// as text to stderr.
+func (v msgCatCommand) reportConflicts(conflicts []util.MergeConflict) error {
+	if v.O.ConflictReport == "" {
+		if err := util.WriteMergeConflicts(os.Stderr, conflicts); err != nil {
+			return NewStandardErrorF("%v", err)
+		}
+		return nil
+	}
+	f, err := os.Create(v.O.ConflictReport)
+	if err != nil {
+		return NewStandardErrorF("failed to create conflict report %s: %v", v.O.ConflictReport, err)
+	}
+	defer f.Close()
+	if err := util.WriteMergeConflictsJSON(f, conflicts); err != nil {
+		return NewStandardErrorF("%v", err)
+	}
+	if len(conflicts) > 0 {
+		fmt.Fprintf(os.Stderr, "%d entries have conflicting translations, see %s\n",
+			len(conflicts), v.O.ConflictReport)
+	}
+	return nil
+}
+
 func (v msgCatCommand) buildFilter() (*util.EntryStateFilter, error) {
 	if v.O.OnlySame && v.O.OnlyObsolete {
 		return nil, NewErrorWithUsage("--only-same and --only-obsolete are mutually exclusive")
@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+
+	"github.com/git-l10n/git-po-helper/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type msgEditCommand struct {
+	cmd *cobra.Command
+	O   struct {
+		Output         string
+		DryRun         bool
+		KeepLayout     bool
+		Range          string
+		Match          []string
+		FixedStrings   bool
+		IgnoreCase     bool
+		Translated     bool
+		Untranslated   bool
+		Fuzzy          bool
+		NoObsolete     bool
+		SetFuzzy       bool
+		UnsetFuzzy     bool
+		ClearMsgstr    bool
+		Replace        string
+		With           string
+		AddFlags       []string
+		RemoveFlags    []string
+		AddComments    []string
+		RemoveComments string
+	}
+}
+
+func (v *msgEditCommand) Command() *cobra.Command {
+	if v.cmd != nil {
+		return v.cmd
+	}
+
+	v.cmd = &cobra.Command{
+		Use:   "msg-edit [options] <po-file>",
+		Short: "Edit entries of a PO file in bulk",
+		Long: `Apply edit operations to the entries of a PO file (or gettext JSON, XLIFF or .mo
+file) and write the result as PO.
+
+Entries are selected by state filter, then by --range over the filtered entries
+(like msg-select), then by --match patterns (matched against msgid, msgid_plural
+and msgstr, like msg-grep). Without selection options, all entries are edited.
+
+Operations, applied in this order to each selected entry:
+  --set-fuzzy, --unset-fuzzy    mark or unmark the entry as fuzzy
+  --clear-msgstr                empty all msgstr forms (and unmark fuzzy)
+  --replace <re> --with <text>  replace a regular expression in msgstr;
+                                <text> may refer to submatches as $1 or ${name}
+  --add-flag, --remove-flag     add or remove a "#," flag, e.g. no-c-format
+  --add-comment                 add a translator comment ("# " line)
+  --remove-comment <re>         remove the translator comments matching <re>
+
+Use --dry-run to print a diff of every changed entry instead of writing the
+result. The number of changed entries is reported on stderr.
+
+Write result to the file given by -o; use -o - or omit -o to write to stdout.
+
+Examples:
+  # Preview a terminology fix, then apply it in place
+  git-po-helper msg-edit --dry-run --replace 贮藏 --with 储藏 po/zh_CN.po
+  git-po-helper msg-edit --keep-layout --replace 贮藏 --with 储藏 -o po/zh_CN.po po/zh_CN.po
+
+  # Mark all entries that mention "rebase" fuzzy for review
+  git-po-helper msg-edit --translated --match rebase --set-fuzzy -o po/zh_CN.po po/zh_CN.po`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return v.Execute(args)
+		},
+	}
+
+	fs := v.cmd.Flags()
+	fs.SortFlags = false
+
+	// General options
+	fs.StringVarP(&v.O.Output, "output", "o", "",
+		"write output to file (use - for stdout); default is stdout")
+	fs.BoolVar(&v.O.DryRun, "dry-run", false, "print a diff of the changed entries; do not write output")
+	fs.BoolVar(&v.O.KeepLayout, "keep-layout", false,
+		"write unmodified entries with their original lines, byte for byte")
+	_ = fs.SetAnnotation("output", "group", []string{"General options"})
+	_ = fs.SetAnnotation("dry-run", "group", []string{"General options"})
+	_ = fs.SetAnnotation("keep-layout", "group", []string{"General options"})
+	_ = viper.BindPFlag("msg-edit--keep-layout", fs.Lookup("keep-layout"))
+
+	// Entry selection
+	fs.StringVar(&v.O.Range, "range", "", "entry range to edit (e.g. 3,5,9-13), over the filtered entries")
+	fs.StringArrayVar(&v.O.Match, "match", nil,
+		"edit entries whose msgid or msgstr matches this pattern (may be given more than once)")
+	fs.BoolVarP(&v.O.FixedStrings, "fixed-strings", "F", false,
+		"--match and --replace patterns are fixed strings, not regular expressions")
+	fs.BoolVarP(&v.O.IgnoreCase, "ignore-case", "i", false, "ignore case in --match and --replace patterns")
+	fs.BoolVar(&v.O.Translated, "translated", false, "select translated entries (msgstr not empty, not fuzzy)")
+	fs.BoolVar(&v.O.Untranslated, "untranslated", false, "select untranslated entries (msgstr empty)")
+	fs.BoolVar(&v.O.Fuzzy, "fuzzy", false, "select fuzzy entries")
+	fs.BoolVar(&v.O.NoObsolete, "no-obsolete", false, "do not edit obsolete entries")
+	for _, name := range []string{"range", "match", "fixed-strings", "ignore-case",
+		"translated", "untranslated", "fuzzy", "no-obsolete"} {
+		_ = fs.SetAnnotation(name, "group", []string{"Entry selection"})
+	}
+
+	// Operations
+	fs.BoolVar(&v.O.SetFuzzy, "set-fuzzy", false, "mark entries as fuzzy")
+	fs.BoolVar(&v.O.UnsetFuzzy, "unset-fuzzy", false, "remove the fuzzy marker (keep translations)")
+	fs.BoolVar(&v.O.ClearMsgstr, "clear-msgstr", false, "empty msgstr and remove the fuzzy marker")
+	fs.StringVar(&v.O.Replace, "replace", "", "pattern to replace in msgstr (use with --with)")
+	fs.StringVar(&v.O.With, "with", "", "replacement for --replace ($1 refers to a submatch)")
+	fs.StringArrayVar(&v.O.AddFlags, "add-flag", nil, "add a flag to the \"#,\" line (may be given more than once)")
+	fs.StringArrayVar(&v.O.RemoveFlags, "remove-flag", nil, "remove a flag from the \"#,\" line (may be given more than once)")
+	fs.StringArrayVar(&v.O.AddComments, "add-comment", nil, "add a translator comment (may be given more than once)")
+	fs.StringVar(&v.O.RemoveComments, "remove-comment", "", "remove translator comments matching this regular expression")
+	for _, name := range []string{"set-fuzzy", "unset-fuzzy", "clear-msgstr", "replace", "with",
+		"add-flag", "remove-flag", "add-comment", "remove-comment"} {
+		_ = fs.SetAnnotation(name, "group", []string{"Operations"})
+	}
+
+	// Custom usage template with grouped flags
+	v.cmd.SetUsageTemplate(`Usage:{{if .Runnable}}
+  {{.UseLine}}{{end}}{{if .HasAvailableSubCommands}}
+  {{.CommandPath}} [command]{{end}}{{if gt (len .Aliases) 0}}
+
+Aliases:
+  {{.NameAndAliases}}{{end}}{{if .HasExample}}
+
+Examples:
+{{.Example}}{{end}}{{if .HasAvailableSubCommands}}
+
+Available Commands:{{range .Commands}}{{if (or .IsAvailableCommand (eq .Name "help"))}}
+  {{rpad .Name .NamePadding }} {{.Short}}{{end}}{{end}}{{end}}{{if .HasAvailableLocalFlags}}
+
+Flags:
+{{flagUsagesByGroup . | trimTrailingWhitespaces}}{{end}}{{if .HasAvailableInheritedFlags}}
+
+Global Flags:
+{{.InheritedFlags.FlagUsages | trimTrailingWhitespaces}}{{end}}{{if .HasHelpSubCommands}}
+
+Additional help topics:{{range .Commands}}{{if .IsAdditionalHelpTopicCommand}}
+  {{rpad .CommandPath .CommandPathPadding}} {{.Short}}{{end}}{{end}}{{end}}{{if .HasAvailableSubCommands}}
+
+Use "{{.CommandPath}} [command] --help" for more information about a command.{{end}}
+`)
+
+	return v.cmd
+}
+
+// pattern compiles a --match or --replace pattern with -F and -i.
+func (v msgEditCommand) pattern(p string) (*regexp.Regexp, error) {
+	if v.O.FixedStrings {
+		p = regexp.QuoteMeta(p)
+	}
+	if v.O.IgnoreCase {
+		p = "(?i)" + p
+	}
+	return regexp.Compile(p)
+}
+
+func (v msgEditCommand) operations() (*util.MsgEditOptions, error) {
+	if v.O.SetFuzzy && v.O.UnsetFuzzy {
+		return nil, NewErrorWithUsage("--set-fuzzy and --unset-fuzzy are mutually exclusive")
+	}
+	if v.cmd.Flags().Changed("with") && v.O.Replace == "" {
+		return nil, NewErrorWithUsage("--with requires --replace")
+	}
+	ops := &util.MsgEditOptions{
+		SetFuzzy:    v.O.SetFuzzy,
+		UnsetFuzzy:  v.O.UnsetFuzzy,
+		ClearMsgstr: v.O.ClearMsgstr,
+		Replacement: v.O.With,
+		AddFlags:    v.O.AddFlags,
+		RemoveFlags: v.O.RemoveFlags,
+		AddComments: v.O.AddComments,
+	}
+	if v.O.Replace != "" {
+		re, err := v.pattern(v.O.Replace)
+		if err != nil {
+			return nil, NewStandardErrorF("bad --replace pattern %q: %v", v.O.Replace, err)
+		}
+		ops.Replace = re
+	}
+	if v.O.RemoveComments != "" {
+		re, err := regexp.Compile(v.O.RemoveComments)
+		if err != nil {
+			return nil, NewStandardErrorF("bad --remove-comment pattern %q: %v", v.O.RemoveComments, err)
+		}
+		ops.RemoveComments = re
+	}
+	if !ops.HasOperation() {
+		return nil, NewErrorWithUsage("msg-edit requires at least one operation")
+	}
+	return ops, nil
+}
+
+func (v msgEditCommand) Execute(args []string) error {
+	if len(args) != 1 {
+		return NewErrorWithUsage("msg-edit requires exactly one argument: <po-file>")
+	}
+	ops, err := v.operations()
+	if err != nil {
+		return err
+	}
+	var matcher *util.EntryMatcher
+	if len(v.O.Match) > 0 {
+		matcher, err = util.NewEntryMatcher(util.MsgGrepOptions{
+			Patterns:     v.O.Match,
+			FixedStrings: v.O.FixedStrings,
+			IgnoreCase:   v.O.IgnoreCase,
+		})
+		if err != nil {
+			return NewStandardErrorF("%v", err)
+		}
+	}
+	filter := &util.EntryStateFilter{
+		Translated:   v.O.Translated,
+		Untranslated: v.O.Untranslated,
+		Fuzzy:        v.O.Fuzzy,
+		WithObsolete: !v.O.NoObsolete,
+		NoObsolete:   v.O.NoObsolete,
+	}
+
+	poFile := args[0]
+	j, err := util.ReadFileToGettextJSON(poFile)
+	if err != nil {
+		return NewStandardErrorF("%v", err)
+	}
+	var diff io.Writer
+	if v.O.DryRun {
+		diff = os.Stdout
+	}
+	result, err := util.EditGettextJSON(j, poFile, v.O.Range, filter, matcher, ops, diff)
+	if err != nil {
+		return NewStandardErrorF("%v", err)
+	}
+	if !v.O.DryRun {
+		// Read the input before creating the output, which may be the same file.
+		var w io.Writer = os.Stdout
+		if v.O.Output != "" && v.O.Output != "-" {
+			f, err := os.Create(v.O.Output)
+			if err != nil {
+				return NewStandardErrorF("failed to create output file %s: %v", v.O.Output, err)
+			}
+			defer f.Close()
+			w = f
+		}
+		if err := util.WriteGettextJSONToPO(j, w, false, false); err != nil {
+			return NewStandardErrorF("%v", err)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "%d of %d selected entries changed\n", result.Changed, result.Selected)
+	return nil
+}
+
+var msgEditCmd = msgEditCommand{}
+
+func init() {
+	rootCmd.AddCommand(msgEditCmd.Command())
+}
@@ -0,0 +1,279 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/git-l10n/git-po-helper/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type msgGrepCommand struct {
+	cmd *cobra.Command
+	O   struct {
+		Patterns     []string
+		FixedStrings bool
+		IgnoreCase   bool
+		InvertMatch  bool
+		Count        bool
+		MsgID        bool
+		MsgIDPlural  bool
+		MsgStr       bool
+		MsgCtxt      bool
+		Comment      bool
+		Location     bool
+		NoHeader     bool
+		Output       string
+		JSON         bool
+		Format       string
+		XLIFFVersion string
+		JSONVersion  int
+		Translated   bool
+		Untranslated bool
+		Fuzzy        bool
+		WithObsolete bool
+		NoObsolete   bool
+		OnlySame     bool
+		OnlyObsolete bool
+		Width        int
+		NoWrap       bool
+		KeepLayout   bool
+	}
+}
+
+func (v *msgGrepCommand) Command() *cobra.Command {
+	if v.cmd != nil {
+		return v.cmd
+	}
+
+	v.cmd = &cobra.Command{
+		Use:   "msg-grep [options] (-e <pattern>... | <pattern>) <po-file>",
+		Short: "Extract entries from PO/POT file by pattern",
+		Long: `Extract the entries of a PO/POT file (or gettext JSON, XLIFF or .mo file) that
+match a pattern, like gettext's msggrep. Patterns are regular expressions (Go
+syntax) unless --fixed-strings is given; use -e several times to match any of
+several patterns, otherwise the first argument is the pattern.
+
+By default, patterns are matched against msgid, msgid_plural and msgstr. Use
+--msgid, --msgid-plural, --msgstr, --msgctxt, --comment (translator and extracted
+comments) and --location (source references such as "builtin/add.c:42") to choose
+the fields to search. Escape sequences are decoded before matching, so "\n"
+in a PO string is a newline.
+
+The state filter options select entries like msg-select before matching. The
+number of matching entries is reported on stderr; use --count to print only the
+number on stdout. Output is PO, or JSON/XLIFF/CSV/TSV with --json or --format.
+
+Examples:
+  # All entries that mention "stash", in msgid or msgstr
+  git-po-helper msg-grep -i stash po/zh_CN.po
+
+  # Translated entries with either of two translations of a term, as JSON
+  git-po-helper msg-grep --msgstr --translated -e 贮藏 -e 储藏 --json po/zh_CN.po
+
+  # Entries from builtin/stash.c
+  git-po-helper msg-grep --location -F builtin/stash.c po/zh_CN.po`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return v.Execute(args)
+		},
+	}
+
+	fs := v.cmd.Flags()
+	fs.SortFlags = false
+
+	// Pattern matching
+	fs.StringArrayVarP(&v.O.Patterns, "regexp", "e", nil, "pattern to search for (may be given more than once)")
+	fs.BoolVarP(&v.O.FixedStrings, "fixed-strings", "F", false, "patterns are fixed strings, not regular expressions")
+	fs.BoolVarP(&v.O.IgnoreCase, "ignore-case", "i", false, "ignore case distinctions")
+	fs.BoolVar(&v.O.InvertMatch, "invert-match", false, "select entries that do not match")
+	fs.BoolVarP(&v.O.Count, "count", "c", false, "only print the number of matching entries")
+	_ = fs.SetAnnotation("regexp", "group", []string{"Pattern matching"})
+	_ = fs.SetAnnotation("fixed-strings", "group", []string{"Pattern matching"})
+	_ = fs.SetAnnotation("ignore-case", "group", []string{"Pattern matching"})
+	_ = fs.SetAnnotation("invert-match", "group", []string{"Pattern matching"})
+	_ = fs.SetAnnotation("count", "group", []string{"Pattern matching"})
+
+	// Fields to search
+	fs.BoolVarP(&v.O.MsgID, "msgid", "K", false, "search msgid")
+	fs.BoolVar(&v.O.MsgIDPlural, "msgid-plural", false, "search msgid_plural")
+	fs.BoolVarP(&v.O.MsgStr, "msgstr", "T", false, "search msgstr")
+	fs.BoolVarP(&v.O.MsgCtxt, "msgctxt", "J", false, "search msgctxt")
+	fs.BoolVarP(&v.O.Comment, "comment", "C", false, "search translator and extracted comments")
+	fs.BoolVarP(&v.O.Location, "location", "N", false, "search source references (file:line)")
+	_ = fs.SetAnnotation("msgid", "group", []string{"Fields to search (default: msgid, msgid_plural, msgstr)"})
+	_ = fs.SetAnnotation("msgid-plural", "group", []string{"Fields to search (default: msgid, msgid_plural, msgstr)"})
+	_ = fs.SetAnnotation("msgstr", "group", []string{"Fields to search (default: msgid, msgid_plural, msgstr)"})
+	_ = fs.SetAnnotation("msgctxt", "group", []string{"Fields to search (default: msgid, msgid_plural, msgstr)"})
+	_ = fs.SetAnnotation("comment", "group", []string{"Fields to search (default: msgid, msgid_plural, msgstr)"})
+	_ = fs.SetAnnotation("location", "group", []string{"Fields to search (default: msgid, msgid_plural, msgstr)"})
+
+	// General options
+	fs.BoolVar(&v.O.NoHeader, "no-header", false, "omit header entry from output")
+	fs.BoolVar(&v.O.JSON, "json", false, "output JSON instead of PO text (same as --format json)")
+	fs.StringVar(&v.O.Format, "format", "", "output format: po (default), json, xliff, csv, or tsv")
+	fs.StringVar(&v.O.XLIFFVersion, "xliff-version", "", "XLIFF version for --format xliff: 1.2 (default) or 2.0")
+	fs.IntVar(&v.O.JSONVersion, "json-version", 0, "gettext JSON schema version for JSON output: 1 (default) or 2")
+	fs.StringVarP(&v.O.Output, "output", "o", "",
+		"write output to file (use - for stdout); empty output overwrites file")
+	_ = fs.SetAnnotation("no-header", "group", []string{"General options"})
+	_ = fs.SetAnnotation("json", "group", []string{"General options"})
+	_ = fs.SetAnnotation("format", "group", []string{"General options"})
+	_ = fs.SetAnnotation("xliff-version", "group", []string{"General options"})
+	_ = fs.SetAnnotation("json-version", "group", []string{"General options"})
+	_ = fs.SetAnnotation("output", "group", []string{"General options"})
+	_ = viper.BindPFlag("msg-grep--xliff-version", fs.Lookup("xliff-version"))
+	_ = viper.BindPFlag("msg-grep--json-version", fs.Lookup("json-version"))
+
+	// State filter: translated, untranslated, fuzzy (OR when combined)
+	fs.BoolVar(&v.O.Translated, "translated", false, "select translated entries (msgstr not empty, not fuzzy)")
+	fs.BoolVar(&v.O.Untranslated, "untranslated", false, "select untranslated entries (msgstr empty)")
+	fs.BoolVar(&v.O.Fuzzy, "fuzzy", false, "select fuzzy entries")
+	_ = fs.SetAnnotation("translated", "group", []string{"State filter"})
+	_ = fs.SetAnnotation("untranslated", "group", []string{"State filter"})
+	_ = fs.SetAnnotation("fuzzy", "group", []string{"State filter"})
+
+	// Obsolete handling: include or exclude
+	fs.BoolVar(&v.O.WithObsolete, "with-obsolete", false, "include obsolete entries (default)")
+	fs.BoolVar(&v.O.NoObsolete, "no-obsolete", false, "exclude obsolete entries")
+	_ = fs.SetAnnotation("with-obsolete", "group", []string{"Obsolete handling"})
+	_ = fs.SetAnnotation("no-obsolete", "group", []string{"Obsolete handling"})
+
+	// Single-state filter: mutually exclusive with state filter above
+	fs.BoolVar(&v.O.OnlySame, "only-same", false, "only entries where msgstr equals msgid")
+	fs.BoolVar(&v.O.OnlyObsolete, "only-obsolete", false, "only obsolete entries")
+	_ = fs.SetAnnotation("only-same", "group", []string{"Single-state filter"})
+	_ = fs.SetAnnotation("only-obsolete", "group", []string{"Single-state filter"})
+
+	// Output layout (PO output only)
+	fs.IntVar(&v.O.Width, "width", 0,
+		"wrap long lines at this page width like msgcat --width (default: no wrapping)")
+	fs.BoolVar(&v.O.NoWrap, "no-wrap", false,
+		"do not wrap long lines; only break after embedded newlines (default)")
+	fs.BoolVar(&v.O.KeepLayout, "keep-layout", false,
+		"write unmodified entries with their original lines, byte for byte")
+	_ = fs.SetAnnotation("width", "group", []string{"Output layout"})
+	_ = fs.SetAnnotation("no-wrap", "group", []string{"Output layout"})
+	_ = fs.SetAnnotation("keep-layout", "group", []string{"Output layout"})
+	_ = viper.BindPFlag("msg-grep--width", fs.Lookup("width"))
+	_ = viper.BindPFlag("msg-grep--no-wrap", fs.Lookup("no-wrap"))
+	_ = viper.BindPFlag("msg-grep--keep-layout", fs.Lookup("keep-layout"))
+
+	// Custom usage template with grouped flags
+	v.cmd.SetUsageTemplate(`Usage:{{if .Runnable}}
+  {{.UseLine}}{{end}}{{if .HasAvailableSubCommands}}
+  {{.CommandPath}} [command]{{end}}{{if gt (len .Aliases) 0}}
+
+Aliases:
+  {{.NameAndAliases}}{{end}}{{if .HasExample}}
+
+Examples:
+{{.Example}}{{end}}{{if .HasAvailableSubCommands}}
+
+Available Commands:{{range .Commands}}{{if (or .IsAvailableCommand (eq .Name "help"))}}
+  {{rpad .Name .NamePadding }} {{.Short}}{{end}}{{end}}{{end}}{{if .HasAvailableLocalFlags}}
+
+Flags:
+{{flagUsagesByGroup . | trimTrailingWhitespaces}}{{end}}{{if .HasAvailableInheritedFlags}}
+
+Global Flags:
+{{.InheritedFlags.FlagUsages | trimTrailingWhitespaces}}{{end}}{{if .HasHelpSubCommands}}
+
+Additional help topics:{{range .Commands}}{{if .IsAdditionalHelpTopicCommand}}
+  {{rpad .CommandPath .CommandPathPadding}} {{.Short}}{{end}}{{end}}{{end}}{{if .HasAvailableSubCommands}}
+
+Use "{{.CommandPath}} [command] --help" for more information about a command.{{end}}
+`)
+
+	return v.cmd
+}
+
+func (v msgGrepCommand) Execute(args []string) error {
+	patterns := v.O.Patterns
+	if len(patterns) == 0 {
+		if len(args) != 2 {
+			return NewErrorWithUsage("msg-grep requires a pattern and a <po-file>")
+		}
+		patterns, args = args[:1], args[1:]
+	}
+	if len(args) != 1 {
+		return NewErrorWithUsage("msg-grep requires exactly one <po-file>")
+	}
+	filter, err := v.buildFilter()
+	if err != nil {
+		return err
+	}
+	format, err := resolveOutputFormat(v.O.Format, v.O.JSON, v.O.XLIFFVersion, v.O.JSONVersion)
+	if err != nil {
+		return err
+	}
+	matcher, err := util.NewEntryMatcher(util.MsgGrepOptions{
+		Patterns:     patterns,
+		FixedStrings: v.O.FixedStrings,
+		IgnoreCase:   v.O.IgnoreCase,
+		InvertMatch:  v.O.InvertMatch,
+		MsgID:        v.O.MsgID,
+		MsgIDPlural:  v.O.MsgIDPlural,
+		MsgStr:       v.O.MsgStr,
+		MsgCtxt:      v.O.MsgCtxt,
+		Comment:      v.O.Comment,
+		Location:     v.O.Location,
+	})
+	if err != nil {
+		return NewStandardErrorF("%v", err)
+	}
+
+	poFile := args[0]
+	peek, err := os.ReadFile(poFile)
+	if err != nil {
+		return NewStandardErrorF("failed to read %s: %v", poFile, err)
+	}
+	if len(peek) > 1024 {
+		peek = peek[:1024]
+	}
+	inputWasPO := !util.IsGettextJSONData(peek) && !util.IsXLIFFData(peek)
+	var w io.Writer = os.Stdout
+	if v.O.Output != "" && v.O.Output != "-" && !v.O.Count {
+		f, err := os.Create(v.O.Output)
+		if err != nil {
+			return NewStandardErrorF("failed to create output file %s: %v", v.O.Output, err)
+		}
+		defer f.Close()
+		w = f
+	}
+	result, err := util.MsgGrepFromFile(poFile, matcher, filter, w, format, v.O.NoHeader, inputWasPO, v.O.Count)
+	if err != nil {
+		return NewStandardErrorF("%v", err)
+	}
+	if v.O.Count {
+		fmt.Println(result.Matched)
+	} else {
+		fmt.Fprintf(os.Stderr, "%d of %d entries matched\n", result.Matched, result.Total)
+	}
+	return nil
+}
+
+func (v msgGrepCommand) buildFilter() (*util.EntryStateFilter, error) {
+	if v.O.OnlySame && v.O.OnlyObsolete {
+		return nil, NewErrorWithUsage("--only-same and --only-obsolete are mutually exclusive")
+	}
+	if (v.O.OnlySame || v.O.OnlyObsolete) && (v.O.Translated || v.O.Untranslated || v.O.Fuzzy) {
+		return nil, NewErrorWithUsage("--only-same/--only-obsolete are mutually exclusive with --translated, --untranslated, --fuzzy")
+	}
+	return &util.EntryStateFilter{
+		Translated:   v.O.Translated,
+		Untranslated: v.O.Untranslated,
+		Fuzzy:        v.O.Fuzzy,
+		WithObsolete: !v.O.NoObsolete,
+		NoObsolete:   v.O.NoObsolete,
+		OnlySame:     v.O.OnlySame,
+		OnlyObsolete: v.O.OnlyObsolete,
+	}, nil
+}
+
+var msgGrepCmd = msgGrepCommand{}
+
+func init() {
+	rootCmd.AddCommand(msgGrepCmd.Command())
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/git-l10n/git-po-helper/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type msgMergeCommand struct {
+	cmd *cobra.Command
+	O   struct {
+		Output          string
+		NoFuzzyMatching bool
+		NoLocation      bool
+		NoLineNumber    bool
+		KeepLayout      bool
+		Width           int
+		NoWrap          bool
+	}
+}
+
+func (v *msgMergeCommand) Command() *cobra.Command {
+	if v.cmd != nil {
+		return v.cmd
+	}
+
+	v.cmd = &cobra.Command{
+		Use:   "msg-merge [-o <output>] <def.po> <ref.pot>",
+		Short: "Merge a PO file with a POT template (msgmerge replacement)",
+		Long: `Merge the translations of <def.po> into the messages of the template <ref.pot>
+and write the result as PO, like msgmerge, without running gettext.
+
+A message of the template with the same msgctxt and msgid as an entry of
+<def.po> keeps its translation. Otherwise the translation of the most similar
+msgid is taken and marked fuzzy, with the old message in "#|" lines. Obsolete
+entries are matched too, so a message that returns to the template gets its
+old translation back. Translated entries that are no longer used become
+obsolete.
+
+A summary is reported on stderr: the statistics of the result, and for each
+message that became fuzzy, why it did and which msgid its translation came
+from.
+
+Write result to the file given by -o; use -o - or omit -o to write to stdout.
+
+Examples:
+  git-po-helper msg-merge --no-line-number --width 79 -o po/zh_CN.po po/zh_CN.po po/git.pot`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return v.Execute(args)
+		},
+	}
+	fs := v.cmd.Flags()
+	fs.StringVarP(&v.O.Output, "output", "o", "",
+		"write output to file (use - for stdout); default is stdout")
+	fs.BoolVar(&v.O.NoFuzzyMatching, "no-fuzzy-matching", false,
+		"do not use fuzzy matching; messages without an exact match are untranslated")
+	fs.BoolVar(&v.O.NoLocation, "no-location", false,
+		"no filename and location in comment for entry")
+	fs.BoolVar(&v.O.NoLineNumber, "no-line-number", false,
+		"no line number in comment for entry")
+	fs.BoolVar(&v.O.KeepLayout, "keep-layout", false,
+		"write unmodified entries with their original lines, byte for byte")
+	fs.IntVar(&v.O.Width, "width", 0,
+		"wrap long lines at this page width like msgmerge --width (default: no wrapping)")
+	fs.BoolVar(&v.O.NoWrap, "no-wrap", false,
+		"do not wrap long lines (only split after embedded newlines)")
+	_ = viper.BindPFlag("msg-merge--keep-layout", fs.Lookup("keep-layout"))
+	_ = viper.BindPFlag("msg-merge--width", fs.Lookup("width"))
+	_ = viper.BindPFlag("msg-merge--no-wrap", fs.Lookup("no-wrap"))
+
+	return v.cmd
+}
+
+func (v msgMergeCommand) Execute(args []string) error {
+	if len(args) != 2 {
+		return NewErrorWithUsage("msg-merge requires exactly two arguments: <def.po> <ref.pot>")
+	}
+	if v.O.NoLocation && v.O.NoLineNumber {
+		return NewErrorWithUsage("--no-location and --no-line-number are mutually exclusive")
+	}
+	j, result, err := util.MsgMergeFile(args[0], args[1], util.MsgMergeOptions{
+		NoFuzzyMatching: v.O.NoFuzzyMatching,
+		NoLocation:      v.O.NoLocation,
+		NoLineNumber:    v.O.NoLineNumber,
+	})
+	if err != nil {
+		return NewStandardErrorF("%v", err)
+	}
+
+	// Read the input before creating the output, which may be the same file.
+	var w io.Writer = os.Stdout
+	if v.O.Output != "" && v.O.Output != "-" {
+		f, err := os.Create(v.O.Output)
+		if err != nil {
+			return NewStandardErrorF("failed to create output file %s: %v", v.O.Output, err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := util.WriteGettextJSONToPO(j, w, false, false); err != nil {
+		return NewStandardErrorF("%v", err)
+	}
+	for _, line := range result.Summary() {
+		fmt.Fprintln(os.Stderr, line)
+	}
+	return nil
+}
+
+var msgMergeCmd = msgMergeCommand{}
+
+func init() {
+	rootCmd.AddCommand(msgMergeCmd.Command())
+}
@@ -1,13 +1,13 @@
 package cmd
 
 import (
-	"bytes"
 	"fmt"
 	"io"
 	"os"
 
 	"github.com/git-l10n/git-po-helper/util"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 type msgSelectCommand struct {
@@ -20,6 +20,9 @@ type msgSelectCommand struct {
 		NoHeader     bool
 		Output       string
 		JSON         bool
+		Format       string
+		XLIFFVersion string
+		JSONVersion  int
 		Translated   bool
 		Untranslated bool
 		Fuzzy        bool
@@ -29,6 +32,14 @@ type msgSelectCommand struct {
 		OnlyObsolete bool
 		UnsetFuzzy   bool
 		ClearFuzzy   bool
+		Width        int
+		NoWrap       bool
+		KeepLayout   bool
+		MsgCtxt      []string
+		References   []string
+		Flags        []string
+		MsgIDRegexps []string
+		Changed      string
 	}
 }
 
@@ -45,6 +56,17 @@ Input can be either a PO/POT file or a JSON file (same schema as produced by --j
 Use -o <file> to write to a file (avoids stderr mixing when redirecting stdout).
 Use --json to output a single JSON object (header_comment, header_meta, entries) instead of PO text.
 See docs/design/msg-select-json-output.md for the gettext JSON schema (GettextJSON/GettextEntry in util/gettext_json.go).
+Use --json-version 2 for schema version 2: plain text strings and comments split into
+translator_comments, extracted_comments, references, flags and previous. Both versions
+are accepted as input.
+
+Use --format xliff to output an XLIFF document for CAT tools (--xliff-version 1.2
+or 2.0). XLIFF input is detected automatically: msgctxt, plural forms, fuzzy state,
+obsolete entries and comments are kept, so PO -> XLIFF -> PO gives the same file.
+
+Use --format csv or --format tsv to review entries in a spreadsheet: one row per
+entry with the columns index, msgctxt, msgid, msgid_plural, msgstr[0], ...,
+fuzzy and comments. Apply the edited msgstr columns back with "msg-apply".
 
 Entry 0 is the header entry; it is included when content entries are selected
 (use --no-header to omit; for JSON output the file header is always included).
@@ -56,6 +78,18 @@ Use --translated, --untranslated, --fuzzy to filter by state (OR relationship).
 Use --no-obsolete to exclude obsolete entries; --with-obsolete to include (default).
 Use --only-same or --only-obsolete for a single state (mutually exclusive with above).
 
+Content selectors choose entries by what they contain. Each selector may be
+given more than once to select any of its values; different selectors must all
+match. They combine with the state filters, and the range applies to the entries
+they select:
+  - --msgctxt CTXT: entries with this msgctxt
+  - --reference FILE: entries with a source reference ("#:") to this file, to a
+    file in this directory, or to a file matching this glob (e.g. "builtin/*.c")
+  - --flag FLAG: entries with this flag ("#,"), e.g. c-format or no-wrap
+  - --msgid-regexp PATTERN: entries whose msgid or msgid_plural matches
+  - --changed REV-RANGE: entries that are new or changed in the revision range
+    of the file (a..b, a.. for a and the worktree, or a for a~ and a)
+
 Range format (--range): comma-separated numbers or ranges, e.g. "3,5,9-13".
 Omit --range to select all entries. Range applies to the filtered list.
   - Single numbers: 3, 5 (extract entries 3 and 5)
@@ -69,6 +103,11 @@ Shortcuts (mutually exclusive with --range and each other):
   - --tail N: last N entries (equivalent to --range "<total-N+1>-<total>")
   - --since N: entries from N to end (equivalent to --range "N-")
 
+PO output regenerates each entry from its fields. Use --keep-layout to write
+unmodified entries with their original lines, so that only changed entries show
+up in diffs. Use --width=79 to wrap long lines like msgcat does by default;
+without --width (or with --no-wrap), lines are only broken after embedded newlines.
+
 Examples:
   git-po-helper msg-select --range "1-10" po/zh_CN.po
   git-po-helper msg-select --no-obsolete po/zh_CN.po
@@ -76,7 +115,13 @@ Examples:
   git-po-helper msg-select --translated --range "-5" -o batch.po po/zh_CN.po
   git-po-helper msg-select --head 10 po/zh_CN.po
   git-po-helper msg-select --tail 5 -o last5.po po/zh_CN.po
-  git-po-helper msg-select --since 100 po/zh_CN.po`,
+  git-po-helper msg-select --since 100 po/zh_CN.po
+  git-po-helper msg-select --format xliff -o zh_CN.xlf po/zh_CN.po
+  git-po-helper msg-select -o po/zh_CN.po zh_CN.xlf
+  git-po-helper msg-select --fuzzy --format csv -o review.csv po/zh_CN.po
+  git-po-helper msg-select --reference builtin/commit.c --untranslated po/zh_CN.po
+  git-po-helper msg-select --flag c-format --msgid-regexp "^--" po/zh_CN.po
+  git-po-helper msg-select --changed v2.45.0.. --head 50 po/zh_CN.po`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return v.Execute(args)
 		},
@@ -91,7 +136,10 @@ Examples:
 	fs.IntVar(&v.O.Tail, "tail", 0, "select last N entries (equivalent to --range \"<total-N+1>-<total>\")")
 	fs.IntVar(&v.O.Since, "since", 0, "select entries from N to end (equivalent to --range \"N-\")")
 	fs.BoolVar(&v.O.NoHeader, "no-header", false, "omit header entry from output")
-	fs.BoolVar(&v.O.JSON, "json", false, "output JSON instead of PO text")
+	fs.BoolVar(&v.O.JSON, "json", false, "output JSON instead of PO text (same as --format json)")
+	fs.StringVar(&v.O.Format, "format", "", "output format: po (default), json, xliff, csv, or tsv")
+	fs.StringVar(&v.O.XLIFFVersion, "xliff-version", "", "XLIFF version for --format xliff: 1.2 (default) or 2.0")
+	fs.IntVar(&v.O.JSONVersion, "json-version", 0, "gettext JSON schema version for JSON output: 1 (default) or 2")
 	fs.StringVarP(&v.O.Output, "output", "o", "",
 		"write output to file (use - for stdout); empty output overwrites file")
 	_ = fs.SetAnnotation("range", "group", []string{"General options"})
@@ -100,7 +148,12 @@ Examples:
 	_ = fs.SetAnnotation("since", "group", []string{"General options"})
 	_ = fs.SetAnnotation("no-header", "group", []string{"General options"})
 	_ = fs.SetAnnotation("json", "group", []string{"General options"})
+	_ = fs.SetAnnotation("format", "group", []string{"General options"})
+	_ = fs.SetAnnotation("xliff-version", "group", []string{"General options"})
+	_ = fs.SetAnnotation("json-version", "group", []string{"General options"})
 	_ = fs.SetAnnotation("output", "group", []string{"General options"})
+	_ = viper.BindPFlag("msg-select--xliff-version", fs.Lookup("xliff-version"))
+	_ = viper.BindPFlag("msg-select--json-version", fs.Lookup("json-version"))
 
 	// State filter: translated, untranslated, fuzzy (OR when combined)
 	fs.BoolVar(&v.O.Translated, "translated", false, "select translated entries (msgstr not empty, not fuzzy)")
@@ -122,6 +175,21 @@ Examples:
 	_ = fs.SetAnnotation("only-same", "group", []string{"Single-state filter"})
 	_ = fs.SetAnnotation("only-obsolete", "group", []string{"Single-state filter"})
 
+	// Content selectors: values of one selector are ORed, selectors are ANDed
+	fs.StringArrayVar(&v.O.MsgCtxt, "msgctxt", nil, "select entries with this msgctxt")
+	fs.StringArrayVar(&v.O.References, "reference", nil,
+		"select entries referencing this source file, directory or glob (\"#:\")")
+	fs.StringArrayVar(&v.O.Flags, "flag", nil, "select entries with this flag (e.g. c-format, no-wrap)")
+	fs.StringArrayVar(&v.O.MsgIDRegexps, "msgid-regexp", nil,
+		"select entries whose msgid or msgid_plural matches this regular expression")
+	fs.StringVar(&v.O.Changed, "changed", "",
+		"select entries new or changed in this revision range (a..b, a.., or a)")
+	_ = fs.SetAnnotation("msgctxt", "group", []string{"Content selectors"})
+	_ = fs.SetAnnotation("reference", "group", []string{"Content selectors"})
+	_ = fs.SetAnnotation("flag", "group", []string{"Content selectors"})
+	_ = fs.SetAnnotation("msgid-regexp", "group", []string{"Content selectors"})
+	_ = fs.SetAnnotation("changed", "group", []string{"Content selectors"})
+
 	// Fuzzy handling
 	fs.BoolVar(&v.O.UnsetFuzzy, "unset-fuzzy", false,
 		"remove fuzzy marker from fuzzy entries in output (keep translations)")
@@ -130,6 +198,20 @@ Examples:
 	_ = fs.SetAnnotation("unset-fuzzy", "group", []string{"Fuzzy handling"})
 	_ = fs.SetAnnotation("clear-fuzzy", "group", []string{"Fuzzy handling"})
 
+	// Output layout (PO output only)
+	fs.IntVar(&v.O.Width, "width", 0,
+		"wrap long lines at this page width like msgcat --width (default: no wrapping)")
+	fs.BoolVar(&v.O.NoWrap, "no-wrap", false,
+		"do not wrap long lines; only break after embedded newlines (default)")
+	fs.BoolVar(&v.O.KeepLayout, "keep-layout", false,
+		"write unmodified entries with their original lines, byte for byte")
+	_ = fs.SetAnnotation("width", "group", []string{"Output layout"})
+	_ = fs.SetAnnotation("no-wrap", "group", []string{"Output layout"})
+	_ = fs.SetAnnotation("keep-layout", "group", []string{"Output layout"})
+	_ = viper.BindPFlag("msg-select--width", fs.Lookup("width"))
+	_ = viper.BindPFlag("msg-select--no-wrap", fs.Lookup("no-wrap"))
+	_ = viper.BindPFlag("msg-select--keep-layout", fs.Lookup("keep-layout"))
+
 	// Custom usage template with grouped flags
 	v.cmd.SetUsageTemplate(`Usage:{{if .Runnable}}
   {{.UseLine}}{{end}}{{if .HasAvailableSubCommands}}
@@ -167,8 +249,17 @@ func (v msgSelectCommand) Execute(args []string) error {
 	if err != nil {
 		return err
 	}
+	format, err := resolveOutputFormat(v.O.Format, v.O.JSON, v.O.XLIFFVersion, v.O.JSONVersion)
+	if err != nil {
+		return err
+	}
 
 	poFile := args[0]
+	// Select changed entries before creating the output, which may be the input.
+	sel, err := v.buildSelector(poFile)
+	if err != nil {
+		return err
+	}
 	var w io.Writer = os.Stdout
 	if v.O.Output != "" && v.O.Output != "-" {
 		f, err := os.Create(v.O.Output)
@@ -185,18 +276,17 @@ func (v msgSelectCommand) Execute(args []string) error {
 	if err != nil {
 		return err
 	}
-	// Load → Filter → Save: ReadFileToGettextJSON auto-detects PO vs JSON
+	// Load → Filter → Save: ReadFileToGettextJSON auto-detects PO vs JSON vs XLIFF
 	peek, err := os.ReadFile(poFile)
 	if err != nil {
 		return NewStandardErrorF("failed to read %s: %v", poFile, err)
 	}
-	if len(peek) > 512 {
-		peek = peek[:512]
+	if len(peek) > 1024 {
+		peek = peek[:1024]
 	}
-	trimmed := bytes.TrimLeft(peek, " \t\r\n")
-	inputWasPO := len(trimmed) == 0 || trimmed[0] != '{'
-	if err := util.MsgSelectFromFile(poFile, rangeSpec, w, v.O.JSON, v.O.NoHeader, inputWasPO,
-		v.O.UnsetFuzzy, v.O.ClearFuzzy, filter); err != nil {
+	inputWasPO := !util.IsGettextJSONData(peek) && !util.IsXLIFFData(peek)
+	if err := util.MsgSelectFromFile(poFile, rangeSpec, w, format, v.O.NoHeader, inputWasPO,
+		v.O.UnsetFuzzy, v.O.ClearFuzzy, filter, sel); err != nil {
 		return NewStandardErrorF("%v", err)
 	}
 	return nil
@@ -232,6 +322,38 @@ func (v msgSelectCommand) resolveRange() (string, error) {
 	return v.O.Range, nil
 }
 
+// resolveOutputFormat returns the output format of msg-select and msg-cat from
+// --format and --json, and checks --xliff-version and --json-version.
+func resolveOutputFormat(format string, json bool, xliffVersion string, jsonVersion int) (string, error) {
+	switch format {
+	case "":
+		format = util.OutputFormatPO
+		if json {
+			format = util.OutputFormatJSON
+		}
+	case util.OutputFormatPO, util.OutputFormatXLIFF, util.OutputFormatCSV, util.OutputFormatTSV:
+		if json {
+			return "", NewErrorWithUsageF("--json conflicts with --format %s", format)
+		}
+	case util.OutputFormatJSON:
+	default:
+		return "", NewErrorWithUsageF("invalid --format %q (use po, json, xliff, csv, or tsv)", format)
+	}
+	switch xliffVersion {
+	case "", util.XLIFFVersion12, util.XLIFFVersion20:
+	default:
+		return "", NewErrorWithUsageF("invalid --xliff-version %q (use %s or %s)",
+			xliffVersion, util.XLIFFVersion12, util.XLIFFVersion20)
+	}
+	switch jsonVersion {
+	case 0, util.GettextJSONVersion1, util.GettextJSONVersion2:
+	default:
+		return "", NewErrorWithUsageF("invalid --json-version %d (use %d or %d)",
+			jsonVersion, util.GettextJSONVersion1, util.GettextJSONVersion2)
+	}
+	return format, nil
+}
+
 func (v msgSelectCommand) buildFilter() (*util.EntryStateFilter, error) {
 	// Mutually exclusive: --only-same and --only-obsolete
 	if v.O.OnlySame && v.O.OnlyObsolete {
@@ -257,6 +379,19 @@ func (v msgSelectCommand) buildFilter() (*util.EntryStateFilter, error) {
 	return &f, nil
 }
 
+func (v msgSelectCommand) buildSelector(poFile string) (*util.EntrySelector, error) {
+	sel, err := util.NewEntrySelector(v.O.MsgCtxt, v.O.References, v.O.Flags, v.O.MsgIDRegexps)
+	if err != nil {
+		return nil, NewErrorWithUsageF("%v", err)
+	}
+	if v.O.Changed != "" {
+		if sel.Changed, err = util.SelectChangedEntries(v.O.Changed, poFile); err != nil {
+			return nil, NewStandardErrorF("--changed %s: %v", v.O.Changed, err)
+		}
+	}
+	return sel, nil
+}
+
 var msgSelectCmd = msgSelectCommand{}
 
 func init() {
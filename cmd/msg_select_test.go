@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const msgSelectTestPO = `# Test file
+msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+
+#: builtin/add.c:10
+#, c-format
+msgid "adding %s"
+msgstr "添加 %s"
+
+#: builtin/add.c:20
+#, fuzzy, c-format
+msgid "removing %s"
+msgstr "删除 %s"
+
+#: builtin/commit.c:30
+#, c-format
+msgid "committing %s"
+msgstr "提交 %s"
+
+#: builtin/commit.c:40
+msgid "done"
+msgstr ""
+`
+
+// runMsgSelect runs the msg-select command with args and returns the content of
+// its output file.
+func runMsgSelect(t *testing.T, args ...string) string {
+	t.Helper()
+	out := filepath.Join(t.TempDir(), "out")
+	v := &msgSelectCommand{}
+	cmd := v.Command()
+	cmd.SetArgs(append([]string{"-o", out}, args...))
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("msg-select %v: %v", args, err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("read %s: %v", out, err)
+	}
+	return string(data)
+}
+
+// writeMsgSelectTestPO writes msgSelectTestPO to a temporary file.
+func writeMsgSelectTestPO(t *testing.T) string {
+	t.Helper()
+	poFile := filepath.Join(t.TempDir(), "zh_CN.po")
+	if err := os.WriteFile(poFile, []byte(msgSelectTestPO), 0644); err != nil {
+		t.Fatalf("write %s: %v", poFile, err)
+	}
+	return poFile
+}
+
+func TestMsgSelectCommand_POInput(t *testing.T) {
+	poFile := writeMsgSelectTestPO(t)
+
+	for _, tc := range []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "state filter and range",
+			args: []string{"--translated", "--tail", "1"},
+			want: `# Test file
+msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+
+#: builtin/commit.c:30
+#, c-format
+msgid "committing %s"
+msgstr "提交 %s"
+
+`,
+		},
+		{
+			name: "fuzzy without header",
+			args: []string{"--fuzzy", "--no-header"},
+			want: `#: builtin/add.c:20
+#, fuzzy, c-format
+msgid "removing %s"
+msgstr "删除 %s"
+
+`,
+		},
+		{
+			name: "nothing selected",
+			args: []string{"--untranslated", "--range", "2-"},
+			want: "",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := runMsgSelect(t, append(tc.args, poFile)...)
+			if got != tc.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+
+	// Streaming the PO file gives the same entries as loading its JSON.
+	jsonFile := filepath.Join(t.TempDir(), "zh_CN.json")
+	if err := os.WriteFile(jsonFile, []byte(runMsgSelect(t, "--json", poFile)), 0644); err != nil {
+		t.Fatalf("write %s: %v", jsonFile, err)
+	}
+	args := []string{"--json", "--no-obsolete", "--range", "2-"}
+	if fromPO, fromJSON := runMsgSelect(t, append(args, poFile)...),
+		runMsgSelect(t, append(args, jsonFile)...); fromPO != fromJSON {
+		t.Errorf("PO input gives:\n%s\nJSON input gives:\n%s", fromPO, fromJSON)
+	}
+}
+
+func TestMsgSelectCommand_Selectors(t *testing.T) {
+	poFile := writeMsgSelectTestPO(t)
+
+	for _, tc := range []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "flag, state filter and range",
+			args: []string{"--flag", "c-format", "--translated", "--tail", "1", "--no-header"},
+			want: `#: builtin/commit.c:30
+#, c-format
+msgid "committing %s"
+msgstr "提交 %s"
+
+`,
+		},
+		{
+			name: "reference and state filter",
+			args: []string{"--reference", "builtin/add.c", "--fuzzy", "--no-header"},
+			want: `#: builtin/add.c:20
+#, fuzzy, c-format
+msgid "removing %s"
+msgstr "删除 %s"
+
+`,
+		},
+		{
+			name: "nothing selected",
+			args: []string{"--reference", "builtin/add.c", "--untranslated"},
+			want: "",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := runMsgSelect(t, append(tc.args, poFile)...)
+			if got != tc.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+
+	// Streaming the PO file selects the same entries as loading its JSON.
+	jsonFile := filepath.Join(t.TempDir(), "zh_CN.json")
+	if err := os.WriteFile(jsonFile, []byte(runMsgSelect(t, "--json", poFile)), 0644); err != nil {
+		t.Fatalf("write %s: %v", jsonFile, err)
+	}
+	args := []string{"--json", "--flag", "c-format", "--range", "2-"}
+	if fromPO, fromJSON := runMsgSelect(t, append(args, poFile)...),
+		runMsgSelect(t, append(args, jsonFile)...); fromPO != fromJSON {
+		t.Errorf("PO input gives:\n%s\nJSON input gives:\n%s", fromPO, fromJSON)
+	}
+}
@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/git-l10n/git-po-helper/util"
+	"github.com/spf13/cobra"
+)
+
+type msgSplitCommand struct {
+	cmd *cobra.Command
+	O   struct {
+		Chunks       int
+		ByFile       bool
+		Team         bool
+		OutputDir    string
+		JSON         bool
+		Translated   bool
+		Untranslated bool
+		Fuzzy        bool
+	}
+}
+
+func (v *msgSplitCommand) Command() *cobra.Command {
+	if v.cmd != nil {
+		return v.cmd
+	}
+
+	v.cmd = &cobra.Command{
+		Use:   "msg-split (-n <chunks> | --team) [--by-file] [-o <dir>] <po-file>",
+		Short: "Split PO/JSON file into chunks to distribute among translators",
+		Long: `Split the entries of a PO file or a gettext JSON file into chunks of
+consecutive entries of about the same size, so that a round of translation can
+be shared among several translators. Obsolete entries are not split.
+
+The number of chunks is given by -n, or with --team by the leader and members
+of the language team in "po/TEAMS", one chunk each. The language is the name
+of the file, e.g. "zh_CN" for "po/zh_CN.po". Use --by-file to keep the entries
+of one source file together: chunks are only cut where the file of the first
+source reference ("#:") changes, so the sizes are less even.
+
+Use --translated, --untranslated, --fuzzy to split only entries in these states
+(OR relationship), e.g. --untranslated --fuzzy for the work left to do.
+
+Chunks are written to the directory given by -o (default: the current
+directory), named after the input file: "zh_CN-1.po", "zh_CN-2.po", ..., or
+with the name of the team member, e.g. "zh_CN-1-jiang-xin.po". Chunks are PO
+files, or JSON files for JSON input or with --json. The header of each chunk
+records where it comes from:
+  X-Split-From: po/zh_CN.po
+  X-Split-Chunk: 2/3
+  X-Split-Range: 41-80 of 120
+  X-Split-Keys: sha256:...
+  X-Split-Assignee: Jiang Xin <worldhello.net@gmail.com>
+
+Use "msg-cat --check-split" to verify that the translated chunks together have
+exactly the entries that were split before merging them.
+
+Examples:
+  git-po-helper msg-split -n 3 --untranslated po/zh_CN.po
+  git-po-helper msg-split --team --by-file -o work po/zh_CN.po
+  git-po-helper msg-cat --check-split -o done.po zh_CN-*.po`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return v.Execute(args)
+		},
+	}
+
+	fs := v.cmd.Flags()
+	fs.SortFlags = false
+
+	// General options
+	fs.IntVarP(&v.O.Chunks, "chunks", "n", 0, "split into this number of chunks")
+	fs.BoolVar(&v.O.Team, "team", false, "split into one chunk for each member of the team in po/TEAMS")
+	fs.BoolVar(&v.O.ByFile, "by-file", false, "keep the entries of one source file in one chunk")
+	fs.StringVarP(&v.O.OutputDir, "output-dir", "o", "", "write chunks to this directory (default: current directory)")
+	fs.BoolVar(&v.O.JSON, "json", false, "write chunks as JSON instead of PO")
+	_ = fs.SetAnnotation("chunks", "group", []string{"General options"})
+	_ = fs.SetAnnotation("team", "group", []string{"General options"})
+	_ = fs.SetAnnotation("by-file", "group", []string{"General options"})
+	_ = fs.SetAnnotation("output-dir", "group", []string{"General options"})
+	_ = fs.SetAnnotation("json", "group", []string{"General options"})
+
+	// State filter: translated, untranslated, fuzzy (OR when combined)
+	fs.BoolVar(&v.O.Translated, "translated", false, "split translated entries (msgstr not empty, not fuzzy)")
+	fs.BoolVar(&v.O.Untranslated, "untranslated", false, "split untranslated entries (msgstr empty)")
+	fs.BoolVar(&v.O.Fuzzy, "fuzzy", false, "split fuzzy entries")
+	_ = fs.SetAnnotation("translated", "group", []string{"State filter"})
+	_ = fs.SetAnnotation("untranslated", "group", []string{"State filter"})
+	_ = fs.SetAnnotation("fuzzy", "group", []string{"State filter"})
+
+	// Custom usage template with grouped flags
+	v.cmd.SetUsageTemplate(`Usage:{{if .Runnable}}
+  {{.UseLine}}{{end}}{{if .HasAvailableSubCommands}}
+  {{.CommandPath}} [command]{{end}}{{if gt (len .Aliases) 0}}
+
+Aliases:
+  {{.NameAndAliases}}{{end}}{{if .HasExample}}
+
+Examples:
+{{.Example}}{{end}}{{if .HasAvailableSubCommands}}
+
+Available Commands:{{range .Commands}}{{if (or .IsAvailableCommand (eq .Name "help"))}}
+  {{rpad .Name .NamePadding }} {{.Short}}{{end}}{{end}}{{end}}{{if .HasAvailableLocalFlags}}
+
+Flags:
+{{flagUsagesByGroup . | trimTrailingWhitespaces}}{{end}}{{if .HasAvailableInheritedFlags}}
+
+Global Flags:
+{{.InheritedFlags.FlagUsages | trimTrailingWhitespaces}}{{end}}{{if .HasHelpSubCommands}}
+
+Additional help topics:{{range .Commands}}{{if .IsAdditionalHelpTopicCommand}}
+  {{rpad .CommandPath .CommandPathPadding}} {{.Short}}{{end}}{{end}}{{end}}{{if .HasAvailableSubCommands}}
+
+Use "{{.CommandPath}} [command] --help" for more information about a command.{{end}}
+`)
+
+	return v.cmd
+}
+
+func (v msgSplitCommand) Execute(args []string) error {
+	if len(args) != 1 {
+		return NewErrorWithUsage("msg-split requires exactly one argument: <po-file>")
+	}
+	if v.O.Team == (v.O.Chunks > 0) {
+		return NewErrorWithUsage("msg-split requires one of -n <chunks> and --team")
+	}
+	poFile := args[0]
+	base := strings.TrimSuffix(filepath.Base(poFile), filepath.Ext(poFile))
+	opts := util.MsgSplitOptions{Chunks: v.O.Chunks, ByFile: v.O.ByFile}
+	if v.O.Team {
+		assignees, err := teamAssignees(base)
+		if err != nil {
+			return NewStandardErrorF("%v", err)
+		}
+		opts.Assignees = assignees
+	}
+
+	data, err := os.ReadFile(poFile)
+	if err != nil {
+		return NewStandardErrorF("failed to read %s: %v", poFile, err)
+	}
+	j, err := util.LoadFileToGettextJSON(data, poFile)
+	if err != nil {
+		return NewStandardErrorF("%v", err)
+	}
+	filter := util.EntryStateFilter{
+		Translated:   v.O.Translated,
+		Untranslated: v.O.Untranslated,
+		Fuzzy:        v.O.Fuzzy,
+		NoObsolete:   true,
+	}
+	j.Entries = util.FilterGettextEntries(j.Entries, filter)
+	chunks, err := util.MsgSplit(j, poFile, opts)
+	if err != nil {
+		return NewStandardErrorF("%v", err)
+	}
+
+	useJSON := v.O.JSON || util.IsGettextJSONData(data)
+	ext := ".po"
+	if useJSON {
+		ext = ".json"
+	}
+	width := len(fmt.Sprint(len(chunks)))
+	for _, c := range chunks {
+		name := fmt.Sprintf("%s-%0*d", base, width, c.Index)
+		if c.Assignee != "" {
+			name += "-" + assigneeSlug(c.Assignee)
+		}
+		file := filepath.Join(v.O.OutputDir, name+ext)
+		if err := writeSplitChunk(file, c.JSON, useJSON); err != nil {
+			return NewStandardErrorF("%v", err)
+		}
+		desc := fmt.Sprintf("%d entries", c.Len())
+		if c.Len() > 0 {
+			desc += fmt.Sprintf(" (%d-%d)", c.First, c.Last)
+		}
+		if c.Assignee != "" {
+			desc += " for " + c.Assignee
+		}
+		fmt.Printf("%s: %s\n", file, desc)
+	}
+	return nil
+}
+
+// teamAssignees returns the leader and the members of the team of locale in
+// po/TEAMS.
+func teamAssignees(locale string) ([]string, error) {
+	teamsFile := filepath.Join(util.PoDir, "TEAMS")
+	if !util.IsFile(teamsFile) {
+		return nil, fmt.Errorf("--team requires %s", teamsFile)
+	}
+	teams, errs := util.ParseTeams(teamsFile)
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+	for _, team := range teams {
+		if strings.SplitN(team.Language, " ", 2)[0] != locale {
+			continue
+		}
+		var assignees []string
+		for _, user := range append([]util.User{team.Leader}, team.Members...) {
+			if user.Name != "" {
+				assignees = append(assignees, fmt.Sprintf("%s <%s>", user.Name, user.Email))
+			}
+		}
+		if len(assignees) == 0 {
+			return nil, fmt.Errorf("team %s in %s has no members", locale, teamsFile)
+		}
+		return assignees, nil
+	}
+	return nil, fmt.Errorf("no team for %s in %s", locale, teamsFile)
+}
+
+// assigneeSlug returns the name of "Name <email>" for file names, e.g.
+// "jiang-xin" for "Jiang Xin <...>".
+func assigneeSlug(assignee string) string {
+	if i := strings.Index(assignee, " <"); i >= 0 {
+		assignee = assignee[:i]
+	}
+	words := strings.FieldsFunc(strings.ToLower(assignee), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, "-")
+}
+
+func writeSplitChunk(file string, j *util.GettextJSON, useJSON bool) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("failed to create output file %s: %v", file, err)
+	}
+	defer f.Close()
+	if useJSON {
+		return util.WriteGettextJSONToJSON(j, f)
+	}
+	return util.WriteGettextJSONToPO(j, f, false, false)
+}
+
+var msgSplitCmd = msgSplitCommand{}
+
+func init() {
+	rootCmd.AddCommand(msgSplitCmd.Command())
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/git-l10n/git-po-helper/flag"
@@ -11,6 +12,15 @@ import (
 
 type statCommand struct {
 	cmd *cobra.Command
+	O   struct {
+		JSON     bool
+		CSV      bool
+		Markdown bool
+		All      bool
+		Pot      string
+		History  string
+		Tags     bool
+	}
 }
 
 func (v *statCommand) Command() *cobra.Command {
@@ -19,53 +29,144 @@ func (v *statCommand) Command() *cobra.Command {
 	}
 
 	v.cmd = &cobra.Command{
-		Use:   "stat <file> [file...]",
-		Short: "Report statistics for PO/JSON file(s)",
-		Long: `Report entry statistics for PO or gettext JSON files:
+		Use:   "stat [--json | --csv | --markdown] [--pot <file>] (--all | --history <rev-range> | <file> [file...])",
+		Short: "Report statistics for PO/JSON/MO file(s)",
+		Long: `Report entry statistics for PO, gettext JSON or compiled .mo files:
   translated   - entries with non-empty translation
   untranslated - entries with empty msgstr
   same         - entries where msgstr equals msgid (suspect untranslated)
   fuzzy        - entries with fuzzy flag
   obsolete     - obsolete entries (#~ format)
 
-Input can be PO/POT files or gettext JSON (same schema as msg-select --json).
-Format is auto-detected: JSON if file starts with '{' after whitespace.
+Input can be PO/POT files, gettext JSON (same schema as msg-select --json) or
+.mo files (little- or big-endian, e.g. shipped in a release build; they only
+contain translated entries). Format is auto-detected: .mo by its magic number,
+JSON if file starts with '{' after whitespace.
 
 When run inside a git worktree, paths are relative to the project root (e.g. po/zh_CN.po).
 When run outside a git repository, paths are relative to the current directory or absolute.
 
+With --json, --csv or --markdown, write one record per file with the counts,
+the total and the percentages of translated, fuzzy and untranslated messages.
+With --pot, the counts are against the messages of the POT file: messages
+missing in a file are untranslated and messages not in the POT are ignored.
+
+With --all, report all po/*.po files sorted by completion, against po/git.pot
+if it exists, e.g. to paste a progress table in an announcement:
+
+  git-po-helper stat --all --markdown
+
+With --history <rev-range>, report all po/*.po files at each commit of the
+range that changed po/ (first-parent), or with --tags at each tagged commit,
+as one time series per language (text, --json or --csv). Files are read from
+git, without checking out the commits. When po/git.pot is in a commit, the
+counts are against it:
+
+  git-po-helper stat --history v2.30.0..v2.45.0 --tags --csv
+
 For review JSON report, use: git-po-helper agent-run report [path]`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return v.Execute(args)
 		},
 	}
+	fs := v.cmd.Flags()
+	fs.BoolVar(&v.O.JSON, "json", false, "write statistics as JSON")
+	fs.BoolVar(&v.O.CSV, "csv", false, "write statistics as CSV")
+	fs.BoolVar(&v.O.Markdown, "markdown", false, "write statistics as a Markdown table")
+	fs.BoolVar(&v.O.All, "all", false,
+		"report all "+util.PoDir+"/*.po files sorted by completion")
+	fs.StringVar(&v.O.Pot, "pot", "",
+		"count against the messages of this POT file (default for --all: "+util.GetPotFilePath()+")")
+	fs.StringVar(&v.O.History, "history", "",
+		"report progress of all languages at each commit of this revision range")
+	fs.BoolVar(&v.O.Tags, "tags", false,
+		"with --history, report only tagged commits (e.g. releases)")
 
 	return v.cmd
 }
 
 func (v statCommand) Execute(args []string) error {
-	if len(args) < 1 {
+	format := util.StatFormatText
+	nFormats := 0
+	for _, f := range []struct {
+		set    bool
+		format string
+	}{
+		{v.O.JSON, util.StatFormatJSON},
+		{v.O.CSV, util.StatFormatCSV},
+		{v.O.Markdown, util.StatFormatMarkdown},
+	} {
+		if f.set {
+			format = f.format
+			nFormats++
+		}
+	}
+	if nFormats > 1 {
+		return NewErrorWithUsage("--json, --csv and --markdown are mutually exclusive")
+	}
+
+	if v.O.History != "" {
+		return v.executeHistory(args, format)
+	}
+	if v.O.Tags {
+		return NewErrorWithUsage("--tags requires --history")
+	}
+
+	potFile := v.O.Pot
+	if v.O.All {
+		if len(args) > 0 {
+			return NewErrorWithUsage("--all cannot be used with file arguments")
+		}
+		files, err := util.ListPoFiles(util.PoDir)
+		if err != nil {
+			return NewStandardErrorF("fail to list PO files: %v", err)
+		}
+		if len(files) == 0 {
+			return NewStandardErrorF("no PO files in %s", util.PoDir)
+		}
+		args = files
+		if potFile == "" && util.Exist(util.GetPotFilePath()) {
+			potFile = util.GetPotFilePath()
+		}
+	} else if len(args) < 1 {
 		return NewErrorWithUsage("stat requires at least one argument: <file> [file...]")
 	}
 
-	var errs []string
-	for i, file := range args {
+	var potKeys map[string]bool
+	if potFile != "" {
+		var err error
+		if potKeys, err = util.LoadPotKeys(potFile); err != nil {
+			return NewStandardErrorF("%v", err)
+		}
+	}
+
+	var (
+		errs    []string
+		reports []*util.StatReport
+	)
+	for _, file := range args {
 		if !util.Exist(file) {
 			errs = append(errs, fmt.Sprintf("file does not exist: %s", file))
 			continue
 		}
 
-		stats, err := util.GetPoStats(file)
+		report, err := util.GetStatReport(file, potKeys)
 		if err != nil {
 			errs = append(errs, fmt.Sprintf("%s: %v", file, err))
 			continue
 		}
+		reports = append(reports, report)
+	}
+	if v.O.All {
+		util.SortStatReports(reports)
+	}
 
-		if flag.Verbose() > 0 {
+	if format == util.StatFormatText && flag.Verbose() > 0 {
+		for i, stats := range reports {
 			if i > 0 {
 				fmt.Println()
 			}
-			title := fmt.Sprintf("File: %s", file)
+			title := fmt.Sprintf("File: %s", stats.File)
 			fmt.Println(title)
 			fmt.Println(strings.Repeat("-", len(title)))
 			fmt.Printf("  translated:   %d\n", stats.Translated)
@@ -73,9 +174,9 @@ func (v statCommand) Execute(args []string) error {
 			fmt.Printf("  same:         %d\n", stats.Same)
 			fmt.Printf("  fuzzy:        %d\n", stats.Fuzzy)
 			fmt.Printf("  obsolete:     %d\n", stats.Obsolete)
-		} else {
-			fmt.Printf("%s: %s", file, util.FormatStatLine(stats))
 		}
+	} else if err := util.WriteStatReports(os.Stdout, reports, format); err != nil {
+		errs = append(errs, err.Error())
 	}
 
 	if len(errs) > 0 {
@@ -84,6 +185,26 @@ func (v statCommand) Execute(args []string) error {
 	return nil
 }
 
+func (v statCommand) executeHistory(args []string, format string) error {
+	if len(args) > 0 || v.O.All || v.O.Pot != "" {
+		return NewErrorWithUsage("--history cannot be used with --all, --pot or file arguments")
+	}
+	if format == util.StatFormatMarkdown {
+		return NewErrorWithUsage("--history cannot be used with --markdown")
+	}
+	points, err := util.GetStatHistory(v.O.History, v.O.Tags)
+	if err != nil {
+		return NewStandardErrorF("%v", err)
+	}
+	if len(points) == 0 {
+		return NewStandardErrorF("no commits in %s", v.O.History)
+	}
+	if err := util.WriteStatHistory(os.Stdout, points, format); err != nil {
+		return NewStandardErrorF("%v", err)
+	}
+	return nil
+}
+
 var statCmd = statCommand{}
 
 func init() {
@@ -28,8 +28,12 @@ func (v *updateCommand) Command() *cobra.Command {
 	v.cmd.Flags().Bool("no-line-number",
 		false,
 		"no line number in comment for entry")
+	v.cmd.Flags().Bool("native-merge",
+		false,
+		"merge with the built-in msgmerge (used when msgmerge is not installed)")
 	_ = viper.BindPFlag("no-location", v.cmd.Flags().Lookup("no-location"))
 	_ = viper.BindPFlag("no-line-number", v.cmd.Flags().Lookup("no-line-number"))
+	_ = viper.BindPFlag("update--native-merge", v.cmd.Flags().Lookup("native-merge"))
 	return v.cmd
 }
 
@@ -644,3 +644,96 @@ func TestLoadPotProjectsFromFile_InvalidYAML(t *testing.T) {
 		t.Fatal("LoadPotProjectsFromFile expected error for invalid YAML")
 	}
 }
+
+func TestLoadCheckPoConfigFromFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "git-po-helper.yaml")
+	if err := os.WriteFile(configPath, []byte(`default_lang_code: zh_CN
+checks:
+  obsolete: off
+  typos: warn
+  languages:
+    zh_CN:
+      typos: error
+      c-format: warn
+glossary:
+  zh_CN: po/glossary/zh_CN.tsv
+  fr: /usr/share/glossary/fr.yaml
+spelling:
+  de:
+    dictionary: /usr/share/hunspell/de_DE.dic
+    words: po/spelling/de.txt
+  fr:
+    dictionary: dict/fr
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadCheckPoConfigFromFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checks := cfg.Checks
+	for _, tc := range []struct{ name, locale, want string }{
+		{"obsolete", "zh_CN", CheckLevelOff},
+		{"typos", "de", CheckLevelWarn},
+		{"typos", "zh_CN", CheckLevelError},
+		{"c-format", "zh_CN", CheckLevelWarn},
+		{"c-format", "de", ""},
+	} {
+		if got := checks.Level(tc.name, tc.locale); got != tc.want {
+			t.Errorf("Level(%q, %q) = %q, want %q", tc.name, tc.locale, got, tc.want)
+		}
+	}
+	merged := checks.Merge(&ChecksConfig{
+		Levels:    map[string]string{"typos": CheckLevelOff},
+		Languages: map[string]map[string]string{"zh_CN": {"obsolete": CheckLevelOn}},
+	})
+	if got := merged.Level("typos", "de"); got != CheckLevelOff {
+		t.Errorf("merged typos for de = %q, want off", got)
+	}
+	if got := merged.Level("typos", "zh_CN"); got != CheckLevelError {
+		t.Errorf("merged typos for zh_CN = %q, want error", got)
+	}
+	if got := merged.Level("obsolete", "zh_CN"); got != CheckLevelOn {
+		t.Errorf("merged obsolete for zh_CN = %q, want on", got)
+	}
+
+	if got, want := cfg.Glossary["zh_CN"], filepath.Join(tmpDir, "po", "glossary", "zh_CN.tsv"); got != want {
+		t.Errorf("zh_CN glossary = %q, want %q", got, want)
+	}
+	if got := cfg.Glossary["fr"]; got != "/usr/share/glossary/fr.yaml" {
+		t.Errorf("fr glossary = %q", got)
+	}
+
+	if got := cfg.Spelling["de"]; got.Dictionary != "/usr/share/hunspell/de_DE" ||
+		got.Words != filepath.Join(tmpDir, "po", "spelling", "de.txt") {
+		t.Errorf("de spelling = %+v", got)
+	}
+	if got := cfg.Spelling["fr"]; got.Dictionary != filepath.Join(tmpDir, "dict", "fr") || got.Words != "" {
+		t.Errorf("fr spelling = %+v", got)
+	}
+
+	overlay := &CheckPoConfig{
+		Checks:   &ChecksConfig{Levels: map[string]string{"obsolete": CheckLevelOn}},
+		Glossary: map[string]string{"fr": "fr.tsv"},
+		Spelling: map[string]SpellingConfig{"fr": {Dictionary: "fr_FR"}},
+	}
+	all := cfg.Merge(overlay)
+	if got := all.Checks.Level("obsolete", "de"); got != CheckLevelOn {
+		t.Errorf("merged obsolete for de = %q, want on", got)
+	}
+	if got := all.Checks.Level("typos", "zh_CN"); got != CheckLevelError {
+		t.Errorf("merged typos for zh_CN = %q, want error", got)
+	}
+	if all.Glossary["fr"] != "fr.tsv" || all.Glossary["zh_CN"] != cfg.Glossary["zh_CN"] {
+		t.Errorf("merged glossary = %v", all.Glossary)
+	}
+	if all.Spelling["fr"].Dictionary != "fr_FR" || all.Spelling["de"] != cfg.Spelling["de"] {
+		t.Errorf("merged spelling = %+v", all.Spelling)
+	}
+
+	if cfg, err := LoadCheckPoConfigFromFile(filepath.Join(tmpDir, "missing.yaml")); cfg != nil || err != nil {
+		t.Errorf("missing file: got %v, %v", cfg, err)
+	}
+}
@@ -1,5 +1,5 @@
 // Package config provides configuration structures and loading for git-po-helper:
-// agent settings and POT project settings, loaded from .git-po-helper.yaml.
+// agent settings, POT project settings and check levels, loaded from .git-po-helper.yaml.
 package config
 
 import (
@@ -371,6 +371,148 @@ func LoadPotProjectsFromFile(configPath string) (map[string]PotProjectEntry, err
 	return section.Projects, nil
 }
 
+// Levels of checks in the "checks" section.
+const (
+	CheckLevelOff   = "off"   // do not run the check
+	CheckLevelOn    = "on"    // run the check with its default level
+	CheckLevelWarn  = "warn"  // report problems as warnings, never fail
+	CheckLevelError = "error" // fail on problems
+)
+
+// ChecksConfig holds the levels of the checks of check-po (key "checks"), by
+// check name, for all languages and for some languages (key "languages",
+// by locale):
+//
+//	checks:
+//	  obsolete: off
+//	  typos: warn
+//	  languages:
+//	    zh_CN:
+//	      typos: error
+type ChecksConfig struct {
+	Levels    map[string]string            `yaml:",inline"`
+	Languages map[string]map[string]string `yaml:"languages"`
+}
+
+// Merge returns c with the levels of overlay added or overridden.
+func (c *ChecksConfig) Merge(overlay *ChecksConfig) *ChecksConfig {
+	result := &ChecksConfig{
+		Levels:    make(map[string]string),
+		Languages: make(map[string]map[string]string),
+	}
+	for _, cfg := range []*ChecksConfig{c, overlay} {
+		if cfg == nil {
+			continue
+		}
+		for name, level := range cfg.Levels {
+			result.Levels[name] = level
+		}
+		for locale, levels := range cfg.Languages {
+			if result.Languages[locale] == nil {
+				result.Languages[locale] = make(map[string]string)
+			}
+			for name, level := range levels {
+				result.Languages[locale][name] = level
+			}
+		}
+	}
+	return result
+}
+
+// Level returns the level of check name for locale, or "" if not configured.
+// A level for the language overrides the level for all languages.
+func (c *ChecksConfig) Level(name, locale string) string {
+	if c == nil {
+		return ""
+	}
+	if level, ok := c.Languages[locale][name]; ok {
+		return level
+	}
+	return c.Levels[name]
+}
+
+// SpellingConfig is the spell checking of check-po for a language.
+type SpellingConfig struct {
+	// Dictionary is the Hunspell dictionary, the path of the .aff and .dic
+	// files without extension, e.g. "/usr/share/hunspell/de_DE".
+	Dictionary string `yaml:"dictionary"`
+	// Words is the personal word list of the language, one word per line.
+	Words string `yaml:"words"`
+}
+
+// CheckPoConfig holds the sections of a config file for check-po: the
+// levels of the checks (key "checks"), the glossary file by locale or
+// language (key "glossary"), e.g. "zh_CN: po/glossary/zh_CN.tsv", and the
+// spell checking by locale or language (key "spelling"), e.g. "de:
+// {dictionary: /usr/share/hunspell/de_DE, words: po/spelling/de.txt}".
+type CheckPoConfig struct {
+	Checks   *ChecksConfig             `yaml:"checks"`
+	Glossary map[string]string         `yaml:"glossary"`
+	Spelling map[string]SpellingConfig `yaml:"spelling"`
+}
+
+// LoadCheckPoConfigFromFile reads configPath and returns its sections for
+// check-po. Relative paths of glossary files, dictionaries and word lists are
+// resolved against the directory of configPath, and the extension of
+// dictionaries is removed. If the file does not exist, returns (nil, nil).
+// On parse error returns (nil, err).
+func LoadCheckPoConfigFromFile(configPath string) (*CheckPoConfig, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	var cfg CheckPoConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML config file: %w", err)
+	}
+	resolve := func(file string) string {
+		if file != "" && !filepath.IsAbs(file) {
+			file = filepath.Join(filepath.Dir(configPath), file)
+		}
+		return file
+	}
+	for locale, file := range cfg.Glossary {
+		cfg.Glossary[locale] = resolve(file)
+	}
+	for locale, spelling := range cfg.Spelling {
+		spelling.Dictionary = resolve(spelling.Dictionary)
+		switch filepath.Ext(spelling.Dictionary) {
+		case ".aff", ".dic":
+			spelling.Dictionary = strings.TrimSuffix(spelling.Dictionary, filepath.Ext(spelling.Dictionary))
+		}
+		spelling.Words = resolve(spelling.Words)
+		cfg.Spelling[locale] = spelling
+	}
+	return &cfg, nil
+}
+
+// Merge returns c with the sections of overlay added or overridden: the
+// levels of the checks, and the glossary files and spell checking by locale.
+func (c *CheckPoConfig) Merge(overlay *CheckPoConfig) *CheckPoConfig {
+	result := &CheckPoConfig{
+		Glossary: make(map[string]string),
+		Spelling: make(map[string]SpellingConfig),
+	}
+	for _, cfg := range []*CheckPoConfig{c, overlay} {
+		if cfg == nil {
+			continue
+		}
+		if cfg.Checks != nil {
+			result.Checks = result.Checks.Merge(cfg.Checks)
+		}
+		for locale, file := range cfg.Glossary {
+			result.Glossary[locale] = file
+		}
+		for locale, spelling := range cfg.Spelling {
+			result.Spelling[locale] = spelling
+		}
+	}
+	return result
+}
+
 // mergeConfigs merges baseConfig and overlay. mergeAgents controls Agents behavior:
 // - mergeAgents true: overlay overrides base; Agents are merged by key (overlay adds or overrides).
 // - mergeAgents false: overlay fills only unset fields in base; Agents are not modified (no merge, no copy).
@@ -0,0 +1,38 @@
+package dict
+
+// Punctuation is a punctuation mark at the end of a message, with the
+// endings of a message which have the mark.
+type Punctuation struct {
+	Name    string
+	Endings []string
+}
+
+// DefaultPunctuation are the punctuation marks of msgid, and of msgstr in
+// languages without an entry in LocalePunctuation. A message ending with
+// "..." ends with an ellipsis, not a period, so ellipsis comes first.
+var DefaultPunctuation = []Punctuation{
+	{Name: "ellipsis", Endings: []string{"...", "…"}},
+	{Name: "colon", Endings: []string{":"}},
+	{Name: "period", Endings: []string{"."}},
+}
+
+// cjkPunctuation accepts full-width punctuation.
+var cjkPunctuation = []Punctuation{
+	{Name: "ellipsis", Endings: []string{"……", "…", "..."}},
+	{Name: "colon", Endings: []string{"：", ":"}},
+	{Name: "period", Endings: []string{"。", "．", "."}},
+}
+
+// LocalePunctuation defines the punctuation marks of msgstr for a locale
+// ("zh_CN") or a language ("zh").
+var LocalePunctuation = map[string][]Punctuation{
+	"ja": cjkPunctuation,
+	"zh": cjkPunctuation,
+	// A space, a no-break space or a narrow no-break space goes before
+	// a colon in French.
+	"fr": {
+		{Name: "ellipsis", Endings: []string{"...", "…"}},
+		{Name: "colon", Endings: []string{" :", "\u00a0:", "\u202f:", ":"}},
+		{Name: "period", Endings: []string{"."}},
+	},
+}
@@ -41,21 +41,25 @@ func NoGPG() bool {
 	return GitHubActionEvent() != "" || viper.GetBool("check--no-gpg") || viper.GetBool("check-commits--no-gpg")
 }
 
-// ReportTypos returns way to display typos (none, warn, error).
-func ReportTypos() int {
-	var value = ""
+// reportIssueValue returns the value of option "--report-<name>" of check,
+// check-po or check-commits.
+func reportIssueValue(name string) string {
+	for _, cmd := range []string{"check", "check-po", "check-commits"} {
+		if v := viper.GetString(cmd + "--report-" + name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
 
+// reportIssue returns way to report the issues of option "--report-<name>"
+// (none, warn, error). Default is error; issues are only reported as warnings
+// in GitHub Actions.
+func reportIssue(name string) int {
 	if GitHubActionEvent() != "" {
 		return ReportIssueWarn
 	}
-	if v := viper.GetString("check--report-typos"); v != "" {
-		value = v
-	} else if v := viper.GetString("check-po--report-typos"); v != "" {
-		value = v
-	} else if v := viper.GetString("check-commits--report-typos"); v != "" {
-		value = v
-	}
-	switch value {
+	switch reportIssueValue(name) {
 	case "none":
 		return ReportIssueNone
 	case "warn":
@@ -67,6 +71,24 @@ func ReportTypos() int {
 	}
 }
 
+// ReportTypos returns way to display typos (none, warn, error).
+func ReportTypos() int {
+	return reportIssue("typos")
+}
+
+// ReportPunctuation returns way to report punctuation and whitespace
+// differences between msgid and msgstr (none, warn, error).
+func ReportPunctuation() int {
+	return reportIssue("punctuation")
+}
+
+// ReportPunctuationEnabled returns true if option "--report-punctuation" is
+// given and is not none, which turns on the punctuation check.
+func ReportPunctuationEnabled() bool {
+	value := reportIssueValue("punctuation")
+	return value != "" && value != "none"
+}
+
 // AllowObsoleteEntries returns true when obsolete entries should be allowed
 // (e.g. after msgmerge in update flow, which creates obsolete entries by design).
 func AllowObsoleteEntries() bool {
@@ -122,6 +144,22 @@ func NoCheckFilter() bool {
 		viper.GetBool("check-commits--no-check-filter")
 }
 
+// CheckOnly returns option "--only" of check-po: names of the only checks to run.
+func CheckOnly() []string {
+	return viper.GetStringSlice("check-po--only")
+}
+
+// CheckSkip returns option "--skip" of check-po: names of the checks not to run.
+func CheckSkip() []string {
+	return viper.GetStringSlice("check-po--skip")
+}
+
+// Consistency returns option "--consistency" of check-po: also report
+// inconsistent translations across the entries of a PO file.
+func Consistency() bool {
+	return viper.GetBool("check-po--consistency")
+}
+
 // NoSpecialGettextVersions returns option "--no-special-gettext-versions".
 func NoSpecialGettextVersions() bool {
 	return viper.GetBool("no-special-gettext-versions")
@@ -142,3 +180,64 @@ func GettextUseMultipleVersions() bool {
 func GetConfigFilePath() string {
 	return viper.GetString("config")
 }
+
+// PoWrapWidth returns the page width for PO output of msg-select, msg-cat,
+// msg-grep and msg-merge (option "--width"), or 0 when long lines are not
+// wrapped: by default, or with "--no-wrap". Strings are always split after
+// embedded newlines.
+func PoWrapWidth() int {
+	if viper.GetBool("msg-select--no-wrap") || viper.GetBool("msg-cat--no-wrap") ||
+		viper.GetBool("msg-grep--no-wrap") || viper.GetBool("msg-merge--no-wrap") {
+		return 0
+	}
+	if v := viper.GetInt("msg-select--width"); v > 0 {
+		return v
+	}
+	if v := viper.GetInt("msg-grep--width"); v > 0 {
+		return v
+	}
+	if v := viper.GetInt("msg-merge--width"); v > 0 {
+		return v
+	}
+	return viper.GetInt("msg-cat--width")
+}
+
+// PoKeepLayout returns option "--keep-layout" of msg-select, msg-cat, msg-grep,
+// msg-apply, msg-edit and msg-merge: write the original lines of unmodified
+// entries instead of regenerating them.
+func PoKeepLayout() bool {
+	return viper.GetBool("msg-select--keep-layout") || viper.GetBool("msg-cat--keep-layout") ||
+		viper.GetBool("msg-grep--keep-layout") || viper.GetBool("msg-apply--keep-layout") ||
+		viper.GetBool("msg-edit--keep-layout") || viper.GetBool("msg-merge--keep-layout")
+}
+
+// XLIFFVersion returns option "--xliff-version" of msg-select, msg-cat and
+// msg-grep: the XLIFF version written by "--format xliff" ("1.2" or "2.0",
+// default "1.2").
+func XLIFFVersion() string {
+	if v := viper.GetString("msg-select--xliff-version"); v != "" {
+		return v
+	}
+	if v := viper.GetString("msg-grep--xliff-version"); v != "" {
+		return v
+	}
+	if v := viper.GetString("msg-cat--xliff-version"); v != "" {
+		return v
+	}
+	return "1.2"
+}
+
+// GettextJSONVersion returns option "--json-version" of msg-select, msg-cat
+// and msg-grep: the schema version of gettext JSON output (1 or 2, default 1).
+func GettextJSONVersion() int {
+	if v := viper.GetInt("msg-select--json-version"); v != 0 {
+		return v
+	}
+	if v := viper.GetInt("msg-grep--json-version"); v != 0 {
+		return v
+	}
+	if v := viper.GetInt("msg-cat--json-version"); v != 0 {
+		return v
+	}
+	return 1
+}
@@ -0,0 +1,93 @@
+#!/bin/sh
+#
+# Test msg-select and msg-cat: PO -> XLIFF -> PO round-trip (XLIFF 1.2 and 2.0)
+# with msgctxt, plural forms, fuzzy and obsolete entries, comments and
+# special chars (\n, \t, \r, \", \\, <, &).
+#
+
+test_description="msg-select and msg-cat PO and XLIFF round-trip"
+
+. ./lib/test-lib.sh
+
+HELPER="$TEST_TARGET_DIRECTORY/git-po-helper --no-special-gettext-versions"
+
+test_expect_success "setup: create input.po" '
+	bsl=$(printf '\''\\\\'\'') &&
+	cat >input.po <<-ENDPO
+	# Test translations for Git package
+	#
+	msgid ""
+	msgstr ""
+	"Project-Id-Version: git\n"
+	"Language: zh_CN\n"
+	"Content-Type: text/plain; charset=UTF-8\n"
+	"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+	# Translator comment
+	#. TRANSLATORS: <tags> & "quotes"
+	#: src/a.c:1
+	msgid ""
+	"Line one\n"
+	"Line two\twith tab\n"
+	"Line three\rwith CR\n"
+	"Line four\"with quote <b>&amp;</b>\n"
+	"Line five${bsl}with slash\n"
+	msgstr ""
+	"第1行\n"
+	"第2行\t带制表符\n"
+	"第3行\r带回车\n"
+	"第4行\"带引号 <b>&amp;</b>\n"
+	"第5行${bsl}带斜线\n"
+
+	msgctxt "verb"
+	msgid "commit"
+	msgstr "提交"
+
+	#, fuzzy, c-format
+	#| msgid "%d old file"
+	msgid "%d file"
+	msgid_plural "%d files"
+	msgstr[0] "%d 个文件"
+	msgstr[1] "%d 个文件"
+
+	msgid "untranslated"
+	msgstr ""
+
+	#~ msgid "old"
+	#~ msgstr "旧"
+	ENDPO
+'
+
+test_expect_success "msg-select: PO -> XLIFF 1.2 -> PO" '
+	$HELPER msg-select --format xliff -o input.xlf input.po &&
+	grep "<xliff version=\"1.2\"" input.xlf &&
+	grep "restype=\"x-gettext-plurals\"" input.xlf &&
+	$HELPER msg-select -o xlf2po.po input.xlf &&
+	test_cmp input.po xlf2po.po
+'
+
+test_expect_success "msg-select: PO -> XLIFF 2.0 -> PO" '
+	$HELPER msg-select --format xliff --xliff-version 2.0 -o input2.xlf input.po &&
+	grep "<xliff version=\"2.0\"" input2.xlf &&
+	grep "<segment state=\"initial\">" input2.xlf &&
+	$HELPER msg-select -o xlf2po2.po input2.xlf &&
+	test_cmp input.po xlf2po2.po
+'
+
+test_expect_success "msg-select: XLIFF -> JSON same as PO -> JSON" '
+	$HELPER msg-select --json input.po >po2json.json &&
+	$HELPER msg-select --format json input.xlf >xlf2json.json &&
+	test_cmp po2json.json xlf2json.json
+'
+
+test_expect_success "msg-cat: PO -> XLIFF -> PO" '
+	$HELPER msg-cat --format xliff -o cat.xlf input.po &&
+	$HELPER msg-cat -o cat.po cat.xlf &&
+	test_cmp input.po cat.po
+'
+
+test_expect_success "msg-select: --json conflicts with --format xliff" '
+	test_must_fail $HELPER msg-select --json --format xliff input.po
+'
+
+test_done
@@ -0,0 +1,74 @@
+#!/bin/sh
+#
+# Test msg-select --format csv/tsv and msg-apply: export entries to a table,
+# edit translations and apply them back onto the PO file.
+#
+
+test_description="msg-select CSV/TSV export and msg-apply re-import"
+
+. ./lib/test-lib.sh
+
+HELPER="$TEST_TARGET_DIRECTORY/git-po-helper --no-special-gettext-versions"
+
+test_expect_success "setup: create input.po" '
+	cat >input.po <<-\ENDPO
+	msgid ""
+	msgstr ""
+	"Content-Type: text/plain; charset=UTF-8\n"
+
+	#: src/a.c:1
+	msgid "Hello"
+	msgstr "你好"
+
+	#, fuzzy
+	msgid "World"
+	msgstr "世界"
+	ENDPO
+'
+
+test_expect_success "msg-select --format tsv" '
+	$HELPER msg-select --format tsv -o out.tsv input.po &&
+	printf "index\tmsgctxt\tmsgid\tmsgid_plural\tmsgstr[0]\tfuzzy\tcomments\n" >expect &&
+	printf "1\t\tHello\t\t你好\t\t#: src/a.c:1\n" >>expect &&
+	printf "2\t\tWorld\t\t世界\tyes\t\n" >>expect &&
+	test_cmp expect out.tsv
+'
+
+test_expect_success "msg-apply: unchanged table keeps PO file" '
+	$HELPER msg-apply -o out.po input.po out.tsv &&
+	test_cmp input.po out.po
+'
+
+test_expect_success "msg-apply: apply edited CSV" '
+	cat >edit.csv <<-\EOF &&
+	msgid,msgstr[0],fuzzy
+	World,世界！,
+	EOF
+	$HELPER msg-apply -o out.po input.po edit.csv &&
+	cat >expect <<-\ENDPO &&
+	msgid ""
+	msgstr ""
+	"Content-Type: text/plain; charset=UTF-8\n"
+
+	#: src/a.c:1
+	msgid "Hello"
+	msgstr "你好"
+
+	msgid "World"
+	msgstr "世界！"
+	ENDPO
+	test_cmp expect out.po
+'
+
+test_expect_success "msg-apply: reject rows of removed msgids" '
+	cat >edit.csv <<-\EOF &&
+	msgid,msgstr[0]
+	Hello,您好
+	Gone,没了
+	EOF
+	test_must_fail $HELPER msg-apply -o out.po input.po edit.csv 2>stderr &&
+	grep "row 3: msgid \"Gone\" not found" stderr &&
+	grep "msgstr \"您好\"" out.po
+'
+
+test_done
@@ -0,0 +1,83 @@
+#!/bin/sh
+#
+# Test msg-grep: select entries by pattern.
+#
+
+test_description="msg-grep: search catalog entries by pattern"
+
+. ./lib/test-lib.sh
+
+HELPER="$TEST_TARGET_DIRECTORY/git-po-helper --no-special-gettext-versions"
+
+test_expect_success "setup: create input.po" '
+	cat >input.po <<-\ENDPO
+	msgid ""
+	msgstr ""
+	"Content-Type: text/plain; charset=UTF-8\n"
+
+	#: builtin/stash.c:10
+	msgid "Stash the changes"
+	msgstr "贮藏修改"
+
+	#. TRANSLATORS: a noun
+	#: builtin/log.c:20
+	msgctxt "noun"
+	msgid "stash"
+	msgstr "储藏"
+
+	#, fuzzy
+	msgid "Hello"
+	msgstr "你好"
+	ENDPO
+'
+
+test_expect_success "msg-grep: case-insensitive match on msgid and msgstr" '
+	$HELPER msg-grep -i stash input.po >out.po 2>err &&
+	cat >expect <<-\ENDPO &&
+	msgid ""
+	msgstr ""
+	"Content-Type: text/plain; charset=UTF-8\n"
+
+	#: builtin/stash.c:10
+	msgid "Stash the changes"
+	msgstr "贮藏修改"
+
+	#. TRANSLATORS: a noun
+	#: builtin/log.c:20
+	msgctxt "noun"
+	msgid "stash"
+	msgstr "储藏"
+
+	ENDPO
+	test_cmp expect out.po &&
+	echo "2 of 3 entries matched" >expect &&
+	test_cmp expect err
+'
+
+test_expect_success "msg-grep: several patterns on msgstr, with --count" '
+	$HELPER msg-grep --msgstr -e 贮藏 -e 储藏 --count input.po >out &&
+	echo 2 >expect &&
+	test_cmp expect out
+'
+
+test_expect_success "msg-grep: invert match with state filter" '
+	$HELPER msg-grep --invert-match --translated -F Hello --count input.po >out &&
+	echo 2 >expect &&
+	test_cmp expect out
+'
+
+test_expect_success "msg-grep: source references and comments" '
+	$HELPER msg-grep --location -F builtin/log.c --count input.po >out &&
+	echo 1 >expect &&
+	test_cmp expect out &&
+	$HELPER msg-grep --comment noun --count input.po >out &&
+	test_cmp expect out
+'
+
+test_expect_success "msg-grep: JSON output" '
+	$HELPER msg-grep --msgctxt -e "^noun$" --json input.po >out.json 2>/dev/null &&
+	grep "\"msgid\": \"stash\"" out.json &&
+	! grep "Stash the changes" out.json
+'
+
+test_done
@@ -0,0 +1,116 @@
+#!/bin/sh
+#
+# Test msg-edit: bulk edits of catalog entries.
+#
+
+test_description="msg-edit: edit catalog entries in bulk"
+
+. ./lib/test-lib.sh
+
+HELPER="$TEST_TARGET_DIRECTORY/git-po-helper --no-special-gettext-versions"
+
+test_expect_success "setup: create input.po" '
+	cat >input.po <<-\ENDPO
+	msgid ""
+	msgstr ""
+	"Content-Type: text/plain; charset=UTF-8\n"
+
+	# keep me
+	#: builtin/stash.c:10
+	#, c-format
+	msgid "Stash %s"
+	msgstr "贮藏 %s"
+
+	#, fuzzy
+	msgid "stash list"
+	msgstr "贮藏列表"
+
+	msgid "Hello"
+	msgstr "你好"
+	ENDPO
+'
+
+test_expect_success "msg-edit: --dry-run prints a diff and writes nothing" '
+	cp input.po orig.po &&
+	$HELPER msg-edit --dry-run --replace 贮藏 --with 储藏 -o input.po input.po >out 2>err &&
+	cat >expect <<-\EOF &&
+	--- a/input.po
+	+++ b/input.po
+	@@ entry 1 (line 8) @@
+	 # keep me
+	 #: builtin/stash.c:10
+	 #, c-format
+	 msgid "Stash %s"
+	-msgstr "贮藏 %s"
+	+msgstr "储藏 %s"
+	@@ entry 2 (line 12) @@
+	 #, fuzzy
+	 msgid "stash list"
+	-msgstr "贮藏列表"
+	+msgstr "储藏列表"
+	EOF
+	test_cmp expect out &&
+	echo "2 of 3 selected entries changed" >expect &&
+	test_cmp expect err &&
+	test_cmp orig.po input.po
+'
+
+test_expect_success "msg-edit: flags and comments of matching entries" '
+	$HELPER msg-edit -F --match "Stash %s" \
+		--add-flag no-c-format --remove-flag c-format \
+		--add-comment "term: 储藏" --remove-comment "^keep" \
+		input.po >out.po 2>err &&
+	cat >expect <<-\ENDPO &&
+	msgid ""
+	msgstr ""
+	"Content-Type: text/plain; charset=UTF-8\n"
+
+	# term: 储藏
+	#: builtin/stash.c:10
+	#, no-c-format
+	msgid "Stash %s"
+	msgstr "贮藏 %s"
+
+	#, fuzzy
+	msgid "stash list"
+	msgstr "贮藏列表"
+
+	msgid "Hello"
+	msgstr "你好"
+	ENDPO
+	test_cmp expect out.po &&
+	echo "1 of 1 selected entries changed" >expect &&
+	test_cmp expect err
+'
+
+test_expect_success "msg-edit: fuzzy state over a range, in place" '
+	cp input.po edit.po &&
+	$HELPER msg-edit --keep-layout --fuzzy --clear-msgstr -o edit.po edit.po &&
+	$HELPER msg-edit --keep-layout --range 3 --set-fuzzy -o edit.po edit.po &&
+	cat >expect <<-\ENDPO &&
+	msgid ""
+	msgstr ""
+	"Content-Type: text/plain; charset=UTF-8\n"
+
+	# keep me
+	#: builtin/stash.c:10
+	#, c-format
+	msgid "Stash %s"
+	msgstr "贮藏 %s"
+
+	msgid "stash list"
+	msgstr ""
+
+	#, fuzzy
+	msgid "Hello"
+	msgstr "你好"
+	ENDPO
+	test_cmp expect edit.po
+'
+
+test_expect_success "msg-edit: an operation is required" '
+	test_must_fail $HELPER msg-edit input.po 2>err &&
+	grep "requires at least one operation" err
+'
+
+test_done
@@ -0,0 +1,97 @@
+#!/bin/sh
+#
+# Test msg-merge: built-in msgmerge with fuzzy matching.
+#
+
+test_description="msg-merge: merge a PO file with a POT template"
+
+. ./lib/test-lib.sh
+
+HELPER="$TEST_TARGET_DIRECTORY/git-po-helper --no-special-gettext-versions"
+
+test_expect_success "setup: create zh_CN.po and git.pot" '
+	cat >zh_CN.po <<-\ENDPO &&
+	msgid ""
+	msgstr ""
+	"Project-Id-Version: git\n"
+	"POT-Creation-Date: 2024-01-01 00:00+0800\n"
+	"Content-Type: text/plain; charset=UTF-8\n"
+
+	# translator note
+	#: builtin/stash.c:10
+	#, c-format
+	msgid "Stash the changes in %s"
+	msgstr "贮藏 %s 中的修改"
+
+	msgid "removed message"
+	msgstr "删除的消息"
+
+	#~ msgid "Hello world"
+	#~ msgstr "你好世界"
+	ENDPO
+	cat >git.pot <<-\ENDPO
+	msgid ""
+	msgstr ""
+	"Project-Id-Version: git\n"
+	"POT-Creation-Date: 2025-06-01 12:00+0800\n"
+	"Content-Type: text/plain; charset=CHARSET\n"
+
+	#: builtin/stash.c:11 builtin/stash.c:99
+	#, c-format
+	msgid "Stash all the changes in %s"
+	msgstr ""
+
+	#: hello.c:1
+	msgid "Hello world"
+	msgstr ""
+
+	#: new.c:1
+	msgid "Completely new text"
+	msgstr ""
+	ENDPO
+'
+
+test_expect_success "msg-merge: fuzzy match, resurrected and obsolete entries" '
+	$HELPER msg-merge --no-line-number zh_CN.po git.pot >out.po 2>err &&
+	cat >expect <<-\ENDPO &&
+	msgid ""
+	msgstr ""
+	"Project-Id-Version: git\n"
+	"POT-Creation-Date: 2025-06-01 12:00+0800\n"
+	"Content-Type: text/plain; charset=UTF-8\n"
+
+	# translator note
+	#: builtin/stash.c
+	#, fuzzy, c-format
+	#| msgid "Stash the changes in %s"
+	msgid "Stash all the changes in %s"
+	msgstr "贮藏 %s 中的修改"
+
+	#: hello.c
+	msgid "Hello world"
+	msgstr "你好世界"
+
+	#: new.c
+	msgid "Completely new text"
+	msgstr ""
+
+	#~ msgid "removed message"
+	#~ msgstr "删除的消息"
+	ENDPO
+	test_cmp expect out.po &&
+	cat >expect <<-\EOF &&
+	1 translated, 1 fuzzy, 1 untranslated, 1 obsolete messages (1 resurrected from obsolete)
+	fuzzy "Stash all the changes in %s": msgid changed (92% similar); was "Stash the changes in %s"
+	EOF
+	test_cmp expect err
+'
+
+test_expect_success "msg-merge: --no-fuzzy-matching and --no-location" '
+	$HELPER msg-merge --no-fuzzy-matching --no-location -o out.po zh_CN.po git.pot 2>err &&
+	! grep "^#:" out.po &&
+	! grep "fuzzy" out.po &&
+	echo "1 translated, 0 fuzzy, 2 untranslated, 2 obsolete messages (1 resurrected from obsolete)" >expect &&
+	test_cmp expect err
+'
+
+test_done
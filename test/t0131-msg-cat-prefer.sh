@@ -0,0 +1,85 @@
+#!/bin/sh
+#
+# Test msg-cat --prefer strategies, conflict report and --fail-on-conflict.
+#
+
+test_description="msg-cat: --prefer, --conflict-report and --fail-on-conflict"
+
+. ./lib/test-lib.sh
+
+HELPER="$TEST_TARGET_DIRECTORY/git-po-helper --no-special-gettext-versions"
+
+test_expect_success "setup: two catalogs with conflicting entries" '
+	cat >a.po <<-\EOF &&
+	msgid ""
+	msgstr ""
+	"Content-Type: text/plain; charset=UTF-8\n"
+
+	msgid "one"
+	msgstr "uno"
+
+	msgid "two"
+	msgstr ""
+
+	#, fuzzy
+	msgid "three"
+	msgstr "tre?"
+	EOF
+	cat >b.po <<-\EOF
+	msgid ""
+	msgstr ""
+	"Content-Type: text/plain; charset=UTF-8\n"
+
+	msgid "one"
+	msgstr "uno"
+
+	msgid "two"
+	msgstr "due"
+
+	msgid "three"
+	msgstr "tre"
+	EOF
+'
+
+test_expect_success "msg-cat --prefer first reports conflicts on stderr" '
+	$HELPER msg-cat -o out.po a.po b.po 2>stderr &&
+	grep "^msgstr \"tre?\"" out.po &&
+	grep "^msgstr \"\"$" out.po &&
+	cat >expect <<-\EOF &&
+	conflict: msgid "three"
+	    a.po: "tre?" (fuzzy) [chosen]
+	    b.po: "tre"
+	EOF
+	test_cmp expect stderr
+'
+
+test_expect_success "msg-cat --prefer non-fuzzy" '
+	$HELPER msg-cat --prefer non-fuzzy -o out.po a.po b.po 2>/dev/null &&
+	grep "^msgstr \"tre\"$" out.po &&
+	grep "^msgstr \"due\"$" out.po &&
+	! grep "fuzzy" out.po
+'
+
+test_expect_success "msg-cat --prefer last" '
+	$HELPER msg-cat --prefer last -o out.po b.po a.po 2>/dev/null &&
+	grep "^msgstr \"tre?\"" out.po
+'
+
+test_expect_success "msg-cat --conflict-report writes JSON" '
+	$HELPER msg-cat --conflict-report conflicts.json -o out.po a.po b.po 2>stderr &&
+	grep "1 entries have conflicting translations, see conflicts.json" stderr &&
+	grep "\"msgid\": \"three\"" conflicts.json &&
+	grep "\"chosen\": true" conflicts.json
+'
+
+test_expect_success "msg-cat --fail-on-conflict" '
+	test_must_fail $HELPER msg-cat --fail-on-conflict -o out.po a.po b.po &&
+	$HELPER msg-cat --fail-on-conflict -o out.po a.po
+'
+
+test_expect_success "msg-cat with invalid --prefer" '
+	test_must_fail $HELPER msg-cat --prefer newest -o out.po a.po b.po 2>stderr &&
+	grep "invalid --prefer" stderr
+'
+
+test_done
@@ -0,0 +1,141 @@
+#!/bin/sh
+#
+# Test msg-split and reassembling its chunks with msg-cat --check-split.
+#
+
+test_description="msg-split: split PO file into chunks"
+
+. ./lib/test-lib.sh
+
+HELPER="$TEST_TARGET_DIRECTORY/git-po-helper -q --no-special-gettext-versions"
+
+test_expect_success "setup: po/de.po and po/TEAMS" '
+	mkdir po &&
+	cat >po/de.po <<-\EOF &&
+	msgid ""
+	msgstr ""
+	"Project-Id-Version: git\n"
+	"Content-Type: text/plain; charset=UTF-8\n"
+
+	#: a.c:1
+	msgid "a1"
+	msgstr ""
+
+	#: a.c:2
+	msgid "a2"
+	msgstr "A2"
+
+	#: a.c:3
+	msgid "a3"
+	msgstr ""
+
+	#: b.c:1
+	msgid "b1"
+	msgstr ""
+
+	#: c.c:1
+	msgid "c1"
+	msgstr ""
+
+	#: c.c:2
+	msgid "c2"
+	msgstr ""
+
+	#~ msgid "old"
+	#~ msgstr "alt"
+	EOF
+	printf "Language:\tde (German)\n" >po/TEAMS &&
+	printf "Leader:\t\tAnna Schmidt <anna@example.com>\n" >>po/TEAMS &&
+	printf "Members:\tBob Meier <bob@example.com>\n" >>po/TEAMS
+'
+
+test_expect_success "msg-split -n 3" '
+	mkdir out &&
+	$HELPER msg-split -n 3 -o out po/de.po >actual &&
+	cat >expect <<-\EOF &&
+	out/de-1.po: 2 entries (1-2)
+	out/de-2.po: 2 entries (3-4)
+	out/de-3.po: 2 entries (5-6)
+	EOF
+	test_cmp expect actual &&
+	cat >expect <<-\EOF &&
+	msgid ""
+	msgstr ""
+	"Project-Id-Version: git\n"
+	"Content-Type: text/plain; charset=UTF-8\n"
+	"X-Split-From: po/de.po\n"
+	"X-Split-Chunk: 2/3\n"
+	"X-Split-Range: 3-4 of 6\n"
+	"X-Split-Keys: sha256:1da6e0097ab9f05e\n"
+
+	#: a.c:3
+	msgid "a3"
+	msgstr ""
+
+	#: b.c:1
+	msgid "b1"
+	msgstr ""
+	EOF
+	test_cmp expect out/de-2.po
+'
+
+test_expect_success "msg-split --team --by-file --untranslated" '
+	mkdir team &&
+	$HELPER msg-split --team --by-file --untranslated -o team po/de.po >actual &&
+	cat >expect <<-\EOF &&
+	team/de-1-anna-schmidt.po: 3 entries (1-3) for Anna Schmidt <anna@example.com>
+	team/de-2-bob-meier.po: 2 entries (4-5) for Bob Meier <bob@example.com>
+	EOF
+	test_cmp expect actual &&
+	grep "^msgid \"[a-z]" team/de-2-bob-meier.po >actual &&
+	cat >expect <<-\EOF &&
+	msgid "c1"
+	msgid "c2"
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "msg-cat --check-split reassembles translated chunks" '
+	sed -e "2!s/^msgstr \"\"$/msgstr \"X\"/" out/de-2.po >de-2.po &&
+	$HELPER msg-cat --check-split -o merged.po out/de-1.po de-2.po out/de-3.po &&
+	cat >expect <<-\EOF &&
+	msgid ""
+	msgstr ""
+	"Project-Id-Version: git\n"
+	"Content-Type: text/plain; charset=UTF-8\n"
+	EOF
+	head -4 merged.po >actual &&
+	test_cmp expect actual &&
+	grep -c "^msgstr \"X\"" merged.po >actual &&
+	echo 2 >expect &&
+	test_cmp expect actual
+'
+
+test_expect_success "msg-cat --check-split: missing and foreign chunks" '
+	test_must_fail $HELPER msg-cat --check-split -o merged.po \
+		out/de-1.po out/de-3.po team/de-1-anna-schmidt.po 2>actual &&
+	cat >expect <<-\EOF &&
+	team/de-1-anna-schmidt.po: chunk 1/2 of another split of po/de.po
+	missing chunk 2/3 of po/de.po
+	EOF
+	grep -v "^ERROR" actual >actual.problems &&
+	test_cmp expect actual.problems
+'
+
+test_expect_success "msg-cat --check-split: entry removed from a chunk" '
+	sed -e "/#: b.c:1/,/^msgstr/d" out/de-2.po >de-2.po &&
+	test_must_fail $HELPER msg-cat --check-split -o merged.po \
+		out/de-1.po de-2.po out/de-3.po 2>actual &&
+	cat >expect <<-\EOF &&
+	de-2.po: has 1 entries, expected 2 (3-4 of 6)
+	EOF
+	grep -v "^ERROR" actual >actual.problems &&
+	test_cmp expect actual.problems
+'
+
+test_expect_success "msg-split requires -n or --team" '
+	test_must_fail $HELPER msg-split po/de.po &&
+	test_must_fail $HELPER msg-split -n 2 --team po/de.po
+'
+
+test_done
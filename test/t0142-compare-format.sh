@@ -0,0 +1,94 @@
+#!/bin/sh
+#
+# Test compare --format word-diff, unified and html.
+#
+
+test_description="compare: --format word-diff, unified and html"
+
+. ./lib/test-lib.sh
+
+HELPER="$TEST_TARGET_DIRECTORY/git-po-helper -q --no-special-gettext-versions"
+
+test_expect_success "setup: create old.po and new.po" '
+	cat >old.po <<-\EOF &&
+	msgid ""
+	msgstr ""
+	"Content-Type: text/plain; charset=UTF-8\n"
+
+	msgid "Hello"
+	msgstr "你好世界"
+
+	#, c-format
+	msgid "remove %s from the index"
+	msgstr "从索引中删除 %s"
+	EOF
+
+	cat >new.po <<-\EOF
+	msgid ""
+	msgstr ""
+	"Content-Type: text/plain; charset=UTF-8\n"
+
+	msgid "Hello"
+	msgstr "您好世界"
+
+	#, fuzzy, c-format
+	#| msgid "remove %s from the index"
+	msgid "remove '\''%s'\'' from the index"
+	msgstr "从索引中删除 %s"
+	EOF
+'
+
+test_expect_success "compare --format word-diff" '
+	$HELPER compare --format word-diff old.po new.po >actual &&
+	cat >expect <<-\EOF &&
+	--- a/old.po
+	+++ b/new.po
+	@@ msgid "Hello" @@
+	msgid "Hello"
+	msgstr "[-你-]{+您+}好世界"
+	@@ msgid "remove '\''%s'\'' from the index" @@
+	#, {+fuzzy, +}c-format
+	msgid "remove {+'\''+}%s{+'\''+} from the index"
+	msgstr "从索引中删除 %s"
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "compare --format unified" '
+	$HELPER compare --format unified -o actual old.po new.po &&
+	cat >expect <<-\EOF &&
+	--- a/old.po
+	+++ b/new.po
+	@@ msgid "Hello" @@
+	 msgid "Hello"
+	-msgstr "你好世界"
+	+msgstr "您好世界"
+	@@ msgid "remove '\''%s'\'' from the index" @@
+	-#, c-format
+	+#, fuzzy, c-format
+	-msgid "remove %s from the index"
+	+msgid "remove '\''%s'\'' from the index"
+	 msgstr "从索引中删除 %s"
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "compare --format html" '
+	$HELPER compare --format html old.po new.po >actual.html &&
+	grep "<del>你</del><ins>您</ins>好世界" actual.html &&
+	grep "^</html>$" actual.html
+'
+
+test_expect_success "compare --format: no output without changes" '
+	$HELPER compare --format word-diff new.po new.po >actual &&
+	test_must_be_empty actual &&
+	$HELPER compare --format unified --assert-no-changes new.po new.po
+'
+
+test_expect_success "compare --format: invalid values" '
+	test_must_fail $HELPER compare --format side-by-side old.po new.po &&
+	test_must_fail $HELPER compare --json --format html old.po new.po &&
+	test_must_fail $HELPER compare --color sometimes old.po new.po
+'
+
+test_done
@@ -0,0 +1,154 @@
+#!/bin/sh
+#
+# Test stat --json, --csv, --markdown, --pot, --all and --history.
+#
+
+test_description="stat: machine-readable and aggregated output"
+
+. ./lib/test-lib.sh
+
+HELPER="$TEST_TARGET_DIRECTORY/git-po-helper -q --no-special-gettext-versions"
+
+test_expect_success "setup: po/git.pot, po/zh_CN.po and po/fr.po" '
+	git init -q repo &&
+	mkdir repo/po &&
+	cat >repo/po/git.pot <<-\EOF &&
+	msgid ""
+	msgstr ""
+	"Content-Type: text/plain; charset=UTF-8\n"
+
+	msgid "a"
+	msgstr ""
+
+	msgid "b"
+	msgstr ""
+
+	msgid "c"
+	msgstr ""
+
+	msgid "d"
+	msgstr ""
+	EOF
+	cat >repo/po/zh_CN.po <<-\EOF &&
+	msgid ""
+	msgstr ""
+	"Content-Type: text/plain; charset=UTF-8\n"
+
+	msgid "a"
+	msgstr "A"
+
+	msgid "b"
+	msgstr "B"
+
+	#, fuzzy
+	msgid "c"
+	msgstr "C"
+
+	msgid "z"
+	msgstr "Z"
+	EOF
+	cat >repo/po/fr.po <<-\EOF
+	msgid ""
+	msgstr ""
+	"Content-Type: text/plain; charset=UTF-8\n"
+
+	msgid "a"
+	msgstr "A"
+
+	msgid "b"
+	msgstr "B"
+
+	msgid "c"
+	msgstr "C"
+
+	msgid "d"
+	msgstr "D"
+	EOF
+'
+
+test_expect_success "stat --csv without POT" '
+	(
+		cd repo &&
+		$HELPER stat --csv po/zh_CN.po
+	) >actual &&
+	cat >expect <<-\EOF &&
+	file,locale,language,translated,fuzzy,untranslated,same,obsolete,total,translated_percent,fuzzy_percent,untranslated_percent
+	po/zh_CN.po,zh_CN,Chinese - China,3,1,0,0,0,4,75.0,25.0,0.0
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "stat --json --pot" '
+	(
+		cd repo &&
+		$HELPER stat --json --pot po/git.pot po/zh_CN.po
+	) >actual &&
+	grep "\"translated\": 2," actual &&
+	grep "\"untranslated\": 1," actual &&
+	grep "\"translated_percent\": 50," actual
+'
+
+test_expect_success "stat --all --markdown" '
+	(
+		cd repo &&
+		$HELPER stat --all --markdown
+	) >actual &&
+	cat >expect <<-\EOF &&
+	| Language | File | Translated | Fuzzy | Untranslated | Completion |
+	|----------|------|-----------:|------:|-------------:|-----------:|
+	| French (fr) | po/fr.po | 4 | 0 | 0 | 100.0% |
+	| Chinese - China (zh_CN) | po/zh_CN.po | 2 | 1 | 1 | 50.0% |
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "stat: invalid option combinations" '
+	(
+		cd repo &&
+		test_must_fail $HELPER stat --json --csv po/fr.po &&
+		test_must_fail $HELPER stat --all po/fr.po
+	)
+'
+
+test_expect_success "stat --history: progress at each commit" '
+	(
+		cd repo &&
+		git add po &&
+		test_tick &&
+		git commit -q -m "initial l10n" &&
+		git tag v1 &&
+		cat >>po/zh_CN.po <<-\EOF &&
+
+		msgid "d"
+		msgstr "D"
+		EOF
+		test_tick &&
+		git commit -q -a -m "l10n: zh_CN: translate d" &&
+		git tag v2 &&
+		$HELPER stat --history v1 --csv >out &&
+		$HELPER stat --history v1..v2 --csv >>out &&
+		$HELPER stat --history HEAD --tags --json >out.json
+	) &&
+	cut -d, -f1,5- repo/out >actual &&
+	cat >expect <<-\EOF &&
+	locale,tags,translated,fuzzy,untranslated,total,translated_percent
+	fr,v1,4,0,0,4,100.0
+	zh_CN,v1,2,1,1,4,50.0
+	locale,tags,translated,fuzzy,untranslated,total,translated_percent
+	fr,v2,4,0,0,4,100.0
+	zh_CN,v2,3,1,0,4,75.0
+	EOF
+	test_cmp expect actual &&
+	grep "\"v2\"" repo/out.json
+'
+
+test_expect_success "stat --history: invalid options" '
+	(
+		cd repo &&
+		test_must_fail $HELPER stat --history HEAD --markdown &&
+		test_must_fail $HELPER stat --history HEAD po/fr.po &&
+		test_must_fail $HELPER stat --tags po/fr.po
+	)
+'
+
+test_done
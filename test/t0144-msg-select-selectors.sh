@@ -0,0 +1,121 @@
+#!/bin/sh
+#
+# Test msg-select content selectors: --msgctxt, --reference, --flag,
+# --msgid-regexp and --changed, combined with state filters and --range.
+#
+
+test_description="msg-select: content selectors"
+
+. ./lib/test-lib.sh
+
+HELPER="$TEST_TARGET_DIRECTORY/git-po-helper -q --no-special-gettext-versions"
+
+test_expect_success "setup: de.po in a git repository" '
+	git init -q repo &&
+	mkdir repo/po &&
+	cat >repo/po/de.po <<-\EOF &&
+	msgid ""
+	msgstr ""
+	"Content-Type: text/plain; charset=UTF-8\n"
+
+	#: builtin/commit.c:12
+	#, c-format
+	msgid "commit %s"
+	msgstr "Commit %s"
+
+	#: builtin/add.c:3 wt-status.c:9
+	msgid "--all is bad"
+	msgstr ""
+
+	#: builtin/commit.c:50
+	msgctxt "verb"
+	msgid "commit"
+	msgstr "committen"
+
+	#: remote.c:1
+	#, fuzzy, no-wrap
+	msgid "remote"
+	msgstr "Fern"
+	EOF
+	(
+		cd repo &&
+		git add po &&
+		test_tick &&
+		git commit -q -m "l10n: de"
+	)
+'
+
+test_expect_success "msg-select --reference" '
+	(
+		cd repo &&
+		$HELPER msg-select --no-header --reference builtin/commit.c po/de.po
+	) >out &&
+	grep "^msgid" out >actual &&
+	cat >expect <<-\EOF &&
+	msgid "commit %s"
+	msgid "commit"
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "msg-select --reference directory with state filter" '
+	(
+		cd repo &&
+		$HELPER msg-select --no-header --reference builtin --untranslated po/de.po
+	) >out &&
+	grep "^msgid" out >actual &&
+	cat >expect <<-\EOF &&
+	msgid "--all is bad"
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "msg-select --flag, --msgctxt and --msgid-regexp" '
+	(
+		cd repo &&
+		$HELPER msg-select --no-header --flag c-format --flag no-wrap po/de.po &&
+		$HELPER msg-select --no-header --msgctxt verb po/de.po &&
+		$HELPER msg-select --no-header --msgid-regexp "^--" po/de.po
+	) >out &&
+	grep "^msgid" out >actual &&
+	cat >expect <<-\EOF &&
+	msgid "commit %s"
+	msgid "remote"
+	msgid "commit"
+	msgid "--all is bad"
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "msg-select: range applies to selected entries" '
+	(
+		cd repo &&
+		$HELPER msg-select --no-header --reference "*.c" --range 2 po/de.po
+	) >out &&
+	grep "^msgid" out >actual &&
+	cat >expect <<-\EOF &&
+	msgid "remote"
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "msg-select --changed" '
+	(
+		cd repo &&
+		sed -e "s/\"Fern\"/\"Remote\"/" po/de.po >po/de.po.new &&
+		mv po/de.po.new po/de.po &&
+		$HELPER msg-select --no-header --changed HEAD.. po/de.po
+	) >out &&
+	grep "^msg" out >actual &&
+	cat >expect <<-\EOF &&
+	msgid "remote"
+	msgstr "Remote"
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "msg-select: bad msgid pattern" '
+	test_must_fail $HELPER msg-select --msgid-regexp "(" repo/po/de.po
+'
+
+test_done
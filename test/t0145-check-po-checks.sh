@@ -0,0 +1,135 @@
+#!/bin/sh
+#
+# Test the checks of check-po: --list-checks, --only, --skip, and the
+# "checks" section of .git-po-helper.yaml (per repository and per language).
+#
+
+test_description="check-po: --list-checks, --only, --skip and checks config"
+
+. ./lib/test-lib.sh
+
+HELPER="$TEST_TARGET_DIRECTORY/git-po-helper -q --no-special-gettext-versions"
+CHECK_PO="check-po --pot-file=no --no-check-filter"
+
+test_expect_success "setup" '
+	git init workdir &&
+	mkdir workdir/po &&
+	cat >workdir/po/zh_CN.po <<-\EOF
+	msgid ""
+	msgstr ""
+	"Project-Id-Version: Git\n"
+	"PO-Revision-Date: 2021-03-04 22:41+0800\n"
+	"Last-Translator: Automatically generated\n"
+	"Language-Team: none\n"
+	"Language: zh_CN\n"
+	"MIME-Version: 1.0\n"
+	"Content-Type: text/plain; charset=UTF-8\n"
+	"Content-Transfer-Encoding: 8bit\n"
+	"Plural-Forms: nplurals=1; plural=0;\n"
+
+	#, c-format
+	msgid "hello %s"
+	msgstr "你好 %s"
+
+	#~ msgid "old"
+	#~ msgstr "旧"
+	EOF
+'
+
+test_expect_success "list checks with their default levels" '
+	(
+		cd workdir &&
+		$HELPER check-po --list-checks >../out
+	) &&
+	grep "^msgfmt  *error  syntax of the file" out &&
+	grep "^obsolete  *error  no obsolete entries$" out &&
+	grep "^project-name  *error " out
+'
+
+test_expect_success "obsolete entries fail by default" '
+	(
+		cd workdir &&
+		test_must_fail $HELPER $CHECK_PO po/zh_CN.po >../out 2>&1
+	) &&
+	grep "ERROR.*you have 1 obsolete entries" out
+'
+
+test_expect_success "check-po --skip" '
+	(
+		cd workdir &&
+		$HELPER $CHECK_PO --skip obsolete po/zh_CN.po >../out 2>&1
+	) &&
+	! grep "obsolete entries" out
+'
+
+test_expect_success "check-po --only" '
+	(
+		cd workdir &&
+		$HELPER $CHECK_PO --only msgfmt,locale po/zh_CN.po >../out 2>&1
+	) &&
+	! grep "obsolete entries" out
+'
+
+test_expect_success "check-po: unknown check, or both --only and --skip" '
+	(
+		cd workdir &&
+		test_must_fail $HELPER $CHECK_PO --only no-such-check po/zh_CN.po >../out 2>&1 &&
+		grep "ERROR: unknown check \"no-such-check\"" ../out &&
+		test_must_fail $HELPER $CHECK_PO --only obsolete --skip obsolete po/zh_CN.po >../out 2>&1 &&
+		grep "ERROR: check \"obsolete\" is given to both --only and --skip" ../out
+	)
+'
+
+test_expect_success "check set to warn in the repository config" '
+	cat >workdir/.git-po-helper.yaml <<-\EOF &&
+	checks:
+	  obsolete: warn
+	EOF
+	(
+		cd workdir &&
+		$HELPER check-po --list-checks >../out &&
+		grep "^obsolete  *warn   no obsolete entries (default: error)$" ../out &&
+		$HELPER $CHECK_PO po/zh_CN.po >../out 2>&1
+	) &&
+	grep "WARNING.*you have 1 obsolete entries" out
+'
+
+test_expect_success "check turned off for one language" '
+	cat >workdir/.git-po-helper.yaml <<-\EOF &&
+	checks:
+	  languages:
+	    zh_CN:
+	      obsolete: off
+	EOF
+	(
+		cd workdir &&
+		$HELPER check-po --list-checks po/zh_CN.po >../out &&
+		grep "^obsolete  *off    no obsolete entries (default: error)$" ../out &&
+		$HELPER check-po --list-checks po/de.po >../out &&
+		grep "^obsolete  *error  no obsolete entries$" ../out &&
+		$HELPER $CHECK_PO po/zh_CN.po >../out 2>&1
+	) &&
+	! grep "obsolete entries" out
+'
+
+test_expect_success "check-po --only runs a check turned off in config" '
+	(
+		cd workdir &&
+		test_must_fail $HELPER $CHECK_PO --only obsolete po/zh_CN.po >../out 2>&1
+	) &&
+	grep "ERROR.*you have 1 obsolete entries" out
+'
+
+test_expect_success "unknown check in config" '
+	cat >workdir/.git-po-helper.yaml <<-\EOF &&
+	checks:
+	  no-such-check: off
+	EOF
+	(
+		cd workdir &&
+		$HELPER check-po --list-checks >../out 2>&1
+	) &&
+	grep "unknown check \"no-such-check\"" out
+'
+
+test_done
@@ -0,0 +1,101 @@
+#!/bin/sh
+#
+# Test --output-format=json|sarif of check-po and check-commits, and their
+# annotations in GitHub Actions.
+#
+
+test_description="check-po and check-commits: --output-format json and sarif"
+
+. ./lib/test-lib.sh
+
+HELPER="$TEST_TARGET_DIRECTORY/git-po-helper -q --no-special-gettext-versions"
+
+test_expect_success "setup" '
+	git init workdir &&
+	mkdir workdir/po &&
+	echo "*.po filter=gettext-no-location" >workdir/.gitattributes &&
+	cat >workdir/po/zh_CN.po <<-\EOF &&
+	msgid ""
+	msgstr ""
+	"Project-Id-Version: Git\n"
+	"PO-Revision-Date: 2021-03-04 22:41+0800\n"
+	"Last-Translator: Automatically generated\n"
+	"Language-Team: none\n"
+	"Language: zh_CN\n"
+	"MIME-Version: 1.0\n"
+	"Content-Type: text/plain; charset=UTF-8\n"
+	"Content-Transfer-Encoding: 8bit\n"
+	"Plural-Forms: nplurals=1; plural=0;\n"
+
+	#, c-format
+	msgid "hello %s"
+	msgstr "你好 %d"
+	EOF
+	(
+		cd workdir &&
+		git add -A &&
+		test_tick &&
+		git commit -m "l10n: zh_CN: init" &&
+		echo >>po/zh_CN.po &&
+		git add -A &&
+		test_tick &&
+		git commit -m "bad subject."
+	)
+'
+
+test_expect_success "check-po --output-format=json" '
+	(
+		cd workdir &&
+		test_must_fail $HELPER check-po --pot-file=no --output-format=json \
+			po/zh_CN.po >../out.json 2>../err
+	) &&
+	jq -e ".ok == false" out.json &&
+	jq -e ".findings[] | select(.rule == \"c-format\" and .level == \"error\" and .file == \"po/zh_CN.po\" and .line == 14)" out.json &&
+	! grep "C format strings" err
+'
+
+test_expect_success "check-po --output-format=sarif" '
+	(
+		cd workdir &&
+		test_must_fail $HELPER check-po --pot-file=no --output-format=sarif \
+			po/zh_CN.po >../out.sarif
+	) &&
+	jq -e ".version == \"2.1.0\"" out.sarif &&
+	jq -e ".runs[0].tool.driver.rules[] | select(.id == \"c-format\")" out.sarif &&
+	jq -e ".runs[0].results[] | select(.ruleId == \"c-format\") | .locations[0].physicalLocation.region.startLine == 14" out.sarif
+'
+
+test_expect_success "check-commits --output-format=json" '
+	(
+		cd workdir &&
+		test_must_fail $HELPER check-commits --no-gpg --pot-file=no --output-format=json \
+			HEAD~..HEAD >../out.json
+	) &&
+	jq -e ".findings[] | select(.rule == \"c-format\" and .file == \"po/zh_CN.po\")" out.json &&
+	jq -e ".findings[] | select(.rule == \"commit-subject\" and .level == \"error\") | .commit" out.json
+'
+
+test_expect_success "check-commits in GitHub Actions: annotations and job summary" '
+	(
+		cd workdir &&
+		GITHUB_STEP_SUMMARY="$(pwd)/../summary.md" &&
+		export GITHUB_STEP_SUMMARY &&
+		test_must_fail $HELPER --github-action-event=pull_request \
+			check-commits --pot-file=no HEAD~..HEAD >../out 2>../err
+	) &&
+	grep "^::error file=po/zh_CN.po,line=14,title=C format strings (commit [0-9a-f]*)::entry 1@L14" out &&
+	grep "^::error title=Commit subject (commit [0-9a-f]*)::commit [0-9a-f]*: subject should not end with period" out &&
+	grep "C format strings" err &&
+	grep "^## git-po-helper check-commits" summary.md &&
+	grep "^| error | C format strings | po/zh_CN.po:14 | " summary.md
+'
+
+test_expect_success "bad --output-format" '
+	(
+		cd workdir &&
+		test_must_fail $HELPER check-po --output-format=xml po/zh_CN.po >../out 2>&1
+	) &&
+	grep "ERROR: bad --output-format \"xml\"" out
+'
+
+test_done
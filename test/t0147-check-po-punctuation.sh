@@ -0,0 +1,106 @@
+#!/bin/sh
+#
+# Test check-po --report-punctuation: whitespace, newlines and final
+# punctuation of msgid and msgstr, with the punctuation of each language.
+#
+
+test_description="check-po: --report-punctuation"
+
+. ./lib/test-lib.sh
+
+HELPER="$TEST_TARGET_DIRECTORY/git-po-helper -q --no-special-gettext-versions"
+CHECK_PO="check-po --pot-file=no --no-check-filter"
+
+test_expect_success "setup" '
+	git init workdir &&
+	mkdir workdir/po &&
+	cat >workdir/po/header <<-\EOF &&
+	msgid ""
+	msgstr ""
+	"Project-Id-Version: Git\n"
+	"PO-Revision-Date: 2021-03-04 22:41+0800\n"
+	"Last-Translator: Automatically generated\n"
+	"Language-Team: none\n"
+	"MIME-Version: 1.0\n"
+	"Content-Type: text/plain; charset=UTF-8\n"
+	"Content-Transfer-Encoding: 8bit\n"
+	"Plural-Forms: nplurals=1; plural=0;\n"
+
+	EOF
+	cp workdir/po/header workdir/po/zh_CN.po &&
+	cat >>workdir/po/zh_CN.po <<-\EOF &&
+	msgid "Name: "
+	msgstr "名称："
+
+	msgid "Done."
+	msgstr "完成。"
+
+	msgid "Loading..."
+	msgstr "加载中……"
+
+	msgid "Removed."
+	msgstr "已删除"
+	EOF
+	cp workdir/po/header workdir/po/fr.po &&
+	cat >>workdir/po/fr.po <<-\EOF
+	msgid "Name:"
+	msgstr "Nom :"
+
+	msgid "Loading..."
+	msgstr "Chargement."
+	EOF
+'
+
+test_expect_success "no punctuation check by default" '
+	(
+		cd workdir &&
+		$HELPER $CHECK_PO po/zh_CN.po >../out 2>&1
+	) &&
+	! grep "Punctuation and whitespace" out
+'
+
+test_expect_success "full-width punctuation of zh_CN" '
+	(
+		cd workdir &&
+		$HELPER $CHECK_PO --report-punctuation=warn po/zh_CN.po >../out 2>&1
+	) &&
+	cat >expect <<-\EOF &&
+	⚠️ Punctuation and whitespace
+	    WARNING [zh_CN.po]  entry 4@L21 (msgid "Removed."): msgid ends with period ("."), but msgstr does not
+	EOF
+	grep -A1 "Punctuation and whitespace" out >actual &&
+	test_cmp expect actual
+'
+
+test_expect_success "French spacing, --report-punctuation=error" '
+	(
+		cd workdir &&
+		test_must_fail $HELPER $CHECK_PO --report-punctuation=error po/fr.po >../out 2>&1
+	) &&
+	cat >expect <<-\EOF &&
+	❌ Punctuation and whitespace
+	    ERROR   [fr.po]     entry 2@L15 (msgid "Loading..."): msgid ends with ellipsis ("..."), but msgstr ends with period (".")
+	EOF
+	grep -A1 "Punctuation and whitespace" out >actual &&
+	test_cmp expect actual
+'
+
+test_expect_success "punctuation check turned on in the repository config" '
+	cat >workdir/.git-po-helper.yaml <<-\EOF &&
+	checks:
+	  punctuation: error
+	EOF
+	(
+		cd workdir &&
+		test_must_fail $HELPER $CHECK_PO po/fr.po >../out 2>&1
+	) &&
+	rm workdir/.git-po-helper.yaml &&
+	cat >expect <<-\EOF &&
+	❌ Punctuation and whitespace
+	    ERROR   [fr.po]     entry 2@L15 (msgid "Loading..."): msgid ends with ellipsis ("..."), but msgstr ends with period (".")
+	EOF
+	grep -A1 "Punctuation and whitespace" out >actual &&
+	test_cmp expect actual
+'
+
+test_done
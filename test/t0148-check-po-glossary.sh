@@ -0,0 +1,100 @@
+#!/bin/sh
+#
+# Test check-po with a glossary of the language: under po/glossary/, or
+# named in .git-po-helper.yaml.
+#
+
+test_description="check-po: glossary terms"
+
+. ./lib/test-lib.sh
+
+HELPER="$TEST_TARGET_DIRECTORY/git-po-helper -q --no-special-gettext-versions"
+CHECK_PO="check-po --pot-file=no --no-check-filter"
+
+test_expect_success "setup" '
+	git init workdir &&
+	mkdir workdir/po &&
+	cat >workdir/po/zh_CN.po <<-\EOF
+	msgid ""
+	msgstr ""
+	"Project-Id-Version: Git\n"
+	"PO-Revision-Date: 2021-03-04 22:41+0800\n"
+	"Last-Translator: Automatically generated\n"
+	"Language-Team: none\n"
+	"MIME-Version: 1.0\n"
+	"Content-Type: text/plain; charset=UTF-8\n"
+	"Content-Transfer-Encoding: 8bit\n"
+	"Plural-Forms: nplurals=1; plural=0;\n"
+
+	msgid "commit your changes"
+	msgstr "提交您的变更"
+
+	msgid "cannot commit"
+	msgstr "不能递交"
+
+	# glossary-ignore: stash
+	msgid "drop the stash"
+	msgstr "丢弃"
+
+	msgid "run `git commit`"
+	msgstr "运行 `git commit`"
+	EOF
+'
+
+test_expect_success "no glossary" '
+	(
+		cd workdir &&
+		$HELPER $CHECK_PO po/zh_CN.po >../out 2>&1
+	) &&
+	! grep "Glossary terms" out
+'
+
+test_expect_success "glossary in po/glossary/" '
+	mkdir workdir/po/glossary &&
+	printf "commit\t提交\t递交\nstash\t贮藏\n" >workdir/po/glossary/zh.tsv &&
+	(
+		cd workdir &&
+		test_must_fail $HELPER $CHECK_PO po/zh_CN.po >../out 2>&1
+	) &&
+	cat >expect <<-\EOF &&
+	❌ Glossary terms
+	    ERROR   [zh_CN.po]  entry 2@L15 (msgid "cannot commit"): term "commit" is not translated as "提交"
+	    ERROR   [zh_CN.po]  entry 2@L15 (msgid "cannot commit"): term "commit" is translated as forbidden "递交"
+	EOF
+	grep -A2 "Glossary terms" out >actual &&
+	test_cmp expect actual
+'
+
+test_expect_success "glossary in .git-po-helper.yaml" '
+	cat >workdir/terms.yaml <<-\EOF &&
+	terms:
+	  - source: chang
+	    match: prefix
+	    target: 更改
+	    note: not 变更
+	EOF
+	cat >workdir/.git-po-helper.yaml <<-\EOF &&
+	glossary:
+	  zh_CN: terms.yaml
+	EOF
+	(
+		cd workdir &&
+		test_must_fail $HELPER $CHECK_PO po/zh_CN.po >../out 2>&1
+	) &&
+	cat >expect <<-\EOF &&
+	❌ Glossary terms
+	    ERROR   [zh_CN.po]  entry 1@L12 (msgid "commit your changes"): term "chang" is not translated as "更改" (not 变更)
+	EOF
+	grep -A1 "Glossary terms" out >actual &&
+	test_cmp expect actual
+'
+
+test_expect_success "glossary is a check" '
+	(
+		cd workdir &&
+		$HELPER $CHECK_PO --skip=glossary po/zh_CN.po >../out 2>&1
+	) &&
+	! grep "Glossary terms" out
+'
+
+test_done
@@ -0,0 +1,79 @@
+#!/bin/sh
+#
+# Test check-po --consistency: identical msgids translated differently, and
+# different msgids with the same translation.
+#
+
+test_description="check-po: --consistency"
+
+. ./lib/test-lib.sh
+
+HELPER="$TEST_TARGET_DIRECTORY/git-po-helper -q --no-special-gettext-versions"
+CHECK_PO="check-po --pot-file=no --no-check-filter"
+
+test_expect_success "setup" '
+	git init workdir &&
+	mkdir workdir/po &&
+	cat >workdir/po/zh_CN.po <<-\EOF
+	msgid ""
+	msgstr ""
+	"Project-Id-Version: Git\n"
+	"PO-Revision-Date: 2021-03-04 22:41+0800\n"
+	"Last-Translator: Automatically generated\n"
+	"Language-Team: none\n"
+	"MIME-Version: 1.0\n"
+	"Content-Type: text/plain; charset=UTF-8\n"
+	"Content-Transfer-Encoding: 8bit\n"
+	"Plural-Forms: nplurals=1; plural=0;\n"
+
+	msgid "%d files"
+	msgstr "%d 文件"
+
+	msgid "one file"
+	msgid_plural "%d files"
+	msgstr[0] "%d 个文件"
+
+	msgid "push"
+	msgstr "推送"
+
+	msgid "pull"
+	msgstr "推送"
+	EOF
+'
+
+test_expect_success "no consistency check by default" '
+	(
+		cd workdir &&
+		$HELPER $CHECK_PO po/zh_CN.po >../out 2>&1
+	) &&
+	! grep "Translation consistency" out
+'
+
+test_expect_success "check-po --consistency" '
+	(
+		cd workdir &&
+		$HELPER $CHECK_PO --consistency po/zh_CN.po >../out 2>&1
+	) &&
+	cat >expect <<-\EOF &&
+	⚠️ Translation consistency
+	    WARNING [zh_CN.po]  msgid "%d files" is translated differently:
+	    WARNING [zh_CN.po]    entry 1@L12: "%d 文件"
+	    WARNING [zh_CN.po]    entry 2@L15 (msgid_plural): "%d 个文件"
+	    WARNING [zh_CN.po]  msgstr "推送" is the translation of different msgids:
+	    WARNING [zh_CN.po]    entry 3@L19: "push"
+	    WARNING [zh_CN.po]    entry 4@L22: "pull"
+	EOF
+	grep -A6 "Translation consistency" out >actual &&
+	test_cmp expect actual
+'
+
+test_expect_success "check-po --only=consistency" '
+	(
+		cd workdir &&
+		$HELPER $CHECK_PO --only=consistency po/zh_CN.po >../out 2>&1
+	) &&
+	grep "Translation consistency" out &&
+	! grep "Syntax check with msgfmt" out
+'
+
+test_done
@@ -0,0 +1,112 @@
+#!/bin/sh
+#
+# Test the spelling check of check-po, with a Hunspell dictionary set in
+# .git-po-helper.yaml and a personal word list in po/spelling/.
+#
+
+test_description="check-po: spelling"
+
+. ./lib/test-lib.sh
+
+HELPER="$TEST_TARGET_DIRECTORY/git-po-helper -q --no-special-gettext-versions"
+CHECK_PO="check-po --pot-file=no --no-check-filter"
+
+test_expect_success "setup" '
+	git init workdir &&
+	mkdir workdir/po workdir/dict &&
+	cat >workdir/po/de.po <<-\EOF &&
+	msgid ""
+	msgstr ""
+	"Project-Id-Version: Git\n"
+	"PO-Revision-Date: 2021-03-04 22:41+0800\n"
+	"Last-Translator: Automatically generated\n"
+	"Language-Team: none\n"
+	"MIME-Version: 1.0\n"
+	"Content-Type: text/plain; charset=UTF-8\n"
+	"Content-Transfer-Encoding: 8bit\n"
+	"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+	msgid "Delete the branch %s"
+	msgstr "Den Zweig %s löschen"
+
+	msgid "Use --force to delete branches"
+	msgstr "Benutze --force, um Zweige zu loschen"
+
+	msgid "See the log of references"
+	msgstr "Siehe das Reflog"
+	EOF
+	cat >workdir/dict/de_DE.aff <<-\EOF &&
+	SET UTF-8
+	TRY esianrtolcdugmphbyfvkwzäöü
+
+	SFX E Y 1
+	SFX E 0 e .
+	EOF
+	cat >workdir/dict/de_DE.dic <<-\EOF
+	8
+	benutze
+	das
+	den
+	löschen
+	siehe
+	um
+	Zweig/E
+	zu
+	EOF
+'
+
+test_expect_success "no spelling check without dictionary" '
+	(
+		cd workdir &&
+		$HELPER $CHECK_PO po/de.po >../out 2>&1
+	) &&
+	! grep "Spelling" out
+'
+
+test_expect_success "spelling with dictionary in .git-po-helper.yaml" '
+	cat >workdir/.git-po-helper.yaml <<-\EOF &&
+	spelling:
+	  de:
+	    dictionary: dict/de_DE
+	EOF
+	(
+		cd workdir &&
+		$HELPER $CHECK_PO po/de.po >../out 2>&1
+	) &&
+	cat >expect <<-\EOF &&
+	⚠️ Spelling
+	    WARNING [de.po]     entry 2@L15 (msgid "Use --force to delete branches"): misspelled word "loschen", suggestions: löschen
+	    WARNING [de.po]     entry 3@L18 (msgid "See the log of references"): misspelled word "Reflog"
+	EOF
+	grep -A2 "Spelling" out >actual &&
+	test_cmp expect actual
+'
+
+test_expect_success "personal word list in po/spelling/" '
+	mkdir workdir/po/spelling &&
+	echo reflog >workdir/po/spelling/de.txt &&
+	(
+		cd workdir &&
+		$HELPER $CHECK_PO po/de.po >../out 2>&1
+	) &&
+	cat >expect <<-\EOF &&
+	⚠️ Spelling
+	    WARNING [de.po]     entry 2@L15 (msgid "Use --force to delete branches"): misspelled word "loschen", suggestions: löschen
+	EOF
+	grep -A1 "Spelling" out >actual &&
+	test_cmp expect actual
+'
+
+test_expect_success "spelling at level error" '
+	cat >>workdir/.git-po-helper.yaml <<-\EOF &&
+	checks:
+	  spelling: error
+	EOF
+	(
+		cd workdir &&
+		test_must_fail $HELPER $CHECK_PO po/de.po >../out 2>&1
+	) &&
+	grep "ERROR   \[de.po\]     entry 2@L15" out
+'
+
+test_done
@@ -156,6 +156,15 @@ func validatePoFileInternal(potFile string, checkFormatOnly bool) error {
 	var cmd *exec.Cmd
 	var toolName string
 
+	// Without gettext tools, fall back to the built-in validator.
+	tool := "msgfmt"
+	if ext == ".pot" {
+		tool = "msgcat"
+	}
+	if _, err := exec.LookPath(tool); err != nil {
+		return validatePoFileBuiltin(potFile, ext != ".pot" && !checkFormatOnly, ext != ".pot")
+	}
+
 	if ext == ".pot" {
 		// For POT files, use msgcat --use-first since POT files have placeholders in headers
 		toolName = "msgcat"
@@ -226,6 +235,22 @@ func validatePoFileInternal(potFile string, checkFormatOnly bool) error {
 	return nil
 }
 
+// validatePoFileBuiltin validates potFile with ValidatePoData when msgfmt/msgcat is
+// not installed. Error messages mirror validatePoFileInternal.
+func validatePoFileBuiltin(potFile string, checkHeader, checkFormat bool) error {
+	log.Debugf("gettext tools not found, validating %s with built-in validator", potFile)
+	result, err := ValidatePoFileBuiltin(potFile, PoValidateOptions{CheckHeader: checkHeader, CheckFormat: checkFormat})
+	if err != nil {
+		return err
+	}
+	if !result.OK() {
+		msgs := append(result.Messages, result.Summary())
+		return fmt.Errorf("file syntax validation failed: %s\nHint: Check the file syntax and fix any errors reported by the built-in validator", strings.Join(msgs, "\n"))
+	}
+	log.Debugf("file validation passed: %s", potFile)
+	return nil
+}
+
 // GetPoFileAbsPath determines the absolute path of a PO file.
 // If poFile is empty, it uses the effective default_lang_code (config or system locale) to construct the path.
 // If poFile is provided but not absolute, it's treated as relative to the repository root.
@@ -78,13 +78,12 @@ func RunAgentTranslateLocalOrchestration(cfg *config.AgentConfig, agentName, poF
 			if entryCount == 0 {
 				log.Infof("no untranslated or fuzzy entries, translation complete")
 				// Step 8 (AGENTS.md): validate final PO and display report before exit
-				cmd := exec.Command("msgfmt", "--check", "--stat", "-o", os.DevNull, poFile)
-				out, err := cmd.CombinedOutput()
+				out, err := runMsgfmtCheck(poFile, true)
 				if err != nil {
 					return result, fmt.Errorf("msgfmt --check --stat failed on final %s: %w\n%s", poFile, err, string(out))
 				}
 				if len(out) > 0 {
-					log.Infof("msgfmt --stat: %s", strings.TrimSpace(string(out)))
+					log.Infof("msgfmt --stat: %s", strings.TrimSpace(out))
 				}
 				cleanupIntermediateFiles(baseDir)
 				result.ExecutionTime = time.Since(startTime)
@@ -253,8 +252,9 @@ func translateOneBatch(cfg *config.AgentConfig, selectedAgent config.AgentEntry,
 //  1. Convert l10n-done.json → l10n-done.po (--unset-fuzzy)
 //  2. Validate msgid consistency: compare pendingPO vs donePO with msgidOnly=true;
 //     any Added entries mean msgid was altered during translation → error
-//  3. Validate PO format with msgfmt --check
-//  4. Merge: msgcat --use-first donePO targetPO → mergedFile → replace targetPO
+//  3. Validate the number of msgstr[] forms against Plural-Forms of targetPO
+//  4. Validate PO format with msgfmt --check
+//  5. Merge: msgcat --use-first donePO targetPO → mergedFile → replace targetPO
 func mergeAndComplete(cfg *config.AgentConfig, selectedAgent config.AgentEntry, doneJSON, donePO, pendingPO, targetPO, mergedFile string, result *AgentRunResult) error {
 	j, err := ReadFileToGettextJSON(doneJSON)
 	if err != nil {
@@ -262,6 +262,15 @@ func mergeAndComplete(cfg *config.AgentConfig, selectedAgent config.AgentEntry,
 	}
 	ClearFuzzyTagFromGettextJSON(j)
 
+	// Plural-Forms of targetPO is authoritative; the agent may have altered header_meta.
+	pf, err := pluralFormsOfPoFile(targetPO)
+	if err != nil {
+		return err
+	}
+	if err := CheckGettextJSONPluralForms(j, pf); err != nil {
+		return fmt.Errorf("ERROR [plural forms]: %s: %w", doneJSON, err)
+	}
+
 	f, err := os.Create(donePO)
 	if err != nil {
 		return fmt.Errorf("create %s: %w", donePO, err)
@@ -280,30 +289,27 @@ func mergeAndComplete(cfg *config.AgentConfig, selectedAgent config.AgentEntry,
 	}
 
 	// Check 2 (AGENTS.md Step 4): PO format
-	cmd := exec.Command("msgfmt", "--check", "-o", os.DevNull, donePO)
-	if out, err := cmd.CombinedOutput(); err != nil {
+	if out, err := runMsgfmtCheck(donePO, false); err != nil {
 		os.Remove(donePO)
-		return fmt.Errorf("msgfmt validation failed: %w\n%s", err, string(out))
+		return fmt.Errorf("msgfmt validation failed: %w\n%s", err, out)
 	}
 
 	// Step 5: msgcat --use-first donePO targetPO → mergedFile
-	cmd = exec.Command("msgcat", "--use-first", donePO, targetPO, "-o", mergedFile)
+	cmd := exec.Command("msgcat", "--use-first", donePO, targetPO, "-o", mergedFile)
 	if out, err := cmd.CombinedOutput(); err != nil {
 		return fmt.Errorf("msgcat failed: %w\n%s", err, string(out))
 	}
 
-	cmd = exec.Command("msgfmt", "--check", "-o", os.DevNull, mergedFile)
-	out, err := cmd.CombinedOutput()
+	out, err := runMsgfmtCheck(mergedFile, false)
 	if err != nil {
-		log.Warnf("msgfmt validation failed, invoking agent to fix: %s", string(out))
-		if fixErr := fixPoWithAgent(cfg, selectedAgent, mergedFile, string(out), result); fixErr != nil {
+		log.Warnf("msgfmt validation failed, invoking agent to fix: %s", out)
+		if fixErr := fixPoWithAgent(cfg, selectedAgent, mergedFile, out, result); fixErr != nil {
 			os.Remove(mergedFile)
-			return fmt.Errorf("msgfmt validation failed and agent fix failed: %w\nOriginal error: %s", fixErr, string(out))
+			return fmt.Errorf("msgfmt validation failed and agent fix failed: %w\nOriginal error: %s", fixErr, out)
 		}
-		cmd = exec.Command("msgfmt", "--check", "-o", os.DevNull, mergedFile)
-		if out2, err2 := cmd.CombinedOutput(); err2 != nil {
+		if out2, err2 := runMsgfmtCheck(mergedFile, false); err2 != nil {
 			os.Remove(mergedFile)
-			return fmt.Errorf("msgfmt still fails after agent fix: %w\n%s", err2, string(out2))
+			return fmt.Errorf("msgfmt still fails after agent fix: %w\n%s", err2, out2)
 		}
 	}
 
@@ -353,6 +359,24 @@ func validateMsgIDConsistency(pendingPO, donePO string) error {
 	return nil
 }
 
+// pluralFormsOfPoFile returns the parsed Plural-Forms header of poFile, or nil
+// when the header has none.
+func pluralFormsOfPoFile(poFile string) (*PluralForms, error) {
+	data, err := os.ReadFile(poFile)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", poFile, err)
+	}
+	po, err := ParsePoEntries(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", poFile, err)
+	}
+	pf, err := po.PluralForms()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", poFile, err)
+	}
+	return pf, nil
+}
+
 // fixPoWithAgent invokes the agent to fix PO file syntax errors. The agent modifies the file in place.
 func fixPoWithAgent(cfg *config.AgentConfig, selectedAgent config.AgentEntry, poFile, msgfmtError string, result *AgentRunResult) error {
 	prompt, err := GetRawPrompt(cfg, "fix-po")
@@ -0,0 +1,523 @@
+// Package util provides the check of the printf directives of c-format entries.
+package util
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CFormatDirective is one printf conversion specification of a c-format string.
+type CFormatDirective struct {
+	// Text is the directive as written, e.g. "%2$-*1$ld".
+	Text string
+	// Offset is the byte offset of the directive in the (unescaped) string.
+	Offset int
+	// Arg is the 1-based number of the argument converted by this directive:
+	// the explicit number of a positional directive ("%2$s"), or the next
+	// argument in sequence for unnumbered directives.
+	Arg int
+	// WidthArg and PrecisionArg are the argument numbers consumed by "*" width
+	// and precision ("%*.*s", "%2$.*1$d"), or 0 when not taken from an argument.
+	WidthArg     int
+	PrecisionArg int
+	// Type is the C type of argument Arg, e.g. "int", "unsigned long", "char *".
+	Type string
+}
+
+// CFormatSpec is the result of parsing a c-format string.
+type CFormatSpec struct {
+	Directives []CFormatDirective
+	// Args maps each argument number used by the string to its C type.
+	Args map[int]string
+	// Positional is true when the string uses numbered arguments ("%1$s").
+	Positional bool
+}
+
+// ParseCFormat parses the printf directives of s (already unescaped) the way
+// the C library does, including numbered arguments, "*" width/precision,
+// length modifiers and the <PRIxNN> macros of xgettext. "%%" is not a directive.
+// An error is returned for an invalid directive, for mixing numbered and
+// unnumbered arguments, for a numbered argument used with different types, and
+// for gaps in the numbered arguments.
+func ParseCFormat(s string) (*CFormatSpec, error) {
+	spec := &CFormatSpec{Args: make(map[int]string)}
+	var (
+		next       = 0 // last unnumbered argument
+		unnumbered = false
+		numbered   = false
+	)
+	addArg := func(d *CFormatDirective, arg int, typ string) error {
+		if old, ok := spec.Args[arg]; ok && old != typ {
+			return fmt.Errorf("directive '%s' uses argument %d as '%s', but it is used as '%s' elsewhere",
+				d.Text, arg, typ, old)
+		}
+		spec.Args[arg] = typ
+		return nil
+	}
+	// takeArg parses an optional "N$" argument number at s[i:].
+	takeArg := func(i int) (arg, end int, err error) {
+		j := i
+		for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+			j++
+		}
+		if j > i && j < len(s) && s[j] == '$' {
+			n, err := strconv.Atoi(s[i:j])
+			if err != nil || n == 0 {
+				return 0, 0, fmt.Errorf("invalid argument number '%s'", s[i:j])
+			}
+			return n, j + 1, nil
+		}
+		return 0, i, nil
+	}
+	useArg := func(explicit int) (int, error) {
+		if explicit > 0 {
+			numbered = true
+		} else {
+			unnumbered = true
+			next++
+			explicit = next
+		}
+		if numbered && unnumbered {
+			return 0, fmt.Errorf("mixes numbered and unnumbered arguments")
+		}
+		return explicit, nil
+	}
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' {
+			continue
+		}
+		start := i
+		i++
+		if i < len(s) && s[i] == '%' {
+			continue
+		}
+		d := CFormatDirective{Offset: start}
+		explicit, j, err := takeArg(i)
+		if err != nil {
+			return nil, err
+		}
+		i = j
+		// Flags.
+		for i < len(s) && strings.IndexByte("-+ #0'I", s[i]) >= 0 {
+			i++
+		}
+		// Field width.
+		if i < len(s) && s[i] == '*' {
+			widthArg, j, err := takeArg(i + 1)
+			if err != nil {
+				return nil, err
+			}
+			i = j
+			if d.WidthArg, err = useArg(widthArg); err != nil {
+				return nil, err
+			}
+		} else {
+			for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+				i++
+			}
+		}
+		// Precision.
+		if i < len(s) && s[i] == '.' {
+			i++
+			if i < len(s) && s[i] == '*' {
+				precArg, j, err := takeArg(i + 1)
+				if err != nil {
+					return nil, err
+				}
+				i = j
+				if d.PrecisionArg, err = useArg(precArg); err != nil {
+					return nil, err
+				}
+			} else {
+				for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+					i++
+				}
+			}
+		}
+		// Length modifier and conversion.
+		typ, j, err := cFormatConversion(s, i)
+		if err != nil {
+			end := j
+			if end > len(s) {
+				end = len(s)
+			}
+			return nil, fmt.Errorf("invalid directive '%s': %v", s[start:end], err)
+		}
+		i = j - 1
+		d.Text = s[start:j]
+		if typ == "" {
+			// "%m" converts errno and takes no argument.
+			if explicit > 0 {
+				return nil, fmt.Errorf("invalid directive '%s': %%m takes no argument", d.Text)
+			}
+			spec.Directives = append(spec.Directives, d)
+			continue
+		}
+		if d.Arg, err = useArg(explicit); err != nil {
+			return nil, err
+		}
+		d.Type = typ
+		for _, a := range []struct {
+			arg int
+			typ string
+		}{{d.WidthArg, "int"}, {d.PrecisionArg, "int"}, {d.Arg, typ}} {
+			if a.arg == 0 {
+				continue
+			}
+			if err := addArg(&d, a.arg, a.typ); err != nil {
+				return nil, err
+			}
+		}
+		spec.Directives = append(spec.Directives, d)
+	}
+
+	spec.Positional = numbered
+	if numbered {
+		for n := 1; n <= len(spec.Args); n++ {
+			if _, ok := spec.Args[n]; !ok {
+				return nil, fmt.Errorf("argument %d is not used, but a higher argument number is", n)
+			}
+		}
+	}
+	return spec, nil
+}
+
+// stripCFormatDirectives returns s (unescaped) with its printf directives
+// replaced by spaces. A '%' that does not start a valid directive is kept as
+// literal text, so one bad directive does not stop the others being stripped.
+func stripCFormatDirectives(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if s[i] == '%' {
+			if end := cFormatDirectiveEnd(s, i); end > i {
+				b.WriteString(" ")
+				i = end
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+		i++
+	}
+	return b.String()
+}
+
+// cFormatDirectiveEnd returns the index after the printf directive starting
+// at s[i], or -1 if there is no valid directive there. "%%" is not a
+// directive.
+func cFormatDirectiveEnd(s string, i int) int {
+	// skipArg skips an optional "N$" argument number at s[i:].
+	skipArg := func(i int) int {
+		j := i
+		for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+			j++
+		}
+		if j > i && j < len(s) && s[j] == '$' && strings.TrimLeft(s[i:j], "0") != "" {
+			return j + 1
+		}
+		return i
+	}
+	skipDigits := func(i int) int {
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		return i
+	}
+
+	i++
+	if i < len(s) && s[i] == '%' {
+		return -1
+	}
+	i = skipArg(i)
+	for i < len(s) && strings.IndexByte("-+ #0'I", s[i]) >= 0 {
+		i++
+	}
+	if i < len(s) && s[i] == '*' {
+		i = skipArg(i + 1)
+	} else {
+		i = skipDigits(i)
+	}
+	if i < len(s) && s[i] == '.' {
+		i++
+		if i < len(s) && s[i] == '*' {
+			i = skipArg(i + 1)
+		} else {
+			i = skipDigits(i)
+		}
+	}
+	if _, end, err := cFormatConversion(s, i); err == nil {
+		return end
+	}
+	return -1
+}
+
+// cFormatConversion parses the length modifier and conversion character at
+// s[i:] and returns the C type of the converted argument ("" for "%m") and the
+// index after the directive.
+func cFormatConversion(s string, i int) (string, int, error) {
+	if i < len(s) && s[i] == '<' {
+		end := strings.IndexByte(s[i:], '>')
+		if end < 0 {
+			return "", len(s), fmt.Errorf("unterminated '<'")
+		}
+		typ, err := cFormatInttypesMacro(s[i+1 : i+end])
+		return typ, i + end + 1, err
+	}
+	var length string
+	for _, m := range []string{"hh", "ll", "h", "l", "L", "q", "j", "z", "Z", "t"} {
+		if strings.HasPrefix(s[i:], m) {
+			length = m
+			i += len(m)
+			break
+		}
+	}
+	if i >= len(s) {
+		return "", i, fmt.Errorf("missing conversion character")
+	}
+	c := s[i]
+	i++
+	switch c {
+	case 'd', 'i':
+		return cFormatIntType(length, false), i, nil
+	case 'o', 'u', 'x', 'X':
+		return cFormatIntType(length, true), i, nil
+	case 'e', 'E', 'f', 'F', 'g', 'G', 'a', 'A':
+		if length == "L" || length == "ll" || length == "q" {
+			return "long double", i, nil
+		}
+		if length == "" || length == "l" {
+			return "double", i, nil
+		}
+	case 'c', 'C':
+		if c == 'C' || length == "l" {
+			return "wint_t", i, nil
+		}
+		if length == "" {
+			return "char", i, nil
+		}
+	case 's', 'S':
+		if c == 'S' || length == "l" {
+			return "wchar_t *", i, nil
+		}
+		if length == "" {
+			return "char *", i, nil
+		}
+	case 'p':
+		if length == "" {
+			return "void *", i, nil
+		}
+	case 'n':
+		return cFormatIntType(length, false) + " *", i, nil
+	case 'm':
+		if length == "" {
+			return "", i, nil
+		}
+	default:
+		return "", i, fmt.Errorf("unknown conversion character '%c'", c)
+	}
+	return "", i, fmt.Errorf("invalid length modifier '%s' for '%c'", length, c)
+}
+
+// cFormatIntType returns the C integer type selected by a length modifier.
+func cFormatIntType(length string, unsigned bool) string {
+	var typ string
+	switch length {
+	case "hh":
+		typ = "char"
+	case "h":
+		typ = "short"
+	case "l":
+		typ = "long"
+	case "ll", "q", "L":
+		typ = "long long"
+	case "j":
+		typ = "intmax_t"
+	case "z", "Z":
+		typ = "size_t"
+	case "t":
+		typ = "ptrdiff_t"
+	default:
+		typ = "int"
+	}
+	if unsigned {
+		switch typ {
+		case "intmax_t":
+			return "uintmax_t"
+		case "size_t", "ptrdiff_t":
+			return typ
+		case "int":
+			return "unsigned int"
+		}
+		return "unsigned " + typ
+	}
+	return typ
+}
+
+// cFormatInttypesMacro returns the type for an <inttypes.h> macro such as
+// "PRIuMAX" or "PRId64", as written by xgettext in "%<PRIuMAX>".
+func cFormatInttypesMacro(name string) (string, error) {
+	if !strings.HasPrefix(name, "PRI") || len(name) < 5 {
+		return "", fmt.Errorf("unknown macro '<%s>'", name)
+	}
+	conv, size := name[3], name[4:]
+	signed := ""
+	switch conv {
+	case 'd', 'i':
+	case 'o', 'u', 'x', 'X':
+		signed = "u"
+	default:
+		return "", fmt.Errorf("unknown macro '<%s>'", name)
+	}
+	size = strings.TrimPrefix(strings.TrimPrefix(size, "LEAST"), "FAST")
+	switch size {
+	case "8", "16", "32", "64":
+		return signed + "int" + size + "_t", nil
+	case "MAX":
+		return signed + "intmax_t", nil
+	case "PTR":
+		return signed + "intptr_t", nil
+	}
+	return "", fmt.Errorf("unknown macro '<%s>'", name)
+}
+
+// directiveForArg returns the text of the directive converting arg, for messages.
+func (spec *CFormatSpec) directiveForArg(arg int) string {
+	for _, d := range spec.Directives {
+		if d.Arg == arg {
+			return d.Text
+		}
+	}
+	for _, d := range spec.Directives {
+		if d.WidthArg == arg || d.PrecisionArg == arg {
+			return d.Text
+		}
+	}
+	return ""
+}
+
+// CompareCFormat compares the printf directives of a translation (msgstr, named
+// by keyword in messages) against its source (msgid or msgid_plural). Both
+// strings are unescaped. Directives may be reordered with numbered arguments,
+// but every argument must keep its type. When strict is false the translation
+// may leave out arguments, which is allowed for plural forms that are used for a
+// single number only (e.g. "one file" for n == 1).
+// Returns one message per mismatch, naming the offending directives.
+func CompareCFormat(msgid, msgstr, keyword string, strict bool) []string {
+	src, err := ParseCFormat(msgid)
+	if err != nil {
+		// Nothing to compare against; msgid problems are for the developers.
+		return nil
+	}
+	dst, err := ParseCFormat(msgstr)
+	if err != nil {
+		return []string{fmt.Sprintf("'%s' is not a valid C format string, unlike 'msgid': %v", keyword, err)}
+	}
+
+	args := make([]int, 0, len(src.Args)+len(dst.Args))
+	for n := range src.Args {
+		args = append(args, n)
+	}
+	for n := range dst.Args {
+		if _, ok := src.Args[n]; !ok {
+			args = append(args, n)
+		}
+	}
+	sort.Ints(args)
+
+	var msgs []string
+	for _, n := range args {
+		srcType, inSrc := src.Args[n]
+		dstType, inDst := dst.Args[n]
+		switch {
+		case !inSrc:
+			msgs = append(msgs, fmt.Sprintf("a format specification for argument %d, as in '%s' ('%s'), doesn't exist in 'msgid'",
+				n, keyword, dst.directiveForArg(n)))
+		case !inDst:
+			if strict {
+				msgs = append(msgs, fmt.Sprintf("a format specification for argument %d ('%s' in 'msgid') doesn't exist in '%s'",
+					n, src.directiveForArg(n), keyword))
+			}
+		case srcType != dstType:
+			msgs = append(msgs, fmt.Sprintf("format specifications in 'msgid' and '%s' for argument %d are not the same: '%s' (%s) vs '%s' (%s)",
+				keyword, n, src.directiveForArg(n), srcType, dst.directiveForArg(n), dstType))
+		}
+	}
+	return msgs
+}
+
+// checkEntryCFormat compares every msgstr form of a c-format entry with its source
+// string, like msgfmt: msgstr is compared with msgid, and every plural form with
+// msgid_plural. Plural forms that pf maps from a single number may omit arguments;
+// without pf every plural form may omit arguments. Untranslated forms are skipped.
+func checkEntryCFormat(e *GettextEntry, pf *PluralForms) []string {
+	var msgs []string
+	singleValued := pluralFormsForSingleNumber(pf)
+	for i, s := range e.MsgStr {
+		if s == "" {
+			continue
+		}
+		msgid, keyword, strict := e.MsgID, "msgstr", true
+		if e.MsgIDPlural != "" {
+			keyword = "msgstr[" + strconv.Itoa(i) + "]"
+			msgid = e.MsgIDPlural
+			strict = pf != nil && !singleValued[i]
+		}
+		msgs = append(msgs, CompareCFormat(poUnescape(msgid), poUnescape(s), keyword, strict)...)
+	}
+	return msgs
+}
+
+// pluralFormsForSingleNumber returns the plural form indexes that pf selects
+// for exactly one n in [0, pluralFormsCheckLimit], e.g. form 0 for
+// "nplurals=2; plural=(n != 1);".
+func pluralFormsForSingleNumber(pf *PluralForms) map[int]bool {
+	if pf == nil {
+		return nil
+	}
+	counts := make(map[int]int)
+	for n := uint64(0); n <= pluralFormsCheckLimit; n++ {
+		idx, err := pf.Eval(n)
+		if err != nil {
+			return nil
+		}
+		counts[idx]++
+	}
+	single := make(map[int]bool)
+	for idx, count := range counts {
+		if count == 1 {
+			single[idx] = true
+		}
+	}
+	return single
+}
+
+// checkPoCFormat checks the printf directives of every translated, non-fuzzy
+// c-format entry against its msgid (see checkEntryCFormat).
+func checkPoCFormat(po *GettextPO) ([]string, bool) {
+	var errs []string
+	pf, err := po.PluralForms()
+	if err != nil {
+		pf = nil
+	}
+	for i := range po.Entries {
+		e := &po.Entries[i]
+		if e.Obsolete || e.Fuzzy || !entryFlags(e)["c-format"] {
+			continue
+		}
+		msgs := checkEntryCFormat(e, pf)
+		if len(msgs) == 0 {
+			continue
+		}
+		msgid := e.MsgID
+		if len(msgid) > 30 {
+			msgid = msgid[:27] + "..."
+		}
+		desc := entryDescWithLine(i+1, msgid, e.EntryLocation)
+		for _, msg := range msgs {
+			errs = append(errs, fmt.Sprintf("%s: %s", desc, msg))
+		}
+	}
+	return errs, len(errs) == 0
+}
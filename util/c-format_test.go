@@ -0,0 +1,247 @@
+package util
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCFormat(t *testing.T) {
+	tests := []struct {
+		name       string
+		s          string
+		wantArgs   map[int]string
+		positional bool
+		wantErr    string
+	}{
+		{
+			name:     "plain",
+			s:        "%s: %d files, 100%% done",
+			wantArgs: map[int]string{1: "char *", 2: "int"},
+		},
+		{
+			name:     "length modifiers",
+			s:        "%lu %lld %hhx %zu %Lf %ls %c",
+			wantArgs: map[int]string{1: "unsigned long", 2: "long long", 3: "unsigned char", 4: "size_t", 5: "long double", 6: "wchar_t *", 7: "char"},
+		},
+		{
+			name:     "star width and precision",
+			s:        "%-*.*s",
+			wantArgs: map[int]string{1: "int", 2: "int", 3: "char *"},
+		},
+		{
+			name:       "numbered arguments",
+			s:          "%2$s %1$d",
+			wantArgs:   map[int]string{1: "int", 2: "char *"},
+			positional: true,
+		},
+		{
+			name:       "numbered precision",
+			s:          "%2$.*1$d",
+			wantArgs:   map[int]string{1: "int", 2: "int"},
+			positional: true,
+		},
+		{
+			name:     "inttypes macro",
+			s:        "%<PRIuMAX> objects, %<PRId64>",
+			wantArgs: map[int]string{1: "uintmax_t", 2: "int64_t"},
+		},
+		{
+			name:     "errno",
+			s:        "failed: %m (%s)",
+			wantArgs: map[int]string{1: "char *"},
+		},
+		{
+			name:    "mixed",
+			s:       "%1$s %s",
+			wantErr: "mixes numbered and unnumbered",
+		},
+		{
+			name:    "gap",
+			s:       "%2$s",
+			wantErr: "argument 1 is not used",
+		},
+		{
+			name:    "conflicting types",
+			s:       "%1$s %1$d",
+			wantErr: "uses argument 1 as 'int'",
+		},
+		{
+			name:    "unknown conversion",
+			s:       "%y",
+			wantErr: "unknown conversion character 'y'",
+		},
+		{
+			name:    "trailing percent",
+			s:       "100%",
+			wantErr: "missing conversion character",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := ParseCFormat(tt.s)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseCFormat(%q) err = %v, want containing %q", tt.s, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseCFormat(%q): %v", tt.s, err)
+			}
+			if spec.Positional != tt.positional {
+				t.Errorf("Positional = %v, want %v", spec.Positional, tt.positional)
+			}
+			if len(spec.Args) != len(tt.wantArgs) {
+				t.Errorf("Args = %v, want %v", spec.Args, tt.wantArgs)
+			}
+			for n, typ := range tt.wantArgs {
+				if spec.Args[n] != typ {
+					t.Errorf("Args[%d] = %q, want %q", n, spec.Args[n], typ)
+				}
+			}
+		})
+	}
+}
+
+func TestCompareCFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		msgid    string
+		msgstr   string
+		strict   bool
+		wantMsgs []string
+	}{
+		{
+			name:   "same",
+			msgid:  "%s: %d",
+			msgstr: "%s：%d",
+			strict: true,
+		},
+		{
+			name:   "reordered with numbered arguments",
+			msgid:  "%s is %d",
+			msgstr: "%2$d 是 %1$s",
+			strict: true,
+		},
+		{
+			name:   "numbered precision reordered",
+			msgid:  "%.*s",
+			msgstr: "%2$.*1$s",
+			strict: true,
+		},
+		{
+			name:   "type mismatch",
+			msgid:  "%s: %d",
+			msgstr: "%2$s: %1$d",
+			strict: true,
+			wantMsgs: []string{
+				"for argument 1 are not the same: '%s' (char *) vs '%1$d' (int)",
+				"for argument 2 are not the same: '%d' (int) vs '%2$s' (char *)",
+			},
+		},
+		{
+			name:     "missing argument",
+			msgid:    "%s: %d",
+			msgstr:   "%s",
+			strict:   true,
+			wantMsgs: []string{"a format specification for argument 2 ('%d' in 'msgid') doesn't exist in 'msgstr'"},
+		},
+		{
+			name:   "missing argument allowed when not strict",
+			msgid:  "%d file",
+			msgstr: "one file",
+		},
+		{
+			name:     "extra argument",
+			msgid:    "%s",
+			msgstr:   "%s %s",
+			wantMsgs: []string{"argument 2, as in 'msgstr' ('%s'), doesn't exist in 'msgid'"},
+		},
+		{
+			name:     "invalid translation",
+			msgid:    "%s",
+			msgstr:   "%1$s %s",
+			strict:   true,
+			wantMsgs: []string{"'msgstr' is not a valid C format string, unlike 'msgid'"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msgs := CompareCFormat(tt.msgid, tt.msgstr, "msgstr", tt.strict)
+			if len(msgs) != len(tt.wantMsgs) {
+				t.Fatalf("CompareCFormat() = %q, want %d message(s)", msgs, len(tt.wantMsgs))
+			}
+			for i, want := range tt.wantMsgs {
+				if !strings.Contains(msgs[i], want) {
+					t.Errorf("message %q does not contain %q", msgs[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckPoCFormat(t *testing.T) {
+	header := "msgid \"\"\nmsgstr \"\"\n\"Project-Id-Version: git\\n\"\n\"Plural-Forms: nplurals=2; plural=(n != 1);\\n\"\n\n"
+	tests := []struct {
+		name    string
+		po      string
+		wantOk  bool
+		wantMsg string
+	}{
+		{
+			name:   "singular form may drop the number",
+			po:     header + "#, c-format\nmsgid \"one file\"\nmsgid_plural \"%d files\"\nmsgstr[0] \"eine Datei\"\nmsgstr[1] \"%d Dateien\"\n",
+			wantOk: true,
+		},
+		{
+			name:    "plural form must keep the number",
+			po:      header + "#, c-format\nmsgid \"one file\"\nmsgid_plural \"%d files\"\nmsgstr[0] \"eine Datei\"\nmsgstr[1] \"Dateien\"\n",
+			wantMsg: "entry 1@L7 (msgid \"one file\"): a format specification for argument 1 ('%d' in 'msgid') doesn't exist in 'msgstr[1]'",
+		},
+		{
+			name:   "singular form is compared with msgid_plural",
+			po:     header + "#, c-format\nmsgid \"one file\"\nmsgid_plural \"%d files\"\nmsgstr[0] \"%d Datei\"\nmsgstr[1] \"%d Dateien\"\n",
+			wantOk: true,
+		},
+		{
+			name:    "singular form mismatch with msgid_plural",
+			po:      header + "#, c-format\nmsgid \"%s has one file\"\nmsgid_plural \"%s has %d files\"\nmsgstr[0] \"%d hat eine Datei\"\nmsgstr[1] \"%s hat %d Dateien\"\n",
+			wantMsg: "format specifications in 'msgid' and 'msgstr[0]' for argument 1 are not the same: '%s' (char *) vs '%d' (int)",
+		},
+		{
+			name:   "only plural form is compared with msgid_plural",
+			po:     "msgid \"\"\nmsgstr \"\"\n\"Plural-Forms: nplurals=1; plural=0;\\n\"\n\n#, c-format\nmsgid \"one file\"\nmsgid_plural \"%d files\"\nmsgstr[0] \"%d 个文件\"\n",
+			wantOk: true,
+		},
+		{
+			name:   "no c-format flag",
+			po:     header + "msgid \"%s\"\nmsgstr \"%d\"\n",
+			wantOk: true,
+		},
+		{
+			name:   "fuzzy entry ignored",
+			po:     header + "#, fuzzy, c-format\nmsgid \"%s\"\nmsgstr \"%d\"\n",
+			wantOk: true,
+		},
+		{
+			name:    "type mismatch",
+			po:      header + "#, c-format\nmsgid \"%<PRIuMAX> objects\"\nmsgstr \"%d 个对象\"\n",
+			wantMsg: "'%<PRIuMAX>' (uintmax_t) vs '%d' (int)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			po, err := ParsePoEntries([]byte(tt.po))
+			if err != nil {
+				t.Fatal(err)
+			}
+			errs, ok := checkPoCFormat(po)
+			if ok != tt.wantOk {
+				t.Fatalf("ok = %v, want %v (errs: %v)", ok, tt.wantOk, errs)
+			}
+			if tt.wantMsg != "" && !strings.Contains(strings.Join(errs, "\n"), tt.wantMsg) {
+				t.Errorf("errs = %v, want containing %q", errs, tt.wantMsg)
+			}
+		})
+	}
+}
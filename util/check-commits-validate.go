@@ -504,6 +504,7 @@ func checkCommitLog(commit string) bool {
 		ok        = true
 		commitLog = newCommitLog(commit)
 	)
+	defer setReportTarget("", commit)()
 	cmd := exec.Command("git",
 		"cat-file",
 		"commit",
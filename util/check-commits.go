@@ -422,6 +422,7 @@ func checkCommits(commits ...string) bool {
 			)
 			ok = true
 			brk = false
+			defer setReportTarget("", commit)()
 			defer func() {
 				const title = "Changes outside po/"
 				if len(warns) > 0 {
@@ -19,6 +19,7 @@ func CheckCorePoFile(locale, poFile string) bool {
 		infos  []string
 	)
 
+	defer setReportTarget(poFile, "")()
 	defer func() {
 		const coreTitle = "Core PO vs git-core.pot"
 		if len(infos) > 0 {
@@ -66,13 +67,20 @@ func CheckCorePoFile(locale, poFile string) bool {
 		return false
 	}
 
-	cmd := exec.Command("msgmerge",
-		"--add-location=file",
-		"--backup=off",
-		"-U",
-		fout.Name(),
-		filepath.Join(PoDir, CorePot))
-	if err = cmd.Run(); err != nil {
+	if _, err = exec.LookPath("msgmerge"); err != nil {
+		log.Debugf("msgmerge not found, update core po file with built-in merge")
+		_, err = msgMergeToFile(fout.Name(), filepath.Join(PoDir, CorePot), fout.Name(),
+			MsgMergeOptions{NoLineNumber: true})
+	} else {
+		cmd := exec.Command("msgmerge",
+			"--add-location=file",
+			"--backup=off",
+			"-U",
+			fout.Name(),
+			filepath.Join(PoDir, CorePot))
+		err = cmd.Run()
+	}
+	if err != nil {
 		errs = append(errs,
 			fmt.Sprintf("fail to update core po file: %s", err))
 		// ShowExecError(err)
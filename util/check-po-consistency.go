@@ -0,0 +1,203 @@
+// Package util provides the check of the consistency of the translations of a PO file.
+package util
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/git-l10n/git-po-helper/config"
+	"github.com/git-l10n/git-po-helper/flag"
+)
+
+func init() {
+	RegisterPoCheck(&PoCheck{
+		Name:        "consistency",
+		Title:       "Translation consistency",
+		Description: "same msgid translated differently, or same msgstr for different msgids (--consistency)",
+		Level:       config.CheckLevelWarn,
+		Disabled:    true,
+		Enable:      flag.Consistency,
+		NeedsPo:     true,
+		Run: func(c *PoCheckContext) ([]string, bool) {
+			return checkPoConsistency(c.Po)
+		},
+	})
+}
+
+// consistencyUnit is a source string of a translated entry and its
+// translation.
+type consistencyUnit struct {
+	entry  int // index of the entry, from 1
+	line   int
+	msgctx *string
+	plural bool // msgid_plural of the entry, else msgid
+	msgID  string
+	msgStr string
+
+	cFormat bool // entry has the c-format flag
+}
+
+func (u *consistencyUnit) String() string {
+	desc := fmt.Sprintf("entry %d", u.entry)
+	if u.line > 0 {
+		desc = fmt.Sprintf("entry %d@L%d", u.entry, u.line)
+	}
+	var notes []string
+	if u.msgctx != nil {
+		notes = append(notes, fmt.Sprintf(`msgctxt "%s"`, *u.msgctx))
+	}
+	if u.plural {
+		notes = append(notes, "msgid_plural")
+	}
+	if len(notes) > 0 {
+		desc += " (" + strings.Join(notes, ", ") + ")"
+	}
+	return desc
+}
+
+// consistencyUnits returns the units of the translated entries of po: msgid
+// with msgstr[0], and msgid_plural with the last plural form for languages
+// with at most two plural forms, where it is the form of numbers above one.
+func consistencyUnits(po *GettextPO) []*consistencyUnit {
+	var units []*consistencyUnit
+	for i := range po.Entries {
+		e := &po.Entries[i]
+		if e.Obsolete || e.Fuzzy || e.MsgID == "" || len(e.MsgStr) == 0 {
+			continue
+		}
+		cFormat := entryFlags(e)["c-format"]
+		if e.MsgStr[0] != "" {
+			units = append(units, &consistencyUnit{
+				entry:  i + 1,
+				line:   e.EntryLocation,
+				msgctx: e.MsgCtxt,
+				msgID:  e.MsgID,
+				msgStr: e.MsgStr[0],
+
+				cFormat: cFormat,
+			})
+		}
+		if e.MsgIDPlural != "" && len(e.MsgStr) <= 2 && e.MsgStr[len(e.MsgStr)-1] != "" {
+			units = append(units, &consistencyUnit{
+				entry:  i + 1,
+				line:   e.EntryLocation,
+				msgctx: e.MsgCtxt,
+				plural: true,
+				msgID:  e.MsgIDPlural,
+				msgStr: e.MsgStr[len(e.MsgStr)-1],
+
+				cFormat: cFormat,
+			})
+		}
+	}
+	return units
+}
+
+// consistencyMsgIDKey returns msgID without the differences which need no
+// different translations: case, surrounding whitespace and final
+// punctuation.
+func consistencyMsgIDKey(msgID string) string {
+	msgID = strings.TrimSpace(strings.ReplaceAll(poUnescape(msgID), "\n", " "))
+	msgID = strings.TrimRightFunc(msgID, func(r rune) bool {
+		return unicode.IsPunct(r) || unicode.IsSpace(r)
+	})
+	return strings.ToLower(msgID)
+}
+
+// hasWords is true for a msgstr with words, not only printf directives and
+// punctuation, such as "%s: %s", which many msgids may share. Directives are
+// only looked for if cFormat is set, as in other strings a '%' is literal.
+func hasWords(s string, cFormat bool) bool {
+	if cFormat {
+		s = stripCFormatDirectives(s)
+	}
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// consistencyClusters groups units by key, and returns the groups for which
+// distinct returns more than one value, ordered by their first entries.
+func consistencyClusters(units []*consistencyUnit,
+	key, distinct func(*consistencyUnit) string) [][]*consistencyUnit {
+	var (
+		keys   []string
+		groups = make(map[string][]*consistencyUnit)
+	)
+	for _, u := range units {
+		k := key(u)
+		if k == "" {
+			continue
+		}
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], u)
+	}
+
+	var clusters [][]*consistencyUnit
+	for _, k := range keys {
+		values := make(map[string]bool)
+		for _, u := range groups[k] {
+			values[distinct(u)] = true
+		}
+		if len(values) > 1 {
+			clusters = append(clusters, groups[k])
+		}
+	}
+	sort.SliceStable(clusters, func(i, j int) bool {
+		return clusters[i][0].entry < clusters[j][0].entry
+	})
+	return clusters
+}
+
+// checkPoConsistency reports clusters of entries which should be unified:
+// identical msgids with different translations, such as in different
+// msgctxt or in singular and plural entries, and different msgids with the
+// same translation, which may be a copy-paste error.
+func checkPoConsistency(po *GettextPO) ([]string, bool) {
+	var msgs []string
+	units := consistencyUnits(po)
+
+	for _, cluster := range consistencyClusters(units,
+		func(u *consistencyUnit) string { return u.msgID },
+		func(u *consistencyUnit) string { return u.msgStr },
+	) {
+		lines := []string{fmt.Sprintf(`msgid "%s" is translated differently:`, cluster[0].msgID)}
+		for _, u := range cluster {
+			lines = append(lines, fmt.Sprintf(`  %s: "%s"`, u, u.msgStr))
+		}
+		msgs = append(msgs, strings.Join(lines, "\n"))
+	}
+
+	// Plural units are left out, as the plural form of languages with one
+	// form is also the translation of msgid.
+	var singular []*consistencyUnit
+	for _, u := range units {
+		if !u.plural {
+			singular = append(singular, u)
+		}
+	}
+	for _, cluster := range consistencyClusters(singular,
+		func(u *consistencyUnit) string {
+			if !hasWords(poUnescape(u.msgStr), u.cFormat) {
+				return ""
+			}
+			return u.msgStr
+		},
+		func(u *consistencyUnit) string { return consistencyMsgIDKey(u.msgID) },
+	) {
+		lines := []string{fmt.Sprintf(`msgstr "%s" is the translation of different msgids:`, cluster[0].msgStr)}
+		for _, u := range cluster {
+			lines = append(lines, fmt.Sprintf(`  %s: "%s"`, u, u.msgID))
+		}
+		msgs = append(msgs, strings.Join(lines, "\n"))
+	}
+
+	return msgs, len(msgs) == 0
+}
@@ -0,0 +1,113 @@
+package util
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckPoConsistency(t *testing.T) {
+	po, err := ParsePoEntries([]byte(`msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+msgctxt "noun"
+msgid "commit"
+msgstr "Commit"
+
+msgctxt "verb"
+msgid "commit"
+msgstr "committen"
+
+msgid "Done."
+msgstr "Fertig."
+
+msgid "done"
+msgstr "Fertig."
+
+msgid "Push"
+msgstr "Versionsstand"
+
+#, c-format
+msgid "%s: %s"
+msgstr "%s: %s"
+
+#, c-format
+msgid "%s - %s"
+msgstr "%s: %s"
+
+msgid "%d files"
+msgstr "%d Dateien"
+
+msgid "one file"
+msgid_plural "%d files"
+msgstr[0] "eine Datei"
+msgstr[1] "%d Datei(en)"
+
+msgid "Commit"
+msgstr "Versionsstand"
+
+#, fuzzy
+msgid "Pull"
+msgstr "Versionsstand"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs, ok := checkPoConsistency(po)
+	if ok {
+		t.Error("expected inconsistencies")
+	}
+	want := []string{
+		`msgid "commit" is translated differently:
+  entry 1@L7 (msgctxt "noun"): "Commit"
+  entry 2@L11 (msgctxt "verb"): "committen"`,
+		`msgid "%d files" is translated differently:
+  entry 8@L31: "%d Dateien"
+  entry 9@L34 (msgid_plural): "%d Datei(en)"`,
+		`msgstr "Versionsstand" is the translation of different msgids:
+  entry 5@L20: "Push"
+  entry 10@L39: "Commit"`,
+	}
+	if strings.Join(errs, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(errs, "\n"), strings.Join(want, "\n"))
+	}
+
+	po, err = ParsePoEntries([]byte(`msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+
+msgid "Done."
+msgstr "Fertig."
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs, ok := checkPoConsistency(po); !ok || len(errs) > 0 {
+		t.Errorf("expected no inconsistencies, got %q", errs)
+	}
+}
+
+func TestHasWords(t *testing.T) {
+	for _, tc := range []struct {
+		s       string
+		cFormat bool
+		want    bool
+	}{
+		{"%s: %s", true, false},
+		{"%2$s (%1$.*3$d)", true, false},
+		{"%<PRIuMAX>%%", true, false},
+		{"%s files", true, true},
+		{"%s 个文件", true, true},
+		{"%ld", true, false},
+		{"100%", true, false},
+		{"%q: %s", true, true},
+		{"%z %s", true, true},
+		{"100% d", false, true},
+		{"%s: %s", false, true},
+	} {
+		if got := hasWords(tc.s, tc.cFormat); got != tc.want {
+			t.Errorf("hasWords(%q, %v) = %v, want %v", tc.s, tc.cFormat, got, tc.want)
+		}
+	}
+}
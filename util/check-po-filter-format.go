@@ -122,7 +122,8 @@ func checkPoFilterFormat(contentPath, repoAttrRelPath, attrSourceCommit, filterA
 
 	missingFilter := filterValue == "unspecified" || filterValue == "unset" || filterValue == ""
 	if missingFilter {
-		errs = append(errs,
+		// One message, for it is one finding of --output-format.
+		errs = append(errs, strings.Join([]string{
 			"No Git `filter` attribute is set for *.po files on this path.",
 			"",
 			"The filter attribute describes how Git should normalize #: location comments on each",
@@ -140,7 +141,7 @@ func checkPoFilterFormat(contentPath, repoAttrRelPath, attrSourceCommit, filterA
 			"See:",
 			"",
 			"    https://lore.kernel.org/git/20220504124121.12683-1-worldhello.net@gmail.com/",
-		)
+		}, "\n"))
 	}
 
 	effectiveFilter := filterValue
@@ -0,0 +1,359 @@
+// Package util provides the check of the glossary terms of msgstr.
+package util
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/git-l10n/git-po-helper/config"
+	"github.com/git-l10n/git-po-helper/dict"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	RegisterPoCheck(&PoCheck{
+		Name:        "glossary",
+		Title:       "Glossary terms",
+		Description: "mandatory translations of the terms of the glossary of the language",
+		Level:       config.CheckLevelError,
+		NeedsPo:     true,
+		Run: func(c *PoCheckContext) ([]string, bool) {
+			file := findGlossaryFile(c)
+			if file == "" {
+				return nil, true
+			}
+			terms, err := loadGlossaryCached(file)
+			if err != nil {
+				return []string{err.Error()}, false
+			}
+			return checkPoGlossary(c.Po, terms)
+		},
+	})
+}
+
+// Matching of the source term of a glossary term in msgid.
+const (
+	GlossaryMatchWord   = "word"   // whole words, ignoring case (default)
+	GlossaryMatchPrefix = "prefix" // words starting with the term, e.g. "commits" for "commit"
+	GlossaryMatchRegex  = "regex"  // source, targets and forbidden variants are regexps
+)
+
+// glossaryIgnoreComment is a translator comment for entries not checked
+// against the glossary: "# glossary-ignore" for all terms, or
+// "# glossary-ignore: commit, stash" for some terms.
+const glossaryIgnoreComment = "glossary-ignore"
+
+// GlossaryTerm is a term of a glossary: msgstr of an entry with Source in
+// msgid must have one of Targets, and none of Forbidden.
+type GlossaryTerm struct {
+	Source    string     `yaml:"source"`
+	Targets   yamlString `yaml:"target"`
+	Forbidden yamlString `yaml:"forbidden"`
+	Match     string     `yaml:"match"`
+	Note      string     `yaml:"note"`
+
+	sourceRe    *regexp.Regexp
+	targetRes   []*regexp.Regexp
+	forbiddenRe []*regexp.Regexp
+}
+
+// yamlString is a list of strings, which may be written as one string in
+// YAML.
+type yamlString []string
+
+func (s *yamlString) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*s = []string{node.Value}
+		return nil
+	}
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*s = list
+	return nil
+}
+
+// compile checks the term and compiles its patterns.
+func (t *GlossaryTerm) compile() error {
+	if t.Source == "" {
+		return fmt.Errorf("no source term")
+	}
+	if len(t.Targets) == 0 && len(t.Forbidden) == 0 {
+		return fmt.Errorf("no target or forbidden translation of %q", t.Source)
+	}
+	literal := func(s string) string { return `(?i)` + regexp.QuoteMeta(s) }
+	var err error
+	switch t.Match {
+	case "", GlossaryMatchWord:
+		t.sourceRe, err = regexp.Compile(`(?i)\b` + regexp.QuoteMeta(t.Source) + `\b`)
+	case GlossaryMatchPrefix:
+		t.sourceRe, err = regexp.Compile(`(?i)\b` + regexp.QuoteMeta(t.Source))
+	case GlossaryMatchRegex:
+		t.sourceRe, err = regexp.Compile(t.Source)
+		literal = func(s string) string { return s }
+	default:
+		return fmt.Errorf("bad match %q of %q (use word, prefix or regex)", t.Match, t.Source)
+	}
+	if err != nil {
+		return err
+	}
+	// Translations are matched anywhere, for languages without spaces
+	// between words, and for inflections.
+	for _, list := range []struct {
+		patterns []string
+		res      *[]*regexp.Regexp
+	}{
+		{t.Targets, &t.targetRes},
+		{t.Forbidden, &t.forbiddenRe},
+	} {
+		for _, p := range list.patterns {
+			re, err := regexp.Compile(literal(p))
+			if err != nil {
+				return err
+			}
+			*list.res = append(*list.res, re)
+		}
+	}
+	return nil
+}
+
+// splitGlossaryField splits a field of a TSV glossary with alternatives
+// separated by "|".
+func splitGlossaryField(field string) []string {
+	var list []string
+	for _, s := range strings.Split(field, "|") {
+		if s = strings.TrimSpace(s); s != "" {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// LoadGlossary reads a glossary file. A ".tsv" file has one term per line,
+// with tab separated columns: source term, target translations,
+// forbidden translations, match (word, prefix or regex) and a note;
+// alternatives are separated by "|", and lines starting with "#" are
+// comments. A ".yaml" or ".yml" file has a list of terms under the key
+// "terms", with the keys source, target, forbidden, match and note.
+func LoadGlossary(file string) ([]*GlossaryTerm, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var terms []*GlossaryTerm
+	switch filepath.Ext(file) {
+	case ".yaml", ".yml":
+		var doc struct {
+			Terms []*GlossaryTerm `yaml:"terms"`
+		}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		for i, t := range doc.Terms {
+			if err := t.compile(); err != nil {
+				return nil, fmt.Errorf("%s: term %d: %w", file, i+1, err)
+			}
+		}
+		terms = doc.Terms
+	case ".tsv":
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for lineNo := 1; scanner.Scan(); lineNo++ {
+			line := strings.TrimRight(scanner.Text(), "\r")
+			if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			fields := strings.Split(line, "\t")
+			for len(fields) < 5 {
+				fields = append(fields, "")
+			}
+			t := &GlossaryTerm{
+				Source:    strings.TrimSpace(fields[0]),
+				Targets:   splitGlossaryField(fields[1]),
+				Forbidden: splitGlossaryField(fields[2]),
+				Match:     strings.TrimSpace(fields[3]),
+				Note:      strings.TrimSpace(fields[4]),
+			}
+			if err := t.compile(); err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", file, lineNo, err)
+			}
+			terms = append(terms, t)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%s: unknown glossary format (use .tsv, .yaml or .yml)", file)
+	}
+	return terms, nil
+}
+
+var (
+	glossaryCacheMu sync.Mutex
+	glossaryCache   = make(map[string][]*GlossaryTerm)
+)
+
+// findGlossaryFile returns the glossary of the language of c, or "": the file
+// named in the config files for the locale or its language, or
+// glossary/<locale>.tsv (.yaml, .yml) in the directory of the PO file,
+// then the same for the language ("zh" for "zh_CN").
+func findGlossaryFile(c *PoCheckContext) string {
+	names := c.languageNames()
+	files := getCheckPoConfig().Glossary
+	for _, name := range names {
+		if file, ok := files[name]; ok {
+			return file
+		}
+	}
+
+	dir := c.poDir()
+	for _, name := range names {
+		for _, ext := range []string{".tsv", ".yaml", ".yml"} {
+			file := filepath.Join(dir, "glossary", name+ext)
+			if IsFile(file) {
+				return file
+			}
+		}
+	}
+	return ""
+}
+
+func loadGlossaryCached(file string) ([]*GlossaryTerm, error) {
+	glossaryCacheMu.Lock()
+	defer glossaryCacheMu.Unlock()
+	if terms, ok := glossaryCache[file]; ok {
+		return terms, nil
+	}
+	terms, err := LoadGlossary(file)
+	if err != nil {
+		return nil, err
+	}
+	glossaryCache[file] = terms
+	return terms, nil
+}
+
+// keptCommandPattern matches quoted commands, such as `git commit`,
+// "git commit" or 'git commit'.
+var keptCommandPattern = regexp.MustCompile("`[^`]*`|\"git [^\"]*\"|'git [^']*'")
+
+// stripKeptWords returns s without the words translations keep, such as
+// options, config variables, placeholders and quoted commands, so that
+// glossary terms and spelling are not checked in them.
+func stripKeptWords(s string) string {
+	s = keptCommandPattern.ReplaceAllString(s, " ")
+	s = dict.KeepWordsPattern.ReplaceAllString(s, " ")
+	for _, re := range dict.GlobalSkipPatterns {
+		s = re.Pattern.ReplaceAllString(s, re.Replace)
+	}
+	return s
+}
+
+// glossaryIgnoredTerms returns the terms of the "glossary-ignore" translator
+// comments of e, and whether all terms are ignored.
+func glossaryIgnoredTerms(e *GettextEntry) (map[string]bool, bool) {
+	ignored := make(map[string]bool)
+	for _, comment := range e.ParsedComments().Translator {
+		comment = strings.TrimSpace(comment)
+		if !strings.HasPrefix(comment, glossaryIgnoreComment) {
+			continue
+		}
+		rest := strings.TrimSpace(strings.TrimPrefix(comment, glossaryIgnoreComment))
+		if rest == "" {
+			return nil, true
+		}
+		if !strings.HasPrefix(rest, ":") {
+			continue
+		}
+		for _, term := range strings.Split(rest[1:], ",") {
+			if term = strings.TrimSpace(term); term != "" {
+				ignored[strings.ToLower(term)] = true
+			}
+		}
+	}
+	return ignored, false
+}
+
+// checkGlossaryTerm reports if msgStr lacks a target of t, or has a
+// forbidden translation.
+func checkGlossaryTerm(t *GlossaryTerm, msgStr string) []string {
+	var msgs []string
+	if len(t.targetRes) > 0 {
+		found := false
+		for _, re := range t.targetRes {
+			if re.MatchString(msgStr) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			msgs = append(msgs, fmt.Sprintf("term %q is not translated as %q",
+				t.Source, strings.Join(t.Targets, `" or "`)))
+		}
+	}
+	for i, re := range t.forbiddenRe {
+		if re.MatchString(msgStr) {
+			msgs = append(msgs, fmt.Sprintf("term %q is translated as forbidden %q",
+				t.Source, t.Forbidden[i]))
+		}
+	}
+	if len(msgs) > 0 && t.Note != "" {
+		msgs[len(msgs)-1] += fmt.Sprintf(" (%s)", t.Note)
+	}
+	return msgs
+}
+
+// checkPoGlossary reports translated entries which do not follow terms.
+func checkPoGlossary(po *GettextPO, terms []*GlossaryTerm) ([]string, bool) {
+	var errs []string
+	for i := range po.Entries {
+		e := &po.Entries[i]
+		if e.Obsolete || e.Fuzzy || e.MsgID == "" {
+			continue
+		}
+		ignored, ignoreAll := glossaryIgnoredTerms(e)
+		if ignoreAll {
+			continue
+		}
+		var msgs []string
+		for n, msgStr := range e.MsgStr {
+			if msgStr == "" {
+				continue
+			}
+			msgID := e.MsgID
+			if n > 0 && e.MsgIDPlural != "" {
+				msgID = e.MsgIDPlural
+			}
+			text := stripKeptWords(poUnescape(msgID))
+			for _, t := range terms {
+				if ignored[strings.ToLower(t.Source)] || !t.sourceRe.MatchString(text) {
+					continue
+				}
+				for _, msg := range checkGlossaryTerm(t, poUnescape(msgStr)) {
+					if len(e.MsgStr) > 1 {
+						msg = fmt.Sprintf("msgstr[%d]: %s", n, msg)
+					}
+					msgs = append(msgs, msg)
+				}
+			}
+		}
+		if len(msgs) == 0 {
+			continue
+		}
+		msgid := e.MsgID
+		if len(msgid) > 30 {
+			msgid = msgid[:27] + "..."
+		}
+		desc := entryDescWithLine(i+1, msgid, e.EntryLocation)
+		for _, msg := range msgs {
+			errs = append(errs, fmt.Sprintf("%s: %s", desc, msg))
+		}
+	}
+	return errs, len(errs) == 0
+}
@@ -0,0 +1,163 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadGlossary(t *testing.T) {
+	dir := t.TempDir()
+	tsv := filepath.Join(dir, "zh_CN.tsv")
+	if err := os.WriteFile(tsv, []byte("# source\ttarget\tforbidden\tmatch\tnote\n"+
+		"\n"+
+		"commit\t提交\t递交\n"+
+		"stash\t贮藏|储藏\t\tprefix\tnoun and verb\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	yml := filepath.Join(dir, "zh_CN.yaml")
+	if err := os.WriteFile(yml, []byte(`terms:
+  - source: commit
+    target: 提交
+    forbidden: [递交]
+  - source: "work(ing)? tree"
+    target: [工作区]
+    match: regex
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	terms, err := LoadGlossary(tsv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(terms) != 2 || terms[1].Source != "stash" ||
+		strings.Join(terms[1].Targets, "|") != "贮藏|储藏" ||
+		terms[1].Match != GlossaryMatchPrefix || terms[1].Note != "noun and verb" {
+		t.Errorf("bad TSV glossary: %+v", terms)
+	}
+
+	terms, err = LoadGlossary(yml)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(terms) != 2 || strings.Join(terms[0].Targets, "|") != "提交" ||
+		strings.Join(terms[0].Forbidden, "|") != "递交" ||
+		!terms[1].sourceRe.MatchString("the working tree") {
+		t.Errorf("bad YAML glossary: %+v", terms)
+	}
+
+	bad := filepath.Join(dir, "bad.tsv")
+	if err := os.WriteFile(bad, []byte("commit\t提交\n\nstash\t贮藏\t\tfuzzy\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadGlossary(bad); err == nil || !strings.Contains(err.Error(), "bad.tsv:3: bad match") {
+		t.Errorf("expected error of bad match, got %v", err)
+	}
+}
+
+func TestCheckGlossaryTerm(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		term   GlossaryTerm
+		msgID  string
+		msgStr string
+		match  bool
+		msgs   []string
+	}{
+		{"word", GlossaryTerm{Source: "commit", Targets: []string{"提交"}},
+			"Commit changes", "记录变更", true,
+			[]string{`term "commit" is not translated as "提交"`}},
+		{"word, not prefix", GlossaryTerm{Source: "commit", Targets: []string{"提交"}},
+			"commits", "", false, nil},
+		{"prefix", GlossaryTerm{Source: "commit", Targets: []string{"提交"}, Match: GlossaryMatchPrefix},
+			"two commits", "两个提交", true, nil},
+		{"alternatives", GlossaryTerm{Source: "stash", Targets: []string{"贮藏", "储藏"}},
+			"drop the stash", "删除储藏", true, nil},
+		{"case-insensitive target", GlossaryTerm{Source: "branch", Targets: []string{"Zweig"}},
+			"Delete branch", "zweig löschen", true, nil},
+		{"forbidden", GlossaryTerm{Source: "commit", Targets: []string{"提交"}, Forbidden: []string{"递交"}, Note: "see glossary"},
+			"commit", "递交提交", true,
+			[]string{`term "commit" is translated as forbidden "递交" (see glossary)`}},
+		{"regex", GlossaryTerm{Source: `(?i)\bwork(ing)? trees?\b`, Targets: []string{`工作(区|树)`}, Match: GlossaryMatchRegex},
+			"Working trees", "工作目录", true,
+			[]string{`term "(?i)\\bwork(ing)? trees?\\b" is not translated as "工作(区|树)"`}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			term := tc.term
+			if err := term.compile(); err != nil {
+				t.Fatal(err)
+			}
+			if match := term.sourceRe.MatchString(tc.msgID); match != tc.match {
+				t.Fatalf("match of %q = %v, want %v", tc.msgID, match, tc.match)
+			}
+			if !tc.match {
+				return
+			}
+			msgs := checkGlossaryTerm(&term, tc.msgStr)
+			if strings.Join(msgs, "\n") != strings.Join(tc.msgs, "\n") {
+				t.Errorf("got:\n%s\nwant:\n%s", strings.Join(msgs, "\n"), strings.Join(tc.msgs, "\n"))
+			}
+		})
+	}
+}
+
+func TestCheckPoGlossary(t *testing.T) {
+	po, err := ParsePoEntries([]byte(`msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+"Plural-Forms: nplurals=1; plural=0;\n"
+
+msgid "commit your changes"
+msgstr "记录您的变更"
+
+msgid "run \"git commit\" or use --commit"
+msgstr "运行 \"git commit\" 或使用 --commit"
+
+msgid "cannot commit: %s"
+msgstr "不能递交：%s"
+
+# glossary-ignore: commit
+msgid "commit the stash"
+msgstr "记录储藏"
+
+# glossary-ignore
+msgid "commit"
+msgstr "记录"
+
+#, fuzzy
+msgid "commit again"
+msgstr "记录"
+
+msgid "one commit"
+msgid_plural "%d commits"
+msgstr[0] "%d 个记录"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	terms := []*GlossaryTerm{
+		{Source: "commit", Targets: []string{"提交"}, Forbidden: []string{"递交"}, Match: GlossaryMatchPrefix},
+		{Source: "stash", Targets: []string{"贮藏"}},
+	}
+	for _, term := range terms {
+		if err := term.compile(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	errs, ok := checkPoGlossary(po, terms)
+	if ok {
+		t.Error("expected errors")
+	}
+	want := []string{
+		`entry 1@L6 (msgid "commit your changes"): term "commit" is not translated as "提交"`,
+		`entry 3@L12 (msgid "cannot commit: %s"): term "commit" is not translated as "提交"`,
+		`entry 3@L12 (msgid "cannot commit: %s"): term "commit" is translated as forbidden "递交"`,
+		`entry 4@L16 (msgid "commit the stash"): term "stash" is not translated as "贮藏"`,
+		`entry 7@L27 (msgid "one commit"): term "commit" is not translated as "提交"`,
+	}
+	if strings.Join(errs, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(errs, "\n"), strings.Join(want, "\n"))
+	}
+}
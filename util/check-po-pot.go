@@ -32,6 +32,12 @@ func CheckWithPotFile(commit, projectName, poFile string) bool {
 		return true
 	}
 
+	reportCommit := commit
+	if reportCommit == "HEAD" {
+		reportCommit = ""
+	}
+	defer setReportTarget(poFile, reportCommit)()
+
 	prompt := ""
 	fileToCheck := poFile
 	locale := strings.TrimSuffix(filepath.Base(poFile), ".po")
@@ -0,0 +1,181 @@
+// Package util provides the check of the punctuation and whitespace of msgstr.
+package util
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/git-l10n/git-po-helper/config"
+	"github.com/git-l10n/git-po-helper/dict"
+	"github.com/git-l10n/git-po-helper/flag"
+	log "github.com/sirupsen/logrus"
+)
+
+func init() {
+	RegisterPoCheck(&PoCheck{
+		Name:        "punctuation",
+		Title:       "Punctuation and whitespace",
+		Description: "msgstr keeps the whitespace, newlines and final punctuation of msgid (--report-punctuation)",
+		Level:       config.CheckLevelError,
+		Disabled:    true,
+		Enable:      flag.ReportPunctuationEnabled,
+		NeedsPo:     true,
+		SuccessLevel: func(c *PoCheckContext) log.Level {
+			return log.WarnLevel
+		},
+		Run: func(c *PoCheckContext) ([]string, bool) {
+			return checkPoPunctuation(c.Locale, c.Po)
+		},
+	})
+}
+
+// localePunctuation returns the punctuation marks of msgstr for locale, as
+// defined for the locale ("zh_CN") or its language ("zh").
+func localePunctuation(locale string) []dict.Punctuation {
+	if p, ok := dict.LocalePunctuation[locale]; ok {
+		return p
+	}
+	if i := strings.IndexAny(locale, "_-@"); i > 0 {
+		if p, ok := dict.LocalePunctuation[locale[:i]]; ok {
+			return p
+		}
+	}
+	return dict.DefaultPunctuation
+}
+
+// finalPunctuation returns the name of the punctuation mark at the end of s,
+// and its ending in s, or "" if s does not end with one of marks.
+func finalPunctuation(s string, marks []dict.Punctuation) (string, string) {
+	for _, mark := range marks {
+		for _, ending := range mark.Endings {
+			if strings.HasSuffix(s, ending) {
+				return mark.Name, ending
+			}
+		}
+	}
+	return "", ""
+}
+
+// isBlank is true for spaces and tabs, but not for newlines.
+func isBlank(r rune) bool {
+	return r == ' ' || r == '\t'
+}
+
+// endsWithWidePunct is true if s ends with a full-width punctuation mark,
+// which needs no space after it, such as "：" or "。".
+func endsWithWidePunct(s string) bool {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return r > unicode.MaxASCII && unicode.IsPunct(r)
+}
+
+// checkPunctuation compares the whitespace, leading and trailing newlines
+// and final punctuation of msgID and msgStr (unescaped). marks are the
+// punctuation marks of msgStr.
+func checkPunctuation(msgID, msgStr string, marks []dict.Punctuation) []string {
+	var msgs []string
+
+	for _, nl := range []struct {
+		name string
+		has  func(string, string) bool
+	}{
+		{"leading", strings.HasPrefix},
+		{"trailing", strings.HasSuffix},
+	} {
+		if a, b := nl.has(msgID, "\n"), nl.has(msgStr, "\n"); a != b {
+			if a {
+				msgs = append(msgs, fmt.Sprintf(`msgid has a %s "\n", but msgstr does not`, nl.name))
+			} else {
+				msgs = append(msgs, fmt.Sprintf(`msgstr has a %s "\n", but msgid does not`, nl.name))
+			}
+		}
+	}
+	msgID = strings.Trim(msgID, "\n")
+	msgStr = strings.Trim(msgStr, "\n")
+
+	idRune, _ := utf8.DecodeRuneInString(msgID)
+	strRune, _ := utf8.DecodeRuneInString(msgStr)
+	if a, b := isBlank(idRune), isBlank(strRune); a != b {
+		if a {
+			msgs = append(msgs, "msgid has leading whitespace, but msgstr does not")
+		} else {
+			msgs = append(msgs, "msgstr has leading whitespace, but msgid does not")
+		}
+	}
+	idRune, _ = utf8.DecodeLastRuneInString(msgID)
+	strRune, _ = utf8.DecodeLastRuneInString(msgStr)
+	if a, b := isBlank(idRune), isBlank(strRune); a != b {
+		// No space is needed after a full-width punctuation mark.
+		if a && !endsWithWidePunct(strings.TrimRightFunc(msgStr, isBlank)) {
+			msgs = append(msgs, "msgid has trailing whitespace, but msgstr does not")
+		} else if b {
+			msgs = append(msgs, "msgstr has trailing whitespace, but msgid does not")
+		}
+	}
+
+	idMark, idEnding := finalPunctuation(strings.TrimRightFunc(msgID, isBlank), dict.DefaultPunctuation)
+	strMark, strEnding := finalPunctuation(strings.TrimRightFunc(msgStr, isBlank), marks)
+	if idMark != strMark {
+		switch {
+		case strMark == "":
+			msgs = append(msgs, fmt.Sprintf("msgid ends with %s (%q), but msgstr does not", idMark, idEnding))
+		case idMark == "":
+			msgs = append(msgs, fmt.Sprintf("msgstr ends with %s (%q), but msgid does not", strMark, strEnding))
+		default:
+			msgs = append(msgs, fmt.Sprintf("msgid ends with %s (%q), but msgstr ends with %s (%q)",
+				idMark, idEnding, strMark, strEnding))
+		}
+	}
+	return msgs
+}
+
+// checkPoPunctuation reports translations whose whitespace, newlines or
+// final punctuation differ from msgid (see flag.ReportPunctuation). The check
+// is off unless turned on by --report-punctuation or the checks config.
+func checkPoPunctuation(locale string, po *GettextPO) ([]string, bool) {
+	if flag.ReportPunctuation() == flag.ReportIssueNone {
+		return nil, true
+	}
+
+	var errs []string
+	marks := localePunctuation(locale)
+	for i := range po.Entries {
+		e := &po.Entries[i]
+		if e.Obsolete || e.Fuzzy || e.MsgID == "" {
+			continue
+		}
+		var msgs []string
+		for n, msgStr := range e.MsgStr {
+			if msgStr == "" {
+				continue
+			}
+			msgID := e.MsgID
+			if n > 0 && e.MsgIDPlural != "" {
+				msgID = e.MsgIDPlural
+			}
+			for _, msg := range checkPunctuation(poUnescape(msgID), poUnescape(msgStr), marks) {
+				if len(e.MsgStr) > 1 {
+					msg = fmt.Sprintf("msgstr[%d]: %s", n, msg)
+				}
+				msgs = append(msgs, msg)
+			}
+		}
+		if len(msgs) == 0 {
+			continue
+		}
+		msgid := e.MsgID
+		if len(msgid) > 30 {
+			msgid = msgid[:27] + "..."
+		}
+		desc := entryDescWithLine(i+1, msgid, e.EntryLocation)
+		for _, msg := range msgs {
+			errs = append(errs, fmt.Sprintf("%s: %s", desc, msg))
+		}
+	}
+
+	if flag.ReportPunctuation() == flag.ReportIssueError && len(errs) > 0 {
+		return errs, false
+	}
+	return errs, true
+}
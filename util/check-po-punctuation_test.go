@@ -0,0 +1,138 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/git-l10n/git-po-helper/config"
+	"github.com/spf13/viper"
+)
+
+func TestCheckPunctuation(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		locale string
+		msgID  string
+		msgStr string
+		msgs   []string
+	}{
+		{"same", "de", "Done.", "Fertig.", nil},
+		{"no period", "de", "Done.", "Fertig",
+			[]string{`msgid ends with period ("."), but msgstr does not`}},
+		{"extra colon", "de", "Name", "Name:",
+			[]string{`msgstr ends with colon (":"), but msgid does not`}},
+		{"ellipsis is not a period", "de", "Loading...", "Laden.",
+			[]string{`msgid ends with ellipsis ("..."), but msgstr ends with period (".")`}},
+		{"unicode ellipsis", "de", "Loading...", "Laden…", nil},
+		{"full-width colon", "zh_CN", "Name: ", "名称：", nil},
+		{"full-width period", "zh_TW", "Done.", "完成。", nil},
+		{"full-width ellipsis", "ja", "Loading...", "読み込み中……", nil},
+		{"full-width only for CJK", "de", "Name:", "Name：",
+			[]string{`msgid ends with colon (":"), but msgstr does not`}},
+		{"French colon", "fr", "Name:", "Nom :", nil},
+		{"French colon, no-break space", "fr", "Name:", "Nom\u00a0:", nil},
+		{"newlines", "de", "\nline\n", "Zeile",
+			[]string{`msgid has a leading "\n", but msgstr does not`,
+				`msgid has a trailing "\n", but msgstr does not`}},
+		{"extra newline", "de", "line", "Zeile\n",
+			[]string{`msgstr has a trailing "\n", but msgid does not`}},
+		{"whitespace", "de", "  indent", "Einzug ",
+			[]string{"msgid has leading whitespace, but msgstr does not",
+				"msgstr has trailing whitespace, but msgid does not"}},
+		{"whitespace and newline", "de", "Name: \n", "Name: \n", nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			msgs := checkPunctuation(tc.msgID, tc.msgStr, localePunctuation(tc.locale))
+			if strings.Join(msgs, "\n") != strings.Join(tc.msgs, "\n") {
+				t.Errorf("got:\n%s\nwant:\n%s", strings.Join(msgs, "\n"), strings.Join(tc.msgs, "\n"))
+			}
+		})
+	}
+}
+
+func TestCheckPoPunctuation(t *testing.T) {
+	po, err := ParsePoEntries([]byte(`msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+msgid "Done."
+msgstr "Fertig"
+
+#, fuzzy
+msgid "Skipped."
+msgstr "Übersprungen"
+
+msgid "Untranslated."
+msgstr ""
+
+msgid "one file:"
+msgid_plural "%d files:"
+msgstr[0] "eine Datei:"
+msgstr[1] "%d Dateien"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer viper.Set("check-po--report-punctuation", "")
+	viper.Set("check-po--report-punctuation", "none")
+	if errs, ok := checkPoPunctuation("de", po); len(errs) > 0 || !ok {
+		t.Errorf("expected no check with --report-punctuation=none, got %q", errs)
+	}
+
+	viper.Set("check-po--report-punctuation", "error")
+	errs, ok := checkPoPunctuation("de", po)
+	if ok {
+		t.Error("expected errors with --report-punctuation=error")
+	}
+	want := []string{
+		`entry 1@L6 (msgid "Done."): msgid ends with period ("."), but msgstr does not`,
+		`entry 4@L16 (msgid "one file:"): msgstr[1]: msgid ends with colon (":"), but msgstr does not`,
+	}
+	if strings.Join(errs, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(errs, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestPunctuationCheckLevel(t *testing.T) {
+	check := LookupPoCheck("punctuation")
+	if level := poCheckLevel(check, "de", nil, nil, nil); level != config.CheckLevelOff {
+		t.Errorf("level %q without option and config, want off", level)
+	}
+
+	viper.Set("check-po--report-punctuation", "warn")
+	level := poCheckLevel(check, "de", nil, nil, nil)
+	viper.Set("check-po--report-punctuation", "")
+	if level != config.CheckLevelError {
+		t.Errorf("level %q with --report-punctuation=warn, want error", level)
+	}
+
+	file := filepath.Join(t.TempDir(), config.GitPoHelperConfigFileName)
+	if err := os.WriteFile(file, []byte("checks:\n  punctuation: error\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadCheckPoConfigFromFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if level := poCheckLevel(check, "de", cfg.Checks, nil, nil); level != config.CheckLevelError {
+		t.Errorf("level %q with the checks config, want error", level)
+	}
+	po, err := ParsePoEntries([]byte(`msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+
+msgid "Done."
+msgstr "Fertig"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs, ok := check.Run(&PoCheckContext{Locale: "de", Po: po})
+	want := `entry 1@L5 (msgid "Done."): msgid ends with period ("."), but msgstr does not`
+	if ok || strings.Join(errs, "\n") != want {
+		t.Errorf("got %v, %q; want false, %q", ok, errs, want)
+	}
+}
@@ -0,0 +1,439 @@
+// Package util provides the registry of the checks of check-po.
+package util
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/git-l10n/git-po-helper/config"
+	"github.com/git-l10n/git-po-helper/flag"
+	"github.com/git-l10n/git-po-helper/repository"
+	log "github.com/sirupsen/logrus"
+)
+
+// PoCheckContext is the PO file checked by the checks of check-po.
+type PoCheckContext struct {
+	Locale      string
+	PoFile      string     // file to check, may be a temporary file
+	Po          *GettextPO // parsed PoFile, set for checks with NeedsPo
+	ProjectName string     // from Project-Id-Version, set for checks with NeedsPo
+	// FilterRepoRelPath and AttrSourceCommit are used for git check-attr,
+	// see CheckPoFileWithPrompt.
+	FilterRepoRelPath string
+	AttrSourceCommit  string
+	IsTipCommit       bool
+}
+
+// PoCheck is a check of check-po, registered by RegisterPoCheck. Each check
+// reports its messages in a section of its own.
+type PoCheck struct {
+	// Name identifies the check in .git-po-helper.yaml and in the options
+	// --only and --skip of check-po, e.g. "c-format".
+	Name string
+	// Title is the title of the section of the report.
+	Title string
+	// Description is shown by check-po --list-checks.
+	Description string
+	// Level is the default level: config.CheckLevelError, or
+	// config.CheckLevelWarn for checks which report but never fail.
+	Level string
+	// Disabled checks only run when enabled in .git-po-helper.yaml or with
+	// check-po --only, or when Enable returns true.
+	Disabled bool
+	// Enable returns true to run a disabled check, e.g. for an option of
+	// check-po. May be nil.
+	Enable func() bool
+	// NeedsPo is true for checks using Po and ProjectName. Checks without it
+	// run even if the file cannot be parsed.
+	NeedsPo bool
+	// SuccessLevel returns the level of the messages of a check which passes,
+	// log.InfoLevel (default when nil) or log.WarnLevel.
+	SuccessLevel func(c *PoCheckContext) log.Level
+	// Run runs the check, and returns its messages and false if it fails.
+	// Checks which do not apply to the file return no messages and true.
+	Run func(c *PoCheckContext) ([]string, bool)
+}
+
+// poChecks are the checks of check-po, in the order they run. Other files
+// add checks with RegisterPoCheck.
+var poChecks = []*PoCheck{
+	{
+		Name:        "msgfmt",
+		Title:       "Syntax check with msgfmt",
+		Description: "syntax of the file (msgfmt --check)",
+		Level:       config.CheckLevelError,
+		Run: func(c *PoCheckContext) ([]string, bool) {
+			return checkPoWithMsgfmt(c.PoFile)
+		},
+	},
+	{
+		Name:        "locale",
+		Title:       "Locale name",
+		Description: "language and country codes of the file name",
+		Level:       config.CheckLevelError,
+		Run: func(c *PoCheckContext) ([]string, bool) {
+			var msgs []string
+			for _, err := range ValidateLocale(c.Locale) {
+				msgs = append(msgs, err.Error())
+			}
+			return msgs, len(msgs) == 0
+		},
+	},
+	{
+		Name:        "header-escapes",
+		Title:       "Syntax of PO header meta lines",
+		Description: "escape characters in the header",
+		Level:       config.CheckLevelError,
+		NeedsPo:     true,
+		Run: func(c *PoCheckContext) ([]string, bool) {
+			return checkPoMetaEscapeChars(c.Po)
+		},
+	},
+	{
+		Name:        "plural-forms",
+		Title:       "Plural forms",
+		Description: "Plural-Forms header and number of msgstr[] forms",
+		Level:       config.CheckLevelError,
+		NeedsPo:     true,
+		Run: func(c *PoCheckContext) ([]string, bool) {
+			return checkPoPluralForms(c.Po)
+		},
+	},
+	{
+		Name:        "c-format",
+		Title:       "C format strings",
+		Description: "printf directives of c-format entries",
+		Level:       config.CheckLevelError,
+		NeedsPo:     true,
+		Run: func(c *PoCheckContext) ([]string, bool) {
+			return checkPoCFormat(c.Po)
+		},
+	},
+	{
+		Name:        "gettext-compat",
+		Title:       "gettext compatibility",
+		Description: "syntax supported by the minimum gettext version of the project",
+		Level:       config.CheckLevelError,
+		NeedsPo:     true,
+		Run: func(c *PoCheckContext) ([]string, bool) {
+			cfg := GetProjectPotConfig(c.ProjectName, c.PoFile)
+			return checkPoCompatibility(c.Po, cfg.MinGettextVersion)
+		},
+	},
+	{
+		Name:        "obsolete",
+		Title:       "Obsolete #~ entries",
+		Description: "no obsolete entries",
+		Level:       config.CheckLevelError,
+		NeedsPo:     true,
+		Run: func(c *PoCheckContext) ([]string, bool) {
+			// Allowed in the update flow, where msgmerge creates them by design.
+			if flag.AllowObsoleteEntries() {
+				return nil, true
+			}
+			return checkPoNoObsoleteEntries(c.Po)
+		},
+	},
+	{
+		Name:         "filter",
+		Title:        "PO filter (.gitattributes)",
+		Description:  "format of the file matches its filter in .gitattributes",
+		Level:        config.CheckLevelError,
+		NeedsPo:      true,
+		SuccessLevel: poFilterSuccessLevel,
+		Run: func(c *PoCheckContext) ([]string, bool) {
+			if flag.NoCheckFilter() {
+				return nil, true
+			}
+			errs, ok := checkPoFilterFormat(c.PoFile, c.FilterRepoRelPath, c.AttrSourceCommit, "")
+			if flag.ReportFileLocations() == flag.ReportIssueNone ||
+				poFilterSuccessLevel(c) == log.WarnLevel {
+				ok = true
+			}
+			return errs, ok
+		},
+	},
+	{
+		Name:        "typos",
+		Title:       "msgid/msgstr pattern check",
+		Description: "variables, options and config names kept in translations (Git only)",
+		Level:       config.CheckLevelError,
+		NeedsPo:     true,
+		SuccessLevel: func(c *PoCheckContext) log.Level {
+			return log.WarnLevel
+		},
+		Run: func(c *PoCheckContext) ([]string, bool) {
+			if !strings.EqualFold(c.ProjectName, "Git") {
+				return nil, true
+			}
+			return checkTyposInPo(c.Locale, c.Po)
+		},
+	},
+	{
+		Name:        "project-name",
+		Title:       "Project name",
+		Description: "Project-Id-Version defines a project name",
+		Level:       config.CheckLevelError,
+		NeedsPo:     true,
+		Run: func(c *PoCheckContext) ([]string, bool) {
+			if c.ProjectName == "" {
+				return []string{"project name is not defined in PO file"}, false
+			}
+			return nil, true
+		},
+	},
+}
+
+// poFilterSuccessLevel reports filter issues as warnings with
+// --report-file-locations=warn, and for commits other than the tip commit,
+// and as information with --report-file-locations=none.
+func poFilterSuccessLevel(c *PoCheckContext) log.Level {
+	if flag.ReportFileLocations() == flag.ReportIssueNone {
+		return log.InfoLevel
+	}
+	if flag.ReportFileLocations() == flag.ReportIssueWarn || !c.IsTipCommit {
+		return log.WarnLevel
+	}
+	return log.InfoLevel
+}
+
+// RegisterPoCheck adds check to the checks of check-po, after the checks
+// registered before. It panics if a check of the same name exists.
+func RegisterPoCheck(check *PoCheck) {
+	if LookupPoCheck(check.Name) != nil {
+		panic(fmt.Sprintf("check %q registered twice", check.Name))
+	}
+	poChecks = append(poChecks, check)
+}
+
+// PoChecks returns the registered checks of check-po, in the order they run.
+func PoChecks() []*PoCheck {
+	return poChecks
+}
+
+// LookupPoCheck returns the check named name, or nil.
+func LookupPoCheck(name string) *PoCheck {
+	for _, check := range poChecks {
+		if check.Name == name {
+			return check
+		}
+	}
+	return nil
+}
+
+// ValidatePoCheckNames returns an error for the first name which is not the
+// name of a check.
+func ValidatePoCheckNames(names []string) error {
+	for _, name := range names {
+		if LookupPoCheck(name) == nil {
+			var known []string
+			for _, check := range poChecks {
+				known = append(known, check.Name)
+			}
+			return fmt.Errorf("unknown check %q (use %s)", name, strings.Join(known, ", "))
+		}
+	}
+	return nil
+}
+
+// poChecksConfigPaths returns the config files of the checks, in the order
+// they are merged: ~/.git-po-helper.yaml, then the one of the repository;
+// or only --config file if set.
+func poChecksConfigPaths() []string {
+	if customPath := flag.GetConfigFilePath(); customPath != "" {
+		return []string{customPath}
+	}
+	var paths []string
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(homeDir, config.GitPoHelperConfigFileName))
+	}
+	if repository.Opened() {
+		paths = append(paths, filepath.Join(repository.WorkDir(), config.GitPoHelperConfigFileName))
+	}
+	return paths
+}
+
+var (
+	cachedCheckPoConfig     *config.CheckPoConfig
+	cachedCheckPoConfigOnce sync.Once
+)
+
+// getCheckPoConfig returns the sections for check-po of the config files
+// merged: ~/.git-po-helper.yaml, then the one of the repository; or only
+// --config file if set. The files are read once and the result is shared by
+// the checks.
+func getCheckPoConfig() *config.CheckPoConfig {
+	cachedCheckPoConfigOnce.Do(func() {
+		merged := &config.CheckPoConfig{}
+		for _, path := range poChecksConfigPaths() {
+			cfg, err := config.LoadCheckPoConfigFromFile(path)
+			if err != nil {
+				log.Warnf("load config from %s: %v", path, err)
+				continue
+			}
+			if cfg == nil {
+				continue
+			}
+			if cfg.Checks != nil {
+				validatePoChecksConfig(path, cfg.Checks)
+			}
+			merged = merged.Merge(cfg)
+		}
+		cachedCheckPoConfig = merged
+	})
+	return cachedCheckPoConfig
+}
+
+// validatePoChecksConfig warns about unknown checks and levels in the
+// "checks" section of path.
+func validatePoChecksConfig(path string, checks *config.ChecksConfig) {
+	validate := func(levels map[string]string) {
+		names := make([]string, 0, len(levels))
+		for name := range levels {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if LookupPoCheck(name) == nil {
+				log.Warnf("%s: unknown check %q", path, name)
+			}
+			switch levels[name] {
+			case config.CheckLevelOff, config.CheckLevelOn, config.CheckLevelWarn, config.CheckLevelError:
+			default:
+				log.Warnf("%s: unknown level %q of check %q (use off, on, warn or error)",
+					path, levels[name], name)
+			}
+		}
+	}
+	validate(checks.Levels)
+	for _, levels := range checks.Languages {
+		validate(levels)
+	}
+}
+
+// poCheckLevel returns the level of check for locale: config.CheckLevelOff,
+// config.CheckLevelWarn or config.CheckLevelError. The level set for the
+// language in cfg overrides the level for all languages, which overrides the
+// default level. A check in only (if not empty) is run even if disabled,
+// and a check in skip is not run.
+func poCheckLevel(check *PoCheck, locale string, cfg *config.ChecksConfig, only, skip []string) string {
+	level := check.Level
+	if check.Disabled && (check.Enable == nil || !check.Enable()) {
+		level = config.CheckLevelOff
+	}
+	switch l := cfg.Level(check.Name, locale); l {
+	case config.CheckLevelOn:
+		level = check.Level
+	case config.CheckLevelOff, config.CheckLevelWarn, config.CheckLevelError:
+		level = l
+	}
+	if len(only) > 0 {
+		if !containsString(only, check.Name) {
+			return config.CheckLevelOff
+		}
+		if level == config.CheckLevelOff {
+			level = check.Level
+		}
+	}
+	if containsString(skip, check.Name) {
+		return config.CheckLevelOff
+	}
+	return level
+}
+
+// poDir returns the po/ directory of the PO file checked: the directory of
+// the file in the worktree of the repository, or of c.PoFile.
+func (c *PoCheckContext) poDir() string {
+	if c.FilterRepoRelPath != "" && repository.Opened() {
+		return filepath.Join(repository.WorkDir(), filepath.Dir(c.FilterRepoRelPath))
+	}
+	return filepath.Dir(c.PoFile)
+}
+
+// languageNames returns the locale of c and its language, e.g. "zh_CN" and
+// "zh", to find the config or the files of the language.
+func (c *PoCheckContext) languageNames() []string {
+	names := []string{c.Locale}
+	if i := strings.IndexAny(c.Locale, "_-@"); i > 0 {
+		names = append(names, c.Locale[:i])
+	}
+	return names
+}
+
+// parsePo parses c.PoFile unless already parsed, and sets c.Po and
+// c.ProjectName. Returns false if the file cannot be parsed.
+func (c *PoCheckContext) parsePo(prompt string) bool {
+	if c.Po != nil {
+		return true
+	}
+	poData, err := os.ReadFile(c.PoFile)
+	if err != nil {
+		log.Errorf(`%s\tfail to read %q: %v`, prompt, c.PoFile, err)
+		return false
+	}
+	po, err := ParsePoEntries(poData)
+	if err != nil {
+		log.Errorf(`%s\tfail to parse %q: %v`, prompt, c.PoFile, err)
+		return false
+	}
+	c.Po = po
+	c.ProjectName = po.GetProject()
+	return true
+}
+
+// runPoChecks runs the checks of c.PoFile at their levels and reports the
+// results. Returns false if a check at level error fails, or if the file
+// cannot be parsed.
+func runPoChecks(c *PoCheckContext, prompt string) bool {
+	ret := true
+	cfg := getCheckPoConfig().Checks
+	only, skip := flag.CheckOnly(), flag.CheckSkip()
+	for _, check := range poChecks {
+		level := poCheckLevel(check, c.Locale, cfg, only, skip)
+		if level == config.CheckLevelOff {
+			log.Debugf("%s\tskip check %s", prompt, check.Name)
+			continue
+		}
+		if check.NeedsPo && !c.parsePo(prompt) {
+			return false
+		}
+		errs, ok := check.Run(c)
+		successLevel := log.InfoLevel
+		if check.SuccessLevel != nil {
+			successLevel = check.SuccessLevel(c)
+		}
+		if level == config.CheckLevelWarn {
+			successLevel = log.WarnLevel
+			ok = true
+		}
+		reportRuleSection(check.Name, check.Title, ok, successLevel, prompt, errs...)
+		ret = ret && ok
+	}
+	return ret
+}
+
+// ListPoChecks writes the registered checks with their levels for locale
+// ("" for all languages), as set by the config files, and their descriptions.
+func ListPoChecks(w io.Writer, locale string) error {
+	cfg := getCheckPoConfig().Checks
+	for _, check := range poChecks {
+		level := poCheckLevel(check, locale, cfg, nil, nil)
+		desc := check.Description
+		defaultLevel := check.Level
+		if check.Disabled {
+			defaultLevel = config.CheckLevelOff
+		}
+		if level != defaultLevel {
+			desc += fmt.Sprintf(" (default: %s)", defaultLevel)
+		}
+		if _, err := fmt.Fprintf(w, "%-16s %-5s  %s\n", check.Name, level, desc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/git-l10n/git-po-helper/config"
+)
+
+func TestPoCheckLevel(t *testing.T) {
+	check := &PoCheck{Name: "c-format", Level: config.CheckLevelError}
+	disabled := &PoCheck{Name: "spell", Level: config.CheckLevelWarn, Disabled: true}
+	enabled := &PoCheck{Name: "spell", Level: config.CheckLevelWarn, Disabled: true,
+		Enable: func() bool { return true }}
+	cfg := &config.ChecksConfig{
+		Levels: map[string]string{"c-format": "warn", "spell": "on"},
+		Languages: map[string]map[string]string{
+			"zh_CN": {"c-format": "off"},
+			"fr":    {"c-format": "error", "spell": "off"},
+		},
+	}
+
+	for _, tc := range []struct {
+		name   string
+		check  *PoCheck
+		locale string
+		cfg    *config.ChecksConfig
+		only   []string
+		skip   []string
+		level  string
+	}{
+		{"default", check, "de", nil, nil, nil, "error"},
+		{"disabled", disabled, "de", nil, nil, nil, "off"},
+		{"enabled by option", enabled, "de", nil, nil, nil, "warn"},
+		{"enabled by option, off in config", enabled, "fr", cfg, nil, nil, "off"},
+		{"config", check, "de", cfg, nil, nil, "warn"},
+		{"config enables", disabled, "de", cfg, nil, nil, "warn"},
+		{"language config", check, "zh_CN", cfg, nil, nil, "off"},
+		{"language config over config", check, "fr", cfg, nil, nil, "error"},
+		{"language config disables", disabled, "fr", cfg, nil, nil, "off"},
+		{"only", check, "de", cfg, []string{"c-format"}, nil, "warn"},
+		{"only enables", check, "zh_CN", cfg, []string{"c-format"}, nil, "error"},
+		{"only enables disabled", disabled, "de", nil, []string{"spell"}, nil, "warn"},
+		{"not in only", check, "de", nil, []string{"msgfmt"}, nil, "off"},
+		{"skip", check, "de", nil, nil, []string{"c-format"}, "off"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if level := poCheckLevel(tc.check, tc.locale, tc.cfg, tc.only, tc.skip); level != tc.level {
+				t.Errorf("level %q, want %q", level, tc.level)
+			}
+		})
+	}
+}
+
+func TestRegisterPoCheck(t *testing.T) {
+	saved := poChecks
+	defer func() { poChecks = saved }()
+
+	check := &PoCheck{Name: "test-check", Level: config.CheckLevelWarn}
+	RegisterPoCheck(check)
+	if got := LookupPoCheck("test-check"); got != check {
+		t.Errorf("LookupPoCheck returned %v", got)
+	}
+	if checks := PoChecks(); checks[len(checks)-1] != check {
+		t.Error("registered check does not run last")
+	}
+	if err := ValidatePoCheckNames([]string{"msgfmt", "test-check"}); err != nil {
+		t.Error(err)
+	}
+	if err := ValidatePoCheckNames([]string{"msgfmt", "no-such-check"}); err == nil {
+		t.Error("expected an error for an unknown check")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for a check registered twice")
+		}
+	}()
+	RegisterPoCheck(&PoCheck{Name: "msgfmt"})
+}
@@ -0,0 +1,191 @@
+// Package util provides the spell check of msgstr with Hunspell dictionaries.
+package util
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/git-l10n/git-po-helper/config"
+)
+
+func init() {
+	RegisterPoCheck(&PoCheck{
+		Name:        "spelling",
+		Title:       "Spelling",
+		Description: "spelling of msgstr with the Hunspell dictionary of the language in .git-po-helper.yaml",
+		Level:       config.CheckLevelWarn,
+		NeedsPo:     true,
+		Run: func(c *PoCheckContext) ([]string, bool) {
+			speller, err := getSpeller(c)
+			if err != nil {
+				return []string{err.Error()}, false
+			}
+			if speller == nil {
+				return nil, true
+			}
+			return checkPoSpelling(c.Po, speller)
+		},
+	})
+}
+
+var (
+	spellerCacheMu sync.Mutex
+	spellerCache   = make(map[string]*Hunspell)
+)
+
+// getSpeller returns the spell checker of the language of c, or nil if no
+// dictionary is set for the locale or its language. The personal word list
+// is the one set with the dictionary, or spelling/<locale>.txt (or
+// <language>.txt) in the directory of the PO file.
+func getSpeller(c *PoCheckContext) (*Hunspell, error) {
+	var (
+		cfg   config.SpellingConfig
+		found bool
+	)
+	names := c.languageNames()
+	for _, name := range names {
+		if cfg, found = getCheckPoConfig().Spelling[name]; found {
+			break
+		}
+	}
+	if !found || cfg.Dictionary == "" {
+		return nil, nil
+	}
+	if cfg.Words == "" {
+		for _, name := range names {
+			file := filepath.Join(c.poDir(), "spelling", name+".txt")
+			if IsFile(file) {
+				cfg.Words = file
+				break
+			}
+		}
+	}
+
+	key := cfg.Dictionary + "\n" + cfg.Words
+	spellerCacheMu.Lock()
+	defer spellerCacheMu.Unlock()
+	if speller, ok := spellerCache[key]; ok {
+		return speller, nil
+	}
+	speller, err := LoadHunspell(cfg.Dictionary)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Words != "" {
+		if err := loadPersonalWords(speller, cfg.Words); err != nil {
+			return nil, err
+		}
+	}
+	spellerCache[key] = speller
+	return speller, nil
+}
+
+// loadPersonalWords adds the words of file to speller: one word per line,
+// lines starting with "#" are comments.
+func loadPersonalWords(speller *Hunspell, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		word := strings.TrimSpace(scanner.Text())
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		speller.AddWord(word)
+	}
+	return scanner.Err()
+}
+
+var (
+	// spellingWordPattern matches words, with digits and underscores so that
+	// identifiers such as "utf8" are not split.
+	spellingWordPattern = regexp.MustCompile(`[\pL\pM\pN_]+(?:['’][\pL\pM]+)*`)
+	// spellingSkipPattern matches URLs and email addresses.
+	spellingSkipPattern = regexp.MustCompile(`\S+://\S+|\S+@\S+`)
+)
+
+// spellingWords returns the words of msgStr to check: not the words
+// translations keep, such as commands, options, placeholders and printf
+// directives, nor words with digits, underscores or upper case letters but
+// the first, which are names, nor the words of msgID, which are left
+// untranslated. Printf directives are only stripped if cFormat is set, as in
+// other strings a '%' is literal.
+func spellingWords(msgID, msgStr string, cFormat bool) []string {
+	skip := make(map[string]bool)
+	for _, word := range spellingWordPattern.FindAllString(msgID, -1) {
+		skip[strings.ToLower(word)] = true
+	}
+
+	msgStr = spellingSkipPattern.ReplaceAllString(msgStr, " ")
+	if cFormat {
+		msgStr = stripCFormatDirectives(msgStr)
+	}
+	msgStr = stripKeptWords(msgStr)
+	var words []string
+	for _, word := range spellingWordPattern.FindAllString(msgStr, -1) {
+		_, size := utf8.DecodeRuneInString(word)
+		if utf8.RuneCountInString(word) < 2 || skip[strings.ToLower(word)] ||
+			strings.IndexFunc(word, func(r rune) bool {
+				return unicode.IsDigit(r) || r == '_'
+			}) >= 0 ||
+			strings.IndexFunc(word[size:], unicode.IsUpper) >= 0 {
+			continue
+		}
+		words = append(words, word)
+	}
+	return words
+}
+
+// checkPoSpelling reports the misspelled words of the translated entries of
+// po, with suggestions.
+func checkPoSpelling(po *GettextPO, speller *Hunspell) ([]string, bool) {
+	var errs []string
+	for i := range po.Entries {
+		e := &po.Entries[i]
+		if e.Obsolete || e.Fuzzy || e.MsgID == "" {
+			continue
+		}
+		var msgs []string
+		reported := make(map[string]bool)
+		cFormat := entryFlags(e)["c-format"]
+		for n, msgStr := range e.MsgStr {
+			msgID := e.MsgID
+			if n > 0 && e.MsgIDPlural != "" {
+				msgID = e.MsgIDPlural
+			}
+			for _, word := range spellingWords(poUnescape(msgID), poUnescape(msgStr), cFormat) {
+				if reported[word] || speller.Spell(word) {
+					continue
+				}
+				reported[word] = true
+				msg := fmt.Sprintf("misspelled word %q", word)
+				if suggestions := speller.Suggest(word); len(suggestions) > 0 {
+					msg += fmt.Sprintf(", suggestions: %s", strings.Join(suggestions, ", "))
+				}
+				msgs = append(msgs, msg)
+			}
+		}
+		if len(msgs) == 0 {
+			continue
+		}
+		msgid := e.MsgID
+		if len(msgid) > 30 {
+			msgid = msgid[:27] + "..."
+		}
+		desc := entryDescWithLine(i+1, msgid, e.EntryLocation)
+		for _, msg := range msgs {
+			errs = append(errs, fmt.Sprintf("%s: %s", desc, msg))
+		}
+	}
+	return errs, len(errs) == 0
+}
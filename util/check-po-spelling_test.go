@@ -0,0 +1,85 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSpellingWords(t *testing.T) {
+	for _, tc := range []struct {
+		msgID   string
+		msgStr  string
+		cFormat bool
+		words   []string
+	}{
+		{"branch %s", "Zweig %s", true, []string{"Zweig"}},
+		{"%1$s of %2$s", "%2$s von %1$s", true, []string{"von"}},
+		{"%<PRIuMAX> objects", "%<PRIuMAX> Objekte", true, []string{"Objekte"}},
+		{"%s is %q", "%s ist %q", true, []string{"ist"}},
+		{"50% done", "zu 50% fertig", false, []string{"zu", "fertig"}},
+		{"5% of all", "5% aller", false, []string{"aller"}},
+		{"use --force", "benutze --force", false, []string{"benutze"}},
+		{"see `git commit`", "siehe `git commit`", false, []string{"siehe"}},
+		{"see core.editor", "siehe core.editor", false, []string{"siehe"}},
+		{"see https://git-scm.com", "siehe https://git-scm.com", false, []string{"siehe"}},
+		{"rebase it", "Rebase ist OK", false, []string{"ist"}},
+		{"utf8 in HEAD", "utf8 im HEAD-Zweig", false, []string{"im", "Zweig"}},
+		{"can't", "l'utilisateur", false, []string{"l'utilisateur"}},
+	} {
+		if words := spellingWords(tc.msgID, tc.msgStr, tc.cFormat); strings.Join(words, " ") != strings.Join(tc.words, " ") {
+			t.Errorf("spellingWords(%q, %q, %v) = %q, want %q", tc.msgID, tc.msgStr, tc.cFormat, words, tc.words)
+		}
+	}
+}
+
+func TestCheckPoSpelling(t *testing.T) {
+	dir := t.TempDir()
+	speller, err := LoadHunspell(writeHunspell(t, dir, "en", testHunspellAff, testHunspellDic))
+	if err != nil {
+		t.Fatal(err)
+	}
+	words := filepath.Join(dir, "words.txt")
+	if err := os.WriteFile(words, []byte("# personal words\nreflog\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadPersonalWords(speller, words); err != nil {
+		t.Fatal(err)
+	}
+
+	po, err := ParsePoEntries([]byte(`msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+msgid "Branch entries"
+msgstr "Branch entrys"
+
+msgid "Lock the reflog with --lock"
+msgstr "Lock reflog --lokc"
+
+#, fuzzy
+msgid "Commit"
+msgstr "Comit"
+
+msgid "%d tree"
+msgid_plural "%d trees"
+msgstr[0] "%d tree"
+msgstr[1] "%d tress"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs, ok := checkPoSpelling(po, speller)
+	if ok {
+		t.Error("expected misspelled words")
+	}
+	want := []string{
+		`entry 1@L6 (msgid "Branch entries"): misspelled word "entrys", suggestions: entry`,
+		`entry 4@L16 (msgid "%d tree"): misspelled word "tress", suggestions: trees`,
+	}
+	if strings.Join(errs, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(errs, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
 )
 
 func checkPoWithMsgfmt(poFile string) ([]string, bool) {
@@ -17,8 +20,8 @@ func checkPoWithMsgfmt(poFile string) ([]string, bool) {
 
 	msgfmt, err := exec.LookPath("msgfmt")
 	if err != nil {
-		errs = append(errs, "no gettext programs found")
-		return errs, false
+		log.Debugf("msgfmt not found, check %s with built-in validator", poFile)
+		return checkPoWithBuiltinValidator(poFile)
 	}
 
 	cmd := exec.Command(msgfmt,
@@ -53,3 +56,46 @@ func checkPoWithMsgfmt(poFile string) ([]string, bool) {
 
 	return errs, true
 }
+
+// checkPoWithBuiltinValidator is the fallback of checkPoWithMsgfmt when gettext is
+// not installed. It reports the same class of diagnostics as "msgfmt --check --statistics",
+// except the printf directives, which are reported by the c-format check.
+func checkPoWithBuiltinValidator(poFile string) ([]string, bool) {
+	result, err := ValidatePoFileBuiltin(poFile, PoValidateOptions{CheckHeader: true, CheckFormat: true, NoCFormat: true})
+	if err != nil {
+		return []string{err.Error()}, false
+	}
+	errs := append([]string{}, result.Messages...)
+	errs = append(errs, result.Summary())
+	return errs, result.OK()
+}
+
+// runMsgfmtCheck validates poFile like "msgfmt --check [--statistics] -o /dev/null".
+// Uses msgfmt when installed, otherwise the built-in validator. Returns the
+// diagnostics (and statistics when withStat is true) as text.
+func runMsgfmtCheck(poFile string, withStat bool) (string, error) {
+	if _, err := exec.LookPath("msgfmt"); err == nil {
+		args := []string{"--check", "-o", os.DevNull}
+		if withStat {
+			args = append(args, "--statistics")
+		}
+		out, err := exec.Command("msgfmt", append(args, poFile)...).CombinedOutput()
+		return string(out), err
+	}
+	result, err := ValidatePoFileBuiltin(poFile, PoValidateOptions{CheckHeader: true, CheckFormat: true})
+	if err != nil {
+		return "", err
+	}
+	lines := append([]string{}, result.Messages...)
+	if !result.OK() || withStat {
+		lines = append(lines, result.Summary())
+	}
+	out := ""
+	if len(lines) > 0 {
+		out = strings.Join(lines, "\n") + "\n"
+	}
+	if !result.OK() {
+		return out, fmt.Errorf("%s", result.Summary())
+	}
+	return out, nil
+}
@@ -53,24 +53,15 @@ func checkPoLocationCommentsNoLineNumbers(po *GettextPO) ([]string, bool) {
 		if len(msgid) > 30 {
 			msgid = msgid[:27] + "..."
 		}
-		hasLocationComments := false
 		entryDesc := entryDescWithLine(i+1, msgid, e.EntryLocation)
-		for _, c := range e.Comments {
-			trimmed := strings.TrimSpace(c)
-			if !strings.HasPrefix(trimmed, "#:") {
-				continue
-			}
-			hasLocationComments = true
-			content := strings.TrimPrefix(trimmed, "#:")
-			content = strings.TrimSpace(content)
-			for _, ref := range strings.Fields(content) {
-				if locationLineNumPattern.MatchString(ref) {
-					errs = append(errs, fmt.Sprintf("%s: location comment contains line number (use file-only or remove): %q", entryDesc, ref))
-					return errs, false
-				}
+		refs := e.ParsedComments().References
+		for _, ref := range refs {
+			if ref.Line > 0 || locationLineNumPattern.MatchString(ref.File) {
+				errs = append(errs, fmt.Sprintf("%s: location comment contains line number (use file-only or remove): %q", entryDesc, ref))
+				return errs, false
 			}
 		}
-		if !hasLocationComments {
+		if len(refs) == 0 {
 			errs = append(errs, fmt.Sprintf("%s: location comment not found (mixed --no-location and --add-location=file)", entryDesc))
 			return errs, false
 		}
@@ -214,12 +205,6 @@ func CheckPoFile(locale, poFile string, compareWithPot bool) bool {
 // .gitattributes) are read from that revision; use the commit being checked (e.g. in check-commits)
 // so bare partial clones can resolve filters without a populated worktree.
 func CheckPoFileWithPrompt(locale, poFile string, compareWithPot bool, prompt string, filterRepoRelPath string, isTipCommit bool, attrSourceCommit string) bool {
-	var (
-		ret  = true
-		ok   bool
-		errs []string
-	)
-
 	if prompt == "" {
 		prompt = fmt.Sprintf("[%s]", locale+".po")
 	}
@@ -229,93 +214,28 @@ func CheckPoFileWithPrompt(locale, poFile string, compareWithPot bool, prompt st
 		return false
 	}
 
-	// Run msgfmt to check syntax of a .po file
-	errs, ok = checkPoWithMsgfmt(poFile)
-	ReportSection("Syntax check with msgfmt", ok, log.InfoLevel, prompt, errs...)
-	ret = ret && ok
-
-	// Get pretty locale name, and validate locale name.
-	locale = strings.TrimSuffix(filepath.Base(locale), ".po")
-	localeErrs := ValidateLocale(locale)
-	if len(localeErrs) > 0 {
-		msgs := make([]string, 0, len(localeErrs))
-		for _, e := range localeErrs {
-			msgs = append(msgs, e.Error())
-		}
-		ReportSection("Locale name", false, log.InfoLevel, prompt, msgs...)
-		ret = false
-	}
-
-	poData, err := os.ReadFile(poFile)
-	if err != nil {
-		log.Errorf(`%s\tfail to read %q: %v`, prompt, poFile, err)
-		return false
-	}
-	po, err := ParsePoEntries(poData)
-	if err != nil {
-		log.Errorf(`%s\tfail to parse %q: %v`, prompt, poFile, err)
-		return false
-	}
-
-	// Check header meta for abnormal newline sequences (e.g. literal \n).
-	errs, ok = checkPoMetaEscapeChars(po)
-	ReportSection("Syntax of PO header meta lines", ok, log.InfoLevel, prompt, errs...)
-	ret = ret && ok
-
-	// Compatibility checks (only when project sets MinGettextVersion): 0.15+ msgctxt/#|/#~ msgctxt, 0.16+ #~|.
-	projectName := po.GetProject()
-	cfg := GetProjectPotConfig(projectName, poFile)
-	if cfg.MinGettextVersion != "" {
-		errs, ok = checkPoCompatibility(po, cfg.MinGettextVersion)
-		ReportSection("gettext compatibility", ok, log.InfoLevel, prompt, errs...)
-		ret = ret && ok
-	}
-
-	// No obsolete entries allowed (unless AllowObsoleteEntries, e.g. in update flow).
-	if !flag.AllowObsoleteEntries() {
-		errs, ok = checkPoNoObsoleteEntries(po)
-		ReportSection("Obsolete #~ entries", ok, log.InfoLevel, prompt, errs...)
-		ret = ret && ok
-	}
-
-	// Policy check: git check-attr filter must be a supported driver name (see checkPoFilterFormat).
-	if !flag.NoCheckFilter() {
-		errs, filterOk := checkPoFilterFormat(poFile, filterRepoRelPath, attrSourceCommit, "")
-		filterReportLevel := log.InfoLevel
-
-		ok = filterOk
-
-		if flag.ReportFileLocations() == flag.ReportIssueNone {
-			filterReportLevel = log.InfoLevel
-			ok = true
-		} else if flag.ReportFileLocations() == flag.ReportIssueWarn {
-			filterReportLevel = log.WarnLevel
-			ok = true
-		} else if !isTipCommit {
-			filterReportLevel = log.WarnLevel
-			ok = true
-		}
-		ReportSection("PO filter (.gitattributes)", ok, filterReportLevel, prompt, errs...)
-		ret = ret && ok
+	reportFile := poFile
+	if filterRepoRelPath != "" {
+		reportFile = filterRepoRelPath
 	}
-
-	// Check possible typos in a .po file (Git project only).
-	if strings.EqualFold(projectName, "Git") {
-		errs, ok = checkTyposInPo(locale, po)
-		ReportSection("msgid/msgstr pattern check", ok, log.WarnLevel, prompt, errs...)
-		ret = ret && ok
-	}
-
-	// Check that Project-Id-Version defines a project name.
-	if projectName == "" {
-		ReportSection("Project name", false, log.InfoLevel, prompt,
-			"project name is not defined in PO file")
-		ret = false
+	defer setReportTarget(reportFile, attrSourceCommit)()
+
+	// Run the registered checks (see PoChecks), as configured in .git-po-helper.yaml.
+	c := &PoCheckContext{
+		Locale:            strings.TrimSuffix(filepath.Base(locale), ".po"),
+		PoFile:            poFile,
+		FilterRepoRelPath: filterRepoRelPath,
+		AttrSourceCommit:  attrSourceCommit,
+		IsTipCommit:       isTipCommit,
 	}
+	ret := runPoChecks(c, prompt)
 
 	// Check incomplete translations against POT (can be disabled with "--pot-file=no" inside CheckWithPoFile).
 	if compareWithPot {
-		if !CheckWithPotFile("HEAD", projectName, poFile) {
+		if !c.parsePo(prompt) {
+			return false
+		}
+		if !CheckWithPotFile("HEAD", c.ProjectName, poFile) {
 			ret = false
 		}
 	}
@@ -94,6 +94,7 @@ func getConfigsFromOneManpage(filename string, onlyCamelCase bool) ([]string, er
 // CheckGitPotFile reads the POT file, verifies it is a Git project, and runs the CamelCase config variable check.
 // Returns an error for read/parse failure, missing or non-Git Project-Id-Version, or check failure.
 func CheckGitPotFile(potFile string) error {
+	defer setReportTarget(potFile, "")()
 	poData, err := os.ReadFile(potFile)
 	if err != nil {
 		return fmt.Errorf("fail to read %q: %w", potFile, err)
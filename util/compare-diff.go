@@ -0,0 +1,510 @@
+// Package util provides word-level diff rendering for compare.
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Output formats of compare. CompareFormatPO and CompareFormatJSON write the
+// new or changed entries; the others write their differences.
+const (
+	CompareFormatPO       = "po"
+	CompareFormatJSON     = "json"
+	CompareFormatWordDiff = "word-diff"
+	CompareFormatUnified  = "unified"
+	CompareFormatHTML     = "html"
+)
+
+// CompareFormats are the valid values of "compare --format".
+var CompareFormats = []string{
+	CompareFormatPO,
+	CompareFormatJSON,
+	CompareFormatWordDiff,
+	CompareFormatUnified,
+	CompareFormatHTML,
+}
+
+// IsCompareFormat returns true if format is one of CompareFormats.
+func IsCompareFormat(format string) bool {
+	return containsString(CompareFormats, format)
+}
+
+// IsCompareDiffFormat returns true if format renders differences instead of
+// entries.
+func IsCompareDiffFormat(format string) bool {
+	switch format {
+	case CompareFormatWordDiff, CompareFormatUnified, CompareFormatHTML:
+		return true
+	}
+	return false
+}
+
+// maxDiffCells limits the size of the LCS table of one string pair; longer
+// strings are shown as deleted and inserted as a whole.
+const maxDiffCells = 4 << 20
+
+// ANSI colors of the diff, like "git diff --color".
+const (
+	diffColorOld     = "\033[31m"
+	diffColorNew     = "\033[32m"
+	diffColorFrag    = "\033[36m"
+	diffColorMeta    = "\033[1m"
+	diffColorReverse = "\033[7m"
+	diffColorReset   = "\033[0m"
+)
+
+// CompareEntryDiff is a new or changed entry with its old version. Old is nil
+// for a new entry. When the msgid of an entry changed in the template, Old is
+// the entry of its previous msgid ("#|" lines) in the old file.
+type CompareEntryDiff struct {
+	Old *GettextEntry
+	New *GettextEntry
+}
+
+// CompareEntryDiffs returns the new or changed entries of newJ, like
+// CompareGettextEntries, each paired with its old version.
+func CompareEntryDiffs(oldJ, newJ *GettextJSON, msgidOnly bool) []CompareEntryDiff {
+	_, reviewEntries := CompareGettextEntries(oldJ, newJ, msgidOnly)
+	oldByKey := make(map[string]*GettextEntry)
+	for i := range oldJ.Entries {
+		e := &oldJ.Entries[i]
+		if !e.Obsolete {
+			oldByKey[entryKey(*e)] = e
+		}
+	}
+	diffs := make([]CompareEntryDiff, 0, len(reviewEntries))
+	for i := range reviewEntries {
+		e := &reviewEntries[i]
+		d := CompareEntryDiff{New: e, Old: oldByKey[entryKey(*e)]}
+		if d.Old == nil {
+			if prev := e.ParsedComments().Previous; prev != nil {
+				d.Old = oldByKey[entryKey(GettextEntry{
+					MsgCtxt:     prev.MsgCtxt,
+					MsgID:       prev.MsgID,
+					MsgIDPlural: prev.MsgIDPlural,
+				})]
+			}
+		}
+		diffs = append(diffs, d)
+	}
+	return diffs
+}
+
+// diffField is one line of an entry: a keyword and its PO-format string in
+// the old and the new entry. hasOld and hasNew tell if the line exists.
+type diffField struct {
+	name           string
+	old, new       string
+	hasOld, hasNew bool
+}
+
+// entryFlagsLine returns the "#," flags of e, including fuzzy, joined by ", ".
+func entryFlagsLine(e *GettextEntry) (string, bool) {
+	if e == nil {
+		return "", false
+	}
+	var flags []string
+	if e.Fuzzy {
+		flags = append(flags, "fuzzy")
+	}
+	flags = append(flags, e.ParsedComments().Flags...)
+	return strings.Join(flags, ", "), len(flags) > 0
+}
+
+// diffFields returns the lines of d to compare: flags, msgctxt, msgid,
+// msgid_plural and msgstr.
+func (d CompareEntryDiff) diffFields() []diffField {
+	var fields []diffField
+	add := func(name string, get func(e *GettextEntry) (string, bool)) {
+		f := diffField{name: name}
+		if d.Old != nil {
+			f.old, f.hasOld = get(d.Old)
+		}
+		f.new, f.hasNew = get(d.New)
+		if f.hasOld || f.hasNew {
+			fields = append(fields, f)
+		}
+	}
+	add("#,", entryFlagsLine)
+	add("msgctxt", func(e *GettextEntry) (string, bool) {
+		if e.MsgCtxt == nil {
+			return "", false
+		}
+		return *e.MsgCtxt, true
+	})
+	add("msgid", func(e *GettextEntry) (string, bool) { return e.MsgID, true })
+	add("msgid_plural", func(e *GettextEntry) (string, bool) { return e.MsgIDPlural, e.MsgIDPlural != "" })
+	plural := d.New.MsgIDPlural != "" || (d.Old != nil && d.Old.MsgIDPlural != "")
+	n := len(d.New.MsgStr)
+	if d.Old != nil && len(d.Old.MsgStr) > n {
+		n = len(d.Old.MsgStr)
+	}
+	if n == 0 {
+		n = 1
+	}
+	for i := 0; i < n; i++ {
+		i := i
+		name := "msgstr"
+		if plural {
+			name = fmt.Sprintf("msgstr[%d]", i)
+		}
+		add(name, func(e *GettextEntry) (string, bool) {
+			if i < len(e.MsgStr) {
+				return e.MsgStr[i], true
+			}
+			return "", i == 0 && e.MsgIDPlural == ""
+		})
+	}
+	return fields
+}
+
+// title returns the hunk title of an entry, e.g. `msgid "Hello"`.
+func (d CompareEntryDiff) title() string {
+	s := fmt.Sprintf(`msgid "%s"`, d.New.MsgID)
+	if d.New.MsgCtxt != nil {
+		s = fmt.Sprintf(`msgctxt "%s" %s`, *d.New.MsgCtxt, s)
+	}
+	return s
+}
+
+// line formats one line of an entry in PO syntax.
+func (f diffField) line(value string) string {
+	if f.name == "#," {
+		return "#, " + value
+	}
+	return fmt.Sprintf(`%s "%s"`, f.name, value)
+}
+
+// diffOp is a run of tokens that are equal ('='), deleted ('-') or
+// inserted ('+').
+type diffOp struct {
+	kind byte
+	text string
+}
+
+// diffTokenClass classifies a rune for diffTokens: 0 for space, 1 for word
+// characters, and 2 for runes that are tokens by themselves.
+func diffTokenClass(r rune) int {
+	switch {
+	case unicode.IsSpace(r):
+		return 0
+	case isCJKRune(r):
+		return 2
+	case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || unicode.Is(unicode.Mn, r):
+		return 1
+	}
+	return 2
+}
+
+// isCJKRune returns true for ideographs, kana and hangul, which are written
+// without spaces between words.
+func isCJKRune(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// diffTokens splits s into words and runs of spaces. CJK characters,
+// punctuation and escape sequences like "\n" are tokens by themselves, so
+// that CJK text is compared character by character.
+func diffTokens(s string) []string {
+	var tokens []string
+	start, class := 0, -1
+	for i := 0; i < len(s); {
+		if s[i] == '\\' && i+1 < len(s) {
+			if class >= 0 {
+				tokens = append(tokens, s[start:i])
+			}
+			_, size := utf8.DecodeRuneInString(s[i+1:])
+			tokens = append(tokens, s[i:i+1+size])
+			i += 1 + size
+			start, class = i, -1
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		c := diffTokenClass(r)
+		if class >= 0 && (c != class || c == 2) {
+			tokens = append(tokens, s[start:i])
+			start = i
+		}
+		class = c
+		i += size
+	}
+	if class >= 0 {
+		tokens = append(tokens, s[start:])
+	}
+	return tokens
+}
+
+// diffWords returns the differences between old and new by tokens. Adjacent
+// runs of the same kind are merged, and within a change the deletion comes
+// before the insertion.
+func diffWords(old, new string) []diffOp {
+	a, b := diffTokens(old), diffTokens(new)
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	var ops []diffOp
+	for _, t := range a[:prefix] {
+		ops = append(ops, diffOp{'=', t})
+	}
+	ops = append(ops, diffTokensLCS(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, t := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{'=', t})
+	}
+	return mergeDiffOps(ops)
+}
+
+// diffTokensLCS returns the token operations turning a into b along a longest
+// common subsequence.
+func diffTokensLCS(a, b []string) []diffOp {
+	var ops []diffOp
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 || n*m > maxDiffCells {
+		for _, t := range a {
+			ops = append(ops, diffOp{'-', t})
+		}
+		for _, t := range b {
+			ops = append(ops, diffOp{'+', t})
+		}
+		return ops
+	}
+	// lcs[i*(m+1)+j] is the LCS length of a[i:] and b[j:].
+	lcs := make([]int32, (n+1)*(m+1))
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i*(m+1)+j] = lcs[(i+1)*(m+1)+j+1] + 1
+			} else if x, y := lcs[(i+1)*(m+1)+j], lcs[i*(m+1)+j+1]; x >= y {
+				lcs[i*(m+1)+j] = x
+			} else {
+				lcs[i*(m+1)+j] = y
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{'=', a[i]})
+			i++
+			j++
+		case lcs[(i+1)*(m+1)+j] >= lcs[i*(m+1)+j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+// mergeDiffOps joins adjacent operations and puts the deleted text of a
+// change before the inserted text.
+func mergeDiffOps(ops []diffOp) []diffOp {
+	var out []diffOp
+	var del, ins strings.Builder
+	flush := func() {
+		if del.Len() > 0 {
+			out = append(out, diffOp{'-', del.String()})
+			del.Reset()
+		}
+		if ins.Len() > 0 {
+			out = append(out, diffOp{'+', ins.String()})
+			ins.Reset()
+		}
+	}
+	for _, op := range ops {
+		switch op.kind {
+		case '-':
+			del.WriteString(op.text)
+		case '+':
+			ins.WriteString(op.text)
+		default:
+			flush()
+			if n := len(out); n > 0 && out[n-1].kind == '=' {
+				out[n-1].text += op.text
+			} else {
+				out = append(out, op)
+			}
+		}
+	}
+	flush()
+	return out
+}
+
+// WriteCompareDiff writes the differences of diffs in format (one of
+// CompareFormatWordDiff, CompareFormatUnified and CompareFormatHTML).
+// oldName and newName label the two files. With color, the word-diff and
+// unified formats use ANSI colors. Nothing is written if diffs is empty.
+func WriteCompareDiff(w io.Writer, diffs []CompareEntryDiff, format, oldName, newName string, color bool) error {
+	if len(diffs) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	switch format {
+	case CompareFormatWordDiff:
+		writeWordDiff(&buf, diffs, oldName, newName, color)
+	case CompareFormatUnified:
+		writeUnifiedDiff(&buf, diffs, oldName, newName, color)
+	case CompareFormatHTML:
+		writeHTMLDiff(&buf, diffs, oldName, newName)
+	default:
+		return fmt.Errorf("unknown diff format %q", format)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// paint wraps s in color when enabled.
+func paint(s, color string, enabled bool) string {
+	if !enabled || s == "" {
+		return s
+	}
+	return color + s + diffColorReset
+}
+
+func writeDiffHeader(buf *bytes.Buffer, oldName, newName string, color bool) {
+	buf.WriteString(paint("--- a/"+oldName, diffColorMeta, color) + "\n")
+	buf.WriteString(paint("+++ b/"+newName, diffColorMeta, color) + "\n")
+}
+
+// writeWordDiff writes each entry as PO lines with changed words marked
+// like "git diff --word-diff": "[-old-]{+new+}", or in colors.
+func writeWordDiff(buf *bytes.Buffer, diffs []CompareEntryDiff, oldName, newName string, color bool) {
+	writeDiffHeader(buf, oldName, newName, color)
+	for _, d := range diffs {
+		buf.WriteString(paint("@@ "+d.title()+" @@", diffColorFrag, color) + "\n")
+		for _, f := range d.diffFields() {
+			var s strings.Builder
+			for _, op := range diffWords(f.old, f.new) {
+				switch op.kind {
+				case '-':
+					if color {
+						s.WriteString(paint(op.text, diffColorOld, true))
+					} else {
+						s.WriteString("[-" + op.text + "-]")
+					}
+				case '+':
+					if color {
+						s.WriteString(paint(op.text, diffColorNew, true))
+					} else {
+						s.WriteString("{+" + op.text + "+}")
+					}
+				default:
+					s.WriteString(op.text)
+				}
+			}
+			if !f.hasNew && color {
+				buf.WriteString(paint(f.line(f.old), diffColorOld, true) + "\n")
+				continue
+			}
+			buf.WriteString(f.line(s.String()) + "\n")
+		}
+	}
+}
+
+// writeUnifiedDiff writes each entry as a unified diff of its PO lines. With
+// color, the changed words of a changed line are also highlighted.
+func writeUnifiedDiff(buf *bytes.Buffer, diffs []CompareEntryDiff, oldName, newName string, color bool) {
+	writeDiffHeader(buf, oldName, newName, color)
+	for _, d := range diffs {
+		buf.WriteString(paint("@@ "+d.title()+" @@", diffColorFrag, color) + "\n")
+		for _, f := range d.diffFields() {
+			if f.hasOld && f.hasNew && f.old == f.new {
+				buf.WriteString(" " + f.line(f.new) + "\n")
+				continue
+			}
+			oldLine, newLine := f.old, f.new
+			if color && f.hasOld && f.hasNew {
+				var o, n strings.Builder
+				for _, op := range diffWords(f.old, f.new) {
+					switch op.kind {
+					case '-':
+						o.WriteString(diffColorReverse + op.text + diffColorReset + diffColorOld)
+					case '+':
+						n.WriteString(diffColorReverse + op.text + diffColorReset + diffColorNew)
+					default:
+						o.WriteString(op.text)
+						n.WriteString(op.text)
+					}
+				}
+				oldLine, newLine = o.String(), n.String()
+			}
+			if f.hasOld {
+				buf.WriteString(paint("-"+f.line(oldLine), diffColorOld, color) + "\n")
+			}
+			if f.hasNew {
+				buf.WriteString(paint("+"+f.line(newLine), diffColorNew, color) + "\n")
+			}
+		}
+	}
+}
+
+const htmlDiffStyle = `body { font-family: sans-serif; }
+.entry { margin: 1em 0; border: 1px solid #ccc; }
+.title { background: #eef; padding: 0.2em 0.5em; font-family: monospace; }
+.new .title { background: #efe; }
+pre { margin: 0; padding: 0.2em 0.5em; white-space: pre-wrap; }
+del { background: #fdd; color: #900; }
+ins { background: #dfd; color: #060; text-decoration: none; }`
+
+// writeHTMLDiff writes a standalone HTML page with one block per entry,
+// marking deleted words with <del> and inserted words with <ins>.
+func writeHTMLDiff(buf *bytes.Buffer, diffs []CompareEntryDiff, oldName, newName string) {
+	title := html.EscapeString(oldName + " → " + newName)
+	buf.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	buf.WriteString("<title>" + title + "</title>\n")
+	buf.WriteString("<style>\n" + htmlDiffStyle + "\n</style>\n</head>\n<body>\n")
+	buf.WriteString("<h1>" + title + "</h1>\n")
+	for _, d := range diffs {
+		class := "entry"
+		if d.Old == nil {
+			class += " new"
+		}
+		buf.WriteString(`<div class="` + class + `">` + "\n")
+		buf.WriteString(`<div class="title">` + html.EscapeString(d.title()) + "</div>\n<pre>")
+		for _, f := range d.diffFields() {
+			var s strings.Builder
+			for _, op := range diffWords(f.old, f.new) {
+				text := html.EscapeString(op.text)
+				switch op.kind {
+				case '-':
+					s.WriteString("<del>" + text + "</del>")
+				case '+':
+					s.WriteString("<ins>" + text + "</ins>")
+				default:
+					s.WriteString(text)
+				}
+			}
+			switch {
+			case !f.hasNew:
+				buf.WriteString("<del>" + html.EscapeString(f.line(f.old)) + "</del>")
+			case f.name == "#,":
+				buf.WriteString("#, " + s.String())
+			default:
+				buf.WriteString(html.EscapeString(f.name+` "`) + s.String() + html.EscapeString(`"`))
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString("</pre>\n</div>\n")
+	}
+	buf.WriteString("</body>\n</html>\n")
+}
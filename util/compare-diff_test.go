@@ -0,0 +1,188 @@
+package util
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDiffTokens(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"remove %s from  index", []string{"remove", " ", "%", "s", " ", "from", "  ", "index"}},
+		{"line\\nnext", []string{"line", "\\n", "next"}},
+		{"删除 %s 文件", []string{"删", "除", " ", "%", "s", " ", "文", "件"}},
+		{"ファイルを開く", []string{"フ", "ァ", "イ", "ル", "を", "開", "く"}},
+		{"can't", []string{"can", "'", "t"}},
+	}
+	for _, tt := range tests {
+		if got := diffTokens(tt.in); !equalStrings(got, tt.want) {
+			t.Errorf("diffTokens(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDiffWords(t *testing.T) {
+	tests := []struct {
+		old, new string
+		want     string
+	}{
+		{"same", "same", "same"},
+		{"", "new text", "{+new text+}"},
+		{"old text", "", "[-old text-]"},
+		{"remove %s from the index", "remove '%s' from the index\\n",
+			"remove {+'+}%s{+'+} from the index{+\\n+}"},
+		{"show the working tree status", "show the status of the working tree",
+			"show the {+status of the +}working tree[- status-]"},
+		{"你好世界", "您好世界", "[-你-]{+您+}好世界"},
+		{"无法打开文件", "不能打开文件", "[-无法-]{+不能+}打开文件"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		for _, op := range diffWords(tt.old, tt.new) {
+			switch op.kind {
+			case '-':
+				buf.WriteString("[-" + op.text + "-]")
+			case '+':
+				buf.WriteString("{+" + op.text + "+}")
+			default:
+				buf.WriteString(op.text)
+			}
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("diffWords(%q, %q) = %q, want %q", tt.old, tt.new, got, tt.want)
+		}
+	}
+}
+
+func TestWriteCompareDiff(t *testing.T) {
+	oldPo := `msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+
+msgid "Hello"
+msgstr "你好世界"
+
+#, c-format
+msgid "remove %s from the index"
+msgstr "从索引中删除 %s"
+
+msgid "World"
+msgstr "世界"
+`
+	newPo := `msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+
+msgid "Hello"
+msgstr "您好世界"
+
+#, fuzzy, c-format
+#| msgid "remove %s from the index"
+msgid "remove '%s' from the index"
+msgstr "从索引中删除 %s"
+
+msgid "World"
+msgstr "世界"
+
+msgid "One file"
+msgid_plural "%d files"
+msgstr[0] "%d 个文件"
+`
+	oldJ, err := LoadFileToGettextJSON([]byte(oldPo), "old.po")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newJ, err := LoadFileToGettextJSON([]byte(newPo), "new.po")
+	if err != nil {
+		t.Fatal(err)
+	}
+	diffs := CompareEntryDiffs(oldJ, newJ, false)
+	if len(diffs) != 3 {
+		t.Fatalf("got %d diffs, want 3", len(diffs))
+	}
+
+	tests := []struct {
+		format string
+		color  bool
+		want   string
+	}{
+		{CompareFormatWordDiff, false, `--- a/old.po
++++ b/new.po
+@@ msgid "Hello" @@
+msgid "Hello"
+msgstr "[-你-]{+您+}好世界"
+@@ msgid "One file" @@
+msgid "{+One file+}"
+msgid_plural "{+%d files+}"
+msgstr[0] "{+%d 个文件+}"
+@@ msgid "remove '%s' from the index" @@
+#, {+fuzzy, +}c-format
+msgid "remove {+'+}%s{+'+} from the index"
+msgstr "从索引中删除 %s"
+`},
+		{CompareFormatUnified, false, `--- a/old.po
++++ b/new.po
+@@ msgid "Hello" @@
+ msgid "Hello"
+-msgstr "你好世界"
++msgstr "您好世界"
+@@ msgid "One file" @@
++msgid "One file"
++msgid_plural "%d files"
++msgstr[0] "%d 个文件"
+@@ msgid "remove '%s' from the index" @@
+-#, c-format
++#, fuzzy, c-format
+-msgid "remove %s from the index"
++msgid "remove '%s' from the index"
+ msgstr "从索引中删除 %s"
+`},
+		{CompareFormatWordDiff, true, "\033[1m--- a/old.po\033[0m\n" +
+			"\033[1m+++ b/new.po\033[0m\n" +
+			"\033[36m@@ msgid \"Hello\" @@\033[0m\n" +
+			"msgid \"Hello\"\n" +
+			"msgstr \"\033[31m你\033[0m\033[32m您\033[0m好世界\"\n"},
+		{CompareFormatUnified, true, "\033[1m--- a/old.po\033[0m\n" +
+			"\033[1m+++ b/new.po\033[0m\n" +
+			"\033[36m@@ msgid \"Hello\" @@\033[0m\n" +
+			" msgid \"Hello\"\n" +
+			"\033[31m-msgstr \"\033[7m你\033[0m\033[31m好世界\"\033[0m\n" +
+			"\033[32m+msgstr \"\033[7m您\033[0m\033[32m好世界\"\033[0m\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		d := diffs
+		if tt.color {
+			d = diffs[:1]
+		}
+		if err := WriteCompareDiff(&buf, d, tt.format, "old.po", "new.po", tt.color); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%s (color=%v):\n%s\nwant:\n%s", tt.format, tt.color, got, tt.want)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCompareDiff(&buf, diffs, CompareFormatHTML, "old.po", "new.po", false); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<title>old.po → new.po</title>",
+		`<div class="entry new">`,
+		"msgstr &#34;<del>你</del><ins>您</ins>好世界&#34;",
+		"#, <ins>fuzzy, </ins>c-format",
+	} {
+		if !bytes.Contains(buf.Bytes(), []byte(want)) {
+			t.Errorf("HTML output has no %q:\n%s", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	if err := WriteCompareDiff(&buf, nil, CompareFormatHTML, "old.po", "new.po", false); err != nil || buf.Len() != 0 {
+		t.Errorf("no diffs: got %q, %v; want empty output", buf.String(), err)
+	}
+}
@@ -0,0 +1,149 @@
+// Package util provides content-based entry selection for msg-select.
+package util
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// EntrySelector selects entries by their content, in addition to the state
+// of EntryStateFilter. Each kind of selector matches if any of its values
+// matches; an entry is selected if all kinds that are set match.
+type EntrySelector struct {
+	// MsgCtxt selects entries whose msgctxt equals one of the values.
+	MsgCtxt []string
+	// References selects entries with a source reference ("#:") to one of
+	// the files: a file name, a directory, or a glob like "builtin/*.c".
+	References []string
+	// Flags selects entries with one of the flags ("#,"), e.g. "c-format".
+	Flags []string
+	// MsgIDRegexps selects entries whose msgid or msgid_plural matches one
+	// of the regular expressions.
+	MsgIDRegexps []*regexp.Regexp
+	// Changed selects entries that are new or changed in a revision range
+	// (see SelectChangedEntries); nil means no such selection.
+	Changed map[string]bool
+}
+
+// NewEntrySelector returns an EntrySelector, compiling msgidPatterns.
+func NewEntrySelector(msgctxt, references, flags, msgidPatterns []string) (*EntrySelector, error) {
+	s := &EntrySelector{
+		MsgCtxt:    msgctxt,
+		References: references,
+		Flags:      flags,
+	}
+	for _, p := range msgidPatterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("bad msgid pattern %q: %w", p, err)
+		}
+		s.MsgIDRegexps = append(s.MsgIDRegexps, re)
+	}
+	return s, nil
+}
+
+// IsEmpty returns true if no selector is set, so that all entries match.
+func (s *EntrySelector) IsEmpty() bool {
+	return s == nil || (len(s.MsgCtxt) == 0 && len(s.References) == 0 &&
+		len(s.Flags) == 0 && len(s.MsgIDRegexps) == 0 && s.Changed == nil)
+}
+
+// matchReference returns true if the source file of a reference matches
+// pattern: the same file, a file under the directory pattern, or a glob.
+func matchReference(pattern, file string) bool {
+	pattern = strings.TrimPrefix(pattern, "./")
+	if pattern == file {
+		return true
+	}
+	if dir := strings.TrimSuffix(pattern, "/"); strings.HasPrefix(file, dir+"/") {
+		return true
+	}
+	ok, _ := path.Match(pattern, file)
+	return ok
+}
+
+// Match returns true if e is selected by s.
+func (s *EntrySelector) Match(e *GettextEntry) bool {
+	if s.IsEmpty() {
+		return true
+	}
+	if len(s.MsgCtxt) > 0 {
+		ctxt := ""
+		if e.MsgCtxt != nil {
+			ctxt = poUnescape(*e.MsgCtxt)
+		}
+		if e.MsgCtxt == nil || !containsString(s.MsgCtxt, ctxt) {
+			return false
+		}
+	}
+	if len(s.MsgIDRegexps) > 0 {
+		matched := false
+		for _, re := range s.MsgIDRegexps {
+			if re.MatchString(poUnescape(e.MsgID)) ||
+				(e.MsgIDPlural != "" && re.MatchString(poUnescape(e.MsgIDPlural))) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(s.References) > 0 || len(s.Flags) > 0 {
+		c := e.ParsedComments()
+		if len(s.References) > 0 && !matchAnyReference(s.References, c.References) {
+			return false
+		}
+		if len(s.Flags) > 0 && !matchAnyFlag(s.Flags, c.Flags, e.Fuzzy) {
+			return false
+		}
+	}
+	if s.Changed != nil && !s.Changed[entryKey(*e)] {
+		return false
+	}
+	return true
+}
+
+func matchAnyReference(patterns []string, refs []PoReference) bool {
+	for _, ref := range refs {
+		for _, p := range patterns {
+			if matchReference(p, ref.File) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchAnyFlag returns true if one of flags is set; "fuzzy" matches fuzzy
+// entries, since the fuzzy flag is not kept with the other flags.
+func matchAnyFlag(flags, entryFlags []string, fuzzy bool) bool {
+	for _, f := range flags {
+		if containsString(entryFlags, f) || (f == "fuzzy" && fuzzy) {
+			return true
+		}
+	}
+	return false
+}
+
+// SelectChangedEntries returns the keys of the entries of file that are new
+// or changed in the revision range revRange ("a..b", "a.." or "a", like
+// compare --range), for EntrySelector.Changed.
+func SelectChangedEntries(revRange, file string) (map[string]bool, error) {
+	target, err := ResolveRevisionsAndFiles(revRange, "", "", []string{file})
+	if err != nil {
+		return nil, err
+	}
+	oldJ, newJ, _, _, err := loadReviewFiles(target.OldCommit, target.OldFile, target.NewCommit, target.NewFile)
+	if err != nil {
+		return nil, err
+	}
+	_, changed := CompareGettextEntries(oldJ, newJ, false)
+	keys := make(map[string]bool, len(changed))
+	for _, e := range changed {
+		keys[entryKey(e)] = true
+	}
+	return keys, nil
+}
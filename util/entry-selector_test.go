@@ -0,0 +1,78 @@
+package util
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestEntrySelectorMatch(t *testing.T) {
+	po := `msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+
+#: builtin/commit.c:12
+#, c-format
+msgid "commit %s"
+msgstr "Commit %s"
+
+#: builtin/add.c:3 wt-status.c:9
+msgid "--all is bad"
+msgid_plural "--all are bad"
+msgstr[0] ""
+msgstr[1] ""
+
+#: builtin/commit.c:50
+msgctxt "verb"
+msgid "commit"
+msgstr "committen"
+
+#: remote.c:1
+#, fuzzy, no-wrap
+msgid "remote"
+msgstr "Fern"
+`
+	j, err := LoadFileToGettextJSON([]byte(po), "de.po")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		sel  *EntrySelector
+		want []string
+	}{
+		{"nil", nil, []string{"commit %s", "--all is bad", "commit", "remote"}},
+		{"empty", &EntrySelector{}, []string{"commit %s", "--all is bad", "commit", "remote"}},
+		{"file", &EntrySelector{References: []string{"builtin/commit.c"}}, []string{"commit %s", "commit"}},
+		{"directory", &EntrySelector{References: []string{"builtin"}}, []string{"commit %s", "--all is bad", "commit"}},
+		{"glob", &EntrySelector{References: []string{"*.c"}}, []string{"--all is bad", "remote"}},
+		{"any of files", &EntrySelector{References: []string{"./remote.c", "wt-status.c"}}, []string{"--all is bad", "remote"}},
+		{"msgctxt", &EntrySelector{MsgCtxt: []string{"verb"}}, []string{"commit"}},
+		{"flags", &EntrySelector{Flags: []string{"c-format", "fuzzy"}}, []string{"commit %s", "remote"}},
+		{"no-wrap", &EntrySelector{Flags: []string{"no-wrap"}}, []string{"remote"}},
+		{"msgid_plural", &EntrySelector{MsgIDRegexps: []*regexp.Regexp{regexp.MustCompile("are bad$")}}, []string{"--all is bad"}},
+		{"and", &EntrySelector{
+			References:   []string{"builtin/"},
+			MsgIDRegexps: []*regexp.Regexp{regexp.MustCompile("^commit")},
+			Flags:        []string{"c-format"},
+		}, []string{"commit %s"}},
+		{"changed", &EntrySelector{Changed: map[string]bool{
+			entryKey(GettextEntry{MsgID: "remote"}): true,
+		}}, []string{"remote"}},
+		{"nothing changed", &EntrySelector{Changed: map[string]bool{}}, nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for i := range j.Entries {
+			if tt.sel.Match(&j.Entries[i]) {
+				got = append(got, j.Entries[i].MsgID)
+			}
+		}
+		if !equalStrings(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := NewEntrySelector(nil, nil, nil, []string{"("}); err == nil {
+		t.Error("expected an error for a bad msgid pattern")
+	}
+}
@@ -2,6 +2,7 @@
 package util
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -14,10 +15,12 @@ import (
 
 // GettextEntry represents a single PO/JSON entry. Used for parsing, comparison, and output.
 // All PO content is represented by fields (Comments, MsgCtxt, MsgID, MsgIDPlural, MsgStr, Obsolete, Fuzzy)
-// and by #|/#~| lines stored in Comments. Output is always generated from fields via writeGettextEntryToPO.
+// and by #|/#~| lines stored in Comments. Output is generated from fields via writeGettextEntryToPO,
+// unless the layout of unmodified entries is kept (see RawLines).
 // MsgCtxt is optional; nil means the line was absent (distinct from empty string).
 // Previous-untranslated (#|) and obsolete-previous (#~|) exist only in Comments; use IsObsolete(),
 // HasPreviousMsgctxt(), HasPreviousMsgid(), HasPreviousMsgidPlural(), and GetPrevious* to detect or read.
+// ParsedComments returns all comment lines as typed fields (see EntryComments).
 type GettextEntry struct {
 	MsgID       string   `json:"msgid"`
 	MsgStr      []string `json:"msgstr,omitempty"` // Always a JSON array; one element = singular, multiple = msgstr[0..]
@@ -28,6 +31,15 @@ type GettextEntry struct {
 	Obsolete    bool     `json:"obsolete,omitempty"` // True for #~ obsolete entries
 	// EntryLocation is the 1-based line number of the msgid line (or #~ msgid for obsolete). Set by ParsePoEntries; not serialized.
 	EntryLocation int `json:"-"`
+	// RawLines are the original lines of the entry (first comment through last msgstr line) as read
+	// by ParsePoEntries or PoReader; not serialized. With --keep-layout they are written instead of
+	// regenerating the entry, as long as the fields still match them (see entryMatchesRawLines).
+	RawLines []string `json:"-"`
+
+	// parsedComments is the structured form of Comments as it was when parsed,
+	// kept in parsedCommentsOf; see ParsedComments. Not serialized.
+	parsedComments   *EntryComments
+	parsedCommentsOf []string
 }
 
 // MsgStrSingle returns the first translation form, or "" if none (singular msgstr or msgstr[0]).
@@ -737,284 +749,291 @@ func startNewEntry(st *poParseState) {
 // ParsePoEntries parses a PO file and returns a GettextPO (header as one entry + content entries).
 // The header includes comments, the empty msgid/msgstr block, and any continuation lines.
 // Content entries are 1-based (header entry with empty msgid is not in Entries).
+// Use NewPoReader to process large files entry by entry instead.
 func ParsePoEntries(data []byte) (*GettextPO, error) {
-	lines := strings.Split(string(data), "\n")
-	var entries []*GettextEntry
-	st := &poParseState{
-		inHeader:           true,
-		hasSeenHeaderBlock: false,
-		headerLines:        nil,
-		currentPluralIndex: -1,
+	pr := NewPoReader(bytes.NewReader(data))
+	headerEntry, err := pr.Header()
+	if err != nil {
+		return nil, err
 	}
-
-	for lineNo, line := range lines {
-		line1Based := lineNo + 1
-		trimmed := strings.TrimSpace(line)
-		st.obsoleteCommentStripPrefix = false
-
-		// Obsolete entry format: #~ msgid, #~ msgstr, #~| msgid (check first, before header/comment)
-		if strings.HasPrefix(trimmed, "#~ ") {
-			rest := trimmed[3:]
-			restTrimmed := strings.TrimSpace(rest)
-			// Set inObsolete only for continuation or msgstr (current entry content), not for msgid/msgctxt which start a new entry.
-			if strings.HasPrefix(restTrimmed, `"`) || strings.HasPrefix(restTrimmed, "msgstr") {
-				st.inObsolete = true
-			}
-			if strings.HasPrefix(restTrimmed, `"`) && (st.inMsgctxt || st.inMsgid || st.inMsgstr || st.inMsgidPlural) {
-				value := strDeQuote(restTrimmed)
-				if st.inMsgctxt {
-					st.msgctxtValue.WriteString(value)
-				} else if st.inMsgid {
-					if st.msgidStartLineNo == 0 {
-						st.msgidStartLineNo = line1Based
-					}
-					st.msgidValue.WriteString(value)
-				} else if st.inMsgidPlural {
-					st.msgidPluralValue.WriteString(value)
-				} else if st.inMsgstr {
-					if st.currentPluralIndex >= 0 {
-						st.msgstrPluralValues[st.currentPluralIndex].WriteString(value)
-					} else {
-						st.msgstrValue.WriteString(value)
-					}
-				}
-				continue
-			}
-			// For obsolete comment lines (#~ #:, #~ #,, etc.), store content without "#~ " (gettext-json-format 7.2 Option A).
-			if strings.HasPrefix(restTrimmed, "#") {
-				st.obsoleteCommentStripPrefix = true
-			}
-			trimmed = rest
-		} else if strings.HasPrefix(trimmed, "#~| ") {
-			rest := trimmed[4:]
-			if strings.HasPrefix(rest, "msgctxt ") || strings.HasPrefix(rest, "msgid ") || strings.HasPrefix(rest, "msgid_plural ") {
-				finishCurrentEntry(st, &entries)
-				if st.currentEntry == nil || st.msgidValue.Len() > 0 || st.msgstrValue.Len() > 0 {
-					startNewEntry(st)
-				} else {
-					resetEntryContent(st)
-				}
-				st.currentEntry.Obsolete = true
-				st.inObsolete = true
-				st.currentEntry.Comments = append(st.currentEntry.Comments, line)
-				continue
-			}
-			// Continuation line: #~| "value" (multi-line #~| msgid "" format)
-			if st.currentEntry != nil {
-				st.currentEntry.Comments = append(st.currentEntry.Comments, line)
-			}
-			continue
+	var entriesVal []GettextEntry
+	for {
+		e, err := pr.Next()
+		if err == io.EOF {
+			break
 		}
-
-		// Header: first msgid "" starts the header block
-		if !st.hasSeenHeaderBlock && strings.HasPrefix(trimmed, "msgid ") {
-			value := strings.TrimPrefix(trimmed, "msgid ")
-			value = strings.TrimSpace(value)
-			value = strDeQuote(value)
-			if value == "" {
-				st.hasSeenHeaderBlock = true
-				st.headerLines = append(st.headerLines, line)
-				continue
-			}
+		if err != nil {
+			return nil, err
 		}
+		entriesVal = append(entriesVal, *e)
+	}
+	if entriesVal == nil {
+		entriesVal = []GettextEntry{}
+	}
+	return &GettextPO{HeaderEntry: headerEntry, Entries: entriesVal}, nil
+}
 
-		// Collect header lines until we leave the header
-		if st.inHeader {
-			if strings.HasPrefix(trimmed, "msgstr ") {
-				value := strings.TrimPrefix(trimmed, "msgstr ")
-				value = strings.TrimSpace(value)
-				value = strDeQuote(value)
-				if st.msgidValue.Len() == 0 && value == "" {
-					st.headerLines = append(st.headerLines, line)
-					continue
+// parseLine parses one line (without the trailing newline) of a PO file.
+// line1Based is the 1-based line number. Entries completed by this line are
+// appended to entries.
+func (st *poParseState) parseLine(line string, line1Based int, entries *[]*GettextEntry) {
+	trimmed := strings.TrimSpace(line)
+	st.obsoleteCommentStripPrefix = false
+
+	// Obsolete entry format: #~ msgid, #~ msgstr, #~| msgid (check first, before header/comment)
+	if strings.HasPrefix(trimmed, "#~ ") {
+		rest := trimmed[3:]
+		restTrimmed := strings.TrimSpace(rest)
+		// Set inObsolete only for continuation or msgstr (current entry content), not for msgid/msgctxt which start a new entry.
+		if strings.HasPrefix(restTrimmed, `"`) || strings.HasPrefix(restTrimmed, "msgstr") {
+			st.inObsolete = true
+		}
+		if strings.HasPrefix(restTrimmed, `"`) && (st.inMsgctxt || st.inMsgid || st.inMsgstr || st.inMsgidPlural) {
+			value := strDeQuote(restTrimmed)
+			if st.inMsgctxt {
+				st.msgctxtValue.WriteString(value)
+			} else if st.inMsgid {
+				if st.msgidStartLineNo == 0 {
+					st.msgidStartLineNo = line1Based
 				}
-			}
-			if strings.HasPrefix(trimmed, `"`) {
-				if st.currentEntry != nil || st.inMsgid || st.inMsgstr || st.inMsgidPlural {
-					// Continuation of an entry, not header; fall through to entry parsing
+				st.msgidValue.WriteString(value)
+			} else if st.inMsgidPlural {
+				st.msgidPluralValue.WriteString(value)
+			} else if st.inMsgstr {
+				if st.currentPluralIndex >= 0 {
+					st.msgstrPluralValues[st.currentPluralIndex].WriteString(value)
 				} else {
-					st.headerLines = append(st.headerLines, trimmed)
-					continue
-				}
-			}
-			if trimmed == "" {
-				if !st.hasSeenHeaderBlock {
-					st.headerLines = append(st.headerLines, line)
-					continue
+					st.msgstrValue.WriteString(value)
 				}
-				st.inHeader = false
-				st.msgidValue.Reset()
-				st.msgstrValue.Reset()
-				continue
-			}
-			if strings.HasPrefix(trimmed, "msgid ") {
-				st.inHeader = false
-				st.msgidValue.Reset()
-				st.msgstrValue.Reset()
-				// Fall through to entry parsing with this msgid line
-			} else {
-				st.headerLines = append(st.headerLines, line)
-				continue
 			}
+			return
 		}
-
-		// Entry parsing: dispatch by line kind
-		kind := classifyPoLine(trimmed)
-		switch kind {
-		case poLineComment, poLineFlagHashComma, poLineFlagHashEq, poLineCommentRef, poLineCommentExtracted, poLineCommentPrev:
-			// When there is no blank line between entries, a comment starts a new entry if the current one is complete.
-			if st.currentEntry != nil && st.msgidValue.Len() > 0 && st.hasSeenMsgstr {
-				finishCurrentEntry(st, &entries)
-				startNewEntry(st)
-			}
-			if st.currentEntry == nil {
-				st.currentEntry = &GettextEntry{}
-			}
-			if st.obsoleteCommentStripPrefix {
-				st.currentEntry.Comments = append(st.currentEntry.Comments, trimmed)
-			} else {
-				st.currentEntry.Comments = append(st.currentEntry.Comments, line)
-			}
-
-		case poLineMsgctxt:
-			if st.currentEntry == nil {
-				st.currentEntry = &GettextEntry{}
-			}
-			if strings.HasPrefix(strings.TrimSpace(line), "#~ ") {
-				st.inObsolete = true
-			}
-			st.inMsgid = false
-			st.inMsgidPlural = false
-			st.inMsgstr = false
-			st.inMsgctxt = true
-			st.hasMsgctxt = true
-			value := strings.TrimPrefix(trimmed, "msgctxt ")
-			value = strings.TrimSpace(value)
-			value = strDeQuote(value)
-			st.msgctxtValue.WriteString(value)
-
-		case poLineMsgid:
-			finishCurrentEntry(st, &entries)
+		// For obsolete comment lines (#~ #:, #~ #,, etc.), store content without "#~ " (gettext-json-format 7.2 Option A).
+		if strings.HasPrefix(restTrimmed, "#") {
+			st.obsoleteCommentStripPrefix = true
+		}
+		trimmed = rest
+	} else if strings.HasPrefix(trimmed, "#~| ") {
+		rest := trimmed[4:]
+		if strings.HasPrefix(rest, "msgctxt ") || strings.HasPrefix(rest, "msgid ") || strings.HasPrefix(rest, "msgid_plural ") {
+			finishCurrentEntry(st, entries)
 			if st.currentEntry == nil || st.msgidValue.Len() > 0 || st.msgstrValue.Len() > 0 {
 				startNewEntry(st)
 			} else {
-				// Keep same entry (had only comments and/or msgctxt); reset only msgid/msgstr/plural state.
-				st.msgidValue.Reset()
-				st.msgstrValue.Reset()
-				st.msgidPluralValue.Reset()
-				st.msgstrPluralValues = nil
-				st.inMsgid = true
-				st.inMsgstr = false
-				st.inMsgidPlural = false
-				st.currentPluralIndex = -1
-				// Preserve st.msgctxtValue and st.hasMsgctxt so finishCurrentEntry will set MsgCtxt.
-			}
-			if strings.HasPrefix(strings.TrimSpace(line), "#~ ") {
-				st.inObsolete = true
+				resetEntryContent(st)
 			}
-			// Always record this line as the msgid line for the current entry (avoids reusing a stale value when we kept same entry above).
-			st.msgidStartLineNo = line1Based
-			st.inMsgctxt = false
-			st.inMsgid = true
-			value := strings.TrimPrefix(trimmed, "msgid ")
-			value = strings.TrimSpace(value)
-			value = strDeQuote(value)
-			st.msgidValue.WriteString(value)
-
-		case poLineMsgidPlural:
-			st.inMsgid = false
-			st.inMsgidPlural = true
-			value := strings.TrimPrefix(trimmed, "msgid_plural ")
-			value = strings.TrimSpace(value)
-			value = strDeQuote(value)
-			st.msgidPluralValue.WriteString(value)
+			st.currentEntry.Obsolete = true
+			st.inObsolete = true
+			st.currentEntry.Comments = append(st.currentEntry.Comments, line)
+			return
+		}
+		// Continuation line: #~| "value" (multi-line #~| msgid "" format)
+		if st.currentEntry != nil {
+			st.currentEntry.Comments = append(st.currentEntry.Comments, line)
+		}
+		return
+	}
 
-		case poLineMsgstrN:
-			st.inMsgid = false
-			st.inMsgidPlural = false
-			st.inMsgstr = true
-			st.hasSeenMsgstr = true
-			idxStr := strings.TrimPrefix(trimmed, "msgstr[")
-			idxStr = strings.Split(idxStr, "]")[0]
-			var idx int
-			_, _ = fmt.Sscanf(idxStr, "%d", &idx)
-			for len(st.msgstrPluralValues) <= idx {
-				st.msgstrPluralValues = append(st.msgstrPluralValues, strings.Builder{})
-			}
-			st.currentPluralIndex = idx
-			value := strings.TrimPrefix(trimmed, fmt.Sprintf("msgstr[%d] ", idx))
-			value = strings.TrimSpace(value)
-			value = strDeQuote(value)
-			st.msgstrPluralValues[idx].WriteString(value)
+	// Header: first msgid "" starts the header block
+	if !st.hasSeenHeaderBlock && strings.HasPrefix(trimmed, "msgid ") {
+		value := strings.TrimPrefix(trimmed, "msgid ")
+		value = strings.TrimSpace(value)
+		value = strDeQuote(value)
+		if value == "" {
+			st.hasSeenHeaderBlock = true
+			st.headerLines = append(st.headerLines, line)
+			return
+		}
+	}
 
-		case poLineMsgstr:
-			st.inMsgid = false
-			st.inMsgidPlural = false
-			st.inMsgstr = true
-			st.hasSeenMsgstr = true
+	// Collect header lines until we leave the header
+	if st.inHeader {
+		if strings.HasPrefix(trimmed, "msgstr ") {
 			value := strings.TrimPrefix(trimmed, "msgstr ")
 			value = strings.TrimSpace(value)
 			value = strDeQuote(value)
-			st.msgstrValue.WriteString(value)
-
-		case poLineQuotedString:
-			if st.inMsgctxt || st.inMsgid || st.inMsgstr || st.inMsgidPlural {
-				value := strDeQuote(trimmed)
-				if st.inMsgctxt {
-					st.msgctxtValue.WriteString(value)
-				} else if st.inMsgid {
-					st.msgidValue.WriteString(value)
-				} else if st.inMsgidPlural {
-					st.msgidPluralValue.WriteString(value)
-				} else if st.inMsgstr {
-					if st.currentPluralIndex >= 0 {
-						st.msgstrPluralValues[st.currentPluralIndex].WriteString(value)
-					} else {
-						st.msgstrValue.WriteString(value)
-					}
-				}
-			} else {
-				log.Warnf("unrecognized PO line at %d (quoted string outside context): %s", line1Based, line)
+			if st.msgidValue.Len() == 0 && value == "" {
+				st.headerLines = append(st.headerLines, line)
+				return
 			}
-
-		case poLineBlank:
-			// Ignore meaningless blank lines: between comments and msgid, or between msgid and msgstr.
-			if st.currentEntry != nil && st.msgidValue.Len() == 0 {
-				// Comments only (no msgid yet); keep comments with the following msgid.
-				continue
+		}
+		if strings.HasPrefix(trimmed, `"`) {
+			if st.currentEntry != nil || st.inMsgid || st.inMsgstr || st.inMsgidPlural {
+				// Continuation of an entry, not header; fall through to entry parsing
+			} else {
+				st.headerLines = append(st.headerLines, trimmed)
+				return
 			}
-			if st.currentEntry != nil && st.msgidValue.Len() > 0 && !st.hasSeenMsgstr {
-				// Have msgid but no msgstr line yet; blank between msgid and msgstr.
-				continue
+		}
+		if trimmed == "" {
+			if !st.hasSeenHeaderBlock {
+				st.headerLines = append(st.headerLines, line)
+				return
 			}
-			finishCurrentEntry(st, &entries)
-			st.currentEntry = nil
-			st.msgctxtValue.Reset()
+			st.inHeader = false
+			st.msgidValue.Reset()
+			st.msgstrValue.Reset()
+			return
+		}
+		if strings.HasPrefix(trimmed, "msgid ") {
+			st.inHeader = false
+			st.msgidValue.Reset()
+			st.msgstrValue.Reset()
+			// Fall through to entry parsing with this msgid line
+		} else {
+			st.headerLines = append(st.headerLines, line)
+			return
+		}
+	}
+
+	// Entry parsing: dispatch by line kind
+	kind := classifyPoLine(trimmed)
+	switch kind {
+	case poLineComment, poLineFlagHashComma, poLineFlagHashEq, poLineCommentRef, poLineCommentExtracted, poLineCommentPrev:
+		// When there is no blank line between entries, a comment starts a new entry if the current one is complete.
+		if st.currentEntry != nil && st.msgidValue.Len() > 0 && st.hasSeenMsgstr {
+			finishCurrentEntry(st, entries)
+			startNewEntry(st)
+		}
+		if st.currentEntry == nil {
+			st.currentEntry = &GettextEntry{}
+		}
+		if st.obsoleteCommentStripPrefix {
+			st.currentEntry.Comments = append(st.currentEntry.Comments, trimmed)
+		} else {
+			st.currentEntry.Comments = append(st.currentEntry.Comments, line)
+		}
+
+	case poLineMsgctxt:
+		if st.currentEntry == nil {
+			st.currentEntry = &GettextEntry{}
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "#~ ") {
+			st.inObsolete = true
+		}
+		st.inMsgid = false
+		st.inMsgidPlural = false
+		st.inMsgstr = false
+		st.inMsgctxt = true
+		st.hasMsgctxt = true
+		value := strings.TrimPrefix(trimmed, "msgctxt ")
+		value = strings.TrimSpace(value)
+		value = strDeQuote(value)
+		st.msgctxtValue.WriteString(value)
+
+	case poLineMsgid:
+		finishCurrentEntry(st, entries)
+		if st.currentEntry == nil || st.msgidValue.Len() > 0 || st.msgstrValue.Len() > 0 {
+			startNewEntry(st)
+		} else {
+			// Keep same entry (had only comments and/or msgctxt); reset only msgid/msgstr/plural state.
 			st.msgidValue.Reset()
 			st.msgstrValue.Reset()
 			st.msgidPluralValue.Reset()
 			st.msgstrPluralValues = nil
-			st.hasMsgctxt = false
-			st.inMsgctxt = false
-			st.inMsgid = false
+			st.inMsgid = true
 			st.inMsgstr = false
 			st.inMsgidPlural = false
 			st.currentPluralIndex = -1
-			st.inObsolete = false
-			st.hasSeenMsgstr = false
+			// Preserve st.msgctxtValue and st.hasMsgctxt so finishCurrentEntry will set MsgCtxt.
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "#~ ") {
+			st.inObsolete = true
+		}
+		// Always record this line as the msgid line for the current entry (avoids reusing a stale value when we kept same entry above).
+		st.msgidStartLineNo = line1Based
+		st.inMsgctxt = false
+		st.inMsgid = true
+		value := strings.TrimPrefix(trimmed, "msgid ")
+		value = strings.TrimSpace(value)
+		value = strDeQuote(value)
+		st.msgidValue.WriteString(value)
 
-		default:
-			log.Warnf("unrecognized PO line at %d: %s", line1Based, line)
+	case poLineMsgidPlural:
+		st.inMsgid = false
+		st.inMsgidPlural = true
+		value := strings.TrimPrefix(trimmed, "msgid_plural ")
+		value = strings.TrimSpace(value)
+		value = strDeQuote(value)
+		st.msgidPluralValue.WriteString(value)
+
+	case poLineMsgstrN:
+		st.inMsgid = false
+		st.inMsgidPlural = false
+		st.inMsgstr = true
+		st.hasSeenMsgstr = true
+		idxStr := strings.TrimPrefix(trimmed, "msgstr[")
+		idxStr = strings.Split(idxStr, "]")[0]
+		var idx int
+		_, _ = fmt.Sscanf(idxStr, "%d", &idx)
+		for len(st.msgstrPluralValues) <= idx {
+			st.msgstrPluralValues = append(st.msgstrPluralValues, strings.Builder{})
+		}
+		st.currentPluralIndex = idx
+		value := strings.TrimPrefix(trimmed, fmt.Sprintf("msgstr[%d] ", idx))
+		value = strings.TrimSpace(value)
+		value = strDeQuote(value)
+		st.msgstrPluralValues[idx].WriteString(value)
+
+	case poLineMsgstr:
+		st.inMsgid = false
+		st.inMsgidPlural = false
+		st.inMsgstr = true
+		st.hasSeenMsgstr = true
+		value := strings.TrimPrefix(trimmed, "msgstr ")
+		value = strings.TrimSpace(value)
+		value = strDeQuote(value)
+		st.msgstrValue.WriteString(value)
+
+	case poLineQuotedString:
+		if st.inMsgctxt || st.inMsgid || st.inMsgstr || st.inMsgidPlural {
+			value := strDeQuote(trimmed)
+			if st.inMsgctxt {
+				st.msgctxtValue.WriteString(value)
+			} else if st.inMsgid {
+				st.msgidValue.WriteString(value)
+			} else if st.inMsgidPlural {
+				st.msgidPluralValue.WriteString(value)
+			} else if st.inMsgstr {
+				if st.currentPluralIndex >= 0 {
+					st.msgstrPluralValues[st.currentPluralIndex].WriteString(value)
+				} else {
+					st.msgstrValue.WriteString(value)
+				}
+			}
+		} else {
+			log.Warnf("unrecognized PO line at %d (quoted string outside context): %s", line1Based, line)
 		}
-	}
 
-	finishCurrentEntry(st, &entries)
-	headerEntry := BuildHeaderEntryFromLines(st.headerLines)
-	entriesVal := make([]GettextEntry, len(entries))
-	for i, e := range entries {
-		entriesVal[i] = *e
+	case poLineBlank:
+		// Ignore meaningless blank lines: between comments and msgid, or between msgid and msgstr.
+		if st.currentEntry != nil && st.msgidValue.Len() == 0 {
+			// Comments only (no msgid yet); keep comments with the following msgid.
+			return
+		}
+		if st.currentEntry != nil && st.msgidValue.Len() > 0 && !st.hasSeenMsgstr {
+			// Have msgid but no msgstr line yet; blank between msgid and msgstr.
+			return
+		}
+		finishCurrentEntry(st, entries)
+		st.currentEntry = nil
+		st.msgctxtValue.Reset()
+		st.msgidValue.Reset()
+		st.msgstrValue.Reset()
+		st.msgidPluralValue.Reset()
+		st.msgstrPluralValues = nil
+		st.hasMsgctxt = false
+		st.inMsgctxt = false
+		st.inMsgid = false
+		st.inMsgstr = false
+		st.inMsgidPlural = false
+		st.currentPluralIndex = -1
+		st.inObsolete = false
+		st.hasSeenMsgstr = false
+
+	default:
+		log.Warnf("unrecognized PO line at %d: %s", line1Based, line)
 	}
-	return &GettextPO{HeaderEntry: headerEntry, Entries: entriesVal}, nil
 }
 
 // entryHasFuzzyFlag returns true if any comment in the entry has the fuzzy flag.
@@ -1171,107 +1190,127 @@ func ParseEntryRange(spec string, maxEntry int) ([]int, error) {
 	return result, nil
 }
 
-// MsgSelect reads a PO/POT file, selects entries by state filter and range,
-// and writes the result to w. Entry 0 (header) is included when content entries
-// are selected, unless noHeader is true. If filter is nil, DefaultFilter() is used.
-// Range applies to the filtered entry list (1 = first matching, etc.).
-func MsgSelect(poFile, rangeSpec string, w io.Writer, noHeader bool, filter *EntryStateFilter) error {
-	data, err := os.ReadFile(poFile)
-	if err != nil {
-		return fmt.Errorf("failed to read %s: %w", poFile, err)
-	}
-
-	po, err := ParsePoEntries(data)
-	if err != nil {
-		return fmt.Errorf("failed to parse %s: %w", poFile, err)
-	}
-
+// forEachSelectedPoEntry streams poFile, selects entries by state filter, content
+// selector (may be nil) and range, and calls fn for each selected entry in file order.
+// Range applies to the filtered entry list, so the file is read twice: once to count
+// matching entries (needed for "N-" and "~N"), once to select them. start is called
+// with the header before the first selected entry, and not at all when no entry is
+// selected.
+func forEachSelectedPoEntry(poFile, rangeSpec string, filter *EntryStateFilter, sel *EntrySelector,
+	start func(header *GettextPO) error, fn func(e *GettextEntry) error) error {
 	f := DefaultFilter()
 	if filter != nil {
 		f = *filter
 	}
+	match := func(e *GettextEntry) bool {
+		return MatchGettextEntryState(*e, f) && sel.Match(e)
+	}
 
-	// Filter by state first
-	filtered := FilterGettextEntries(po.Entries, f)
-	maxEntry := len(filtered)
+	maxEntry := 0
+	err := forEachPoFileEntry(poFile, nil, func(e *GettextEntry) error {
+		if match(e) {
+			maxEntry++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
 	indices, err := ParseEntryRange(rangeSpec, maxEntry)
 	if err != nil {
 		return fmt.Errorf("invalid range %q: %w", rangeSpec, err)
 	}
-
-	// Map range indices to filtered entries
-	var selected []*GettextEntry
-	for _, idx := range indices {
-		if idx > 0 && idx <= len(filtered) {
-			selected = append(selected, &filtered[idx-1])
-		}
-	}
-
-	// If no content entries, output empty
-	if len(selected) == 0 {
+	if len(indices) == 0 {
 		return nil
 	}
 
-	// Write header (unless skipped)
-	if !noHeader {
-		for _, line := range po.HeaderLines() {
-			if _, err := io.WriteString(w, line); err != nil {
+	var header *GettextPO
+	matched := 0
+	return forEachPoFileEntry(poFile, func(h *GettextPO) error {
+		header = h
+		return nil
+	}, func(e *GettextEntry) error {
+		if len(indices) == 0 || !match(e) {
+			return nil
+		}
+		matched++
+		if matched != indices[0] {
+			return nil
+		}
+		if header != nil {
+			if err := start(header); err != nil {
 				return err
 			}
-			if !strings.HasSuffix(line, "\n") {
-				if _, err := io.WriteString(w, "\n"); err != nil {
-					return err
-				}
-			}
-		}
-		if _, err := io.WriteString(w, "\n"); err != nil {
-			return err
+			header = nil
 		}
+		indices = indices[1:]
+		return fn(e)
+	})
+}
+
+// forEachPoFileEntry reads poFile with a PoReader, calls header (if not nil) with
+// the header and fn for every content entry.
+func forEachPoFileEntry(poFile string, header func(*GettextPO) error, fn func(e *GettextEntry) error) error {
+	file, err := os.Open(poFile)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", poFile, err)
 	}
+	defer file.Close()
 
-	// Write selected entries (from fields via writeGettextEntryToPO)
-	for i, entry := range selected {
-		if err := writeGettextEntryToPO(w, *entry); err != nil {
+	pr := NewPoReader(file)
+	if header != nil {
+		po, err := pr.HeaderPO()
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", poFile, err)
+		}
+		if err := header(po); err != nil {
 			return err
 		}
-		if i < len(selected)-1 {
-			if _, err := io.WriteString(w, "\n"); err != nil {
-				return err
-			}
+	}
+	for {
+		e, err := pr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", poFile, err)
+		}
+		if err := fn(e); err != nil {
+			return err
 		}
 	}
+}
 
-	return nil
+// MsgSelect reads a PO/POT file, selects entries by state filter and range,
+// and writes the result to w. Entry 0 (header) is included when content entries
+// are selected, unless noHeader is true. If filter is nil, DefaultFilter() is used.
+// Range applies to the filtered entry list (1 = first matching, etc.).
+// The file is streamed, so memory use does not grow with the catalog size.
+func MsgSelect(poFile, rangeSpec string, w io.Writer, noHeader bool, filter *EntryStateFilter) error {
+	pw := NewPoWriter(w)
+	return forEachSelectedPoEntry(poFile, rangeSpec, filter, nil, func(header *GettextPO) error {
+		if noHeader {
+			return nil
+		}
+		return pw.WriteHeader(header.HeaderLines())
+	}, pw.WriteEntry)
 }
 
 // WriteGettextJSONFromPOFile reads a PO/POT file, selects entries by state filter and range,
 // and writes a single JSON object to w. If filter is nil, DefaultFilter() is used.
+// Nothing is written when no entry is selected. The file is streamed like MsgSelect.
 func WriteGettextJSONFromPOFile(poFile, rangeSpec string, w io.Writer, filter *EntryStateFilter) error {
-	data, err := os.ReadFile(poFile)
-	if err != nil {
-		return fmt.Errorf("failed to read %s: %w", poFile, err)
-	}
-	po, err := ParsePoEntries(data)
-	if err != nil {
-		return fmt.Errorf("failed to parse %s: %w", poFile, err)
-	}
-	j := GettextJSONFromGettextPO(po)
-	f := DefaultFilter()
-	if filter != nil {
-		f = *filter
-	}
-	entriesSlice := j.Entries
-	filtered := FilterGettextEntries(entriesSlice, f)
-	maxEntry := len(filtered)
-	indices, err := ParseEntryRange(rangeSpec, maxEntry)
-	if err != nil {
-		return fmt.Errorf("invalid range %q: %w", rangeSpec, err)
-	}
-	var selected []*GettextEntry
-	for _, idx := range indices {
-		if idx > 0 && idx <= len(filtered) {
-			selected = append(selected, &filtered[idx-1])
-		}
+	var jw *GettextJSONWriter
+	err := forEachSelectedPoEntry(poFile, rangeSpec, filter, nil, func(header *GettextPO) error {
+		j := GettextJSONFromGettextPO(header)
+		var err error
+		jw, err = NewGettextJSONWriter(w, j.HeaderComment, j.HeaderMeta)
+		return err
+	}, func(e *GettextEntry) error {
+		return jw.WriteEntry(e)
+	})
+	if err != nil || jw == nil {
+		return err
 	}
-	return BuildGettextJSON(j.HeaderComment, j.HeaderMeta, selected, w)
+	return jw.Close()
 }
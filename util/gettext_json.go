@@ -7,13 +7,24 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
+	"github.com/git-l10n/git-po-helper/flag"
 	log "github.com/sirupsen/logrus"
 	"github.com/tidwall/gjson"
 )
 
+// Output formats of msg-select and msg-cat (option "--format").
+const (
+	OutputFormatPO    = "po"
+	OutputFormatJSON  = "json"
+	OutputFormatXLIFF = "xliff"
+	OutputFormatCSV   = "csv"
+	OutputFormatTSV   = "tsv"
+)
+
 // GettextJSON is the top-level structure for msg-select --json output.
 type GettextJSON struct {
 	HeaderComment string         `json:"header_comment"`
@@ -165,7 +176,7 @@ func WriteGettextJSONToJSON(j *GettextJSON, w io.Writer, indent ...bool) error {
 	enc := json.NewEncoder(w)
 	enc.SetEscapeHTML(false)
 	if doIndent {
-		enc.SetIndent("", "  ")
+		enc = newGettextJSONEncoder(w, "")
 	}
 	if err := enc.Encode(j); err != nil {
 		return fmt.Errorf("encode gettext JSON: %w", err)
@@ -173,6 +184,24 @@ func WriteGettextJSONToJSON(j *GettextJSON, w io.Writer, indent ...bool) error {
 	return nil
 }
 
+// newGettextJSONEncoder returns the encoder for indented gettext JSON output.
+// prefix is used by GettextJSONWriter to indent entries inside the "entries" array.
+func newGettextJSONEncoder(w io.Writer, prefix string) *json.Encoder {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent(prefix, "  ")
+	return enc
+}
+
+// jsonStringNoEscapeHTML encodes s as a JSON string without HTML escaping.
+func jsonStringNoEscapeHTML(s string) string {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
 // parseGettextJSONWithGjson parses gettext JSON using gjson, which can tolerate
 // some malformed LLM output (e.g. missing colons). Returns nil if parsing fails.
 func parseGettextJSONWithGjson(data []byte, err error) *GettextJSON {
@@ -282,6 +311,13 @@ func ParseGettextJSONBytes(data []byte) (*GettextJSON, error) {
 	if len(bytes.TrimSpace(data)) == 0 {
 		return &GettextJSON{Entries: []GettextEntry{}}, nil
 	}
+	switch version := gettextJSONVersion(data); version {
+	case GettextJSONVersion1:
+	case GettextJSONVersion2:
+		return parseGettextJSONV2(data)
+	default:
+		return nil, fmt.Errorf("unsupported gettext JSON version %d", version)
+	}
 	var out GettextJSON
 	if err := json.Unmarshal(data, &out); err != nil {
 		prepared := PrepareJSONForParse(data, err)
@@ -359,30 +395,10 @@ func entryKey(e GettextEntry) string {
 
 // MergeGettextJSON merges multiple GettextJSON sources. Header is taken from the first source.
 // For entries, the first occurrence of each msgid (and msgid_plural for plurals) wins by file order.
+// See MergeGettextJSONPrefer for other strategies and the report of conflicting translations.
 func MergeGettextJSON(sources []*GettextJSON) *GettextJSON {
-	if len(sources) == 0 {
-		return &GettextJSON{}
-	}
-	seen := make(map[string]bool)
-	var merged []GettextEntry
-	for _, j := range sources {
-		if j == nil {
-			continue
-		}
-		for _, e := range j.Entries {
-			k := entryKey(e)
-			if seen[k] {
-				continue
-			}
-			seen[k] = true
-			merged = append(merged, e)
-		}
-	}
-	return &GettextJSON{
-		HeaderComment: sources[0].HeaderComment,
-		HeaderMeta:    sources[0].HeaderMeta,
-		Entries:       merged,
-	}
+	merged, _ := MergeGettextJSONPrefer(sources, nil, MergePreferFirst)
+	return merged
 }
 
 // ClearFuzzyTagFromGettextJSON clears only the fuzzy marker from all entries.
@@ -441,18 +457,33 @@ func ClearFuzzyFromGettextJSON(j *GettextJSON) {
 	}
 }
 
-// LoadFileToGettextJSON loads file data (PO, POT, or gettext JSON) into GettextJSON.
-// Format is detected by content (starts with '{' after trim). Used by ReadFileToGettextJSON,
+// LoadFileToGettextJSON loads file data (PO, POT, gettext JSON, XLIFF, or .mo) into GettextJSON.
+// Format is detected by content (starts with '{' after trim, an <xliff> document, or the
+// .mo magic number). Used by ReadFileToGettextJSON,
 // stat, and compare. For JSON parse failure, returns FormatGettextJSONParseError.
 // Empty or whitespace-only file yields empty GettextJSON (msg-select/msg-cat safe).
 func LoadFileToGettextJSON(data []byte, path string) (*GettextJSON, error) {
+	if IsMoData(data) {
+		j, err := ParseMo(data)
+		if err != nil {
+			return nil, fmt.Errorf("parse MO %s: %w", path, err)
+		}
+		return j, nil
+	}
 	trimmed := bytes.TrimLeft(data, " \t\r\n")
 	if len(trimmed) == 0 {
 		return &GettextJSON{Entries: []GettextEntry{}}, nil
 	}
-	if trimmed[0] == '{' {
+	if IsGettextJSONData(data) {
 		return ParseGettextJSONBytesForCompare(data, path)
 	}
+	if IsXLIFFData(data) {
+		j, err := ParseXLIFF(data)
+		if err != nil {
+			return nil, fmt.Errorf("parse XLIFF %s: %w", path, err)
+		}
+		return j, nil
+	}
 	// PO/POT
 	po, err := ParsePoEntries(data)
 	if err != nil {
@@ -461,6 +492,12 @@ func LoadFileToGettextJSON(data []byte, path string) (*GettextJSON, error) {
 	return GettextJSONFromGettextPO(po), nil
 }
 
+// IsGettextJSONData returns true if data looks like gettext JSON (starts with '{' after whitespace).
+func IsGettextJSONData(data []byte) bool {
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '{'
+}
+
 // GettextJSONFromEntries builds GettextJSON from header and entries (strips fuzzy from comments).
 func GettextJSONFromEntries(headerComment, headerMeta string, entries []*GettextEntry) *GettextJSON {
 	entriesForJSON := make([]GettextEntry, 0, len(entries))
@@ -484,8 +521,8 @@ func GettextJSONFromEntries(headerComment, headerMeta string, entries []*Gettext
 	}
 }
 
-// ReadFileToGettextJSON reads a single file (PO, POT, or gettext JSON) and returns GettextJSON.
-// Format is detected by content (starts with '{' after whitespace).
+// ReadFileToGettextJSON reads a single file (PO, POT, gettext JSON, XLIFF, or .mo) and returns GettextJSON.
+// Format is detected by content (see LoadFileToGettextJSON).
 func ReadFileToGettextJSON(path string) (*GettextJSON, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -497,23 +534,50 @@ func ReadFileToGettextJSON(path string) (*GettextJSON, error) {
 // MsgSelectFromFile implements the 3-step flow: Load → Filter → Save.
 // 1. Load: reads PO or JSON file into GettextJSON (format auto-detected).
 // 2. Filter: applies EntryStateFilter and range spec to the loaded data.
-// 3. Save: writes filtered result as JSON (useJSON) or PO (noHeader for PO output).
+// A PO/POT file is streamed instead (see forEachSelectedPoEntry), so that only
+// the selected entries are held in memory.
+// 3. Save: writes filtered result in format (OutputFormatPO, OutputFormatJSON,
+// OutputFormatXLIFF, OutputFormatCSV or OutputFormatTSV); noHeader omits the
+// header from PO and XLIFF output (CSV/TSV never have it).
 // If filter is nil, DefaultFilter() is used. When no content entries match, nothing is
 // written (empty file for both JSON and PO output).
 // inputWasPO: when true, PO output matches MsgSelect format (trailing newline after last entry); when false, matches WriteGettextJSONToPO format.
 // unsetFuzzy: remove fuzzy marker from entries, keep translations. clearFuzzy: remove fuzzy marker and clear msgstr for fuzzy entries.
-func MsgSelectFromFile(path, rangeSpec string, w io.Writer, useJSON, noHeader, inputWasPO bool, unsetFuzzy, clearFuzzy bool, filter *EntryStateFilter) error {
+// sel: when not nil, only entries matching its content selectors are kept, before the range applies.
+func MsgSelectFromFile(path, rangeSpec string, w io.Writer, format string, noHeader, inputWasPO bool, unsetFuzzy, clearFuzzy bool, filter *EntryStateFilter, sel *EntrySelector) error {
+	if inputWasPO {
+		isPO, err := isPoFile(path)
+		if err != nil {
+			return err
+		}
+		if isPO {
+			out, err := selectGettextJSONFromPoFile(path, rangeSpec, filter, sel)
+			if err != nil {
+				return err
+			}
+			return writeSelectedEntries(out, w, path, format, noHeader, inputWasPO, unsetFuzzy, clearFuzzy)
+		}
+	}
 	// Step 1: Load from PO or JSON
 	j, err := ReadFileToGettextJSON(path)
 	if err != nil {
 		return err
 	}
-	// Step 2: Filter by state and range
+	// Step 2: Filter by state, content and range
 	f := DefaultFilter()
 	if filter != nil {
 		f = *filter
 	}
 	filtered := FilterGettextEntries(j.Entries, f)
+	if !sel.IsEmpty() {
+		var matched []GettextEntry
+		for i := range filtered {
+			if sel.Match(&filtered[i]) {
+				matched = append(matched, filtered[i])
+			}
+		}
+		filtered = matched
+	}
 	maxEntry := len(filtered)
 	indices, err := ParseEntryRange(rangeSpec, maxEntry)
 	if err != nil {
@@ -530,18 +594,82 @@ func MsgSelectFromFile(path, rangeSpec string, w io.Writer, useJSON, noHeader, i
 		HeaderMeta:    j.HeaderMeta,
 		Entries:       selected,
 	}
+	// Step 3: Save in requested format
+	return writeSelectedEntries(out, w, path, format, noHeader, inputWasPO, unsetFuzzy, clearFuzzy)
+}
+
+// isPoFile returns true if path is a PO/POT file, and not gettext JSON,
+// XLIFF or .mo (see LoadFileToGettextJSON).
+func isPoFile(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, fmt.Errorf("read %s: %w", path, err)
+	}
+	defer f.Close()
+	peek := make([]byte, 1024)
+	n, err := io.ReadFull(f, peek)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return false, fmt.Errorf("read %s: %w", path, err)
+	}
+	peek = peek[:n]
+	return !IsMoData(peek) && !IsGettextJSONData(peek) && !IsXLIFFData(peek), nil
+}
+
+// selectGettextJSONFromPoFile streams the PO/POT file path and returns its header
+// with the entries selected by filter, sel and rangeSpec, like MsgSelectFromFile
+// does for a loaded file.
+func selectGettextJSONFromPoFile(path, rangeSpec string, filter *EntryStateFilter, sel *EntrySelector) (*GettextJSON, error) {
+	var (
+		header   *GettextPO
+		selected []*GettextEntry
+	)
+	err := forEachSelectedPoEntry(path, rangeSpec, filter, sel, func(h *GettextPO) error {
+		header = h
+		return nil
+	}, func(e *GettextEntry) error {
+		selected = append(selected, e)
+		return nil
+	})
+	if err != nil || header == nil {
+		return &GettextJSON{Entries: []GettextEntry{}}, err
+	}
+	j := GettextJSONFromGettextPO(header)
+	return GettextJSONFromEntries(j.HeaderComment, j.HeaderMeta, selected), nil
+}
+
+// writeSelectedEntries applies unsetFuzzy and clearFuzzy to the entries selected
+// by MsgSelectFromFile and writes them, or nothing when no entry is selected.
+func writeSelectedEntries(out *GettextJSON, w io.Writer, path, format string, noHeader, inputWasPO, unsetFuzzy, clearFuzzy bool) error {
 	if unsetFuzzy {
 		ClearFuzzyTagFromGettextJSON(out)
 	}
 	if clearFuzzy {
 		ClearFuzzyFromGettextJSON(out)
 	}
-	// Step 3: Save in requested format
-	if len(selected) == 0 {
+	if len(out.Entries) == 0 {
 		return nil // No content entries: write nothing (empty output file)
 	}
-	if useJSON {
+	return writeSelectedGettextJSON(out, w, path, format, noHeader, inputWasPO)
+}
+
+// writeSelectedGettextJSON writes the entries selected from the file path by
+// msg-select or msg-grep in format (see MsgSelectFromFile).
+func writeSelectedGettextJSON(out *GettextJSON, w io.Writer, path, format string, noHeader, inputWasPO bool) error {
+	switch format {
+	case OutputFormatJSON:
+		if flag.GettextJSONVersion() == GettextJSONVersion2 {
+			return WriteGettextJSONToJSONV2(out, w)
+		}
 		return WriteGettextJSONToJSON(out, w)
+	case OutputFormatXLIFF:
+		if noHeader {
+			out.HeaderComment, out.HeaderMeta = "", ""
+		}
+		return WriteGettextJSONToXLIFF(out, w, flag.XLIFFVersion(), filepath.Base(path))
+	case OutputFormatCSV:
+		return WriteGettextJSONToTable(out, w, ',')
+	case OutputFormatTSV:
+		return WriteGettextJSONToTable(out, w, '\t')
 	}
 	return WriteGettextJSONToPO(out, w, noHeader, inputWasPO)
 }
@@ -666,82 +794,9 @@ func WriteGettextJSONToPO(j *GettextJSON, w io.Writer, noHeader, addTrailingNewl
 		}
 	}
 	for ei, entry := range j.Entries {
-		wroteFuzzyFlag := false
-		commentPrefix := ""
-		if entry.Obsolete {
-			commentPrefix = "#~ "
-		}
-		for _, c := range entry.Comments {
-			trimmed := strings.TrimSpace(c)
-			prefix := commentPrefix
-			if prefix != "" && (strings.HasPrefix(trimmed, "#~| ") || strings.HasPrefix(trimmed, "#| ")) {
-				prefix = ""
-			}
-			if strings.HasPrefix(trimmed, "#,") {
-				line := MergeFuzzyIntoFlagLine(c, entry.Fuzzy)
-				if entry.Fuzzy {
-					wroteFuzzyFlag = true
-				}
-				if _, err := io.WriteString(w, prefix+line+"\n"); err != nil {
-					return err
-				}
-			} else if strings.HasPrefix(trimmed, "#~| ") || strings.HasPrefix(trimmed, "#| ") {
-				linePrefix := "#| "
-				if strings.HasPrefix(trimmed, "#~| ") {
-					linePrefix = "#~| "
-				}
-				rest := trimmed[len(linePrefix):]
-				if err := writePreviousLineToPO(w, linePrefix, rest); err != nil {
-					return err
-				}
-			} else {
-				if _, err := io.WriteString(w, prefix+c); err != nil {
-					return err
-				}
-				if !strings.HasSuffix(c, "\n") {
-					if _, err := io.WriteString(w, "\n"); err != nil {
-						return err
-					}
-				}
-			}
-		}
-		if entry.Fuzzy && !wroteFuzzyFlag {
-			if _, err := io.WriteString(w, "#, fuzzy\n"); err != nil {
-				return err
-			}
-		}
-		prefix := ""
-		if entry.Obsolete {
-			prefix = "#~ "
-		}
-		if entry.MsgCtxt != nil {
-			if err := writePoStringWithPrefix(w, prefix, "msgctxt", *entry.MsgCtxt); err != nil {
-				return err
-			}
-		}
-		if err := writePoStringWithPrefix(w, prefix, "msgid", entry.MsgID); err != nil {
+		if err := writeGettextEntryToPO(w, entry); err != nil {
 			return err
 		}
-		if entry.MsgIDPlural != "" {
-			if err := writePoStringWithPrefix(w, prefix, "msgid_plural", entry.MsgIDPlural); err != nil {
-				return err
-			}
-		}
-		if len(entry.MsgStr) > 1 || (entry.MsgIDPlural != "" && len(entry.MsgStr) > 0) {
-			for i, s := range entry.MsgStr {
-				if err := writePoStringWithPrefix(w, prefix, "msgstr["+strconv.Itoa(i)+"]", s); err != nil {
-					return err
-				}
-			}
-		} else if len(entry.MsgStr) == 1 {
-			if err := writePoStringWithPrefix(w, prefix, "msgstr", entry.MsgStr[0]); err != nil {
-				return err
-			}
-		} else {
-			if err := writePoStringWithPrefix(w, prefix, "msgstr", ""); err != nil {
-				return err
-			}
-		}
 		if ei < len(j.Entries)-1 {
 			if _, err := io.WriteString(w, "\n"); err != nil {
 				return err
@@ -787,8 +842,12 @@ func writePreviousLineToPO(w io.Writer, linePrefix, rest string) error {
 	return err
 }
 
-// writeGettextEntryToPO writes a single GettextEntry as PO content (used by noHeader path).
+// writeGettextEntryToPO writes a single GettextEntry as PO content. With --keep-layout,
+// an unmodified entry is written with its original lines (see GettextEntry.RawLines).
 func writeGettextEntryToPO(w io.Writer, entry GettextEntry) error {
+	if kept, err := writeRawLinesIfUnchanged(w, &entry); kept || err != nil {
+		return err
+	}
 	wroteFuzzyFlag := false
 	commentPrefix := ""
 	if entry.Obsolete {
@@ -809,6 +868,13 @@ func writeGettextEntryToPO(w io.Writer, entry GettextEntry) error {
 				return err
 			}
 		} else if strings.HasPrefix(trimmed, "#~| ") || strings.HasPrefix(trimmed, "#| ") {
+			// Without a "#," line, "#, fuzzy" goes before the previous msgid like gettext writes it.
+			if entry.Fuzzy && !wroteFuzzyFlag && !hasFlagLine(entry.Comments) {
+				if _, err := io.WriteString(w, "#, fuzzy\n"); err != nil {
+					return err
+				}
+				wroteFuzzyFlag = true
+			}
 			// #| and #~| lines: parse and re-output with proper poEscape so JSON roundtrip
 			// (which may store values with JSON escaping) produces valid PO.
 			linePrefix := "#| "
@@ -870,9 +936,23 @@ func writeGettextEntryToPO(w io.Writer, entry GettextEntry) error {
 	return nil
 }
 
+// hasFlagLine returns true if comments have a "#," line.
+func hasFlagLine(comments []string) bool {
+	for _, c := range comments {
+		if strings.HasPrefix(strings.TrimSpace(c), "#,") {
+			return true
+		}
+	}
+	return false
+}
+
 // writePoStringWithPrefix writes a keyword and value with optional prefix (e.g. "#~ " for obsolete).
 // Value is in PO format; multi-line uses literal \n (backslash+n) as separator.
+// With --width, long lines are also wrapped like msgcat (see writeWrappedPoString).
 func writePoStringWithPrefix(w io.Writer, prefix, keyword, value string) error {
+	if width := flag.PoWrapWidth(); width > 0 {
+		return writeWrappedPoString(w, prefix, keyword, value, width)
+	}
 	parts := strings.Split(value, "\\n")
 	if len(parts) == 1 {
 		_, err := io.WriteString(w, prefix+keyword+" \""+poEscape(value)+"\"\n")
@@ -218,7 +218,7 @@ func TestReadFileToGettextJSON_EmptyFile(t *testing.T) {
 	}
 	// MsgSelectFromFile should not error (output empty per empty-selection behavior)
 	var buf bytes.Buffer
-	if err := MsgSelectFromFile(emptyPath, "1-", &buf, true, false, false, false, false, nil); err != nil {
+	if err := MsgSelectFromFile(emptyPath, "1-", &buf, OutputFormatJSON, false, false, false, false, nil, nil); err != nil {
 		t.Fatalf("MsgSelectFromFile on empty JSON: %v", err)
 	}
 }
@@ -238,14 +238,14 @@ msgstr "一"
 	}
 	// Range selects nothing
 	var bufJSON bytes.Buffer
-	if err := MsgSelectFromFile(poPath, "10-20", &bufJSON, true, false, true, false, false, nil); err != nil {
+	if err := MsgSelectFromFile(poPath, "10-20", &bufJSON, OutputFormatJSON, false, true, false, false, nil, nil); err != nil {
 		t.Fatalf("MsgSelectFromFile JSON: %v", err)
 	}
 	if bufJSON.Len() != 0 {
 		t.Errorf("JSON output should be empty when no entries selected, got %d bytes", bufJSON.Len())
 	}
 	var bufPO bytes.Buffer
-	if err := MsgSelectFromFile(poPath, "10-20", &bufPO, false, false, true, false, false, nil); err != nil {
+	if err := MsgSelectFromFile(poPath, "10-20", &bufPO, OutputFormatPO, false, true, false, false, nil, nil); err != nil {
 		t.Fatalf("MsgSelectFromFile PO: %v", err)
 	}
 	if bufPO.Len() != 0 {
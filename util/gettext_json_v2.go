@@ -0,0 +1,143 @@
+// Package util provides version 2 of the gettext JSON schema with structured comments.
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/tidwall/gjson"
+)
+
+// Versions of the gettext JSON schema. Version 1 has no "version" field and
+// keeps comments as raw lines with strings in PO format (e.g. "\\n"). Version
+// 2 has "version": 2, plain text strings (PO escapes decoded) and the comment
+// lines split into the fields of EntryComments.
+const (
+	GettextJSONVersion1 = 1
+	GettextJSONVersion2 = 2
+)
+
+// gettextEntryV2 is one element of "entries" in gettext JSON version 2.
+type gettextEntryV2 struct {
+	MsgCtxt     *string  `json:"msgctxt,omitempty"`
+	MsgID       string   `json:"msgid"`
+	MsgIDPlural string   `json:"msgid_plural,omitempty"`
+	MsgStr      []string `json:"msgstr"`
+	EntryComments
+	Fuzzy    bool `json:"fuzzy"`
+	Obsolete bool `json:"obsolete,omitempty"`
+}
+
+// gettextJSONV2 is the top-level object of gettext JSON version 2.
+type gettextJSONV2 struct {
+	Version       int              `json:"version"`
+	HeaderComment string           `json:"header_comment"`
+	HeaderMeta    string           `json:"header_meta"`
+	Entries       []gettextEntryV2 `json:"entries"`
+}
+
+// gettextEntryToV2 converts an entry (strings in PO format) to version 2.
+func gettextEntryToV2(e *GettextEntry) gettextEntryV2 {
+	out := gettextEntryV2{
+		MsgID:         poUnescape(e.MsgID),
+		MsgIDPlural:   poUnescape(e.MsgIDPlural),
+		MsgStr:        make([]string, len(e.MsgStr)),
+		EntryComments: e.ParsedComments(),
+		Fuzzy:         e.Fuzzy,
+		Obsolete:      e.Obsolete,
+	}
+	if e.MsgCtxt != nil {
+		s := poUnescape(*e.MsgCtxt)
+		out.MsgCtxt = &s
+	}
+	for i, s := range e.MsgStr {
+		out.MsgStr[i] = poUnescape(s)
+	}
+	if p := out.Previous; p != nil {
+		prev := PreviousMsg{MsgID: poUnescape(p.MsgID), MsgIDPlural: poUnescape(p.MsgIDPlural)}
+		if p.MsgCtxt != nil {
+			s := poUnescape(*p.MsgCtxt)
+			prev.MsgCtxt = &s
+		}
+		out.Previous = &prev
+	}
+	return out
+}
+
+// gettextEntryFromV2 converts a version 2 entry back to GettextEntry (strings in PO format).
+func gettextEntryFromV2(v gettextEntryV2) GettextEntry {
+	e := GettextEntry{
+		MsgID:       jsonDecodedToPoFormat(v.MsgID),
+		MsgIDPlural: jsonDecodedToPoFormat(v.MsgIDPlural),
+		Fuzzy:       v.Fuzzy,
+		Obsolete:    v.Obsolete,
+	}
+	if v.MsgCtxt != nil {
+		s := jsonDecodedToPoFormat(*v.MsgCtxt)
+		e.MsgCtxt = &s
+	}
+	for _, s := range v.MsgStr {
+		e.MsgStr = append(e.MsgStr, jsonDecodedToPoFormat(s))
+	}
+	c := v.EntryComments
+	if p := c.Previous; p != nil {
+		prev := PreviousMsg{MsgID: jsonDecodedToPoFormat(p.MsgID), MsgIDPlural: jsonDecodedToPoFormat(p.MsgIDPlural)}
+		if p.MsgCtxt != nil {
+			s := jsonDecodedToPoFormat(*p.MsgCtxt)
+			prev.MsgCtxt = &s
+		}
+		c.Previous = &prev
+	}
+	e.SetParsedComments(c)
+	return e
+}
+
+// WriteGettextJSONToJSONV2 writes j as indented gettext JSON version 2.
+func WriteGettextJSONToJSONV2(j *GettextJSON, w io.Writer) error {
+	if j == nil {
+		j = &GettextJSON{}
+	}
+	out := gettextJSONV2{
+		Version:       GettextJSONVersion2,
+		HeaderComment: j.HeaderComment,
+		HeaderMeta:    poUnescape(j.HeaderMeta),
+		Entries:       make([]gettextEntryV2, 0, len(j.Entries)),
+	}
+	for i := range j.Entries {
+		out.Entries = append(out.Entries, gettextEntryToV2(&j.Entries[i]))
+	}
+	if err := newGettextJSONEncoder(w, "").Encode(&out); err != nil {
+		return fmt.Errorf("encode gettext JSON: %w", err)
+	}
+	return nil
+}
+
+// gettextJSONVersion returns the "version" field of gettext JSON data, or
+// GettextJSONVersion1 if there is none.
+func gettextJSONVersion(data []byte) int64 {
+	if v := gjson.GetBytes(data, "version"); v.Exists() {
+		return v.Int()
+	}
+	return GettextJSONVersion1
+}
+
+// parseGettextJSONV2 decodes gettext JSON version 2. Malformed JSON is
+// repaired with PrepareJSONForParse like version 1.
+func parseGettextJSONV2(data []byte) (*GettextJSON, error) {
+	var v gettextJSONV2
+	if err := json.Unmarshal(data, &v); err != nil {
+		if err2 := json.Unmarshal(PrepareJSONForParse(data, err), &v); err2 != nil {
+			return nil, fmt.Errorf("decode gettext JSON: %w", err)
+		}
+	}
+	out := &GettextJSON{
+		HeaderComment: v.HeaderComment,
+		HeaderMeta:    jsonDecodedToPoFormat(v.HeaderMeta),
+		Entries:       make([]GettextEntry, 0, len(v.Entries)),
+	}
+	for _, e := range v.Entries {
+		out.Entries = append(out.Entries, gettextEntryFromV2(e))
+	}
+	return out, nil
+}
@@ -0,0 +1,123 @@
+package util
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const gettextJSONV2TestPO = `# Chinese translations for Git package
+#
+msgid ""
+msgstr ""
+"Project-Id-Version: git\n"
+"Content-Type: text/plain; charset=UTF-8\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+# Translator note
+#. TRANSLATORS: keep "%s"
+#: builtin/add.c:42 builtin/add.c
+#, c-format
+#= wrap
+msgid "add '%s'\n"
+msgstr "添加 '%s'\n"
+
+#, fuzzy, c-format
+#| msgctxt "old"
+#| msgid "%d old file"
+#| msgid_plural "%d old files"
+msgctxt "verb"
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] "%d 个文件"
+msgstr[1] "%d 个文件们"
+
+#~ # Obsolete note
+#~| msgid "older"
+#~ msgid "old"
+#~ msgstr "旧\t的"
+`
+
+func TestGettextJSONV2RoundTrip(t *testing.T) {
+	po, err := ParsePoEntries([]byte(gettextJSONV2TestPO))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wantPO, b bytes.Buffer
+	if err := WriteGettextJSONToPO(GettextJSONFromGettextPO(po), &wantPO, false, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteGettextJSONToJSONV2(GettextJSONFromGettextPO(po), &b); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`"version": 2,`,
+		`"header_meta": "Project-Id-Version: git\nContent-Type: text/plain; charset=UTF-8\nPlural-Forms: nplurals=2; plural=(n != 1);\n",`,
+		`"msgid": "add '%s'\n",`,
+		`"translator_comments": [
+        "Translator note"
+      ],`,
+		`"extracted_comments": [
+        "TRANSLATORS: keep \"%s\""
+      ],`,
+		`"references": [
+        {
+          "file": "builtin/add.c",
+          "line": 42
+        },
+        {
+          "file": "builtin/add.c"
+        }
+      ],`,
+		`"other_comments": [
+        "#= wrap"
+      ],`,
+		`"previous": {
+        "msgctxt": "old",
+        "msgid": "%d old file",
+        "msgid_plural": "%d old files"
+      },
+      "fuzzy": true`,
+		`"msgstr": [
+        "旧\t的"
+      ],`,
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("JSON v2 output does not contain:\n%s\noutput:\n%s", want, b.String())
+		}
+	}
+	if strings.Contains(b.String(), `"comments"`) || strings.Contains(b.String(), `"fuzzy",`) {
+		t.Errorf("JSON v2 output has raw comments or a fuzzy flag:\n%s", b.String())
+	}
+
+	j, err := ParseGettextJSONBytes(b.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := WriteGettextJSONToPO(j, &out, false, false); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != wantPO.String() {
+		t.Errorf("PO -> JSON v2 -> PO differs:\n%s", out.String())
+	}
+}
+
+func TestParseGettextJSONBytes_Version(t *testing.T) {
+	j, err := ParseGettextJSONBytes([]byte(`{"version": 2, "header_meta": "Language: de\n",
+"entries": [{"msgid": "Tab\there", "msgstr": ["Tab\thier"], "flags": ["c-format"],
+"references": [{"file": "a.c", "line": 3}], "fuzzy": true}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := j.Entries[0]
+	if j.HeaderMeta != `Language: de\n` || e.MsgID != `Tab\there` || e.MsgStr[0] != `Tab\thier` || !e.Fuzzy ||
+		!equalStrings(e.Comments, []string{"#: a.c:3", "#, c-format"}) {
+		t.Errorf("got %+v, entry %+v", j, e)
+	}
+
+	if _, err := ParseGettextJSONBytes([]byte(`{"version": 3, "entries": []}`)); err == nil ||
+		!strings.Contains(err.Error(), "unsupported gettext JSON version 3") {
+		t.Errorf("version 3: err = %v", err)
+	}
+}
@@ -0,0 +1,607 @@
+// Package util provides a spell checker reading Hunspell dictionaries.
+package util
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Hunspell is a spell checker using the .aff and .dic files of a Hunspell
+// dictionary. It supports a subset of Hunspell, enough for the common
+// dictionaries: prefixes and suffixes with cross products, flag aliases (AF),
+// simple compounds (COMPOUNDFLAG, COMPOUNDBEGIN, COMPOUNDMIDDLE,
+// COMPOUNDEND, ONLYINCOMPOUND, COMPOUNDMIN), NEEDAFFIX, FORBIDDENWORD and
+// KEEPCASE, and suggestions with REP and TRY. Dictionaries are in UTF-8 or
+// ISO8859-1.
+type Hunspell struct {
+	flagMode string          // "char" (default), "long", "num" or "UTF-8"
+	aliases  []hunspellFlags // AF flag vectors, from 1
+	words    map[string][]hunspellFlags
+	prefixes map[string][]*hunspellAffix // by added text
+	suffixes map[string][]*hunspellAffix // by added text
+	rep      [][2]string
+	try      string
+
+	needAffix      string
+	forbidden      string
+	keepCase       string
+	onlyInCompound string
+	compoundFlag   string
+	compoundBegin  string
+	compoundMiddle string
+	compoundEnd    string
+	compoundMin    int
+}
+
+// hunspellFlags are the flags of a word of the dictionary.
+type hunspellFlags map[string]bool
+
+// hunspellAffix is a rule of a PFX or SFX class.
+type hunspellAffix struct {
+	flag   string
+	cross  bool
+	strip  string
+	add    string
+	cond   *regexp.Regexp
+	suffix bool
+}
+
+// hunspellSuggestionsMax is the number of suggestions of Suggest.
+const hunspellSuggestionsMax = 4
+
+// LoadHunspell reads the Hunspell dictionary of the files base.aff and
+// base.dic.
+func LoadHunspell(base string) (*Hunspell, error) {
+	h := &Hunspell{
+		flagMode:    "char",
+		words:       make(map[string][]hunspellFlags),
+		prefixes:    make(map[string][]*hunspellAffix),
+		suffixes:    make(map[string][]*hunspellAffix),
+		compoundMin: 3,
+	}
+	aff, err := os.ReadFile(base + ".aff")
+	if err != nil {
+		return nil, err
+	}
+	encoding, err := h.parseAff(aff)
+	if err != nil {
+		return nil, fmt.Errorf("%s.aff: %w", base, err)
+	}
+	dic, err := os.ReadFile(base + ".dic")
+	if err != nil {
+		return nil, err
+	}
+	if err := h.parseDic(hunspellDecode(dic, encoding)); err != nil {
+		return nil, fmt.Errorf("%s.dic: %w", base, err)
+	}
+	return h, nil
+}
+
+// hunspellEncoding returns the encoding of the SET line of aff.
+func hunspellEncoding(aff []byte) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(aff))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "SET" {
+			switch enc := strings.ToUpper(fields[1]); enc {
+			case "UTF-8", "ISO8859-1", "ISO-8859-1":
+				return enc, nil
+			default:
+				return "", fmt.Errorf("unsupported encoding %q (use UTF-8 or ISO8859-1)", fields[1])
+			}
+		}
+	}
+	return "ISO8859-1", nil
+}
+
+// hunspellDecode returns data in encoding as UTF-8.
+func hunspellDecode(data []byte, encoding string) []byte {
+	if encoding == "UTF-8" {
+		return data
+	}
+	var b bytes.Buffer
+	for _, c := range data {
+		b.WriteRune(rune(c))
+	}
+	return b.Bytes()
+}
+
+// parseFlags returns the flags of a word, or the flag vector of an alias
+// number of AF.
+func (h *Hunspell) parseFlags(s string) hunspellFlags {
+	if len(h.aliases) > 0 {
+		if n, err := strconv.Atoi(s); err == nil && n > 0 && n <= len(h.aliases) {
+			return h.aliases[n-1]
+		}
+	}
+	return h.splitFlags(s)
+}
+
+// splitFlags splits flags in the format of FLAG.
+func (h *Hunspell) splitFlags(s string) hunspellFlags {
+	flags := make(hunspellFlags)
+	switch h.flagMode {
+	case "long":
+		runes := []rune(s)
+		for i := 0; i+1 < len(runes); i += 2 {
+			flags[string(runes[i:i+2])] = true
+		}
+	case "num":
+		for _, f := range strings.Split(s, ",") {
+			if f = strings.TrimSpace(f); f != "" {
+				flags[f] = true
+			}
+		}
+	default:
+		for _, r := range s {
+			flags[string(r)] = true
+		}
+	}
+	return flags
+}
+
+// parseFlag returns the first flag of s, for the options of the .aff file.
+func (h *Hunspell) parseFlag(s string) string {
+	switch h.flagMode {
+	case "long":
+		if runes := []rune(s); len(runes) >= 2 {
+			return string(runes[:2])
+		}
+	case "num":
+		return strings.Split(s, ",")[0]
+	default:
+		if r, _ := utf8.DecodeRuneInString(s); r != utf8.RuneError {
+			return string(r)
+		}
+	}
+	return s
+}
+
+// hunspellCondition compiles the condition of an affix rule, which uses a
+// subset of the syntax of regexps: ".", "[...]" and "[^...]".
+func hunspellCondition(cond string, suffix bool) (*regexp.Regexp, error) {
+	if cond == "." {
+		return nil, nil
+	}
+	var b strings.Builder
+	inClass := false
+	for _, r := range cond {
+		switch {
+		case r == '[':
+			inClass = true
+			b.WriteRune(r)
+		case r == ']':
+			inClass = false
+			b.WriteRune(r)
+		case r == '.' || r == '^' && inClass:
+			b.WriteRune(r)
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	if suffix {
+		return regexp.Compile("(?:" + b.String() + ")$")
+	}
+	return regexp.Compile("^(?:" + b.String() + ")")
+}
+
+// parseAff reads the options and affixes of the .aff file, and returns its
+// encoding.
+func (h *Hunspell) parseAff(data []byte) (string, error) {
+	encoding, err := hunspellEncoding(data)
+	if err != nil {
+		return "", err
+	}
+	data = hunspellDecode(data, encoding)
+
+	affixHeaders := make(map[string]bool) // "SFX A", with the cross product
+	aliasesCount := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "FLAG":
+			h.flagMode = fields[1]
+		case "TRY":
+			h.try = fields[1]
+		case "AF":
+			// The first line is the number of aliases.
+			if !aliasesCount {
+				aliasesCount = true
+				continue
+			}
+			h.aliases = append(h.aliases, h.splitFlags(fields[1]))
+		case "REP":
+			if len(fields) >= 3 {
+				h.rep = append(h.rep, [2]string{
+					strings.ReplaceAll(fields[1], "_", " "),
+					strings.ReplaceAll(fields[2], "_", " "),
+				})
+			}
+		case "NEEDAFFIX":
+			h.needAffix = h.parseFlag(fields[1])
+		case "FORBIDDENWORD":
+			h.forbidden = h.parseFlag(fields[1])
+		case "KEEPCASE":
+			h.keepCase = h.parseFlag(fields[1])
+		case "ONLYINCOMPOUND":
+			h.onlyInCompound = h.parseFlag(fields[1])
+		case "COMPOUNDFLAG":
+			h.compoundFlag = h.parseFlag(fields[1])
+		case "COMPOUNDBEGIN":
+			h.compoundBegin = h.parseFlag(fields[1])
+		case "COMPOUNDMIDDLE":
+			h.compoundMiddle = h.parseFlag(fields[1])
+		case "COMPOUNDEND":
+			h.compoundEnd = h.parseFlag(fields[1])
+		case "COMPOUNDMIN":
+			if n, err := strconv.Atoi(fields[1]); err == nil && n > 0 {
+				h.compoundMin = n
+			}
+		case "PFX", "SFX":
+			if len(fields) < 4 {
+				return "", fmt.Errorf("line %d: bad affix %q", lineNo, line)
+			}
+			key := fields[0] + " " + fields[1]
+			cross, ok := affixHeaders[key]
+			if !ok {
+				// Header: SFX flag cross_product count
+				affixHeaders[key] = fields[2] == "Y"
+				continue
+			}
+			if len(fields) < 5 {
+				fields = append(fields, ".")
+			}
+			affix := &hunspellAffix{
+				flag:   h.parseFlag(fields[1]),
+				cross:  cross,
+				strip:  fields[2],
+				add:    fields[3],
+				suffix: fields[0] == "SFX",
+			}
+			if affix.strip == "0" {
+				affix.strip = ""
+			}
+			// Continuation classes of twofold affixes are not supported.
+			if i := strings.Index(affix.add, "/"); i >= 0 {
+				affix.add = affix.add[:i]
+			}
+			if affix.add == "0" {
+				affix.add = ""
+			}
+			if affix.cond, err = hunspellCondition(fields[4], affix.suffix); err != nil {
+				return "", fmt.Errorf("line %d: bad condition %q: %w", lineNo, fields[4], err)
+			}
+			if affix.suffix {
+				h.suffixes[affix.add] = append(h.suffixes[affix.add], affix)
+			} else {
+				h.prefixes[affix.add] = append(h.prefixes[affix.add], affix)
+			}
+		}
+	}
+	return encoding, scanner.Err()
+}
+
+// parseDic reads the words of the .dic file.
+func (h *Hunspell) parseDic(data []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		// The first line is the number of words.
+		if lineNo == 1 {
+			if _, err := strconv.Atoi(line); err == nil {
+				continue
+			}
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// Morphological fields follow a tab or a space.
+		if i := strings.IndexAny(line, "\t "); i >= 0 {
+			line = line[:i]
+		}
+		word, flags := line, ""
+		for i := 0; i < len(line); i++ {
+			if line[i] == '\\' {
+				i++
+			} else if line[i] == '/' {
+				word, flags = line[:i], line[i+1:]
+				break
+			}
+		}
+		word = strings.ReplaceAll(word, `\/`, "/")
+		h.words[word] = append(h.words[word], h.parseFlags(flags))
+	}
+	return scanner.Err()
+}
+
+// AddWord adds word to the dictionary, e.g. from a personal word list.
+func (h *Hunspell) AddWord(word string) {
+	h.words[word] = append(h.words[word], hunspellFlags{})
+}
+
+// lookup returns true if word is in the dictionary with flags accepted by
+// match, and is not forbidden.
+func (h *Hunspell) lookup(word string, match func(hunspellFlags) bool) bool {
+	for _, flags := range h.words[word] {
+		if h.forbidden != "" && flags[h.forbidden] {
+			return false
+		}
+	}
+	for _, flags := range h.words[word] {
+		if match(flags) {
+			return true
+		}
+	}
+	return false
+}
+
+// isForbidden returns true for a FORBIDDENWORD of the dictionary.
+func (h *Hunspell) isForbidden(word string) bool {
+	if h.forbidden == "" {
+		return false
+	}
+	for _, flags := range h.words[word] {
+		if flags[h.forbidden] {
+			return true
+		}
+	}
+	return false
+}
+
+// stripAffixes returns true if word is a word of the dictionary with a
+// prefix, a suffix or both, for which root accepts the flags of the word.
+func (h *Hunspell) stripAffixes(word string, root func(hunspellFlags) bool) bool {
+	for i := 0; i <= len(word); i++ {
+		if i < len(word) && !utf8.RuneStart(word[i]) {
+			continue
+		}
+		for _, sfx := range h.suffixes[word[i:]] {
+			stem := word[:i] + sfx.strip
+			if stem == "" || sfx.cond != nil && !sfx.cond.MatchString(stem) {
+				continue
+			}
+			if h.lookup(stem, func(flags hunspellFlags) bool {
+				return flags[sfx.flag] && root(flags)
+			}) {
+				return true
+			}
+			if !sfx.cross {
+				continue
+			}
+			// Cross product of a prefix and a suffix.
+			for j := 0; j <= len(stem); j++ {
+				if j < len(stem) && !utf8.RuneStart(stem[j]) {
+					continue
+				}
+				for _, pfx := range h.prefixes[stem[:j]] {
+					base := pfx.strip + stem[j:]
+					if !pfx.cross || base == "" || pfx.cond != nil && !pfx.cond.MatchString(base) {
+						continue
+					}
+					if h.lookup(base, func(flags hunspellFlags) bool {
+						return flags[sfx.flag] && flags[pfx.flag] && root(flags)
+					}) {
+						return true
+					}
+				}
+			}
+		}
+	}
+	for j := 1; j <= len(word); j++ {
+		if j < len(word) && !utf8.RuneStart(word[j]) {
+			continue
+		}
+		for _, pfx := range h.prefixes[word[:j]] {
+			base := pfx.strip + word[j:]
+			if base == "" || pfx.cond != nil && !pfx.cond.MatchString(base) {
+				continue
+			}
+			if h.lookup(base, func(flags hunspellFlags) bool {
+				return flags[pfx.flag] && root(flags)
+			}) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkRoot returns true if word is in the dictionary, with or without
+// affixes.
+func (h *Hunspell) checkRoot(word string) bool {
+	if h.lookup(word, func(flags hunspellFlags) bool {
+		return !flags[h.needAffix] && !flags[h.onlyInCompound]
+	}) {
+		return true
+	}
+	return h.stripAffixes(word, func(flags hunspellFlags) bool {
+		return !flags[h.onlyInCompound]
+	})
+}
+
+// checkCompoundPart returns true if word, with or without affixes, may be a
+// part of compound words, with COMPOUNDFLAG or with positionFlag
+// (COMPOUNDBEGIN, COMPOUNDMIDDLE or COMPOUNDEND).
+func (h *Hunspell) checkCompoundPart(word, positionFlag string) bool {
+	inCompound := func(flags hunspellFlags) bool {
+		return h.compoundFlag != "" && flags[h.compoundFlag] ||
+			positionFlag != "" && flags[positionFlag]
+	}
+	if h.lookup(word, func(flags hunspellFlags) bool {
+		return inCompound(flags) && !flags[h.needAffix]
+	}) {
+		return true
+	}
+	return h.stripAffixes(word, inCompound)
+}
+
+// checkCompound returns true if word is made of words of the dictionary
+// which may be the parts of compound words.
+func (h *Hunspell) checkCompound(word string, first bool, depth int) bool {
+	if depth > 5 {
+		return false
+	}
+	runes := []rune(word)
+	for i := h.compoundMin; i <= len(runes)-h.compoundMin; i++ {
+		part, rest := string(runes[:i]), string(runes[i:])
+		positionFlag := h.compoundMiddle
+		if first {
+			positionFlag = h.compoundBegin
+		}
+		if !h.checkCompoundPart(part, positionFlag) {
+			continue
+		}
+		if h.checkCompoundPart(rest, h.compoundEnd) || h.checkCompound(rest, false, depth+1) {
+			return true
+		}
+	}
+	return false
+}
+
+// check returns true if word, in the case it has, is correct.
+func (h *Hunspell) check(word string) bool {
+	if h.isForbidden(word) {
+		return false
+	}
+	if h.checkRoot(word) {
+		return true
+	}
+	if h.compoundFlag != "" || h.compoundBegin != "" {
+		return h.checkCompound(word, true, 0)
+	}
+	return false
+}
+
+// Spell returns true if word is correct. Capitalized and upper case words
+// are also correct if their lower case or capitalized forms are, unless the
+// dictionary keeps the case of these forms (KEEPCASE).
+func (h *Hunspell) Spell(word string) bool {
+	if h.check(word) {
+		return true
+	}
+	var forms []string
+	lower := strings.ToLower(word)
+	if strings.ToUpper(word) == word {
+		forms = []string{capitalize(lower), lower}
+	} else if isCapitalized(word) {
+		forms = []string{lower}
+	}
+	for _, form := range forms {
+		if form != word && !h.keepsCase(form) && h.check(form) {
+			return true
+		}
+	}
+	return false
+}
+
+// keepsCase returns true for a word with the flag KEEPCASE, which is not
+// correct in other cases.
+func (h *Hunspell) keepsCase(word string) bool {
+	return h.keepCase != "" && h.lookup(word, func(flags hunspellFlags) bool {
+		return flags[h.keepCase]
+	})
+}
+
+// capitalize returns s with its first letter in upper case.
+func capitalize(s string) string {
+	r, n := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[n:]
+}
+
+// isCapitalized returns true for "Word", with only the first letter in
+// upper case.
+func isCapitalized(s string) bool {
+	r, n := utf8.DecodeRuneInString(s)
+	return unicode.IsUpper(r) && strings.ToLower(s[n:]) == s[n:]
+}
+
+// Suggest returns correct words close to word: with the replacements of
+// REP, with one letter removed, added or changed (letters of TRY), or two
+// letters swapped, or split in two words.
+func (h *Hunspell) Suggest(word string) []string {
+	var (
+		suggestions []string
+		seen        = map[string]bool{word: true}
+	)
+	add := func(s string) bool {
+		if seen[s] {
+			return false
+		}
+		seen[s] = true
+		for _, w := range strings.Fields(s) {
+			if !h.Spell(w) {
+				return false
+			}
+		}
+		if isCapitalized(word) && !isCapitalized(s) {
+			s = capitalize(s)
+		}
+		suggestions = append(suggestions, s)
+		return len(suggestions) >= hunspellSuggestionsMax
+	}
+
+	for _, rep := range h.rep {
+		for i := 0; i < len(word); {
+			j := strings.Index(word[i:], rep[0])
+			if j < 0 {
+				break
+			}
+			j += i
+			if add(word[:j] + rep[1] + word[j+len(rep[0]):]) {
+				return suggestions
+			}
+			i = j + 1
+		}
+	}
+
+	runes := []rune(word)
+	try := h.try
+	if try == "" {
+		try = "abcdefghijklmnopqrstuvwxyz"
+	}
+	for i := 0; i+1 < len(runes); i++ {
+		swapped := append([]rune(nil), runes...)
+		swapped[i], swapped[i+1] = swapped[i+1], swapped[i]
+		if add(string(swapped)) {
+			return suggestions
+		}
+	}
+	for i := range runes {
+		if add(string(runes[:i]) + string(runes[i+1:])) {
+			return suggestions
+		}
+	}
+	for _, r := range try {
+		for i := 0; i <= len(runes); i++ {
+			if i < len(runes) && runes[i] != r {
+				if add(string(runes[:i]) + string(r) + string(runes[i+1:])) {
+					return suggestions
+				}
+			}
+			if add(string(runes[:i]) + string(r) + string(runes[i:])) {
+				return suggestions
+			}
+		}
+	}
+	for i := 1; i < len(runes); i++ {
+		if add(string(runes[:i]) + " " + string(runes[i:])) {
+			return suggestions
+		}
+	}
+	return suggestions
+}
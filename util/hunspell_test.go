@@ -0,0 +1,165 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeHunspell writes the dictionary base.aff and base.dic in dir.
+func writeHunspell(t *testing.T, dir, base, aff, dic string) string {
+	t.Helper()
+	base = filepath.Join(dir, base)
+	if err := os.WriteFile(base+".aff", []byte(aff), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(base+".dic", []byte(dic), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return base
+}
+
+const testHunspellAff = `# Test dictionary
+SET UTF-8
+TRY esianrtolcdugmphbyfvkwz
+FORBIDDENWORD X
+KEEPCASE K
+NEEDAFFIX N
+COMPOUNDFLAG C
+
+REP 1
+REP ie ei
+
+PFX U Y 1
+PFX U 0 un .
+
+SFX S Y 2
+SFX S y ies [^aeiou]y
+SFX S 0 s [^y]
+
+SFX D N 2
+SFX D 0 d e
+SFX D 0 ed [^e]
+`
+
+const testHunspellDic = `12
+branch/S
+commit/SD
+entry/S
+receive/D
+recieve/X
+happy/U
+lock/USD
+sure/N
+sure/NU
+Git/K
+work/C
+tree/CS
+`
+
+func TestHunspellSpell(t *testing.T) {
+	h, err := LoadHunspell(writeHunspell(t, t.TempDir(), "en", testHunspellAff, testHunspellDic))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for word, want := range map[string]bool{
+		"branch":    true,
+		"Branch":    true,
+		"BRANCH":    true,
+		"bRANCH":    false,
+		"branchs":   true, // SFX S
+		"entries":   true, // SFX S with strip
+		"entrys":    false,
+		"received":  true, // SFX D, condition "e"
+		"commited":  true, // SFX D, condition "[^e]"
+		"commitd":   false,
+		"unhappy":   true, // PFX U
+		"unlocks":   true, // cross product
+		"unlocked":  false,
+		"sure":      false, // NEEDAFFIX
+		"unsure":    true,
+		"recieve":   false, // FORBIDDENWORD
+		"Git":       true,
+		"GIT":       false, // KEEPCASE
+		"git":       false,
+		"worktree":  true, // compound
+		"worktrees": true,
+		"treework":  true,
+		"workbench": false,
+	} {
+		if got := h.Spell(word); got != want {
+			t.Errorf("Spell(%q) = %v, want %v", word, got, want)
+		}
+	}
+
+	h.AddWord("rebase")
+	if !h.Spell("rebase") || !h.Spell("Rebase") {
+		t.Error("personal word not accepted")
+	}
+}
+
+func TestHunspellSuggest(t *testing.T) {
+	h, err := LoadHunspell(writeHunspell(t, t.TempDir(), "en", testHunspellAff, testHunspellDic))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for word, want := range map[string][]string{
+		"recieve":    {"receive"}, // REP
+		"brnach":     {"branch"},  // swap
+		"Comit":      {"Commit"},  // insert, capitalized
+		"entyr":      {"entry"},
+		"committ":    {"commit", "commits"}, // delete, replace
+		"branchtree": {"branch tree"},       // split
+		"xyzzy":      nil,
+	} {
+		if got := h.Suggest(word); !reflect.DeepEqual(got, want) {
+			t.Errorf("Suggest(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
+
+func TestHunspellFormats(t *testing.T) {
+	dir := t.TempDir()
+
+	// Long flags and flag aliases.
+	h, err := LoadHunspell(writeHunspell(t, dir, "long", `SET UTF-8
+FLAG long
+AF 2
+AF AaBb
+AF Bb
+SFX Aa Y 1
+SFX Aa 0 s .
+PFX Bb Y 1
+PFX Bb 0 re .
+`, `2
+load/1
+make/2
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for word, want := range map[string]bool{
+		"loads":   true,
+		"reloads": true,
+		"remake":  true,
+		"makes":   false,
+	} {
+		if got := h.Spell(word); got != want {
+			t.Errorf("long flags: Spell(%q) = %v, want %v", word, got, want)
+		}
+	}
+
+	// ISO8859-1 dictionary.
+	h, err = LoadHunspell(writeHunspell(t, dir, "fr", "SET ISO8859-1\n", "1\ncaf\xe9\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !h.Spell("café") {
+		t.Error(`ISO8859-1: "café" not found`)
+	}
+
+	if _, err := LoadHunspell(writeHunspell(t, dir, "ru", "SET KOI8-R\n", "0\n")); err == nil {
+		t.Error("expected an error for an unsupported encoding")
+	}
+}
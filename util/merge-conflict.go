@@ -0,0 +1,224 @@
+// Package util provides conflict resolution for merging catalogs in msg-cat.
+package util
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Strategies to choose among entries of the same msgctxt, msgid and
+// msgid_plural in msg-cat inputs (option "--prefer").
+const (
+	// MergePreferFirst keeps the first occurrence by file order.
+	MergePreferFirst = "first"
+	// MergePreferLast keeps the last occurrence by file order.
+	MergePreferLast = "last"
+	// MergePreferTranslated keeps the first occurrence with a translation,
+	// fuzzy or not.
+	MergePreferTranslated = "translated"
+	// MergePreferNonFuzzy keeps the first translated occurrence that is not
+	// fuzzy, or else the first translated one.
+	MergePreferNonFuzzy = "non-fuzzy"
+	// MergePreferLongest keeps the occurrence with the longest translation.
+	MergePreferLongest = "longest"
+)
+
+// MergePreferStrategies are the valid values of "--prefer".
+var MergePreferStrategies = []string{
+	MergePreferFirst,
+	MergePreferLast,
+	MergePreferTranslated,
+	MergePreferNonFuzzy,
+	MergePreferLongest,
+}
+
+// IsMergePreferStrategy returns true if s is one of MergePreferStrategies.
+func IsMergePreferStrategy(s string) bool {
+	return containsString(MergePreferStrategies, s)
+}
+
+// MergeCandidate is one translation of a conflicting entry. Strings are plain
+// text (PO escapes decoded).
+type MergeCandidate struct {
+	Source string   `json:"source"`
+	MsgStr []string `json:"msgstr"`
+	Fuzzy  bool     `json:"fuzzy,omitempty"`
+	Chosen bool     `json:"chosen,omitempty"`
+}
+
+// MergeConflict is an entry that has different translations in the inputs.
+// Inputs where the entry is untranslated do not conflict.
+type MergeConflict struct {
+	MsgCtxt     *string          `json:"msgctxt,omitempty"`
+	MsgID       string           `json:"msgid"`
+	MsgIDPlural string           `json:"msgid_plural,omitempty"`
+	Candidates  []MergeCandidate `json:"candidates"`
+}
+
+// mergeOccurrence is an entry of one input.
+type mergeOccurrence struct {
+	source int
+	entry  GettextEntry
+}
+
+// MergeGettextJSONPrefer merges sources like MergeGettextJSON, but chooses
+// among the entries of the same key with the strategy prefer (one of
+// MergePreferStrategies). Entries keep the position of their first
+// occurrence. It also returns the entries whose translations differ between
+// the sources; names are the names of the sources used in the report.
+func MergeGettextJSONPrefer(sources []*GettextJSON, names []string, prefer string) (*GettextJSON, []MergeConflict) {
+	if len(sources) == 0 {
+		return &GettextJSON{}, nil
+	}
+	var keys []string
+	occurrences := make(map[string][]mergeOccurrence)
+	for i, j := range sources {
+		if j == nil {
+			continue
+		}
+		for _, e := range j.Entries {
+			k := entryKey(e)
+			if _, ok := occurrences[k]; !ok {
+				keys = append(keys, k)
+			}
+			occurrences[k] = append(occurrences[k], mergeOccurrence{source: i, entry: e})
+		}
+	}
+	merged := make([]GettextEntry, 0, len(keys))
+	var conflicts []MergeConflict
+	for _, k := range keys {
+		occ := occurrences[k]
+		chosen := chooseMergeOccurrence(occ, prefer)
+		merged = append(merged, occ[chosen].entry)
+		if c := mergeConflictOf(occ, chosen, names); c != nil {
+			conflicts = append(conflicts, *c)
+		}
+	}
+	return &GettextJSON{
+		HeaderComment: sources[0].HeaderComment,
+		HeaderMeta:    sources[0].HeaderMeta,
+		Entries:       merged,
+	}, conflicts
+}
+
+// chooseMergeOccurrence returns the index in occ of the entry chosen by prefer.
+func chooseMergeOccurrence(occ []mergeOccurrence, prefer string) int {
+	switch prefer {
+	case MergePreferLast:
+		return len(occ) - 1
+	case MergePreferTranslated:
+		for i := range occ {
+			if isEntryTranslated(&occ[i].entry) {
+				return i
+			}
+		}
+	case MergePreferNonFuzzy:
+		for i := range occ {
+			if isEntryTranslated(&occ[i].entry) && !occ[i].entry.Fuzzy {
+				return i
+			}
+		}
+		return chooseMergeOccurrence(occ, MergePreferTranslated)
+	case MergePreferLongest:
+		best, bestLen := 0, -1
+		for i := range occ {
+			n := 0
+			for _, s := range occ[i].entry.MsgStr {
+				n += utf8.RuneCountInString(poUnescape(s))
+			}
+			if n > bestLen {
+				best, bestLen = i, n
+			}
+		}
+		return best
+	}
+	return 0
+}
+
+// mergeConflictOf returns the conflict of the occurrences of one key, or nil
+// if all translated occurrences have the same msgstr.
+func mergeConflictOf(occ []mergeOccurrence, chosen int, names []string) *MergeConflict {
+	var first []string
+	conflict := false
+	for i := range occ {
+		e := &occ[i].entry
+		if !isEntryTranslated(e) {
+			continue
+		}
+		if first == nil {
+			first = e.MsgStr
+		} else if !equalStrings(first, e.MsgStr) {
+			conflict = true
+		}
+	}
+	if !conflict {
+		return nil
+	}
+	e := occ[chosen].entry
+	c := &MergeConflict{MsgID: poUnescape(e.MsgID), MsgIDPlural: poUnescape(e.MsgIDPlural)}
+	if e.MsgCtxt != nil {
+		s := poUnescape(*e.MsgCtxt)
+		c.MsgCtxt = &s
+	}
+	for i, o := range occ {
+		if !isEntryTranslated(&o.entry) && i != chosen {
+			continue
+		}
+		name := fmt.Sprintf("#%d", o.source+1)
+		if o.source < len(names) {
+			name = names[o.source]
+		}
+		msgstr := make([]string, len(o.entry.MsgStr))
+		for k, s := range o.entry.MsgStr {
+			msgstr[k] = poUnescape(s)
+		}
+		c.Candidates = append(c.Candidates, MergeCandidate{
+			Source: name,
+			MsgStr: msgstr,
+			Fuzzy:  o.entry.Fuzzy,
+			Chosen: i == chosen,
+		})
+	}
+	return c
+}
+
+// WriteMergeConflicts writes conflicts as text: one line per entry, then one
+// indented line per translation, marking the chosen one.
+func WriteMergeConflicts(w io.Writer, conflicts []MergeConflict) error {
+	for _, c := range conflicts {
+		line := fmt.Sprintf("conflict: msgid %q", c.MsgID)
+		if c.MsgCtxt != nil {
+			line += fmt.Sprintf(" (msgctxt %q)", *c.MsgCtxt)
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+		for _, cand := range c.Candidates {
+			quoted := make([]string, len(cand.MsgStr))
+			for i, s := range cand.MsgStr {
+				quoted[i] = fmt.Sprintf("%q", s)
+			}
+			line := fmt.Sprintf("    %s: %s", cand.Source, strings.Join(quoted, " | "))
+			if cand.Fuzzy {
+				line += " (fuzzy)"
+			}
+			if cand.Chosen {
+				line += " [chosen]"
+			}
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteMergeConflictsJSON writes conflicts as an indented JSON array.
+func WriteMergeConflictsJSON(w io.Writer, conflicts []MergeConflict) error {
+	if conflicts == nil {
+		conflicts = []MergeConflict{}
+	}
+	return newGettextJSONEncoder(w, "").Encode(conflicts)
+}
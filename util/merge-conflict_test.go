@@ -0,0 +1,93 @@
+package util
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMergeGettextJSONPrefer(t *testing.T) {
+	a := &GettextJSON{
+		HeaderMeta: "H: A\\n",
+		Entries: []GettextEntry{
+			{MsgID: "one", MsgStr: []string{"uno"}},
+			{MsgID: "two", MsgStr: []string{""}},
+			{MsgID: "three", MsgStr: []string{"tre?"}, Fuzzy: true},
+		},
+	}
+	b := &GettextJSON{
+		HeaderMeta: "H: B\\n",
+		Entries: []GettextEntry{
+			{MsgID: "four", MsgStr: []string{"quattro"}},
+			{MsgID: "one", MsgStr: []string{"UNO"}},
+			{MsgID: "two", MsgStr: []string{"due"}},
+			{MsgID: "three", MsgStr: []string{"tre"}},
+		},
+	}
+	c := &GettextJSON{
+		Entries: []GettextEntry{
+			{MsgID: "one", MsgStr: []string{"uno"}},
+			{MsgID: "three", MsgStr: []string{"tre, ecco"}, Fuzzy: true},
+		},
+	}
+	tests := []struct {
+		prefer string
+		want   []string // msgstr of one, two, three, four
+	}{
+		{MergePreferFirst, []string{"uno", "", "tre?", "quattro"}},
+		{MergePreferLast, []string{"uno", "due", "tre, ecco", "quattro"}},
+		{MergePreferTranslated, []string{"uno", "due", "tre?", "quattro"}},
+		{MergePreferNonFuzzy, []string{"uno", "due", "tre", "quattro"}},
+		{MergePreferLongest, []string{"uno", "due", "tre, ecco", "quattro"}},
+	}
+	for _, tt := range tests {
+		merged, conflicts := MergeGettextJSONPrefer([]*GettextJSON{a, b, c}, []string{"a.po", "b.po", "c.po"}, tt.prefer)
+		if merged.HeaderMeta != "H: A\\n" {
+			t.Errorf("%s: header from first: got %q", tt.prefer, merged.HeaderMeta)
+		}
+		var got, ids []string
+		for _, e := range merged.Entries {
+			ids = append(ids, e.MsgID)
+			got = append(got, e.MsgStrSingle())
+		}
+		if !equalStrings(ids, []string{"one", "two", "three", "four"}) {
+			t.Errorf("%s: entries %q, want order of first occurrence", tt.prefer, ids)
+		}
+		if !equalStrings(got, tt.want) {
+			t.Errorf("%s: msgstr %q, want %q", tt.prefer, got, tt.want)
+		}
+		// "two" is untranslated in a.po, which does not conflict.
+		if len(conflicts) != 2 || conflicts[0].MsgID != "one" || conflicts[1].MsgID != "three" {
+			t.Fatalf("%s: conflicts %+v", tt.prefer, conflicts)
+		}
+	}
+
+	_, conflicts := MergeGettextJSONPrefer([]*GettextJSON{a, b, c}, []string{"a.po", "b.po", "c.po"}, MergePreferNonFuzzy)
+	var buf bytes.Buffer
+	if err := WriteMergeConflicts(&buf, conflicts); err != nil {
+		t.Fatal(err)
+	}
+	want := `conflict: msgid "one"
+    a.po: "uno" [chosen]
+    b.po: "UNO"
+    c.po: "uno"
+conflict: msgid "three"
+    a.po: "tre?" (fuzzy)
+    b.po: "tre" [chosen]
+    c.po: "tre, ecco" (fuzzy)
+`
+	if got := buf.String(); got != want {
+		t.Errorf("conflict report:\n%s\nwant:\n%s", got, want)
+	}
+	if conflicts[0].Candidates[0].Chosen != true {
+		t.Errorf("first occurrence of \"one\" should be chosen: %+v", conflicts[0])
+	}
+
+	buf.Reset()
+	if err := WriteMergeConflictsJSON(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("empty JSON report: %q", buf.String())
+	}
+}
@@ -0,0 +1,350 @@
+// Package util provides a .mo (GNU gettext binary catalog) reader and writer.
+package util
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	// moMagic is the magic number of a .mo file in the byte order it was written.
+	moMagic = 0x950412de
+	// moHeaderSize is the size of the fixed .mo header (7 uint32 fields).
+	moHeaderSize = 28
+	// moContextSeparator separates msgctxt from msgid in .mo keys.
+	moContextSeparator = "\x04"
+	// moSegmentsEnd ends the segment list of a system dependent string.
+	moSegmentsEnd = 0xffffffff
+)
+
+// moMessage is one key/value pair of a .mo file. Plural forms are joined with NUL.
+type moMessage struct {
+	key, value string
+}
+
+// moMessagesFromGettextPO returns the messages to write to a .mo file, sorted by key,
+// like msgfmt: the header entry, fuzzy or not, and every non-obsolete entry whose
+// msgstr (msgstr[0] of a plural entry) is not empty. Fuzzy entries are only
+// included when useFuzzy is true, like msgfmt --use-fuzzy.
+func moMessagesFromGettextPO(po *GettextPO, useFuzzy bool) []moMessage {
+	var msgs []moMessage
+	if len(po.HeaderEntry.MsgStr) > 0 && po.HeaderEntry.MsgStr[0] != "" {
+		msgs = append(msgs, moMessage{key: "", value: poUnescape(po.HeaderEntry.MsgStr[0])})
+	}
+	for _, e := range po.Entries {
+		if e.Obsolete || (e.Fuzzy && !useFuzzy) || len(e.MsgStr) == 0 || e.MsgStr[0] == "" {
+			continue
+		}
+		key := poUnescape(e.MsgID)
+		if e.MsgCtxt != nil {
+			key = poUnescape(*e.MsgCtxt) + moContextSeparator + key
+		}
+		var value string
+		if e.MsgIDPlural != "" {
+			key += "\x00" + poUnescape(e.MsgIDPlural)
+			var b bytes.Buffer
+			for i, s := range e.MsgStr {
+				if i > 0 {
+					b.WriteByte(0)
+				}
+				b.WriteString(poUnescape(s))
+			}
+			value = b.String()
+		} else {
+			value = poUnescape(e.MsgStrSingle())
+		}
+		msgs = append(msgs, moMessage{key: key, value: value})
+	}
+	sort.Slice(msgs, func(i, j int) bool { return msgs[i].key < msgs[j].key })
+	return msgs
+}
+
+// WriteMo writes po as a little-endian .mo file to w, with a hash table for
+// fast lookup (same layout as msgfmt output).
+func WriteMo(po *GettextPO, w io.Writer, useFuzzy bool) error {
+	if po == nil {
+		po = &GettextPO{}
+	}
+	msgs := moMessagesFromGettextPO(po, useFuzzy)
+	n := uint32(len(msgs))
+	hashSize := moHashTableSize(n)
+	origOffset := uint32(moHeaderSize)
+	transOffset := origOffset + 8*n
+	hashOffset := transOffset + 8*n
+	stringsOffset := hashOffset + 4*hashSize
+
+	var (
+		orig    = make([]uint32, 0, 2*n)
+		trans   = make([]uint32, 0, 2*n)
+		strData bytes.Buffer
+	)
+	for _, m := range msgs {
+		orig = append(orig, uint32(len(m.key)), stringsOffset+uint32(strData.Len()))
+		strData.WriteString(m.key)
+		strData.WriteByte(0)
+	}
+	for _, m := range msgs {
+		trans = append(trans, uint32(len(m.value)), stringsOffset+uint32(strData.Len()))
+		strData.WriteString(m.value)
+		strData.WriteByte(0)
+	}
+
+	hashTable := make([]uint32, hashSize)
+	for i, m := range msgs {
+		// Lookup hashes the msgid only (up to the NUL before msgid_plural).
+		key := m.key
+		if idx := bytes.IndexByte([]byte(key), 0); idx >= 0 {
+			key = key[:idx]
+		}
+		hv := moHashString(key)
+		idx := hv % hashSize
+		incr := 1 + hv%(hashSize-2)
+		for hashTable[idx] != 0 {
+			if idx >= hashSize-incr {
+				idx -= hashSize - incr
+			} else {
+				idx += incr
+			}
+		}
+		hashTable[idx] = uint32(i) + 1
+	}
+
+	var b bytes.Buffer
+	header := []uint32{moMagic, 0, n, origOffset, transOffset, hashSize, hashOffset}
+	for _, part := range [][]uint32{header, orig, trans, hashTable} {
+		if err := binary.Write(&b, binary.LittleEndian, part); err != nil {
+			return fmt.Errorf("encode .mo: %w", err)
+		}
+	}
+	b.Write(strData.Bytes())
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// CompilePoFileToMo compiles poFile into moFile. The PO file is validated with
+// ValidatePoData first (checks selected by opts); on fatal errors no .mo is written
+// and the diagnostics are returned in the error.
+func CompilePoFileToMo(poFile, moFile string, useFuzzy bool, opts PoValidateOptions) (*PoValidateResult, error) {
+	data, err := os.ReadFile(poFile)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", poFile, err)
+	}
+	result := ValidatePoData(data, poFile, opts)
+	if !result.OK() {
+		return result, fmt.Errorf("%s: %s", poFile, result.Summary())
+	}
+	po, err := ParsePoEntries(data)
+	if err != nil {
+		return result, fmt.Errorf("parse %s: %w", poFile, err)
+	}
+	var buf bytes.Buffer
+	if err := WriteMo(po, &buf, useFuzzy); err != nil {
+		return result, err
+	}
+	if err := os.WriteFile(moFile, buf.Bytes(), 0644); err != nil {
+		return result, fmt.Errorf("write %s: %w", moFile, err)
+	}
+	return result, nil
+}
+
+// moHashTableSize returns the hash table size used by msgfmt: the smallest odd
+// prime >= 4n/3, and at least 3.
+func moHashTableSize(n uint32) uint32 {
+	size := n * 4 / 3
+	if size < 3 {
+		size = 3
+	}
+	if size%2 == 0 {
+		size++
+	}
+	for !isOddPrime(size) {
+		size += 2
+	}
+	return size
+}
+
+func isOddPrime(n uint32) bool {
+	for d := uint32(3); d*d <= n; d += 2 {
+		if n%d == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// moHashString is the hashpjw function used by GNU gettext for .mo hash tables.
+func moHashString(s string) uint32 {
+	var hval uint32
+	for i := 0; i < len(s); i++ {
+		hval = (hval << 4) + uint32(s[i])
+		if g := hval & (0xf << 28); g != 0 {
+			hval ^= g >> 24
+			hval ^= g
+		}
+	}
+	return hval
+}
+
+// IsMoData returns true if data starts with the .mo magic number in either byte order.
+func IsMoData(data []byte) bool {
+	return len(data) >= 4 &&
+		(binary.LittleEndian.Uint32(data) == moMagic || binary.BigEndian.Uint32(data) == moMagic)
+}
+
+// moReader reads the tables of a .mo file in its byte order, with bounds checks.
+type moReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+func (mr *moReader) u32(off uint32) (uint32, error) {
+	if uint64(off)+4 > uint64(len(mr.data)) {
+		return 0, fmt.Errorf("offset %d out of range", off)
+	}
+	return mr.order.Uint32(mr.data[off:]), nil
+}
+
+// str returns the string described by the length/offset pair at table+8*i.
+func (mr *moReader) str(table, i uint32) (string, error) {
+	length, err := mr.u32(table + 8*i)
+	if err != nil {
+		return "", err
+	}
+	off, err := mr.u32(table + 8*i + 4)
+	if err != nil {
+		return "", err
+	}
+	if uint64(off)+uint64(length) > uint64(len(mr.data)) {
+		return "", fmt.Errorf("string %d at offset %d out of range", i, off)
+	}
+	return string(mr.data[off : off+length]), nil
+}
+
+// sysdepStr returns the system dependent string at off (minor revision 1),
+// with each system dependent segment written back as in the PO file, e.g.
+// "<PRIuMAX>".
+func (mr *moReader) sysdepStr(off, segmentsOffset, nSegments uint32) (string, error) {
+	var b strings.Builder
+	pos, err := mr.u32(off)
+	if err != nil {
+		return "", err
+	}
+	for i := off + 4; ; i += 8 {
+		segsize, err := mr.u32(i)
+		if err != nil {
+			return "", err
+		}
+		ref, err := mr.u32(i + 4)
+		if err != nil {
+			return "", err
+		}
+		if uint64(pos)+uint64(segsize) > uint64(len(mr.data)) {
+			return "", fmt.Errorf("system dependent string at offset %d out of range", off)
+		}
+		b.Write(mr.data[pos : pos+segsize])
+		pos += segsize
+		if ref == moSegmentsEnd {
+			break
+		}
+		if ref >= nSegments {
+			return "", fmt.Errorf("invalid system dependent segment %d", ref)
+		}
+		name, err := mr.str(segmentsOffset, ref)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString("<" + strings.TrimSuffix(name, "\x00") + ">")
+	}
+	return strings.TrimSuffix(b.String(), "\x00"), nil
+}
+
+// ParseMo decodes a little- or big-endian .mo file into GettextJSON. The header
+// meta comes from the translation of the empty msgid; msgctxt and plural forms
+// are split from the keys and values. Entries are in the order of the file
+// (sorted by msgid), all not fuzzy and without comments, since .mo files keep
+// neither.
+func ParseMo(data []byte) (*GettextJSON, error) {
+	mr := &moReader{data: data, order: binary.LittleEndian}
+	if len(data) < moHeaderSize || !IsMoData(data) {
+		return nil, fmt.Errorf("not a .mo file")
+	}
+	if binary.LittleEndian.Uint32(data) != moMagic {
+		mr.order = binary.BigEndian
+	}
+	header := make([]uint32, 7)
+	for i := range header {
+		header[i], _ = mr.u32(uint32(4 * i))
+	}
+	revision, n, origOffset, transOffset := header[1], header[2], header[3], header[4]
+	if major := revision >> 16; major > 1 {
+		return nil, fmt.Errorf("unsupported .mo file revision %d.%d", major, revision&0xffff)
+	}
+
+	type message struct{ key, value string }
+	var msgs []message
+	for i := uint32(0); i < n; i++ {
+		key, err := mr.str(origOffset, i)
+		if err != nil {
+			return nil, fmt.Errorf("bad .mo file: %w", err)
+		}
+		value, err := mr.str(transOffset, i)
+		if err != nil {
+			return nil, fmt.Errorf("bad .mo file: %w", err)
+		}
+		msgs = append(msgs, message{key, value})
+	}
+	if revision&0xffff >= 1 && len(data) >= moHeaderSize+20 {
+		sysdep := make([]uint32, 5)
+		for i := range sysdep {
+			sysdep[i], _ = mr.u32(uint32(moHeaderSize + 4*i))
+		}
+		nSegments, segmentsOffset, nStrings, origTab, transTab :=
+			sysdep[0], sysdep[1], sysdep[2], sysdep[3], sysdep[4]
+		for i := uint32(0); i < nStrings; i++ {
+			var pair [2]string
+			for k, tab := range []uint32{origTab, transTab} {
+				off, err := mr.u32(tab + 4*i)
+				if err == nil {
+					pair[k], err = mr.sysdepStr(off, segmentsOffset, nSegments)
+				}
+				if err != nil {
+					return nil, fmt.Errorf("bad .mo file: %w", err)
+				}
+			}
+			msgs = append(msgs, message{pair[0], pair[1]})
+		}
+		sort.SliceStable(msgs, func(i, j int) bool { return msgs[i].key < msgs[j].key })
+	}
+
+	j := &GettextJSON{Entries: []GettextEntry{}}
+	for _, m := range msgs {
+		if m.key == "" {
+			j.HeaderMeta = jsonDecodedToPoFormat(m.value)
+			continue
+		}
+		e := GettextEntry{Comments: []string{}}
+		key := m.key
+		if i := strings.Index(key, moContextSeparator); i >= 0 {
+			ctxt := jsonDecodedToPoFormat(key[:i])
+			e.MsgCtxt = &ctxt
+			key = key[i+len(moContextSeparator):]
+		}
+		if i := strings.IndexByte(key, 0); i >= 0 {
+			e.MsgID = jsonDecodedToPoFormat(key[:i])
+			e.MsgIDPlural = jsonDecodedToPoFormat(key[i+1:])
+			for _, s := range strings.Split(m.value, "\x00") {
+				e.MsgStr = append(e.MsgStr, jsonDecodedToPoFormat(s))
+			}
+		} else {
+			e.MsgID = jsonDecodedToPoFormat(key)
+			e.MsgStr = []string{jsonDecodedToPoFormat(m.value)}
+		}
+		j.Entries = append(j.Entries, e)
+	}
+	return j, nil
+}
@@ -0,0 +1,331 @@
+package util
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// moSelectionTestPO has a fuzzy header and the entries msgfmt leaves out of
+// a .mo file: fuzzy (unless --use-fuzzy), obsolete and untranslated ones, and
+// plural entries with an empty msgstr[0].
+const moSelectionTestPO = `# Test file
+#, fuzzy
+msgid ""
+msgstr ""
+"Project-Id-Version: git\n"
+"Content-Type: text/plain; charset=UTF-8\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+msgid "Hello"
+msgstr "你好"
+
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] "%d 个文件"
+msgstr[1] ""
+
+msgid "%d dir"
+msgid_plural "%d dirs"
+msgstr[0] ""
+msgstr[1] "%d 个目录"
+
+#, fuzzy
+msgid "Fuzzy"
+msgstr "模糊"
+
+msgid "Untranslated"
+msgstr ""
+
+#~ msgid "Obsolete"
+#~ msgstr "废弃"
+`
+
+func TestWriteMo(t *testing.T) {
+	po, err := ParsePoEntries([]byte(validateTestHeader + `msgid "Hello"
+msgstr "你好"
+
+msgctxt "menu"
+msgid "File"
+msgstr "文件"
+
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] "%d 个文件"
+msgstr[1] "%d 个文件们"
+
+#, fuzzy
+msgid "Fuzzy"
+msgstr "模糊"
+
+msgid "Untranslated"
+msgstr ""
+
+#~ msgid "Obsolete"
+#~ msgstr "废弃"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteMo(po, &buf, false); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	u32 := func(off uint32) uint32 { return binary.LittleEndian.Uint32(data[off:]) }
+	if u32(0) != moMagic {
+		t.Fatalf("bad magic %#x", u32(0))
+	}
+	n := u32(8)
+	if n != 4 {
+		t.Fatalf("got %d messages, want 4 (header, Hello, menu|File, plural)", n)
+	}
+	origOff, transOff, hashSize, hashOff := u32(12), u32(16), u32(20), u32(24)
+	str := func(table, i uint32) string {
+		l, off := u32(table+8*i), u32(table+8*i+4)
+		if data[off+l] != 0 {
+			t.Errorf("string %d not NUL terminated", i)
+		}
+		return string(data[off : off+l])
+	}
+	got := make(map[string]string)
+	for i := uint32(0); i < n; i++ {
+		got[str(origOff, i)] = str(transOff, i)
+	}
+	want := map[string]string{
+		"Hello":               "你好",
+		"menu\x04File":        "文件",
+		"%d file\x00%d files": "%d 个文件\x00%d 个文件们",
+		"":                    poUnescape(po.HeaderEntry.MsgStr[0]),
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("translation of %q = %q, want %q", k, got[k], v)
+		}
+	}
+	// Every message must be reachable through the hash table.
+	for i := uint32(0); i < n; i++ {
+		key := str(origOff, i)
+		if idx := strings.IndexByte(key, 0); idx >= 0 {
+			key = key[:idx]
+		}
+		hv := moHashString(key)
+		idx, incr := hv%hashSize, 1+hv%(hashSize-2)
+		found := false
+		for j := uint32(0); j < hashSize; j++ {
+			slot := u32(hashOff + 4*idx)
+			if slot == 0 {
+				break
+			}
+			if slot == i+1 {
+				found = true
+				break
+			}
+			idx = (idx + incr) % hashSize
+		}
+		if !found {
+			t.Errorf("message %q not found via hash table", key)
+		}
+	}
+
+	buf.Reset()
+	if err := WriteMo(po, &buf, true); err != nil {
+		t.Fatal(err)
+	}
+	if n := binary.LittleEndian.Uint32(buf.Bytes()[8:]); n != 5 {
+		t.Errorf("with useFuzzy got %d messages, want 5", n)
+	}
+}
+
+func TestMoMessagesFromGettextPO(t *testing.T) {
+	po, err := ParsePoEntries([]byte(moSelectionTestPO))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		useFuzzy bool
+		keys     []string
+	}{
+		{false, []string{"", "%d file\x00%d files", "Hello"}},
+		{true, []string{"", "%d file\x00%d files", "Fuzzy", "Hello"}},
+	} {
+		var keys []string
+		for _, m := range moMessagesFromGettextPO(po, tc.useFuzzy) {
+			keys = append(keys, m.key)
+		}
+		if strings.Join(keys, "|") != strings.Join(tc.keys, "|") {
+			t.Errorf("useFuzzy=%v: got keys %q, want %q", tc.useFuzzy, keys, tc.keys)
+		}
+	}
+}
+
+// TestWriteMo_msgfmt checks that WriteMo writes the same messages as msgfmt.
+func TestWriteMo_msgfmt(t *testing.T) {
+	if _, err := exec.LookPath("msgfmt"); err != nil {
+		t.Skip("msgfmt not in PATH")
+	}
+	dir := t.TempDir()
+	poFile := filepath.Join(dir, "zh_CN.po")
+	if err := os.WriteFile(poFile, []byte(moSelectionTestPO), 0644); err != nil {
+		t.Fatal(err)
+	}
+	po, err := ParsePoEntries([]byte(moSelectionTestPO))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// moToPO returns the messages of a .mo file as PO text.
+	moToPO := func(data []byte) string {
+		j, err := ParseMo(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b bytes.Buffer
+		if err := WriteGettextJSONToPO(j, &b, false, false); err != nil {
+			t.Fatal(err)
+		}
+		return b.String()
+	}
+	for _, useFuzzy := range []bool{false, true} {
+		moFile := filepath.Join(dir, "msgfmt.mo")
+		args := []string{"-o", moFile, poFile}
+		if useFuzzy {
+			args = append([]string{"--use-fuzzy"}, args...)
+		}
+		if out, err := exec.Command("msgfmt", args...).CombinedOutput(); err != nil {
+			t.Fatalf("msgfmt %v: %v\n%s", args, err, out)
+		}
+		want, err := os.ReadFile(moFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := WriteMo(po, &buf, useFuzzy); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := moToPO(buf.Bytes()), moToPO(want); got != want {
+			t.Errorf("useFuzzy=%v: WriteMo gives:\n%s\nmsgfmt gives:\n%s", useFuzzy, got, want)
+		}
+	}
+}
+
+// buildTestMo builds a .mo file without hash table in the given byte order.
+// sysdep holds one system dependent message whose key and value are split at
+// "<PRIuMAX>" (revision 0.1), as written by msgfmt for Git's PO files.
+func buildTestMo(order binary.ByteOrder, msgs [][2]string, sysdep [2]string) []byte {
+	n := uint32(len(msgs))
+	revision, headerSize := uint32(0), uint32(moHeaderSize)
+	if sysdep[0] != "" {
+		revision, headerSize = 1, moHeaderSize+20
+	}
+	origOff := headerSize
+	transOff := origOff + 8*n
+	var tables, strData bytes.Buffer
+	strOff := transOff + 8*n
+	if sysdep[0] != "" {
+		// segments table (1 entry), orig/trans sysdep tables (1 entry each),
+		// and two sysdep_string structs of 2 segment pairs each.
+		strOff += 8 + 4 + 4 + 2*(4+16)
+	}
+	put := func(b *bytes.Buffer, v ...uint32) {
+		_ = binary.Write(b, order, v)
+	}
+	addStr := func(s string) uint32 {
+		off := strOff + uint32(strData.Len())
+		strData.WriteString(s)
+		strData.WriteByte(0)
+		return off
+	}
+	for k := 0; k < 2; k++ {
+		for _, m := range msgs {
+			put(&tables, uint32(len(m[k])), addStr(m[k]))
+		}
+	}
+	var header bytes.Buffer
+	put(&header, moMagic, revision, n, origOff, transOff, 0, 0)
+	if sysdep[0] != "" {
+		segOff := transOff + 8*n
+		put(&header, 1, segOff, 1, segOff+8, segOff+12)
+		put(&tables, uint32(len("PRIuMAX")+1), addStr("PRIuMAX"))
+		put(&tables, segOff+16, segOff+16+20)
+		for _, s := range sysdep {
+			parts := strings.SplitN(s, "<PRIuMAX>", 2)
+			off := addStr(parts[0] + parts[1])
+			put(&tables, off, uint32(len(parts[0])), 0, uint32(len(parts[1])+1), moSegmentsEnd)
+		}
+	}
+	return append(append(header.Bytes(), tables.Bytes()...), strData.Bytes()...)
+}
+
+func TestParseMo(t *testing.T) {
+	msgs := [][2]string{
+		{"", "Project-Id-Version: git\nPlural-Forms: nplurals=2; plural=(n != 1);\n"},
+		{"%d file\x00%d files", "%d 个文件\x00%d 个文件们"},
+		{"Hello", "你好\t\"世界\""},
+		{"menu\x04File", "文件"},
+	}
+	sysdep := [2]string{"%<PRIuMAX> bytes", "%<PRIuMAX> 字节"}
+	want := `msgid ""
+msgstr ""
+"Project-Id-Version: git\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+msgid "%<PRIuMAX> bytes"
+msgstr "%<PRIuMAX> 字节"
+
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] "%d 个文件"
+msgstr[1] "%d 个文件们"
+
+msgid "Hello"
+msgstr "你好\t\"世界\""
+
+msgctxt "menu"
+msgid "File"
+msgstr "文件"
+`
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		data := buildTestMo(order, msgs, sysdep)
+		j, err := LoadFileToGettextJSON(data, "git.mo")
+		if err != nil {
+			t.Fatalf("%s: %v", order, err)
+		}
+		var b bytes.Buffer
+		if err := WriteGettextJSONToPO(j, &b, false, false); err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != want {
+			t.Errorf("%s: got:\n%s\nwant:\n%s", order, b.String(), want)
+		}
+	}
+
+	// A .mo file written by WriteMo reads back to its translated entries.
+	po, err := ParsePoEntries([]byte(validateTestHeader + "msgid \"Hello\"\nmsgstr \"你好\"\n\nmsgid \"Untranslated\"\nmsgstr \"\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteMo(po, &buf, false); err != nil {
+		t.Fatal(err)
+	}
+	j, err := ParseMo(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j.HeaderMeta != po.HeaderEntry.MsgStr[0] || len(j.Entries) != 1 || j.Entries[0].MsgStr[0] != "你好" {
+		t.Errorf("ParseMo(WriteMo()) = %+v", j)
+	}
+
+	for _, bad := range [][]byte{
+		[]byte("msgid \"\"\n"),
+		buildTestMo(binary.LittleEndian, msgs, [2]string{})[:40],
+	} {
+		if _, err := ParseMo(bad); err == nil {
+			t.Errorf("ParseMo(%q) = nil error", bad)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+// Package util provides bulk editing of PO entries for msg-edit.
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// MsgEditOptions are the operations of msg-edit, applied to each selected
+// entry in the order of the fields below.
+type MsgEditOptions struct {
+	SetFuzzy    bool
+	UnsetFuzzy  bool
+	ClearMsgstr bool
+	// Replace, if not nil, is replaced by Replacement in every msgstr form
+	// (with PO escapes decoded, so "\n" matches a newline). Replacement may
+	// refer to submatches as $1 or ${name}.
+	Replace     *regexp.Regexp
+	Replacement string
+	AddFlags    []string
+	RemoveFlags []string
+	// AddComments are translator comments ("# " lines) to add, if not present.
+	AddComments []string
+	// RemoveComments, if not nil, removes the translator comments it matches.
+	RemoveComments *regexp.Regexp
+}
+
+// HasOperation returns true if any operation is set.
+func (o *MsgEditOptions) HasOperation() bool {
+	return o.SetFuzzy || o.UnsetFuzzy || o.ClearMsgstr || o.Replace != nil ||
+		len(o.AddFlags) > 0 || len(o.RemoveFlags) > 0 || len(o.AddComments) > 0 || o.RemoveComments != nil
+}
+
+// setFuzzy sets the fuzzy state of e; "fuzzy" is also removed from "#," lines
+// of entries read from PO files.
+func setFuzzy(e *GettextEntry, fuzzy bool) {
+	e.Fuzzy = fuzzy
+	if fuzzy {
+		return
+	}
+	var comments []string
+	for _, c := range e.Comments {
+		if stripped := StripFuzzyFromCommentLine(c); stripped != "" {
+			comments = append(comments, stripped)
+		}
+	}
+	e.Comments = comments
+}
+
+// Apply applies the operations to e and returns true if e changed.
+func (o *MsgEditOptions) Apply(e *GettextEntry) bool {
+	before := *e
+	before.MsgStr = append([]string(nil), e.MsgStr...)
+	before.Comments = append([]string(nil), e.Comments...)
+
+	if o.SetFuzzy {
+		setFuzzy(e, true)
+	}
+	if o.UnsetFuzzy {
+		setFuzzy(e, false)
+	}
+	if o.ClearMsgstr {
+		for k := range e.MsgStr {
+			e.MsgStr[k] = ""
+		}
+		setFuzzy(e, false)
+	}
+	if o.Replace != nil {
+		for k, s := range e.MsgStr {
+			if s == "" {
+				continue
+			}
+			text := poUnescape(s)
+			if replaced := o.Replace.ReplaceAllString(text, o.Replacement); replaced != text {
+				e.MsgStr[k] = jsonDecodedToPoFormat(replaced)
+			}
+		}
+	}
+
+	c := e.ParsedComments()
+	commentsChanged := false
+	for _, f := range o.AddFlags {
+		if f == "fuzzy" {
+			setFuzzy(e, true)
+		} else if !containsString(c.Flags, f) {
+			c.Flags = append(c.Flags, f)
+			commentsChanged = true
+		}
+	}
+	for _, f := range o.RemoveFlags {
+		if f == "fuzzy" {
+			setFuzzy(e, false)
+		} else if containsString(c.Flags, f) {
+			c.Flags = removeString(c.Flags, f)
+			commentsChanged = true
+		}
+	}
+	if o.RemoveComments != nil {
+		var kept []string
+		for _, s := range c.Translator {
+			if !o.RemoveComments.MatchString(s) {
+				kept = append(kept, s)
+			}
+		}
+		if len(kept) != len(c.Translator) {
+			c.Translator = kept
+			commentsChanged = true
+		}
+	}
+	for _, s := range o.AddComments {
+		if !containsString(c.Translator, s) {
+			c.Translator = append(c.Translator, s)
+			commentsChanged = true
+		}
+	}
+	if commentsChanged {
+		e.SetParsedComments(c)
+	}
+
+	return e.Fuzzy != before.Fuzzy || !equalStrings(e.MsgStr, before.MsgStr) ||
+		!equalStrings(commentsWithoutFuzzy(e.Comments), commentsWithoutFuzzy(before.Comments))
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func removeString(list []string, s string) []string {
+	var out []string
+	for _, v := range list {
+		if v != s {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// MsgEditResult is the result of EditGettextJSON.
+type MsgEditResult struct {
+	// Selected is the number of entries chosen by filter, range and pattern.
+	Selected int
+	// Changed is the number of selected entries that the operations changed.
+	Changed int
+}
+
+// EditGettextJSON applies ops to the entries of j chosen by filter
+// (DefaultFilter() if nil), then by rangeSpec over the filtered entries (like
+// msg-select), then by m (all entries if nil). Other entries are kept as they
+// are. When diff is not nil, a diff of each changed entry is written to it,
+// with path in the file header lines.
+func EditGettextJSON(j *GettextJSON, path, rangeSpec string, filter *EntryStateFilter, m *EntryMatcher,
+	ops *MsgEditOptions, diff io.Writer) (*MsgEditResult, error) {
+	f := DefaultFilter()
+	if filter != nil {
+		f = *filter
+	}
+	var filtered []int
+	for i := range j.Entries {
+		if MatchGettextEntryState(j.Entries[i], f) {
+			filtered = append(filtered, i)
+		}
+	}
+	indices, err := ParseEntryRange(rangeSpec, len(filtered))
+	if err != nil {
+		return nil, fmt.Errorf("invalid range %q: %w", rangeSpec, err)
+	}
+	result := &MsgEditResult{}
+	wroteHeader := false
+	for _, idx := range indices {
+		if idx < 1 || idx > len(filtered) {
+			continue
+		}
+		i := filtered[idx-1]
+		e := &j.Entries[i]
+		if m != nil && !m.Match(e) {
+			continue
+		}
+		result.Selected++
+		var before bytes.Buffer
+		if diff != nil {
+			if err := writeGettextEntryToPO(&before, *e); err != nil {
+				return nil, err
+			}
+		}
+		if !ops.Apply(e) {
+			continue
+		}
+		result.Changed++
+		if diff == nil {
+			continue
+		}
+		var after bytes.Buffer
+		if err := writeGettextEntryToPO(&after, *e); err != nil {
+			return nil, err
+		}
+		if !wroteHeader {
+			if _, err := fmt.Fprintf(diff, "--- a/%s\n+++ b/%s\n", path, path); err != nil {
+				return nil, err
+			}
+			wroteHeader = true
+		}
+		hunk := fmt.Sprintf("@@ entry %d", i+1)
+		if e.EntryLocation > 0 {
+			hunk += fmt.Sprintf(" (line %d)", e.EntryLocation)
+		}
+		lines := diffLines(strings.Split(strings.TrimSuffix(before.String(), "\n"), "\n"),
+			strings.Split(strings.TrimSuffix(after.String(), "\n"), "\n"))
+		if _, err := io.WriteString(diff, hunk+" @@\n"+strings.Join(lines, "\n")+"\n"); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// diffLines returns a line diff of a and b: common lines are prefixed with a
+// space, removed lines with "-" and added lines with "+". Entries are short,
+// so the longest common subsequence is computed directly.
+func diffLines(a, b []string) []string {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for k := len(b) - 1; k >= 0; k-- {
+			if a[i] == b[k] {
+				lcs[i][k] = lcs[i+1][k+1] + 1
+			} else if lcs[i+1][k] >= lcs[i][k+1] {
+				lcs[i][k] = lcs[i+1][k]
+			} else {
+				lcs[i][k] = lcs[i][k+1]
+			}
+		}
+	}
+	var out []string
+	i, k := 0, 0
+	for i < len(a) || k < len(b) {
+		switch {
+		case i < len(a) && k < len(b) && a[i] == b[k]:
+			out = append(out, " "+a[i])
+			i++
+			k++
+		case k == len(b) || (i < len(a) && lcs[i+1][k] >= lcs[i][k+1]):
+			out = append(out, "-"+a[i])
+			i++
+		default:
+			out = append(out, "+"+b[k])
+			k++
+		}
+	}
+	return out
+}
@@ -0,0 +1,154 @@
+package util
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const msgEditTestPO = `msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+
+# keep me
+#: builtin/stash.c:10
+#, c-format
+msgid "Stash %s"
+msgstr "贮藏 %s"
+
+#, fuzzy
+msgid "stash list"
+msgstr "贮藏列表"
+
+msgid "Hello"
+msgstr "你好"
+
+msgid "Empty"
+msgstr ""
+`
+
+func parseMsgEditTestPO(t *testing.T) *GettextJSON {
+	po, err := ParsePoEntries([]byte(msgEditTestPO))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return GettextJSONFromGettextPO(po)
+}
+
+func TestMsgEditApply(t *testing.T) {
+	tests := []struct {
+		name        string
+		ops         MsgEditOptions
+		entry       int
+		wantChanged bool
+		wantFuzzy   bool
+		wantMsgStr  string
+		wantComment []string
+	}{
+		{"set fuzzy", MsgEditOptions{SetFuzzy: true}, 3, true, true, "你好", nil},
+		{"set fuzzy on fuzzy entry", MsgEditOptions{SetFuzzy: true}, 2, false, true, "贮藏列表", nil},
+		{"unset fuzzy", MsgEditOptions{UnsetFuzzy: true}, 2, true, false, "贮藏列表", nil},
+		{"clear msgstr", MsgEditOptions{ClearMsgstr: true}, 2, true, false, "", nil},
+		{"clear empty msgstr", MsgEditOptions{ClearMsgstr: true}, 4, false, false, "", nil},
+		{"replace", MsgEditOptions{Replace: regexp.MustCompile("贮藏"), Replacement: "储藏"},
+			1, true, false, "储藏 %s", []string{"# keep me", "#: builtin/stash.c:10", "#, c-format"}},
+		{"replace with submatch", MsgEditOptions{Replace: regexp.MustCompile(`(\S+) (%s)`), Replacement: "$2 $1"},
+			1, true, false, "%s 贮藏", nil},
+		{"replace no match", MsgEditOptions{Replace: regexp.MustCompile("xyz")}, 1, false, false, "贮藏 %s", nil},
+		{"add and remove flags", MsgEditOptions{AddFlags: []string{"no-c-format"}, RemoveFlags: []string{"c-format"}},
+			1, true, false, "贮藏 %s", []string{"# keep me", "#: builtin/stash.c:10", "#, no-c-format"}},
+		{"add existing flag", MsgEditOptions{AddFlags: []string{"c-format"}}, 1, false, false, "贮藏 %s", nil},
+		{"fuzzy flag", MsgEditOptions{AddFlags: []string{"fuzzy"}}, 3, true, true, "你好", nil},
+		{"replace comment", MsgEditOptions{AddComments: []string{"term: 储藏"}, RemoveComments: regexp.MustCompile("^keep")},
+			1, true, false, "贮藏 %s", []string{"# term: 储藏", "#: builtin/stash.c:10", "#, c-format"}},
+		{"add existing comment", MsgEditOptions{AddComments: []string{"keep me"}}, 1, false, false, "贮藏 %s", nil},
+	}
+	for _, tt := range tests {
+		j := parseMsgEditTestPO(t)
+		e := &j.Entries[tt.entry-1]
+		if got := tt.ops.Apply(e); got != tt.wantChanged {
+			t.Errorf("%s: changed = %v, want %v", tt.name, got, tt.wantChanged)
+		}
+		if e.Fuzzy != tt.wantFuzzy {
+			t.Errorf("%s: fuzzy = %v, want %v", tt.name, e.Fuzzy, tt.wantFuzzy)
+		}
+		if got := poUnescape(e.MsgStr[0]); got != tt.wantMsgStr {
+			t.Errorf("%s: msgstr = %q, want %q", tt.name, got, tt.wantMsgStr)
+		}
+		if tt.wantComment != nil && !equalStrings(commentsWithoutFuzzy(e.Comments), tt.wantComment) {
+			t.Errorf("%s: comments = %q, want %q", tt.name, e.Comments, tt.wantComment)
+		}
+	}
+}
+
+func TestEditGettextJSON(t *testing.T) {
+	tests := []struct {
+		name         string
+		rangeSpec    string
+		filter       *EntryStateFilter
+		pattern      string
+		wantSelected int
+		wantChanged  int
+	}{
+		{"all", "", nil, "", 4, 2},
+		{"range", "2-3", nil, "", 2, 1},
+		{"translated", "", &EntryStateFilter{Translated: true}, "", 2, 1},
+		{"range over filtered", "2", &EntryStateFilter{Translated: true}, "", 1, 0},
+		{"pattern", "", nil, "(?i)stash", 2, 2},
+	}
+	ops := &MsgEditOptions{Replace: regexp.MustCompile("贮藏"), Replacement: "储藏"}
+	for _, tt := range tests {
+		j := parseMsgEditTestPO(t)
+		var m *EntryMatcher
+		var err error
+		if tt.pattern != "" {
+			if m, err = NewEntryMatcher(MsgGrepOptions{Patterns: []string{tt.pattern}}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		result, err := EditGettextJSON(j, "zh_CN.po", tt.rangeSpec, tt.filter, m, ops, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if result.Selected != tt.wantSelected || result.Changed != tt.wantChanged {
+			t.Errorf("%s: result = %+v, want %d selected, %d changed",
+				tt.name, result, tt.wantSelected, tt.wantChanged)
+		}
+	}
+
+	j := parseMsgEditTestPO(t)
+	if _, err := EditGettextJSON(j, "zh_CN.po", "x", nil, nil, ops, nil); err == nil {
+		t.Error("bad range: got nil error")
+	}
+}
+
+func TestEditGettextJSONDiff(t *testing.T) {
+	j := parseMsgEditTestPO(t)
+	var b bytes.Buffer
+	ops := &MsgEditOptions{Replace: regexp.MustCompile("贮藏"), Replacement: "储藏"}
+	if _, err := EditGettextJSON(j, "po/zh_CN.po", "1", nil, nil, ops, &b); err != nil {
+		t.Fatal(err)
+	}
+	want := `--- a/po/zh_CN.po
++++ b/po/zh_CN.po
+@@ entry 1 (line 8) @@
+ # keep me
+ #: builtin/stash.c:10
+ #, c-format
+ msgid "Stash %s"
+-msgstr "贮藏 %s"
++msgstr "储藏 %s"
+`
+	if got := b.String(); got != want {
+		t.Errorf("diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	got := diffLines(strings.Split("a b c d", " "), strings.Split("a x c d e", " "))
+	want := []string{" a", "-b", "+x", " c", " d", "+e"}
+	if !equalStrings(got, want) {
+		t.Errorf("diffLines = %q, want %q", got, want)
+	}
+}
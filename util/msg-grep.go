@@ -0,0 +1,149 @@
+// Package util provides pattern matching of PO entries for msg-grep.
+package util
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// MsgGrepOptions are the options of msg-grep. When none of the field options
+// is set, patterns are matched against msgid, msgid_plural and msgstr.
+type MsgGrepOptions struct {
+	// Patterns are regular expressions (or fixed strings with FixedStrings).
+	// An entry matches if any pattern matches any of the selected fields.
+	Patterns     []string
+	FixedStrings bool
+	IgnoreCase   bool
+	// InvertMatch selects the entries that do not match.
+	InvertMatch bool
+
+	// Fields to search.
+	MsgID       bool
+	MsgIDPlural bool
+	MsgStr      bool
+	MsgCtxt     bool
+	// Comment searches translator and extracted comments.
+	Comment bool
+	// Location searches source references ("#:"), e.g. "builtin/add.c:42".
+	Location bool
+}
+
+// EntryMatcher matches PO entries against the patterns of MsgGrepOptions.
+type EntryMatcher struct {
+	opts     MsgGrepOptions
+	patterns []*regexp.Regexp
+}
+
+// NewEntryMatcher compiles the patterns of opts.
+func NewEntryMatcher(opts MsgGrepOptions) (*EntryMatcher, error) {
+	if len(opts.Patterns) == 0 {
+		return nil, fmt.Errorf("no pattern given")
+	}
+	if !opts.MsgID && !opts.MsgIDPlural && !opts.MsgStr && !opts.MsgCtxt && !opts.Comment && !opts.Location {
+		opts.MsgID, opts.MsgIDPlural, opts.MsgStr = true, true, true
+	}
+	m := &EntryMatcher{opts: opts}
+	for _, p := range opts.Patterns {
+		expr := p
+		if opts.FixedStrings {
+			expr = regexp.QuoteMeta(p)
+		}
+		if opts.IgnoreCase {
+			expr = "(?i)" + expr
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("bad pattern %q: %w", p, err)
+		}
+		m.patterns = append(m.patterns, re)
+	}
+	return m, nil
+}
+
+// fields returns the text of the selected fields of e, with PO escapes decoded.
+func (m *EntryMatcher) fields(e *GettextEntry) []string {
+	var out []string
+	if m.opts.MsgCtxt && e.MsgCtxt != nil {
+		out = append(out, poUnescape(*e.MsgCtxt))
+	}
+	if m.opts.MsgID {
+		out = append(out, poUnescape(e.MsgID))
+	}
+	if m.opts.MsgIDPlural && e.MsgIDPlural != "" {
+		out = append(out, poUnescape(e.MsgIDPlural))
+	}
+	if m.opts.MsgStr {
+		for _, s := range e.MsgStr {
+			out = append(out, poUnescape(s))
+		}
+	}
+	if m.opts.Comment || m.opts.Location {
+		c := e.ParsedComments()
+		if m.opts.Comment {
+			out = append(out, c.Translator...)
+			out = append(out, c.Extracted...)
+		}
+		if m.opts.Location {
+			for _, ref := range c.References {
+				out = append(out, ref.String())
+			}
+		}
+	}
+	return out
+}
+
+// Match returns whether e is selected: any pattern matches any selected field,
+// or, with InvertMatch, none does.
+func (m *EntryMatcher) Match(e *GettextEntry) bool {
+	for _, s := range m.fields(e) {
+		for _, re := range m.patterns {
+			if re.MatchString(s) {
+				return !m.opts.InvertMatch
+			}
+		}
+	}
+	return m.opts.InvertMatch
+}
+
+// MsgGrepResult is the result of MsgGrepFromFile.
+type MsgGrepResult struct {
+	// Matched is the number of entries written.
+	Matched int
+	// Total is the number of entries that passed the state filter.
+	Total int
+}
+
+// MsgGrepFromFile reads a PO, JSON, XLIFF or .mo file, keeps the entries that
+// pass filter (DefaultFilter() if nil) and match m, and writes them to w in
+// format like MsgSelectFromFile. When countOnly is set or no entry matches,
+// nothing is written.
+func MsgGrepFromFile(path string, m *EntryMatcher, filter *EntryStateFilter, w io.Writer,
+	format string, noHeader, inputWasPO, countOnly bool) (*MsgGrepResult, error) {
+	j, err := ReadFileToGettextJSON(path)
+	if err != nil {
+		return nil, err
+	}
+	f := DefaultFilter()
+	if filter != nil {
+		f = *filter
+	}
+	filtered := FilterGettextEntries(j.Entries, f)
+	result := &MsgGrepResult{Total: len(filtered)}
+	var selected []GettextEntry
+	for i := range filtered {
+		if m.Match(&filtered[i]) {
+			selected = append(selected, filtered[i])
+		}
+	}
+	result.Matched = len(selected)
+	if countOnly || len(selected) == 0 {
+		return result, nil
+	}
+	out := &GettextJSON{
+		HeaderComment: j.HeaderComment,
+		HeaderMeta:    j.HeaderMeta,
+		Entries:       selected,
+	}
+	return result, writeSelectedGettextJSON(out, w, path, format, noHeader, inputWasPO)
+}
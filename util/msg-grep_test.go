@@ -0,0 +1,126 @@
+package util
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const msgGrepTestPO = `msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+
+# Use the glossary term
+#: builtin/stash.c:10
+msgid "Stash the changes"
+msgstr "贮藏修改"
+
+#. TRANSLATORS: "stash" is a noun here
+#: builtin/stash.c:20 builtin/log.c
+msgctxt "noun"
+msgid "stash"
+msgstr "储藏"
+
+#: builtin/add.c
+#, fuzzy
+msgid "%d path\nadded"
+msgid_plural "%d paths\nadded"
+msgstr[0] "添加了 %d 个路径"
+msgstr[1] ""
+
+#~ msgid "old stash"
+#~ msgstr "旧贮藏"
+`
+
+func TestEntryMatcher(t *testing.T) {
+	po, err := ParsePoEntries([]byte(msgGrepTestPO))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		opts MsgGrepOptions
+		want []int
+	}{
+		{"default fields", MsgGrepOptions{Patterns: []string{"stash"}}, []int{2, 4}},
+		{"ignore case", MsgGrepOptions{Patterns: []string{"stash"}, IgnoreCase: true}, []int{1, 2, 4}},
+		{"invert", MsgGrepOptions{Patterns: []string{"stash"}, IgnoreCase: true, InvertMatch: true}, []int{3}},
+		{"several patterns", MsgGrepOptions{Patterns: []string{"贮藏", "储藏"}, MsgStr: true}, []int{1, 2, 4}},
+		{"fixed string", MsgGrepOptions{Patterns: []string{"%d path"}, FixedStrings: true}, []int{3}},
+		{"regexp metachar as fixed string", MsgGrepOptions{Patterns: []string{"."}, FixedStrings: true}, nil},
+		{"newline decoded", MsgGrepOptions{Patterns: []string{`paths\nadded$`}, MsgIDPlural: true}, []int{3}},
+		{"msgid only", MsgGrepOptions{Patterns: []string{"paths"}, MsgID: true}, nil},
+		{"msgctxt", MsgGrepOptions{Patterns: []string{"^noun$"}, MsgCtxt: true}, []int{2}},
+		{"comment", MsgGrepOptions{Patterns: []string{"noun|glossary"}, Comment: true}, []int{1, 2}},
+		{"location", MsgGrepOptions{Patterns: []string{`^builtin/stash\.c:\d+$`}, Location: true}, []int{1, 2}},
+		{"location file", MsgGrepOptions{Patterns: []string{"builtin/log.c"}, FixedStrings: true, Location: true}, []int{2}},
+	}
+	for _, tt := range tests {
+		m, err := NewEntryMatcher(tt.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var got []int
+		for i := range po.Entries {
+			if m.Match(&po.Entries[i]) {
+				got = append(got, i+1)
+			}
+		}
+		if !equalInts(got, tt.want) {
+			t.Errorf("%s: matched entries %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := NewEntryMatcher(MsgGrepOptions{Patterns: []string{"("}}); err == nil {
+		t.Error("bad pattern: got nil error")
+	}
+	if _, err := NewEntryMatcher(MsgGrepOptions{}); err == nil {
+		t.Error("no pattern: got nil error")
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMsgGrepFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "zh_CN.po")
+	if err := os.WriteFile(path, []byte(msgGrepTestPO), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewEntryMatcher(MsgGrepOptions{Patterns: []string{"stash"}, IgnoreCase: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	result, err := MsgGrepFromFile(path, m, &EntryStateFilter{NoObsolete: true}, &b, OutputFormatPO, true, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Matched != 2 || result.Total != 3 {
+		t.Errorf("result = %+v, want 2 of 3 matched", result)
+	}
+	out := b.String()
+	if strings.Contains(out, "Content-Type") || !strings.Contains(out, `msgid "Stash the changes"`) ||
+		!strings.Contains(out, `msgctxt "noun"`) || strings.Contains(out, "old stash") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	b.Reset()
+	if result, err = MsgGrepFromFile(path, m, nil, &b, OutputFormatJSON, false, true, true); err != nil {
+		t.Fatal(err)
+	}
+	if result.Matched != 3 || result.Total != 4 || b.Len() != 0 {
+		t.Errorf("count only: result = %+v, output %q", result, b.String())
+	}
+}
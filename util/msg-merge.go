@@ -0,0 +1,596 @@
+// Package util provides a native msgmerge: merging the translations of a PO
+// file into a POT template.
+package util
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+	"strings"
+)
+
+// msgMergeFuzzyThreshold is the least similarity of two msgids for a fuzzy
+// match, the FUZZY_THRESHOLD of msgmerge.
+const msgMergeFuzzyThreshold = 0.6
+
+// msgMergeFuzzyCandidates is the number of entries, with the most trigrams
+// in common with a msgid, whose similarity is computed in a fuzzy search.
+const msgMergeFuzzyCandidates = 100
+
+// msgMergeReferenceWidth is the page width of "#:" lines, which are wrapped
+// like msgmerge does.
+const msgMergeReferenceWidth = 79
+
+// MsgMergeOptions are the options of MsgMerge, named after those of msgmerge.
+type MsgMergeOptions struct {
+	// NoFuzzyMatching leaves messages without an exact match untranslated.
+	NoFuzzyMatching bool
+	// NoLocation drops "#:" references, like msgmerge --no-location.
+	NoLocation bool
+	// NoLineNumber drops the line numbers of references, like msgmerge
+	// --add-location=file.
+	NoLineNumber bool
+}
+
+// MsgMergeFuzzy explains why a message of the template became fuzzy: the
+// translation of Previous was taken for it.
+type MsgMergeFuzzy struct {
+	// MsgCtxt and MsgID are those of the template message, in PO format.
+	MsgCtxt *string
+	MsgID   string
+	// Previous is the message whose translation was taken.
+	Previous PreviousMsg
+	// Similarity of the msgids, from msgMergeFuzzyThreshold to 1.
+	Similarity float64
+	// Reasons, e.g. "msgid changed (87% similar)" or "msgctxt changed".
+	Reasons []string
+}
+
+// MsgMergeResult is the result of MsgMerge. The counts are those of the
+// merged catalog.
+type MsgMergeResult struct {
+	Translated   int
+	Fuzzy        int
+	Untranslated int
+	Obsolete     int
+	// Resurrected is the number of obsolete entries whose translation was used again.
+	Resurrected int
+	// FuzzyMatches are the messages that became fuzzy by this merge, in
+	// template order. Entries that were fuzzy before are not included.
+	FuzzyMatches []MsgMergeFuzzy
+}
+
+// msgMergeCandidate is a translated entry of the PO file for fuzzy matching.
+type msgMergeCandidate struct {
+	index int
+	text  []rune
+	hist  map[rune]int
+	grams int
+	masks lcsMasks
+}
+
+type msgMerger struct {
+	def        *GettextJSON
+	opts       MsgMergeOptions
+	nplurals   int
+	exact      map[string]int
+	candidates []msgMergeCandidate
+	// trigrams maps each trigram of a msgid to the candidates having it.
+	trigrams map[string][]int
+	// shared counts the trigrams in common with a msgid, by candidate.
+	shared []int
+	used   []bool
+}
+
+func msgMergeKey(msgctxt *string, msgid string) string {
+	if msgctxt == nil {
+		return "\x04" + msgid
+	}
+	return *msgctxt + "\x00" + msgid
+}
+
+// MsgMerge merges the translations of def (a PO file) into ref (a POT
+// template) like msgmerge:
+//
+//   - a message with the same msgctxt and msgid keeps its translation and state;
+//   - otherwise the translation of the most similar msgid (see
+//     msgMergeFuzzyThreshold) is taken and marked fuzzy, with the old
+//     message in "#|" lines;
+//   - obsolete entries are matched the same way, so they come back to life
+//     when their message returns to the template;
+//   - translated entries of def that are not used become obsolete, and
+//     untranslated ones are dropped.
+//
+// Translator comments come from def; extracted comments, references and
+// format flags come from ref. The header is that of def, with the
+// POT-Creation-Date of ref.
+func MsgMerge(def, ref *GettextJSON, opts MsgMergeOptions) (*GettextJSON, *MsgMergeResult) {
+	m := &msgMerger{
+		def:      def,
+		opts:     opts,
+		nplurals: 2,
+		exact:    make(map[string]int),
+		trigrams: make(map[string][]int),
+		used:     make([]bool, len(def.Entries)),
+	}
+	if pf, err := PluralFormsFromHeaderMeta(def.HeaderMeta); err == nil && pf != nil {
+		m.nplurals = pf.NPlurals
+	}
+	// Active entries take precedence over obsolete entries of the same message.
+	for _, obsolete := range []bool{false, true} {
+		for i := range def.Entries {
+			e := &def.Entries[i]
+			if e.Obsolete != obsolete {
+				continue
+			}
+			key := msgMergeKey(e.MsgCtxt, e.MsgID)
+			if _, ok := m.exact[key]; !ok {
+				m.exact[key] = i
+			}
+		}
+	}
+	for i := range def.Entries {
+		if !isEntryTranslated(&def.Entries[i]) {
+			continue
+		}
+		text := []rune(poUnescape(def.Entries[i].MsgID))
+		grams := trigrams(text)
+		for _, g := range grams {
+			m.trigrams[g] = append(m.trigrams[g], len(m.candidates))
+		}
+		m.candidates = append(m.candidates, msgMergeCandidate{
+			index: i,
+			text:  text,
+			hist:  runeHistogram(text),
+			grams: len(grams),
+			masks: newLCSMasks(text),
+		})
+	}
+
+	out := &GettextJSON{
+		HeaderComment: def.HeaderComment,
+		HeaderMeta:    def.HeaderMeta,
+		Entries:       make([]GettextEntry, 0, len(ref.Entries)),
+	}
+	if date := headerMetaField(ref.HeaderMeta, "POT-Creation-Date"); date != "" {
+		out.HeaderMeta = setHeaderMetaField(out.HeaderMeta, "POT-Creation-Date", date)
+	}
+	result := &MsgMergeResult{}
+	for i := range ref.Entries {
+		entry := m.mergeEntry(&ref.Entries[i], result)
+		switch {
+		case entry.Fuzzy:
+			result.Fuzzy++
+		case isEntryTranslated(&entry):
+			result.Translated++
+		default:
+			result.Untranslated++
+		}
+		out.Entries = append(out.Entries, entry)
+	}
+	for i := range def.Entries {
+		e := def.Entries[i]
+		if m.used[i] || !isEntryTranslated(&e) {
+			continue
+		}
+		if !e.Obsolete {
+			c := e.ParsedComments()
+			e = GettextEntry{
+				MsgID:       e.MsgID,
+				MsgIDPlural: e.MsgIDPlural,
+				MsgCtxt:     e.MsgCtxt,
+				MsgStr:      e.MsgStr,
+				Fuzzy:       e.Fuzzy,
+				Obsolete:    true,
+			}
+			e.SetParsedComments(EntryComments{Translator: c.Translator, Previous: c.Previous})
+		}
+		result.Obsolete++
+		out.Entries = append(out.Entries, e)
+	}
+	return out, result
+}
+
+// MsgMergeFile reads defFile (PO, JSON, XLIFF or .mo) and refFile (the POT
+// template) and merges them with MsgMerge.
+func MsgMergeFile(defFile, refFile string, opts MsgMergeOptions) (*GettextJSON, *MsgMergeResult, error) {
+	def, err := ReadFileToGettextJSON(defFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	ref, err := ReadFileToGettextJSON(refFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	out, result := MsgMerge(def, ref, opts)
+	return out, result, nil
+}
+
+// isEntryTranslated returns true if any msgstr form of e is not empty.
+func isEntryTranslated(e *GettextEntry) bool {
+	for _, s := range e.MsgStr {
+		if s != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeEntry returns the merged entry for the template message r.
+func (m *msgMerger) mergeEntry(r *GettextEntry, result *MsgMergeResult) GettextEntry {
+	rc := r.ParsedComments()
+	c := EntryComments{Extracted: rc.Extracted, References: rc.References, Flags: rc.Flags}
+	entry := GettextEntry{MsgCtxt: r.MsgCtxt, MsgID: r.MsgID, MsgIDPlural: r.MsgIDPlural}
+
+	idx, exact := m.exact[msgMergeKey(r.MsgCtxt, r.MsgID)]
+	similarity := 1.0
+	if !exact && !m.opts.NoFuzzyMatching {
+		idx, similarity = m.fuzzySearch(r)
+	} else if !exact {
+		idx = -1
+	}
+	if idx < 0 {
+		entry.MsgStr = make([]string, 1)
+		if r.MsgIDPlural != "" {
+			entry.MsgStr = make([]string, m.nplurals)
+		}
+		m.setComments(&entry, c)
+		return entry
+	}
+
+	d := &m.def.Entries[idx]
+	if d.Obsolete && !m.used[idx] {
+		result.Resurrected++
+	}
+	m.used[idx] = true
+	dc := d.ParsedComments()
+	c.Translator = dc.Translator
+	for _, f := range dc.Flags {
+		// Format flags are determined by the template; others, such as
+		// no-wrap, are the translator's.
+		if !strings.HasSuffix(f, "-format") && !containsString(c.Flags, f) {
+			c.Flags = append(c.Flags, f)
+		}
+	}
+	entry.MsgStr = mergeMsgStr(d, r, m.nplurals)
+	entry.Fuzzy = d.Fuzzy
+
+	reasons := msgMergeFuzzyReasons(r, d, similarity, exact)
+	if len(reasons) > 0 {
+		prev := PreviousMsg{MsgCtxt: d.MsgCtxt, MsgID: d.MsgID, MsgIDPlural: d.MsgIDPlural}
+		if !d.Fuzzy {
+			result.FuzzyMatches = append(result.FuzzyMatches, MsgMergeFuzzy{
+				MsgCtxt:    r.MsgCtxt,
+				MsgID:      r.MsgID,
+				Previous:   prev,
+				Similarity: similarity,
+				Reasons:    reasons,
+			})
+		}
+		entry.Fuzzy = true
+		if dc.Previous == nil || !d.Fuzzy {
+			c.Previous = &prev
+		} else {
+			c.Previous = dc.Previous
+		}
+	} else if entry.Fuzzy {
+		c.Previous = dc.Previous
+	}
+	// With --keep-layout, the original lines are written if nothing changed.
+	entry.RawLines = d.RawLines
+	m.setComments(&entry, c)
+	return entry
+}
+
+// setComments sets the comment lines of e from c, with references filtered by
+// the location options and wrapped like msgmerge.
+func (m *msgMerger) setComments(e *GettextEntry, c EntryComments) {
+	refs := c.References
+	c.References = nil
+	lines := c.Lines(e.Obsolete)
+	if m.opts.NoLocation || len(refs) == 0 {
+		e.Comments = lines
+		return
+	}
+	if m.opts.NoLineNumber {
+		var files []PoReference
+		seen := make(map[string]bool)
+		for _, ref := range refs {
+			if !seen[ref.File] {
+				seen[ref.File] = true
+				files = append(files, PoReference{File: ref.File})
+			}
+		}
+		refs = files
+	}
+	pos := len(c.Translator) + len(c.Extracted)
+	e.Comments = append(append(append([]string{}, lines[:pos]...), referenceLines(refs)...), lines[pos:]...)
+}
+
+// referenceLines returns "#:" lines for refs, wrapped at msgMergeReferenceWidth.
+func referenceLines(refs []PoReference) []string {
+	var lines []string
+	line := "#:"
+	for _, ref := range refs {
+		s := ref.String()
+		if line != "#:" && len(line)+1+len(s) > msgMergeReferenceWidth {
+			lines = append(lines, line)
+			line = "#:"
+		}
+		line += " " + s
+	}
+	return append(lines, line)
+}
+
+// mergeMsgStr returns the translation of d for the template message r, with
+// the number of forms adjusted when one of them is a plural message.
+func mergeMsgStr(d, r *GettextEntry, nplurals int) []string {
+	msgstr := append([]string(nil), d.MsgStr...)
+	if len(msgstr) == 0 {
+		msgstr = []string{""}
+	}
+	switch {
+	case r.MsgIDPlural != "" && d.MsgIDPlural == "":
+		forms := make([]string, nplurals)
+		for i := range forms {
+			forms[i] = msgstr[0]
+		}
+		return forms
+	case r.MsgIDPlural == "" && d.MsgIDPlural != "":
+		return msgstr[:1]
+	}
+	return msgstr
+}
+
+// msgMergeFuzzyReasons returns why the translation of d is fuzzy for r, or
+// nil if it is an exact match.
+func msgMergeFuzzyReasons(r, d *GettextEntry, similarity float64, exact bool) []string {
+	var reasons []string
+	if !exact {
+		if r.MsgID != d.MsgID {
+			reasons = append(reasons, fmt.Sprintf("msgid changed (%d%% similar)", int(math.Floor(similarity*100))))
+		}
+		if (r.MsgCtxt == nil) != (d.MsgCtxt == nil) || (r.MsgCtxt != nil && *r.MsgCtxt != *d.MsgCtxt) {
+			reasons = append(reasons, "msgctxt changed")
+		}
+	}
+	switch {
+	case r.MsgIDPlural != "" && d.MsgIDPlural == "":
+		reasons = append(reasons, "msgid_plural added")
+	case r.MsgIDPlural == "" && d.MsgIDPlural != "":
+		reasons = append(reasons, "msgid_plural removed")
+	case r.MsgIDPlural != d.MsgIDPlural:
+		reasons = append(reasons, "msgid_plural changed")
+	}
+	if len(reasons) > 0 && d.Obsolete {
+		reasons = append(reasons, "from an obsolete entry")
+	}
+	return reasons
+}
+
+// fuzzySearch returns the index of the translated entry whose msgid is most
+// similar to that of r, and the similarity; or -1 if none reaches
+// msgMergeFuzzyThreshold. On a tie, an entry with the same msgctxt wins.
+//
+// Like the fuzzy index of msgmerge, only the msgMergeFuzzyCandidates entries with
+// the most trigrams in common are compared, unless the msgid is too short to
+// have trigrams.
+func (m *msgMerger) fuzzySearch(r *GettextEntry) (int, float64) {
+	text := []rune(poUnescape(r.MsgID))
+	hist := runeHistogram(text)
+	best, bestSimilarity := -1, msgMergeFuzzyThreshold
+	for _, ci := range m.fuzzyCandidates(text) {
+		c := &m.candidates[ci]
+		total := float64(len(text) + len(c.text))
+		if total == 0 {
+			continue
+		}
+		// Cheap upper bounds of the similarity first.
+		shorter := len(text)
+		if len(c.text) < shorter {
+			shorter = len(c.text)
+		}
+		if 2*float64(shorter)/total < bestSimilarity ||
+			2*float64(histogramIntersection(hist, c.hist))/total < bestSimilarity {
+			continue
+		}
+		similarity := 2 * float64(c.masks.lcsLength(text)) / total
+		switch {
+		case similarity > bestSimilarity, best < 0 && similarity == bestSimilarity:
+		case similarity == bestSimilarity && sameMsgCtxt(m.def.Entries[c.index].MsgCtxt, r.MsgCtxt) &&
+			!sameMsgCtxt(m.def.Entries[best].MsgCtxt, r.MsgCtxt):
+		default:
+			continue
+		}
+		best, bestSimilarity = c.index, similarity
+	}
+	return best, bestSimilarity
+}
+
+// fuzzyCandidates returns the indices of the candidates to compare with text,
+// in the order of candidates.
+func (m *msgMerger) fuzzyCandidates(text []rune) []int {
+	grams := trigrams(text)
+	if len(grams) == 0 {
+		all := make([]int, len(m.candidates))
+		for i := range all {
+			all[i] = i
+		}
+		return all
+	}
+	if m.shared == nil {
+		m.shared = make([]int, len(m.candidates))
+	}
+	var list []int
+	for _, g := range grams {
+		for _, ci := range m.trigrams[g] {
+			if m.shared[ci] == 0 {
+				list = append(list, ci)
+			}
+			m.shared[ci]++
+		}
+	}
+	// Rank by the Dice coefficient of the trigram sets, so that long msgids
+	// do not win only by having many trigrams.
+	score := make([]float64, len(m.candidates))
+	for _, ci := range list {
+		score[ci] = float64(m.shared[ci]) / float64(len(grams)+m.candidates[ci].grams)
+		m.shared[ci] = 0
+	}
+	if len(list) > msgMergeFuzzyCandidates {
+		sort.Slice(list, func(i, k int) bool {
+			if score[list[i]] != score[list[k]] {
+				return score[list[i]] > score[list[k]]
+			}
+			return list[i] < list[k]
+		})
+		list = list[:msgMergeFuzzyCandidates]
+	}
+	sort.Ints(list)
+	return list
+}
+
+// trigrams returns the distinct trigrams of text.
+func trigrams(text []rune) []string {
+	var grams []string
+	seen := make(map[string]bool)
+	for i := 0; i+3 <= len(text); i++ {
+		g := string(text[i : i+3])
+		if !seen[g] {
+			seen[g] = true
+			grams = append(grams, g)
+		}
+	}
+	return grams
+}
+
+func sameMsgCtxt(a, b *string) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+func runeHistogram(text []rune) map[rune]int {
+	hist := make(map[rune]int)
+	for _, r := range text {
+		hist[r]++
+	}
+	return hist
+}
+
+func histogramIntersection(a, b map[rune]int) int {
+	if len(b) < len(a) {
+		a, b = b, a
+	}
+	n := 0
+	for r, count := range a {
+		if other := b[r]; other < count {
+			n += other
+		} else {
+			n += count
+		}
+	}
+	return n
+}
+
+// lcsMasks holds, for each rune of a text b, the bit mask of its positions in
+// b, for computing the length of the longest common subsequence of b and
+// other texts with the bit-parallel algorithm of Hyyrö.
+type lcsMasks struct {
+	n     int
+	masks map[rune][]uint64
+}
+
+func newLCSMasks(b []rune) lcsMasks {
+	words := (len(b) + 63) / 64
+	l := lcsMasks{n: len(b), masks: make(map[rune][]uint64)}
+	for i, r := range b {
+		mask := l.masks[r]
+		if mask == nil {
+			mask = make([]uint64, words)
+			l.masks[r] = mask
+		}
+		mask[i/64] |= 1 << uint(i%64)
+	}
+	return l
+}
+
+// lcsLength returns the length of the longest common subsequence of a and
+// the text of l. The similarity 2*LCS/(len(a)+len(b)) is what fstrcmp of
+// msgmerge computes.
+func (l lcsMasks) lcsLength(a []rune) int {
+	if l.n == 0 {
+		return 0
+	}
+	v := make([]uint64, (l.n+63)/64)
+	for i := range v {
+		v[i] = ^uint64(0)
+	}
+	for _, r := range a {
+		mask := l.masks[r]
+		if mask == nil {
+			continue
+		}
+		var carry uint64
+		for w := range v {
+			var sum uint64
+			sum, carry = bits.Add64(v[w], v[w]&mask[w], carry)
+			v[w] = sum | (v[w] &^ mask[w])
+		}
+	}
+	n := 0
+	for i := 0; i < l.n; i++ {
+		if v[i/64]&(1<<uint(i%64)) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// headerMetaField returns the value of field in a header msgstr stored in PO
+// format, or "" if there is none.
+func headerMetaField(headerMeta, field string) string {
+	po := &GettextPO{HeaderEntry: GettextEntry{MsgStr: []string{headerMeta}}}
+	return po.GetMeta(field)
+}
+
+// setHeaderMetaField replaces the value of field in a header msgstr stored in
+// PO format. The header is returned unchanged if it has no such field.
+func setHeaderMetaField(headerMeta, field, value string) string {
+	lines := strings.Split(headerMeta, `\n`)
+	for i, line := range lines {
+		if strings.HasPrefix(line, field+":") {
+			lines[i] = field + ": " + jsonDecodedToPoFormat(value)
+			return strings.Join(lines, `\n`)
+		}
+	}
+	return headerMeta
+}
+
+// Summary returns the statistics of the merge and one line for each message
+// that became fuzzy, with the reasons and the previous msgid.
+func (r *MsgMergeResult) Summary() []string {
+	stat := fmt.Sprintf("%d translated, %d fuzzy, %d untranslated, %d obsolete messages",
+		r.Translated, r.Fuzzy, r.Untranslated, r.Obsolete)
+	if r.Resurrected > 0 {
+		stat += fmt.Sprintf(" (%d resurrected from obsolete)", r.Resurrected)
+	}
+	lines := []string{stat}
+	for _, f := range r.FuzzyMatches {
+		msg := fmt.Sprintf("fuzzy %q: %s", poUnescape(f.MsgID), strings.Join(f.Reasons, ", "))
+		if f.Previous.MsgID != f.MsgID {
+			msg += fmt.Sprintf("; was %q", poUnescape(f.Previous.MsgID))
+		}
+		if !sameMsgCtxt(f.Previous.MsgCtxt, f.MsgCtxt) {
+			msg += fmt.Sprintf("; context was %s", msgCtxtDesc(f.Previous.MsgCtxt))
+		}
+		lines = append(lines, msg)
+	}
+	return lines
+}
+
+func msgCtxtDesc(msgctxt *string) string {
+	if msgctxt == nil {
+		return "none"
+	}
+	return fmt.Sprintf("%q", poUnescape(*msgctxt))
+}
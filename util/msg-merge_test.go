@@ -0,0 +1,241 @@
+package util
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+const msgMergeTestPO = `# Chinese translations.
+msgid ""
+msgstr ""
+"Project-Id-Version: git\n"
+"POT-Creation-Date: 2024-01-01 00:00+0800\n"
+"Content-Type: text/plain; charset=UTF-8\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+# translator note
+#: builtin/stash.c:10
+#, c-format
+msgid "Stash the changes in %s"
+msgstr "贮藏 %s 中的修改"
+
+#: builtin/stash.c:20
+msgid "stash list"
+msgstr "贮藏列表"
+
+#: builtin/add.c:1
+msgid "Nothing specified"
+msgstr "没有指定"
+
+msgid "removed message"
+msgstr "删除的消息"
+
+msgid "untranslated gone"
+msgstr ""
+
+#~ msgid "Hello world"
+#~ msgstr "你好世界"
+
+#, fuzzy
+#| msgid "Old fuzzy"
+msgid "Still fuzzy"
+msgstr "仍然模糊"
+`
+
+const msgMergeTestPOT = `msgid ""
+msgstr ""
+"Project-Id-Version: git\n"
+"POT-Creation-Date: 2025-06-01 12:00+0800\n"
+"Content-Type: text/plain; charset=CHARSET\n"
+
+#: builtin/stash.c:11 builtin/stash.c:99
+#, c-format
+msgid "Stash all the changes in %s"
+msgstr ""
+
+#: builtin/stash.c:21
+msgctxt "noun"
+msgid "stash list"
+msgstr ""
+
+#: builtin/add.c:2
+msgid "Nothing specified"
+msgid_plural "Nothings specified"
+msgstr[0] ""
+msgstr[1] ""
+
+#: hello.c:1
+msgid "Hello world"
+msgstr ""
+
+#: new.c:1
+msgid "Completely new text"
+msgstr ""
+
+msgid "Still fuzzy"
+msgstr ""
+`
+
+func parseMsgMergeTestFile(t *testing.T, content string) *GettextJSON {
+	po, err := ParsePoEntries([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return GettextJSONFromGettextPO(po)
+}
+
+func TestMsgMerge(t *testing.T) {
+	def := parseMsgMergeTestFile(t, msgMergeTestPO)
+	ref := parseMsgMergeTestFile(t, msgMergeTestPOT)
+	out, result := MsgMerge(def, ref, MsgMergeOptions{NoLineNumber: true})
+
+	if result.Translated != 1 || result.Fuzzy != 4 || result.Untranslated != 1 ||
+		result.Obsolete != 1 || result.Resurrected != 1 {
+		t.Errorf("result = %+v", result)
+	}
+	wantSummary := []string{
+		"1 translated, 4 fuzzy, 1 untranslated, 1 obsolete messages (1 resurrected from obsolete)",
+		`fuzzy "Stash all the changes in %s": msgid changed (92% similar); was "Stash the changes in %s"`,
+		`fuzzy "stash list": msgctxt changed; context was none`,
+		`fuzzy "Nothing specified": msgid_plural added`,
+	}
+	if got := result.Summary(); !equalStrings(got, wantSummary) {
+		t.Errorf("summary:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(wantSummary, "\n"))
+	}
+
+	var b bytes.Buffer
+	if err := WriteGettextJSONToPO(out, &b, false, false); err != nil {
+		t.Fatal(err)
+	}
+	want := `# Chinese translations.
+msgid ""
+msgstr ""
+"Project-Id-Version: git\n"
+"POT-Creation-Date: 2025-06-01 12:00+0800\n"
+"Content-Type: text/plain; charset=UTF-8\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+# translator note
+#: builtin/stash.c
+#, fuzzy, c-format
+#| msgid "Stash the changes in %s"
+msgid "Stash all the changes in %s"
+msgstr "贮藏 %s 中的修改"
+
+#: builtin/stash.c
+#, fuzzy
+#| msgid "stash list"
+msgctxt "noun"
+msgid "stash list"
+msgstr "贮藏列表"
+
+#: builtin/add.c
+#, fuzzy
+#| msgid "Nothing specified"
+msgid "Nothing specified"
+msgid_plural "Nothings specified"
+msgstr[0] "没有指定"
+msgstr[1] "没有指定"
+
+#: hello.c
+msgid "Hello world"
+msgstr "你好世界"
+
+#: new.c
+msgid "Completely new text"
+msgstr ""
+
+#, fuzzy
+#| msgid "Old fuzzy"
+msgid "Still fuzzy"
+msgstr "仍然模糊"
+
+#~ msgid "removed message"
+#~ msgstr "删除的消息"
+`
+	if got := b.String(); got != want {
+		t.Errorf("merged PO:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Merging again with the same template changes nothing.
+	again, result := MsgMerge(out, ref, MsgMergeOptions{NoLineNumber: true})
+	var b2 bytes.Buffer
+	if err := WriteGettextJSONToPO(again, &b2, false, false); err != nil {
+		t.Fatal(err)
+	}
+	if b2.String() != b.String() || len(result.FuzzyMatches) != 0 {
+		t.Errorf("second merge changed the result:\n%s", b2.String())
+	}
+}
+
+func TestMsgMergeOptions(t *testing.T) {
+	def := parseMsgMergeTestFile(t, msgMergeTestPO)
+	ref := parseMsgMergeTestFile(t, msgMergeTestPOT)
+
+	out, result := MsgMerge(def, ref, MsgMergeOptions{NoFuzzyMatching: true})
+	if result.Fuzzy != 2 || result.Untranslated != 3 || len(result.FuzzyMatches) != 1 {
+		t.Errorf("no fuzzy matching: result = %+v", result)
+	}
+	if got := out.Entries[0].Comments; !equalStrings(got, []string{"#: builtin/stash.c:11 builtin/stash.c:99", "#, c-format"}) {
+		t.Errorf("references with line numbers: %q", got)
+	}
+
+	out, _ = MsgMerge(def, ref, MsgMergeOptions{NoLocation: true})
+	for _, e := range out.Entries {
+		if len(e.ParsedComments().References) > 0 {
+			t.Errorf("no location: %q has references %q", e.MsgID, e.Comments)
+		}
+	}
+}
+
+func TestReferenceLines(t *testing.T) {
+	var refs []PoReference
+	for i := 0; i < 6; i++ {
+		refs = append(refs, PoReference{File: "builtin/submodule--helper.c", Line: 1000 + i})
+	}
+	want := []string{
+		"#: builtin/submodule--helper.c:1000 builtin/submodule--helper.c:1001",
+		"#: builtin/submodule--helper.c:1002 builtin/submodule--helper.c:1003",
+		"#: builtin/submodule--helper.c:1004 builtin/submodule--helper.c:1005",
+	}
+	if got := referenceLines(refs); !equalStrings(got, want) {
+		t.Errorf("referenceLines = %q, want %q", got, want)
+	}
+}
+
+func TestLCSLength(t *testing.T) {
+	dp := func(a, b []rune) int {
+		prev := make([]int, len(b)+1)
+		for i := range a {
+			cur := make([]int, len(b)+1)
+			for k := range b {
+				switch {
+				case a[i] == b[k]:
+					cur[k+1] = prev[k] + 1
+				case prev[k+1] > cur[k]:
+					cur[k+1] = prev[k+1]
+				default:
+					cur[k+1] = cur[k]
+				}
+			}
+			prev = cur
+		}
+		return prev[len(b)]
+	}
+	rnd := rand.New(rand.NewSource(1))
+	text := func() []rune {
+		s := make([]rune, rnd.Intn(150))
+		for i := range s {
+			s[i] = []rune("abc 中文")[rnd.Intn(6)]
+		}
+		return s
+	}
+	for i := 0; i < 200; i++ {
+		a, b := text(), text()
+		if got, want := newLCSMasks(b).lcsLength(a), dp(a, b); got != want {
+			t.Fatalf("lcsLength(%q, %q) = %d, want %d", string(a), string(b), got, want)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+// Package util provides splitting of PO files into chunks for translators.
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Header fields recorded in each chunk by MsgSplit.
+const (
+	SplitHeaderFrom     = "X-Split-From"     // the split file, e.g. "po/zh_CN.po"
+	SplitHeaderChunk    = "X-Split-Chunk"    // "2/3": chunk 2 of 3
+	SplitHeaderRange    = "X-Split-Range"    // "41-80 of 120", or "0 of 120" when empty
+	SplitHeaderKeys     = "X-Split-Keys"     // digest of the keys of all chunks
+	SplitHeaderAssignee = "X-Split-Assignee" // team member of the chunk
+)
+
+// MsgSplitOptions specifies how MsgSplit partitions entries.
+type MsgSplitOptions struct {
+	// Chunks is the number of chunks; ignored when Assignees is set.
+	Chunks int
+	// ByFile keeps the entries of one source file in one chunk: chunks are
+	// only cut where the file of the first reference ("#:") changes.
+	ByFile bool
+	// Assignees gives one chunk to each assignee, e.g. the members of a team.
+	Assignees []string
+}
+
+// MsgSplitChunk is one chunk of MsgSplit: the entries First to Last
+// (1-based) of the split entries. An empty chunk has Last == First-1.
+type MsgSplitChunk struct {
+	Index    int // 1-based
+	First    int
+	Last     int
+	Assignee string
+	JSON     *GettextJSON
+}
+
+// Len returns the number of entries of the chunk.
+func (c *MsgSplitChunk) Len() int {
+	return c.Last - c.First + 1
+}
+
+// splitKeysDigest returns a short digest of the keys of entries, independent
+// of their order.
+func splitKeysDigest(keys []string) string {
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+	sum := sha256.Sum256([]byte(strings.Join(sorted, "\n")))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// splitEntryFile returns the source file of the first reference of e.
+func splitEntryFile(e *GettextEntry) string {
+	if refs := e.ParsedComments().References; len(refs) > 0 {
+		return refs[0].File
+	}
+	return ""
+}
+
+// splitCuts returns the end (exclusive) of each of n chunks of total
+// entries. Chunks are cut at the allowed positions (sorted, from 0 to total)
+// that are nearest to an even split.
+func splitCuts(allowed []int, total, n int) []int {
+	cuts := make([]int, 0, n)
+	prev, k := 0, 0
+	for i := 1; i < n; i++ {
+		target := int(math.Round(float64(total) * float64(i) / float64(n)))
+		for k+1 < len(allowed) && allowed[k+1] <= target {
+			k++
+		}
+		cut := allowed[k]
+		if k+1 < len(allowed) && allowed[k+1]-target < target-cut {
+			cut = allowed[k+1]
+		}
+		if cut < prev {
+			cut = prev
+		}
+		cuts = append(cuts, cut)
+		prev = cut
+	}
+	return append(cuts, total)
+}
+
+// MsgSplit partitions the entries of j (obsolete entries and the header
+// excluded) into chunks of consecutive entries of about the same size.
+// Each chunk has the header of j with fields recording origin (the name of
+// the split file), its position and a digest of all split keys, so that
+// CheckSplitChunks can verify the reassembled chunks.
+func MsgSplit(j *GettextJSON, origin string, opts MsgSplitOptions) ([]MsgSplitChunk, error) {
+	n := opts.Chunks
+	if len(opts.Assignees) > 0 {
+		n = len(opts.Assignees)
+	}
+	if n < 1 {
+		return nil, fmt.Errorf("cannot split into %d chunks", n)
+	}
+	var entries []GettextEntry
+	var keys []string
+	for _, e := range filterObsolete(j.Entries) {
+		if e.MsgID == "" {
+			continue
+		}
+		entries = append(entries, e)
+		keys = append(keys, entryKey(e))
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no entries to split in %s", origin)
+	}
+
+	allowed := make([]int, 0, len(entries)+1)
+	for i := 0; i <= len(entries); i++ {
+		if !opts.ByFile || i == 0 || i == len(entries) ||
+			splitEntryFile(&entries[i-1]) != splitEntryFile(&entries[i]) {
+			allowed = append(allowed, i)
+		}
+	}
+	digest := splitKeysDigest(keys)
+	chunks := make([]MsgSplitChunk, 0, n)
+	start := 0
+	for i, end := range splitCuts(allowed, len(entries), n) {
+		c := MsgSplitChunk{Index: i + 1, First: start + 1, Last: end}
+		if len(opts.Assignees) > 0 {
+			c.Assignee = opts.Assignees[i]
+		}
+		meta := setOrAddHeaderMetaField(j.HeaderMeta, SplitHeaderFrom, origin)
+		meta = setOrAddHeaderMetaField(meta, SplitHeaderChunk, fmt.Sprintf("%d/%d", c.Index, n))
+		meta = setOrAddHeaderMetaField(meta, SplitHeaderRange, formatSplitRange(c.First, c.Last, len(entries)))
+		meta = setOrAddHeaderMetaField(meta, SplitHeaderKeys, digest)
+		if c.Assignee != "" {
+			meta = setOrAddHeaderMetaField(meta, SplitHeaderAssignee, c.Assignee)
+		}
+		c.JSON = &GettextJSON{
+			HeaderComment: j.HeaderComment,
+			HeaderMeta:    meta,
+			Entries:       append([]GettextEntry{}, entries[start:end]...),
+		}
+		chunks = append(chunks, c)
+		start = end
+	}
+	return chunks, nil
+}
+
+func formatSplitRange(first, last, total int) string {
+	if last < first {
+		return fmt.Sprintf("0 of %d", total)
+	}
+	return fmt.Sprintf("%d-%d of %d", first, last, total)
+}
+
+// setOrAddHeaderMetaField is setHeaderMetaField, but adds field at the end
+// of the header if it has no such field.
+func setOrAddHeaderMetaField(headerMeta, field, value string) string {
+	if headerMetaField(headerMeta, field) != "" {
+		return setHeaderMetaField(headerMeta, field, value)
+	}
+	if headerMeta != "" && !strings.HasSuffix(headerMeta, `\n`) {
+		headerMeta += `\n`
+	}
+	return headerMeta + field + ": " + jsonDecodedToPoFormat(value) + `\n`
+}
+
+// RemoveSplitHeaderFields removes the fields added by MsgSplit from a header
+// msgstr stored in PO format.
+func RemoveSplitHeaderFields(headerMeta string) string {
+	lines := strings.Split(headerMeta, `\n`)
+	kept := lines[:0]
+	for _, line := range lines {
+		if strings.HasPrefix(line, "X-Split-") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, `\n`)
+}
+
+// SplitInfo is the split fields of the header of a chunk.
+type SplitInfo struct {
+	From   string
+	Chunk  int
+	Chunks int
+	First  int
+	Last   int
+	Total  int
+	Keys   string
+}
+
+// ParseSplitInfo returns the split fields of a header msgstr stored in PO
+// format, or nil if the header is not the header of a chunk.
+func ParseSplitInfo(headerMeta string) (*SplitInfo, error) {
+	info := SplitInfo{
+		From: headerMetaField(headerMeta, SplitHeaderFrom),
+		Keys: headerMetaField(headerMeta, SplitHeaderKeys),
+	}
+	if info.From == "" {
+		return nil, nil
+	}
+	chunk := headerMetaField(headerMeta, SplitHeaderChunk)
+	if _, err := fmt.Sscanf(chunk, "%d/%d", &info.Chunk, &info.Chunks); err != nil ||
+		info.Chunk < 1 || info.Chunk > info.Chunks {
+		return nil, fmt.Errorf("bad %s: %q", SplitHeaderChunk, chunk)
+	}
+	rng := headerMetaField(headerMeta, SplitHeaderRange)
+	badRange := fmt.Errorf("bad %s: %q", SplitHeaderRange, rng)
+	fields := strings.SplitN(rng, " of ", 2)
+	if len(fields) != 2 {
+		return nil, badRange
+	}
+	total, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return nil, badRange
+	}
+	info.Total = total
+	if fields[0] == "0" {
+		info.First, info.Last = 1, 0
+		return &info, nil
+	}
+	if _, err := fmt.Sscanf(fields[0], "%d-%d", &info.First, &info.Last); err != nil ||
+		info.First < 1 || info.Last < info.First || info.Last > total {
+		return nil, badRange
+	}
+	return &info, nil
+}
+
+// CheckSplitChunks verifies that sources (named names) are all the chunks of
+// one MsgSplit, and that together they have exactly the split keys: no chunk
+// is missing, no entry is duplicated, added or removed. It returns the
+// problems found.
+func CheckSplitChunks(sources []*GettextJSON, names []string) []string {
+	var (
+		problems []string
+		first    *SplitInfo
+		seen     = make(map[int]string)
+		keyFile  = make(map[string]string)
+		keys     []string
+	)
+	for i, j := range sources {
+		info, err := ParseSplitInfo(j.HeaderMeta)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", names[i], err))
+			continue
+		}
+		if info == nil {
+			problems = append(problems, fmt.Sprintf("%s: not a chunk of msg-split (no %s header)",
+				names[i], SplitHeaderFrom))
+			continue
+		}
+		if first == nil {
+			first = info
+		} else if info.From != first.From || info.Chunks != first.Chunks ||
+			info.Total != first.Total || info.Keys != first.Keys {
+			problems = append(problems, fmt.Sprintf("%s: chunk %d/%d of another split of %s",
+				names[i], info.Chunk, info.Chunks, info.From))
+			continue
+		}
+		if other, ok := seen[info.Chunk]; ok {
+			problems = append(problems, fmt.Sprintf("%s: chunk %d/%d is also %s",
+				names[i], info.Chunk, info.Chunks, other))
+			continue
+		}
+		seen[info.Chunk] = names[i]
+
+		count := 0
+		for _, e := range filterObsolete(j.Entries) {
+			if e.MsgID == "" {
+				continue
+			}
+			count++
+			key := entryKey(e)
+			if other, ok := keyFile[key]; ok {
+				problems = append(problems, fmt.Sprintf("%s: msgid %q is also in %s",
+					names[i], poUnescape(e.MsgID), other))
+				continue
+			}
+			keyFile[key] = names[i]
+			keys = append(keys, key)
+		}
+		if want := info.Last - info.First + 1; count != want {
+			problems = append(problems, fmt.Sprintf("%s: has %d entries, expected %d (%s)",
+				names[i], count, want, formatSplitRange(info.First, info.Last, info.Total)))
+		}
+	}
+	if first == nil {
+		return problems
+	}
+	for i := 1; i <= first.Chunks; i++ {
+		if _, ok := seen[i]; !ok {
+			problems = append(problems, fmt.Sprintf("missing chunk %d/%d of %s", i, first.Chunks, first.From))
+		}
+	}
+	if len(problems) == 0 && (len(keys) != first.Total || splitKeysDigest(keys) != first.Keys) {
+		problems = append(problems, fmt.Sprintf("chunks have %d entries, but not the %d entries split from %s",
+			len(keys), first.Total, first.From))
+	}
+	return problems
+}
@@ -0,0 +1,169 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func msgSplitTestJSON() *GettextJSON {
+	j := &GettextJSON{HeaderMeta: `Project-Id-Version: git\nContent-Type: text/plain; charset=UTF-8\n`}
+	for _, ref := range []string{"a.c:1", "a.c:2", "a.c:3", "b.c:1", "c.c:1", "c.c:2", "c.c:3"} {
+		j.Entries = append(j.Entries, GettextEntry{
+			Comments: []string{"#: " + ref + "\n"},
+			MsgID:    strings.ReplaceAll(ref, ".c:", ""),
+			MsgStr:   []string{""},
+		})
+	}
+	j.Entries = append(j.Entries, GettextEntry{MsgID: "old", MsgStr: []string{"alt"}, Obsolete: true})
+	return j
+}
+
+func TestMsgSplit(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		opts   MsgSplitOptions
+		ranges []string
+	}{
+		{"one chunk", MsgSplitOptions{Chunks: 1}, []string{"1-7 of 7"}},
+		{"by count", MsgSplitOptions{Chunks: 3}, []string{"1-2 of 7", "3-5 of 7", "6-7 of 7"}},
+		{"by file", MsgSplitOptions{Chunks: 3, ByFile: true}, []string{"1-3 of 7", "4-4 of 7", "5-7 of 7"}},
+		{"by file, few files", MsgSplitOptions{Chunks: 5, ByFile: true},
+			[]string{"0 of 7", "1-3 of 7", "4-4 of 7", "5-7 of 7", "0 of 7"}},
+		{"assignees", MsgSplitOptions{Assignees: []string{"A <a@x.org>", "B <b@x.org>"}},
+			[]string{"1-4 of 7", "5-7 of 7"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			chunks, err := MsgSplit(msgSplitTestJSON(), "po/de.po", tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ranges []string
+			sources := make([]*GettextJSON, 0, len(chunks))
+			names := make([]string, 0, len(chunks))
+			for _, c := range chunks {
+				info, err := ParseSplitInfo(c.JSON.HeaderMeta)
+				if err != nil || info == nil {
+					t.Fatalf("chunk %d: split info %v, %v", c.Index, info, err)
+				}
+				if info.Chunk != c.Index || info.Chunks != len(chunks) || info.From != "po/de.po" {
+					t.Errorf("chunk %d: bad split info %+v", c.Index, info)
+				}
+				if len(c.JSON.Entries) != c.Len() {
+					t.Errorf("chunk %d: %d entries, want %d", c.Index, len(c.JSON.Entries), c.Len())
+				}
+				if len(tc.opts.Assignees) > 0 &&
+					headerMetaField(c.JSON.HeaderMeta, SplitHeaderAssignee) != tc.opts.Assignees[c.Index-1] {
+					t.Errorf("chunk %d: bad assignee in %q", c.Index, c.JSON.HeaderMeta)
+				}
+				ranges = append(ranges, headerMetaField(c.JSON.HeaderMeta, SplitHeaderRange))
+				sources = append(sources, c.JSON)
+				names = append(names, fmt.Sprintf("de-%d.po", c.Index))
+			}
+			if strings.Join(ranges, "|") != strings.Join(tc.ranges, "|") {
+				t.Errorf("ranges %q, want %q", ranges, tc.ranges)
+			}
+			if problems := CheckSplitChunks(sources, names); len(problems) > 0 {
+				t.Errorf("unexpected problems: %q", problems)
+			}
+		})
+	}
+
+	if _, err := MsgSplit(&GettextJSON{}, "po/de.po", MsgSplitOptions{Chunks: 2}); err == nil {
+		t.Error("expected an error for no entries")
+	}
+}
+
+func TestCheckSplitChunks(t *testing.T) {
+	split := func() []*GettextJSON {
+		chunks, err := MsgSplit(msgSplitTestJSON(), "po/de.po", MsgSplitOptions{Chunks: 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var sources []*GettextJSON
+		for _, c := range chunks {
+			sources = append(sources, c.JSON)
+		}
+		return sources
+	}
+	other, err := MsgSplit(msgSplitTestJSON(), "po/fr.po", MsgSplitOptions{Chunks: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{"de-1.po", "de-2.po", "de-3.po"}
+
+	for _, tc := range []struct {
+		name     string
+		edit     func(s []*GettextJSON) []*GettextJSON
+		problems []string
+	}{
+		{
+			name:     "missing chunk",
+			edit:     func(s []*GettextJSON) []*GettextJSON { return []*GettextJSON{s[0], s[2]} },
+			problems: []string{"missing chunk 2/3 of po/de.po"},
+		},
+		{
+			name:     "same chunk twice",
+			edit:     func(s []*GettextJSON) []*GettextJSON { return []*GettextJSON{s[0], s[1], s[1]} },
+			problems: []string{"de-3.po: chunk 2/3 is also de-2.po", "missing chunk 3/3 of po/de.po"},
+		},
+		{
+			name:     "chunk of another split",
+			edit:     func(s []*GettextJSON) []*GettextJSON { return []*GettextJSON{s[0], s[1], other[2].JSON} },
+			problems: []string{"de-3.po: chunk 3/3 of another split of po/fr.po", "missing chunk 3/3 of po/de.po"},
+		},
+		{
+			name: "not a chunk",
+			edit: func(s []*GettextJSON) []*GettextJSON {
+				return []*GettextJSON{s[0], s[1], msgSplitTestJSON()}
+			},
+			problems: []string{"de-3.po: not a chunk of msg-split (no X-Split-From header)",
+				"missing chunk 3/3 of po/de.po"},
+		},
+		{
+			name: "entry removed",
+			edit: func(s []*GettextJSON) []*GettextJSON {
+				s[1].Entries = s[1].Entries[1:]
+				return s
+			},
+			problems: []string{"de-2.po: has 2 entries, expected 3 (3-5 of 7)"},
+		},
+		{
+			name: "entry duplicated",
+			edit: func(s []*GettextJSON) []*GettextJSON {
+				s[0].Entries = append(s[0].Entries, s[1].Entries[0])
+				return s
+			},
+			problems: []string{"de-1.po: has 3 entries, expected 2 (1-2 of 7)",
+				`de-2.po: msgid "a3" is also in de-1.po`},
+		},
+		{
+			name: "msgid changed",
+			edit: func(s []*GettextJSON) []*GettextJSON {
+				s[2].Entries[0].MsgID = "x"
+				return s
+			},
+			problems: []string{"chunks have 7 entries, but not the 7 entries split from po/de.po"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sources := tc.edit(split())
+			problems := CheckSplitChunks(sources, names[:len(sources)])
+			if strings.Join(problems, "\n") != strings.Join(tc.problems, "\n") {
+				t.Errorf("problems:\n%s\nwant:\n%s", strings.Join(problems, "\n"), strings.Join(tc.problems, "\n"))
+			}
+		})
+	}
+}
+
+func TestRemoveSplitHeaderFields(t *testing.T) {
+	chunks, err := MsgSplit(msgSplitTestJSON(), "po/de.po",
+		MsgSplitOptions{Assignees: []string{"A <a@x.org>"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := msgSplitTestJSON().HeaderMeta
+	if got := RemoveSplitHeaderFields(chunks[0].JSON.HeaderMeta); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,482 @@
+// Package util provides Plural-Forms header parsing and evaluation.
+package util
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pluralFormsCheckLimit is the largest n evaluated when validating that a
+// plural expression always yields an index below nplurals (msgfmt checks a
+// similar range).
+const pluralFormsCheckLimit = 1000
+
+// PluralForms is a parsed "Plural-Forms:" header value, e.g.
+// "nplurals=2; plural=(n != 1);".
+type PluralForms struct {
+	NPlurals int
+	// Expr is the plural expression as written in the header (without the trailing ';').
+	Expr string
+	expr pluralNode
+}
+
+// errPluralFormsTemplate is returned for the untouched POT template value
+// "nplurals=INTEGER; plural=EXPRESSION;".
+var errPluralFormsTemplate = errors.New("Plural-Forms is the POT template placeholder (nplurals=INTEGER; plural=EXPRESSION;)")
+
+// ParsePluralForms parses the value of a Plural-Forms header line.
+func ParsePluralForms(value string) (*PluralForms, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, errors.New("empty Plural-Forms")
+	}
+	var nplurals, plural string
+	var hasNPlurals, hasPlural bool
+	for _, field := range strings.Split(value, ";") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		eq := strings.IndexByte(field, '=')
+		if eq < 0 {
+			return nil, fmt.Errorf("bad Plural-Forms field %q (want key=value)", field)
+		}
+		key := strings.TrimSpace(field[:eq])
+		val := strings.TrimSpace(field[eq+1:])
+		switch key {
+		case "nplurals":
+			nplurals, hasNPlurals = val, true
+		case "plural":
+			plural, hasPlural = val, true
+		default:
+			return nil, fmt.Errorf("unknown Plural-Forms field %q", key)
+		}
+	}
+	if nplurals == "INTEGER" || plural == "EXPRESSION" {
+		return nil, errPluralFormsTemplate
+	}
+	if !hasNPlurals {
+		return nil, errors.New("Plural-Forms has no nplurals")
+	}
+	if !hasPlural {
+		return nil, errors.New("Plural-Forms has no plural expression")
+	}
+	n, err := strconv.Atoi(nplurals)
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("invalid nplurals %q in Plural-Forms", nplurals)
+	}
+	node, err := parsePluralExpr(plural)
+	if err != nil {
+		return nil, fmt.Errorf("invalid plural expression %q: %w", plural, err)
+	}
+	return &PluralForms{NPlurals: n, Expr: plural, expr: node}, nil
+}
+
+// Eval returns the index of the plural form used for count n.
+func (pf *PluralForms) Eval(n uint64) (int, error) {
+	v, err := pf.expr.eval(n)
+	if err != nil {
+		return 0, err
+	}
+	if v >= uint64(pf.NPlurals) {
+		return 0, fmt.Errorf("plural expression yields %d for n=%d, but nplurals=%d", v, n, pf.NPlurals)
+	}
+	return int(v), nil
+}
+
+// Validate evaluates the expression for n in [0, pluralFormsCheckLimit] and
+// reports the first n for which the result is out of range or evaluation fails.
+func (pf *PluralForms) Validate() error {
+	for n := uint64(0); n <= pluralFormsCheckLimit; n++ {
+		if _, err := pf.Eval(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PluralFormsFromHeaderMeta parses the Plural-Forms line of a header msgstr
+// stored in PO format (as in GettextJSON.HeaderMeta). Returns nil, nil when
+// the header has no Plural-Forms line.
+func PluralFormsFromHeaderMeta(headerMeta string) (*PluralForms, error) {
+	po := &GettextPO{HeaderEntry: GettextEntry{MsgStr: []string{headerMeta}}}
+	return po.PluralForms()
+}
+
+// PluralForms parses the Plural-Forms header of the PO file.
+// Returns nil, nil when the header has no Plural-Forms line.
+func (po *GettextPO) PluralForms() (*PluralForms, error) {
+	value := po.GetMeta("Plural-Forms")
+	if value == "" {
+		return nil, nil
+	}
+	return ParsePluralForms(value)
+}
+
+// checkPluralFormCount returns an error message if a plural entry does not have exactly
+// nplurals msgstr forms, or "" when the count is right or the entry is not a plural entry.
+func checkPluralFormCount(e *GettextEntry, entryIndex, nplurals int) string {
+	if e.MsgIDPlural == "" || len(e.MsgStr) == nplurals {
+		return ""
+	}
+	msgid := e.MsgID
+	if len(msgid) > 30 {
+		msgid = msgid[:27] + "..."
+	}
+	return fmt.Sprintf("%s: has %d msgstr[] forms, but Plural-Forms requires nplurals=%d",
+		entryDescWithLine(entryIndex, msgid, e.EntryLocation), len(e.MsgStr), nplurals)
+}
+
+// checkPoPluralForms parses the Plural-Forms header and checks that every plural
+// entry has exactly nplurals msgstr forms. Obsolete entries are ignored.
+func checkPoPluralForms(po *GettextPO) ([]string, bool) {
+	var (
+		errs       []string
+		hasPlurals bool
+	)
+	for _, e := range po.Entries {
+		if e.MsgIDPlural != "" && !e.Obsolete {
+			hasPlurals = true
+			break
+		}
+	}
+	pf, err := po.PluralForms()
+	if err != nil {
+		return []string{fmt.Sprintf("header: %v", err)}, false
+	}
+	if pf == nil {
+		if hasPlurals {
+			return []string{"header: missing Plural-Forms, but the file has plural entries"}, false
+		}
+		return nil, true
+	}
+	if err := pf.Validate(); err != nil {
+		errs = append(errs, fmt.Sprintf("header: bad Plural-Forms %q: %v", pf.Expr, err))
+	}
+	for i := range po.Entries {
+		e := &po.Entries[i]
+		if e.Obsolete {
+			continue
+		}
+		if msg := checkPluralFormCount(e, i+1, pf.NPlurals); msg != "" {
+			errs = append(errs, msg)
+		}
+	}
+	return errs, len(errs) == 0
+}
+
+// CheckGettextJSONPluralForms checks that every plural entry in j has exactly
+// pf.NPlurals msgstr forms. When pf is nil, the Plural-Forms of j.HeaderMeta is
+// used; if neither is available (or the header is the POT placeholder), no check is done.
+// Used to reject agent output with a wrong number of msgstr[] forms.
+func CheckGettextJSONPluralForms(j *GettextJSON, pf *PluralForms) error {
+	if j == nil {
+		return nil
+	}
+	if pf == nil {
+		var err error
+		pf, err = PluralFormsFromHeaderMeta(j.HeaderMeta)
+		if err == errPluralFormsTemplate {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if pf == nil {
+			return nil
+		}
+	}
+	var msgs []string
+	for i := range j.Entries {
+		e := &j.Entries[i]
+		if e.Obsolete {
+			continue
+		}
+		if msg := checkPluralFormCount(e, i+1, pf.NPlurals); msg != "" {
+			msgs = append(msgs, "  "+msg)
+		}
+	}
+	if len(msgs) > 0 {
+		return fmt.Errorf("%d plural entr(ies) with wrong number of msgstr[] forms:\n%s",
+			len(msgs), strings.Join(msgs, "\n"))
+	}
+	return nil
+}
+
+// pluralNode is a node of a parsed plural expression.
+type pluralNode interface {
+	eval(n uint64) (uint64, error)
+}
+
+type pluralVar struct{}
+
+type pluralConst uint64
+
+type pluralUnary struct {
+	op string
+	x  pluralNode
+}
+
+type pluralBinary struct {
+	op   string
+	x, y pluralNode
+}
+
+type pluralCond struct {
+	cond, yes, no pluralNode
+}
+
+func (pluralVar) eval(n uint64) (uint64, error) { return n, nil }
+
+func (c pluralConst) eval(uint64) (uint64, error) { return uint64(c), nil }
+
+func (u pluralUnary) eval(n uint64) (uint64, error) {
+	v, err := u.x.eval(n)
+	if err != nil {
+		return 0, err
+	}
+	// Only "!" is a unary operator in plural expressions.
+	return pluralBool(v == 0), nil
+}
+
+func (b pluralBinary) eval(n uint64) (uint64, error) {
+	x, err := b.x.eval(n)
+	if err != nil {
+		return 0, err
+	}
+	// Short-circuit logical operators like C.
+	switch b.op {
+	case "&&":
+		if x == 0 {
+			return 0, nil
+		}
+	case "||":
+		if x != 0 {
+			return 1, nil
+		}
+	}
+	y, err := b.y.eval(n)
+	if err != nil {
+		return 0, err
+	}
+	switch b.op {
+	case "&&", "||":
+		return pluralBool(y != 0), nil
+	case "==":
+		return pluralBool(x == y), nil
+	case "!=":
+		return pluralBool(x != y), nil
+	case "<":
+		return pluralBool(x < y), nil
+	case "<=":
+		return pluralBool(x <= y), nil
+	case ">":
+		return pluralBool(x > y), nil
+	case ">=":
+		return pluralBool(x >= y), nil
+	case "+":
+		return x + y, nil
+	case "-":
+		return x - y, nil
+	case "*":
+		return x * y, nil
+	case "/", "%":
+		if y == 0 {
+			return 0, fmt.Errorf("division by zero for n=%d", n)
+		}
+		if b.op == "/" {
+			return x / y, nil
+		}
+		return x % y, nil
+	}
+	return 0, fmt.Errorf("unknown operator %q", b.op)
+}
+
+func (c pluralCond) eval(n uint64) (uint64, error) {
+	v, err := c.cond.eval(n)
+	if err != nil {
+		return 0, err
+	}
+	if v != 0 {
+		return c.yes.eval(n)
+	}
+	return c.no.eval(n)
+}
+
+func pluralBool(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// pluralParser is a recursive-descent parser for the C subset used by plural
+// expressions: n, unsigned integers, ! * / % + - < <= > >= == != && || ?: and parentheses.
+type pluralParser struct {
+	tokens []string
+	pos    int
+}
+
+// pluralBinaryLevels lists binary operators from lowest to highest precedence.
+var pluralBinaryLevels = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func parsePluralExpr(s string) (pluralNode, error) {
+	tokens, err := tokenizePluralExpr(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("empty expression")
+	}
+	p := &pluralParser{tokens: tokens}
+	node, err := p.parseCond()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos])
+	}
+	return node, nil
+}
+
+func tokenizePluralExpr(s string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+				j++
+			}
+			tokens = append(tokens, s[i:j])
+			i = j
+		case c == 'n':
+			tokens = append(tokens, "n")
+			i++
+		default:
+			if i+1 < len(s) {
+				switch two := s[i : i+2]; two {
+				case "&&", "||", "==", "!=", "<=", ">=":
+					tokens = append(tokens, two)
+					i += 2
+					continue
+				}
+			}
+			if strings.IndexByte("!*/%+-<>?:()", c) < 0 {
+				return nil, fmt.Errorf("unexpected character %q", c)
+			}
+			tokens = append(tokens, string(c))
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+func (p *pluralParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *pluralParser) parseCond() (pluralNode, error) {
+	cond, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != "?" {
+		return cond, nil
+	}
+	p.pos++
+	yes, err := p.parseCond()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != ":" {
+		return nil, errors.New("missing ':' in conditional expression")
+	}
+	p.pos++
+	no, err := p.parseCond()
+	if err != nil {
+		return nil, err
+	}
+	return pluralCond{cond: cond, yes: yes, no: no}, nil
+}
+
+func (p *pluralParser) parseBinary(level int) (pluralNode, error) {
+	if level >= len(pluralBinaryLevels) {
+		return p.parseUnary()
+	}
+	x, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek()
+		found := false
+		for _, o := range pluralBinaryLevels[level] {
+			if op == o {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return x, nil
+		}
+		p.pos++
+		y, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		x = pluralBinary{op: op, x: x, y: y}
+	}
+}
+
+func (p *pluralParser) parseUnary() (pluralNode, error) {
+	tok := p.peek()
+	switch {
+	case tok == "":
+		return nil, errors.New("unexpected end of expression")
+	case tok == "!":
+		p.pos++
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return pluralUnary{op: "!", x: x}, nil
+	case tok == "(":
+		p.pos++
+		x, err := p.parseCond()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, errors.New("missing ')'")
+		}
+		p.pos++
+		return x, nil
+	case tok == "n":
+		p.pos++
+		return pluralVar{}, nil
+	case tok[0] >= '0' && tok[0] <= '9':
+		p.pos++
+		v, err := strconv.ParseUint(tok, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad number %q", tok)
+		}
+		return pluralConst(v), nil
+	}
+	return nil, fmt.Errorf("unexpected %q", tok)
+}
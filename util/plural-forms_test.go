@@ -0,0 +1,201 @@
+package util
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePluralForms(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		nplurals int
+		want     map[uint64]int // n -> plural index
+		wantErr  string
+	}{
+		{
+			name:     "germanic",
+			value:    "nplurals=2; plural=(n != 1);",
+			nplurals: 2,
+			want:     map[uint64]int{0: 1, 1: 0, 2: 1, 100: 1},
+		},
+		{
+			name:     "single form",
+			value:    "nplurals=1; plural=0;",
+			nplurals: 1,
+			want:     map[uint64]int{0: 0, 1: 0, 7: 0},
+		},
+		{
+			name:     "french",
+			value:    "nplurals=2; plural=n>1;",
+			nplurals: 2,
+			want:     map[uint64]int{0: 0, 1: 0, 2: 1},
+		},
+		{
+			name:     "russian nested ternary",
+			value:    "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+			nplurals: 3,
+			want:     map[uint64]int{1: 0, 2: 1, 5: 2, 11: 2, 21: 0, 22: 1, 112: 2},
+		},
+		{
+			name:     "arabic",
+			value:    "nplurals=6; plural=n==0 ? 0 : n==1 ? 1 : n==2 ? 2 : n%100>=3 && n%100<=10 ? 3 : n%100>=11 ? 4 : 5;",
+			nplurals: 6,
+			want:     map[uint64]int{0: 0, 1: 1, 2: 2, 3: 3, 11: 4, 100: 5},
+		},
+		{
+			name:     "logical not",
+			value:    "nplurals=2; plural=!(n==1);",
+			nplurals: 2,
+			want:     map[uint64]int{1: 0, 3: 1},
+		},
+		{
+			name:    "POT placeholder",
+			value:   "nplurals=INTEGER; plural=EXPRESSION;",
+			wantErr: "placeholder",
+		},
+		{
+			name:    "missing plural",
+			value:   "nplurals=2;",
+			wantErr: "no plural expression",
+		},
+		{
+			name:    "bad nplurals",
+			value:   "nplurals=0; plural=0;",
+			wantErr: "invalid nplurals",
+		},
+		{
+			name:    "unbalanced parenthesis",
+			value:   "nplurals=2; plural=(n != 1;",
+			wantErr: "missing ')'",
+		},
+		{
+			name:    "unknown identifier",
+			value:   "nplurals=2; plural=m != 1;",
+			wantErr: "unexpected character",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pf, err := ParsePluralForms(tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParsePluralForms(%q) err = %v, want containing %q", tt.value, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePluralForms(%q): %v", tt.value, err)
+			}
+			if pf.NPlurals != tt.nplurals {
+				t.Errorf("NPlurals = %d, want %d", pf.NPlurals, tt.nplurals)
+			}
+			if err := pf.Validate(); err != nil {
+				t.Errorf("Validate: %v", err)
+			}
+			for n, want := range tt.want {
+				got, err := pf.Eval(n)
+				if err != nil {
+					t.Errorf("Eval(%d): %v", n, err)
+				} else if got != want {
+					t.Errorf("Eval(%d) = %d, want %d", n, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPluralFormsValidate_OutOfRange(t *testing.T) {
+	pf, err := ParsePluralForms("nplurals=2; plural=n;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pf.Validate(); err == nil || !strings.Contains(err.Error(), "nplurals=2") {
+		t.Errorf("Validate() err = %v, want out-of-range error", err)
+	}
+	pf, err = ParsePluralForms("nplurals=2; plural=1/n;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pf.Validate(); err == nil || !strings.Contains(err.Error(), "division by zero") {
+		t.Errorf("Validate() err = %v, want division by zero", err)
+	}
+}
+
+func TestCheckPoPluralForms(t *testing.T) {
+	header := "msgid \"\"\nmsgstr \"\"\n\"Project-Id-Version: git\\n\"\n\"Plural-Forms: nplurals=2; plural=(n != 1);\\n\"\n\n"
+	tests := []struct {
+		name    string
+		po      string
+		wantOk  bool
+		wantMsg string
+	}{
+		{
+			name:   "correct count",
+			po:     header + "msgid \"file\"\nmsgid_plural \"files\"\nmsgstr[0] \"Datei\"\nmsgstr[1] \"Dateien\"\n",
+			wantOk: true,
+		},
+		{
+			name:    "too few forms",
+			po:      header + "msgid \"a\"\nmsgstr \"A\"\n\nmsgid \"file\"\nmsgid_plural \"files\"\nmsgstr[0] \"Datei\"\n",
+			wantOk:  false,
+			wantMsg: "entry 2@L9",
+		},
+		{
+			name:   "obsolete entry ignored",
+			po:     header + "#~ msgid \"file\"\n#~ msgid_plural \"files\"\n#~ msgstr[0] \"Datei\"\n",
+			wantOk: true,
+		},
+		{
+			name:    "missing header with plural entries",
+			po:      "msgid \"\"\nmsgstr \"\"\n\"Project-Id-Version: git\\n\"\n\nmsgid \"file\"\nmsgid_plural \"files\"\nmsgstr[0] \"\"\nmsgstr[1] \"\"\n",
+			wantOk:  false,
+			wantMsg: "missing Plural-Forms",
+		},
+		{
+			name:   "missing header without plural entries",
+			po:     "msgid \"\"\nmsgstr \"\"\n\"Project-Id-Version: git\\n\"\n\nmsgid \"a\"\nmsgstr \"A\"\n",
+			wantOk: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			po, err := ParsePoEntries([]byte(tt.po))
+			if err != nil {
+				t.Fatal(err)
+			}
+			errs, ok := checkPoPluralForms(po)
+			if ok != tt.wantOk {
+				t.Fatalf("ok = %v, want %v (errs: %v)", ok, tt.wantOk, errs)
+			}
+			if tt.wantMsg != "" && !strings.Contains(strings.Join(errs, "\n"), tt.wantMsg) {
+				t.Errorf("errs = %v, want containing %q", errs, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestCheckGettextJSONPluralForms(t *testing.T) {
+	j := &GettextJSON{
+		HeaderMeta: "Plural-Forms: nplurals=2; plural=(n != 1);\\n",
+		Entries: []GettextEntry{
+			{MsgID: "file", MsgIDPlural: "files", MsgStr: []string{"Datei", "Dateien"}},
+		},
+	}
+	if err := CheckGettextJSONPluralForms(j, nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	j.Entries = append(j.Entries, GettextEntry{MsgID: "dir", MsgIDPlural: "dirs", MsgStr: []string{"a", "b", "c"}})
+	if err := CheckGettextJSONPluralForms(j, nil); err == nil || !strings.Contains(err.Error(), "has 3 msgstr[] forms") {
+		t.Errorf("err = %v, want wrong count error", err)
+	}
+	pf, _ := ParsePluralForms("nplurals=3; plural=n%3;")
+	if err := CheckGettextJSONPluralForms(j, pf); err == nil || !strings.Contains(err.Error(), "nplurals=3") {
+		t.Errorf("err = %v, want error against explicit nplurals=3", err)
+	}
+	// POT placeholder header: nothing to check against.
+	j.HeaderMeta = "Plural-Forms: nplurals=INTEGER; plural=EXPRESSION;\\n"
+	if err := CheckGettextJSONPluralForms(j, nil); err != nil {
+		t.Errorf("unexpected error for POT header: %v", err)
+	}
+}
@@ -0,0 +1,249 @@
+// Package util provides a structured view of the comment lines of PO entries.
+package util
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Unicode isolates written by xgettext 0.20+ around file names with spaces
+// in "#:" references (FIRST STRONG ISOLATE and POP DIRECTIONAL ISOLATE).
+const (
+	poRefIsolateStart = "\u2068"
+	poRefIsolateEnd   = "\u2069"
+)
+
+// PoReference is one source reference of a "#:" comment, such as "builtin/add.c:42".
+// Line is 0 when the reference has no line number (--add-location=file).
+type PoReference struct {
+	File string `json:"file"`
+	Line int    `json:"line,omitempty"`
+}
+
+// ParsePoReference parses one reference of a "#:" comment. A trailing ":N" is
+// the line number; Unicode isolates around the file name are removed.
+func ParsePoReference(s string) PoReference {
+	s = strings.TrimSuffix(strings.TrimPrefix(s, poRefIsolateStart), poRefIsolateEnd)
+	if i := strings.LastIndexByte(s, ':'); i > 0 {
+		if n, err := strconv.Atoi(s[i+1:]); err == nil && n > 0 && isASCIIDigits(s[i+1:]) {
+			return PoReference{File: strings.TrimSuffix(s[:i], poRefIsolateEnd), Line: n}
+		}
+	}
+	return PoReference{File: s}
+}
+
+// String returns the reference as written in a "#:" comment. File names with
+// spaces are wrapped in Unicode isolates like xgettext does.
+func (r PoReference) String() string {
+	file := r.File
+	if strings.ContainsAny(file, " \t") {
+		file = poRefIsolateStart + file + poRefIsolateEnd
+	}
+	if r.Line > 0 {
+		return file + ":" + strconv.Itoa(r.Line)
+	}
+	return file
+}
+
+func isASCIIDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// splitPoReferences splits the content of a "#:" comment into references,
+// keeping file names wrapped in Unicode isolates together.
+func splitPoReferences(content string) []PoReference {
+	var refs []PoReference
+	var pending string
+	for _, field := range strings.Fields(content) {
+		if pending != "" {
+			field = pending + " " + field
+			pending = ""
+		}
+		if strings.HasPrefix(field, poRefIsolateStart) && !strings.Contains(field, poRefIsolateEnd) {
+			pending = field
+			continue
+		}
+		refs = append(refs, ParsePoReference(field))
+	}
+	if pending != "" {
+		refs = append(refs, ParsePoReference(pending))
+	}
+	return refs
+}
+
+// PreviousMsg holds the previous msgctxt, msgid and msgid_plural of a fuzzy
+// entry ("#|" lines) or of an obsolete entry ("#~|" lines), in PO format.
+type PreviousMsg struct {
+	MsgCtxt     *string `json:"msgctxt,omitempty"`
+	MsgID       string  `json:"msgid,omitempty"`
+	MsgIDPlural string  `json:"msgid_plural,omitempty"`
+}
+
+// EntryComments is the structured form of GettextEntry.Comments.
+// Translator and extracted comments are without their "# " and "#. " markers.
+// Flags do not include "fuzzy", which is GettextEntry.Fuzzy. Lines that fit
+// none of the fields (e.g. "#=" sticky flags or "#text" without a space) are
+// kept as they are in Other.
+type EntryComments struct {
+	Translator []string      `json:"translator_comments,omitempty"`
+	Extracted  []string      `json:"extracted_comments,omitempty"`
+	References []PoReference `json:"references,omitempty"`
+	Flags      []string      `json:"flags,omitempty"`
+	Previous   *PreviousMsg  `json:"previous,omitempty"`
+	Other      []string      `json:"other_comments,omitempty"`
+}
+
+// ParseEntryComments parses comment lines as stored in GettextEntry.Comments.
+func ParseEntryComments(lines []string) EntryComments {
+	var c EntryComments
+	lastPrevious := ""
+	for _, line := range lines {
+		line = strings.TrimRight(line, "\r\n")
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "#~| ") || strings.HasPrefix(trimmed, "#| "):
+			rest := strings.TrimSpace(trimmed[strings.Index(trimmed, "|")+1:])
+			if kw := c.addPrevious(rest, lastPrevious); kw != "" {
+				lastPrevious = kw
+				continue
+			}
+			c.Other = append(c.Other, line)
+		case trimmed == "#." || strings.HasPrefix(trimmed, "#. "):
+			c.Extracted = append(c.Extracted, strings.TrimPrefix(strings.TrimPrefix(trimmed, "#."), " "))
+		case strings.HasPrefix(trimmed, "#:"):
+			c.References = append(c.References, splitPoReferences(trimmed[2:])...)
+		case strings.HasPrefix(trimmed, "#,"):
+			for _, f := range strings.Split(trimmed[2:], ",") {
+				if f = strings.TrimSpace(f); f != "" && f != "fuzzy" {
+					c.Flags = append(c.Flags, f)
+				}
+			}
+		case line == "#" || strings.HasPrefix(line, "# "):
+			c.Translator = append(c.Translator, strings.TrimPrefix(strings.TrimPrefix(line, "#"), " "))
+		default:
+			c.Other = append(c.Other, line)
+		}
+	}
+	return c
+}
+
+// addPrevious adds the rest of a "#|" line (after the marker) to c.Previous and
+// returns its keyword, or "" if the line is not a previous msgctxt/msgid/msgid_plural.
+// A quoted continuation line is appended to the value of last.
+func (c *EntryComments) addPrevious(rest, last string) string {
+	kw, quoted := "", rest
+	for _, k := range []string{"msgctxt", "msgid_plural", "msgid"} {
+		if strings.HasPrefix(rest, k+" ") {
+			kw, quoted = k, strings.TrimSpace(rest[len(k)+1:])
+			break
+		}
+	}
+	if kw == "" {
+		if last == "" || !strings.HasPrefix(rest, `"`) {
+			return ""
+		}
+		kw = last
+	}
+	if c.Previous == nil {
+		c.Previous = &PreviousMsg{}
+	}
+	value := poParsedToPoFormat(strDeQuote(quoted))
+	switch kw {
+	case "msgctxt":
+		if c.Previous.MsgCtxt != nil {
+			value = *c.Previous.MsgCtxt + value
+		}
+		c.Previous.MsgCtxt = &value
+	case "msgid":
+		c.Previous.MsgID += value
+	case "msgid_plural":
+		c.Previous.MsgIDPlural += value
+	}
+	return kw
+}
+
+// Lines returns the comment lines for GettextEntry.Comments in the order
+// written by gettext: translator and extracted comments, references, flags,
+// previous strings ("#~|" for obsolete entries, else "#|"), then Other.
+func (c EntryComments) Lines(obsolete bool) []string {
+	lines := []string{}
+	for _, s := range c.Translator {
+		if s == "" {
+			lines = append(lines, "#")
+		} else {
+			lines = append(lines, "# "+s)
+		}
+	}
+	for _, s := range c.Extracted {
+		if s == "" {
+			lines = append(lines, "#.")
+		} else {
+			lines = append(lines, "#. "+s)
+		}
+	}
+	if len(c.References) > 0 {
+		refs := make([]string, len(c.References))
+		for i, r := range c.References {
+			refs[i] = r.String()
+		}
+		lines = append(lines, "#: "+strings.Join(refs, " "))
+	}
+	if len(c.Flags) > 0 {
+		lines = append(lines, "#, "+strings.Join(c.Flags, ", "))
+	}
+	if p := c.Previous; p != nil {
+		prefix := "#| "
+		if obsolete {
+			prefix = "#~| "
+		}
+		if p.MsgCtxt != nil {
+			lines = append(lines, prefix+"msgctxt \""+poEscape(*p.MsgCtxt)+"\"")
+		}
+		lines = append(lines, prefix+"msgid \""+poEscape(p.MsgID)+"\"")
+		if p.MsgIDPlural != "" {
+			lines = append(lines, prefix+"msgid_plural \""+poEscape(p.MsgIDPlural)+"\"")
+		}
+	}
+	return append(lines, c.Other...)
+}
+
+// ParsedComments returns the structured form of the entry's comment lines.
+// The lines are parsed once and the result is kept on the entry until
+// Comments changes, so checks and filters can call it for every entry.
+func (e *GettextEntry) ParsedComments() EntryComments {
+	if e == nil {
+		return EntryComments{}
+	}
+	if e.parsedComments == nil || !equalStrings(e.parsedCommentsOf, e.Comments) {
+		c := ParseEntryComments(e.Comments)
+		e.parsedComments = &c
+		e.parsedCommentsOf = append([]string(nil), e.Comments...)
+	}
+	return e.parsedComments.clone()
+}
+
+// SetParsedComments replaces the entry's comment lines with the lines of c.
+func (e *GettextEntry) SetParsedComments(c EntryComments) {
+	e.Comments = c.Lines(e.Obsolete)
+	e.parsedComments, e.parsedCommentsOf = nil, nil
+}
+
+// clone returns a copy of c that shares no slices with c, so the copy kept on
+// an entry is not changed by callers of ParsedComments.
+func (c EntryComments) clone() EntryComments {
+	c.Translator = append([]string(nil), c.Translator...)
+	c.Extracted = append([]string(nil), c.Extracted...)
+	c.References = append([]PoReference(nil), c.References...)
+	c.Flags = append([]string(nil), c.Flags...)
+	c.Other = append([]string(nil), c.Other...)
+	if c.Previous != nil {
+		p := *c.Previous
+		c.Previous = &p
+	}
+	return c
+}
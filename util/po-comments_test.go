@@ -0,0 +1,108 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseEntryComments(t *testing.T) {
+	ctxt := `old\"ctx\"`
+	lines := []string{
+		"# Translator note",
+		"#",
+		"#.  TRANSLATORS: keep %s",
+		"#: builtin/add.c:42 builtin/add.c:50\n",
+		"#: \u2068docs/with space.c\u2069:7 refs.c foo.c:12,5",
+		"#, fuzzy, c-format",
+		`#| msgctxt "old\"ctx\""`,
+		`#| msgid ""`,
+		`#| "first line\n"`,
+		`#| "second"`,
+		"#= sticky",
+	}
+	want := EntryComments{
+		Translator: []string{"Translator note", ""},
+		Extracted:  []string{" TRANSLATORS: keep %s"},
+		References: []PoReference{
+			{File: "builtin/add.c", Line: 42},
+			{File: "builtin/add.c", Line: 50},
+			{File: "docs/with space.c", Line: 7},
+			{File: "refs.c"},
+			{File: "foo.c:12,5"},
+		},
+		Flags:    []string{"c-format"},
+		Previous: &PreviousMsg{MsgCtxt: &ctxt, MsgID: `first line\nsecond`},
+		Other:    []string{"#= sticky"},
+	}
+	got := ParseEntryComments(lines)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseEntryComments() = %+v\nwant %+v", got, want)
+	}
+
+	wantLines := []string{
+		"# Translator note",
+		"#",
+		"#.  TRANSLATORS: keep %s",
+		"#: builtin/add.c:42 builtin/add.c:50 \u2068docs/with space.c\u2069:7 refs.c foo.c:12,5",
+		"#, c-format",
+		`#~| msgctxt "old\"ctx\""`,
+		`#~| msgid "first line\nsecond"`,
+		"#= sticky",
+	}
+	if lines := got.Lines(true); !reflect.DeepEqual(lines, wantLines) {
+		t.Errorf("Lines(true) = %q\nwant %q", lines, wantLines)
+	}
+	if again := ParseEntryComments(got.Lines(false)); !reflect.DeepEqual(again, want) {
+		t.Errorf("ParseEntryComments(Lines()) = %+v\nwant %+v", again, want)
+	}
+}
+
+func TestGettextEntryParsedComments(t *testing.T) {
+	e := &GettextEntry{Comments: []string{"# note", "#, c-format"}}
+	c := e.ParsedComments()
+	if !reflect.DeepEqual(c.Flags, []string{"c-format"}) {
+		t.Fatalf("flags = %q", c.Flags)
+	}
+	// Changing the returned comments does not change the parsed form kept on e.
+	c.Flags[0] = "no-c-format"
+	c.Translator = append(c.Translator, "other")
+	if got := e.ParsedComments(); !reflect.DeepEqual(got.Flags, []string{"c-format"}) ||
+		!reflect.DeepEqual(got.Translator, []string{"note"}) {
+		t.Errorf("after changing the result: %+v", got)
+	}
+
+	// Changes of Comments, in place or not, are parsed again.
+	e.Comments[1] = "#, python-format"
+	if got := e.ParsedComments().Flags; !reflect.DeepEqual(got, []string{"python-format"}) {
+		t.Errorf("after changing a line: flags = %q", got)
+	}
+	e.Comments = append(e.Comments, "#: a.c:1")
+	if got := e.ParsedComments().References; !reflect.DeepEqual(got, []PoReference{{File: "a.c", Line: 1}}) {
+		t.Errorf("after adding a line: references = %+v", got)
+	}
+
+	e.SetParsedComments(EntryComments{Flags: []string{"c-format", "no-wrap"}})
+	if got := e.ParsedComments(); !reflect.DeepEqual(got, EntryComments{Flags: []string{"c-format", "no-wrap"}}) ||
+		!reflect.DeepEqual(e.Comments, []string{"#, c-format, no-wrap"}) {
+		t.Errorf("after SetParsedComments: %+v, lines %q", got, e.Comments)
+	}
+}
+
+func TestParsePoReference(t *testing.T) {
+	tests := []struct {
+		in   string
+		want PoReference
+	}{
+		{"builtin/add.c:42", PoReference{File: "builtin/add.c", Line: 42}},
+		{"builtin/add.c", PoReference{File: "builtin/add.c"}},
+		{"C:/src/a.c:3", PoReference{File: "C:/src/a.c", Line: 3}},
+		{"a.c:x", PoReference{File: "a.c:x"}},
+		{"a.c:+3", PoReference{File: "a.c:+3"}},
+		{"\u2068my file.c\u2069", PoReference{File: "my file.c"}},
+	}
+	for _, tt := range tests {
+		if got := ParsePoReference(tt.in); got != tt.want {
+			t.Errorf("ParsePoReference(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+// Package util provides streaming PO reader and writer for large catalogs.
+package util
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// PoReader reads a PO/POT file entry by entry, so that large catalogs (compendia,
+// full-history exports) can be processed in constant memory. It uses the same
+// parser as ParsePoEntries: reading all entries yields the same header and entries.
+//
+//	pr := NewPoReader(f)
+//	header, err := pr.Header()
+//	for {
+//		e, err := pr.Next()
+//		if err == io.EOF {
+//			break
+//		}
+//		...
+//	}
+type PoReader struct {
+	r       *bufio.Reader
+	st      *poParseState
+	lineNo  int
+	eof     bool
+	err     error
+	pending []*GettextEntry
+	header  *GettextEntry
+	// raw collects the lines of the entry being parsed, for GettextEntry.RawLines.
+	raw []string
+}
+
+// NewPoReader returns a PoReader reading PO content from r.
+func NewPoReader(r io.Reader) *PoReader {
+	return &PoReader{
+		r: bufio.NewReader(r),
+		st: &poParseState{
+			inHeader:           true,
+			currentPluralIndex: -1,
+		},
+	}
+}
+
+// readLine feeds the next line to the parser. At end of input the last entry
+// is finished and pr.eof is set. Lines are split on "\n" like ParsePoEntries
+// always did with strings.Split, so a final newline yields a trailing empty line.
+func (pr *PoReader) readLine() {
+	line, err := pr.r.ReadString('\n')
+	if err != nil && err != io.EOF {
+		pr.err = err
+		return
+	}
+	pr.lineNo++
+	line = strings.TrimSuffix(line, "\n")
+	n := len(pr.pending)
+	pr.st.parseLine(line, pr.lineNo, &pr.pending)
+	if len(pr.pending) > n {
+		// The entry was finished by this line, which belongs to the next entry.
+		pr.pending[len(pr.pending)-1].RawLines = trimBlankLines(pr.raw)
+		pr.raw = nil
+	}
+	if pr.st.inHeader {
+		pr.raw = nil
+	} else {
+		pr.raw = append(pr.raw, line)
+	}
+	if err == io.EOF {
+		n = len(pr.pending)
+		finishCurrentEntry(pr.st, &pr.pending)
+		if len(pr.pending) > n {
+			pr.pending[len(pr.pending)-1].RawLines = trimBlankLines(pr.raw)
+		}
+		pr.raw = nil
+		pr.st.currentEntry = nil
+		pr.eof = true
+	}
+}
+
+// trimBlankLines returns lines without leading and trailing blank lines.
+func trimBlankLines(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Header returns the header entry (comments and meta in msgstr). It reads
+// ahead until the header block ends; an empty entry is returned when the file
+// has no header.
+func (pr *PoReader) Header() (GettextEntry, error) {
+	for pr.header == nil {
+		if pr.err != nil {
+			return GettextEntry{}, pr.err
+		}
+		if !pr.st.inHeader || pr.eof || len(pr.pending) > 0 {
+			h := BuildHeaderEntryFromLines(pr.st.headerLines)
+			pr.header = &h
+			pr.st.headerLines = nil
+			break
+		}
+		pr.readLine()
+	}
+	return *pr.header, nil
+}
+
+// HeaderPO returns the header as a GettextPO without entries, for header
+// helpers such as GetMeta, HeaderLines and PluralForms.
+func (pr *PoReader) HeaderPO() (*GettextPO, error) {
+	h, err := pr.Header()
+	if err != nil {
+		return nil, err
+	}
+	return &GettextPO{HeaderEntry: h}, nil
+}
+
+// Next returns the next content entry, or io.EOF when there are no more entries.
+func (pr *PoReader) Next() (*GettextEntry, error) {
+	if _, err := pr.Header(); err != nil {
+		return nil, err
+	}
+	for len(pr.pending) == 0 {
+		if pr.err != nil {
+			return nil, pr.err
+		}
+		if pr.eof {
+			return nil, io.EOF
+		}
+		pr.readLine()
+	}
+	e := pr.pending[0]
+	pr.pending[0] = nil
+	pr.pending = pr.pending[1:]
+	return e, nil
+}
+
+// PoWriter writes a PO file entry by entry, in the same layout as BuildPoContent
+// and MsgSelect: the header block, then entries separated by blank lines.
+type PoWriter struct {
+	w       io.Writer
+	entries int
+}
+
+// NewPoWriter returns a PoWriter writing to w.
+func NewPoWriter(w io.Writer) *PoWriter {
+	return &PoWriter{w: w}
+}
+
+// WriteHeader writes header lines (see GettextPO.HeaderLines) followed by a
+// blank line. It must be called before the first WriteEntry; nothing is written
+// for empty lines.
+func (pw *PoWriter) WriteHeader(lines []string) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	for _, line := range lines {
+		if _, err := io.WriteString(pw.w, line); err != nil {
+			return err
+		}
+		if !strings.HasSuffix(line, "\n") {
+			if _, err := io.WriteString(pw.w, "\n"); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := io.WriteString(pw.w, "\n")
+	return err
+}
+
+// WriteEntry writes one entry, preceded by a blank line unless it is the first.
+func (pw *PoWriter) WriteEntry(e *GettextEntry) error {
+	if pw.entries > 0 {
+		if _, err := io.WriteString(pw.w, "\n"); err != nil {
+			return err
+		}
+	}
+	pw.entries++
+	return writeGettextEntryToPO(pw.w, *e)
+}
+
+// GettextJSONWriter writes gettext JSON entry by entry with the same output as
+// WriteGettextJSONToJSON (indented), without holding all entries in memory.
+type GettextJSONWriter struct {
+	w       io.Writer
+	entries int
+}
+
+// NewGettextJSONWriter writes the header fields and opens the "entries" array.
+func NewGettextJSONWriter(w io.Writer, headerComment, headerMeta string) (*GettextJSONWriter, error) {
+	jw := &GettextJSONWriter{w: w}
+	var b strings.Builder
+	b.WriteString("{\n  \"header_comment\": ")
+	b.WriteString(jsonStringNoEscapeHTML(headerComment))
+	b.WriteString(",\n  \"header_meta\": ")
+	b.WriteString(jsonStringNoEscapeHTML(headerMeta))
+	b.WriteString(",\n  \"entries\": [")
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return nil, err
+	}
+	return jw, nil
+}
+
+// WriteEntry writes one entry; fuzzy is stripped from comments like GettextJSONFromEntries.
+func (jw *GettextJSONWriter) WriteEntry(e *GettextEntry) error {
+	j := GettextJSONFromEntries("", "", []*GettextEntry{e})
+	var buf strings.Builder
+	enc := newGettextJSONEncoder(&buf, "    ")
+	if err := enc.Encode(&j.Entries[0]); err != nil {
+		return err
+	}
+	sep := ",\n    "
+	if jw.entries == 0 {
+		sep = "\n    "
+	}
+	jw.entries++
+	_, err := io.WriteString(jw.w, sep+strings.TrimSuffix(buf.String(), "\n"))
+	return err
+}
+
+// Close closes the "entries" array and the top-level object.
+func (jw *GettextJSONWriter) Close() error {
+	end := "]\n}\n"
+	if jw.entries > 0 {
+		end = "\n  ]\n}\n"
+	}
+	_, err := io.WriteString(jw.w, end)
+	return err
+}
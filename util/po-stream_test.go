@@ -0,0 +1,144 @@
+package util
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+const poStreamTestContent = `# Header comment
+msgid ""
+msgstr ""
+"Project-Id-Version: git\n"
+"Content-Type: text/plain; charset=UTF-8\n"
+
+#: a.c:1
+msgid "one"
+msgstr "eins"
+
+#, fuzzy
+msgid "two <b>"
+msgstr "zwei <b>"
+
+msgid "file"
+msgid_plural "files"
+msgstr[0] "Datei"
+msgstr[1] "Dateien"
+
+#~ msgid "old"
+#~ msgstr "alt"`
+
+func TestPoReader(t *testing.T) {
+	for _, content := range []string{poStreamTestContent, poStreamTestContent + "\n"} {
+		want, err := ParsePoEntries([]byte(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pr := NewPoReader(strings.NewReader(content))
+		// Next before Header still returns the first entry; the header is read ahead.
+		first, err := pr.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first.MsgID != "one" || first.EntryLocation != 8 {
+			t.Errorf("first entry = %q@L%d, want \"one\"@L8", first.MsgID, first.EntryLocation)
+		}
+		header, err := pr.HeaderPO()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := header.GetProject(); got != "git" {
+			t.Errorf("GetProject() = %q, want git", got)
+		}
+		got := []GettextEntry{*first}
+		for {
+			e, err := pr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, *e)
+		}
+		if len(got) != len(want.Entries) {
+			t.Fatalf("read %d entries, want %d", len(got), len(want.Entries))
+		}
+		if !got[1].Fuzzy || !got[3].Obsolete || got[2].MsgStr[1] != "Dateien" {
+			t.Errorf("unexpected entries: %+v", got)
+		}
+		if _, err := pr.Next(); err != io.EOF {
+			t.Errorf("Next() after end = %v, want io.EOF", err)
+		}
+	}
+}
+
+func TestPoReader_NoHeader(t *testing.T) {
+	pr := NewPoReader(strings.NewReader("msgid \"a\"\nmsgstr \"b\"\n"))
+	h, err := pr.Header()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.MsgStr) != 0 {
+		t.Errorf("header = %+v, want empty", h)
+	}
+	e, err := pr.Next()
+	if err != nil || e.MsgID != "a" {
+		t.Fatalf("Next() = %v, %v", e, err)
+	}
+}
+
+func TestPoWriter(t *testing.T) {
+	po, err := ParsePoEntries([]byte(poStreamTestContent))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	pw := NewPoWriter(&b)
+	if err := pw.WriteHeader(po.HeaderLines()); err != nil {
+		t.Fatal(err)
+	}
+	for i := range po.Entries {
+		if err := pw.WriteEntry(&po.Entries[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := BuildPoContent(po.HeaderLines(), po.EntriesPtr())
+	if b.String() != string(want) {
+		t.Errorf("PoWriter output:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestGettextJSONWriter(t *testing.T) {
+	po, err := ParsePoEntries([]byte(poStreamTestContent))
+	if err != nil {
+		t.Fatal(err)
+	}
+	j := GettextJSONFromGettextPO(po)
+	for _, n := range []int{0, 1, len(po.Entries)} {
+		var want, got bytes.Buffer
+		if err := WriteGettextJSONToJSON(&GettextJSON{
+			HeaderComment: j.HeaderComment,
+			HeaderMeta:    j.HeaderMeta,
+			Entries:       j.Entries[:n],
+		}, &want); err != nil {
+			t.Fatal(err)
+		}
+		jw, err := NewGettextJSONWriter(&got, j.HeaderComment, j.HeaderMeta)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < n; i++ {
+			if err := jw.WriteEntry(&po.Entries[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := jw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if got.String() != want.String() {
+			t.Errorf("%d entries: GettextJSONWriter output:\n%s\nwant:\n%s", n, got.String(), want.String())
+		}
+	}
+}
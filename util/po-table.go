@@ -0,0 +1,311 @@
+// Package util provides CSV/TSV export of PO entries and re-import of edited translations.
+package util
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Column names of the CSV/TSV table written by WriteGettextJSONToTable. The
+// msgstr forms are named "msgstr[0]", "msgstr[1]", ...
+const (
+	tableColumnIndex       = "index"
+	tableColumnMsgctxt     = "msgctxt"
+	tableColumnMsgid       = "msgid"
+	tableColumnMsgidPlural = "msgid_plural"
+	tableColumnFuzzy       = "fuzzy"
+	tableColumnComments    = "comments"
+)
+
+// TableSeparator returns the field separator of a CSV/TSV file: tab for files
+// with extension .tsv or .tab, comma for .csv. For other extensions, the first
+// line of data decides.
+func TableSeparator(path string, data []byte) rune {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".tsv", ".tab":
+		return '\t'
+	case ".csv":
+		return ','
+	}
+	firstLine := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		firstLine = data[:i]
+	}
+	if bytes.Count(firstLine, []byte("\t")) > bytes.Count(firstLine, []byte(",")) {
+		return '\t'
+	}
+	return ','
+}
+
+// tsvEscape encodes a value for a TSV field: backslash, tab, newline and
+// carriage return are written as \\, \t, \n and \r, so that every row is one line.
+func tsvEscape(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+	return r.Replace(s)
+}
+
+// tsvUnescape decodes a TSV field written by tsvEscape.
+func tsvUnescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			switch s[i+1] {
+			case 'n':
+				b.WriteByte('\n')
+				i++
+				continue
+			case 't':
+				b.WriteByte('\t')
+				i++
+				continue
+			case 'r':
+				b.WriteByte('\r')
+				i++
+				continue
+			case '\\':
+				b.WriteByte('\\')
+				i++
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// WriteGettextJSONToTable writes the entries of j as CSV (sep ',') or TSV (sep
+// '\t') with a header row and the columns index, msgctxt, msgid, msgid_plural,
+// msgstr[0..n-1], fuzzy and comments. n is the largest number of msgstr forms.
+// Values are plain text: CSV quotes fields with newlines, TSV escapes them.
+// The file header entry is not written.
+func WriteGettextJSONToTable(j *GettextJSON, w io.Writer, sep rune) error {
+	if j == nil {
+		return nil
+	}
+	forms := 1
+	for _, e := range j.Entries {
+		if len(e.MsgStr) > forms {
+			forms = len(e.MsgStr)
+		}
+	}
+	header := []string{tableColumnIndex, tableColumnMsgctxt, tableColumnMsgid, tableColumnMsgidPlural}
+	for i := 0; i < forms; i++ {
+		header = append(header, fmt.Sprintf("msgstr[%d]", i))
+	}
+	header = append(header, tableColumnFuzzy, tableColumnComments)
+
+	rows := [][]string{header}
+	for i, e := range j.Entries {
+		ctxt := ""
+		if e.MsgCtxt != nil {
+			ctxt = poUnescape(*e.MsgCtxt)
+		}
+		row := []string{strconv.Itoa(i + 1), ctxt, poUnescape(e.MsgID), poUnescape(e.MsgIDPlural)}
+		for k := 0; k < forms; k++ {
+			s := ""
+			if k < len(e.MsgStr) {
+				s = poUnescape(e.MsgStr[k])
+			}
+			row = append(row, s)
+		}
+		fuzzy := ""
+		if e.Fuzzy {
+			fuzzy = "yes"
+		}
+		row = append(row, fuzzy, strings.Join(e.Comments, "\n"))
+		rows = append(rows, row)
+	}
+
+	if sep != '\t' {
+		cw := csv.NewWriter(w)
+		cw.Comma = sep
+		if err := cw.WriteAll(rows); err != nil {
+			return fmt.Errorf("write CSV: %w", err)
+		}
+		return nil
+	}
+	for _, row := range rows {
+		fields := make([]string, len(row))
+		for k, f := range row {
+			fields[k] = tsvEscape(f)
+		}
+		if _, err := io.WriteString(w, strings.Join(fields, "\t")+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readTable reads CSV (sep ',') or TSV (sep '\t') rows. A UTF-8 BOM, as saved
+// by spreadsheet applications, is ignored.
+func readTable(data []byte, sep rune) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if sep != '\t' {
+		cr := csv.NewReader(bytes.NewReader(data))
+		cr.Comma = sep
+		cr.FieldsPerRecord = -1
+		return cr.ReadAll()
+	}
+	var rows [][]string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		for k, f := range fields {
+			fields[k] = tsvUnescape(f)
+		}
+		rows = append(rows, fields)
+	}
+	return rows, nil
+}
+
+// TableApplyResult is the result of ApplyTableToGettextJSON.
+type TableApplyResult struct {
+	// Updated is the number of entries whose msgstr or fuzzy state changed.
+	Updated int
+	// Rejected describes rows that were not applied, e.g. "row 5: msgid "..." not found".
+	Rejected []string
+}
+
+// parseTableFuzzy returns the value of a fuzzy cell; spreadsheets may turn
+// "yes" into TRUE or similar.
+func parseTableFuzzy(s string) bool {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "yes", "y", "true", "1", "x", "fuzzy":
+		return true
+	}
+	return false
+}
+
+// ApplyTableToGettextJSON applies the msgstr columns (and the fuzzy column, if
+// present) of an edited CSV/TSV table from WriteGettextJSONToTable to the
+// entries of j. Rows are matched by msgctxt, msgid and msgid_plural, the same
+// key as msg-cat uses to merge entries, so rows may be reordered or removed and
+// other columns deleted. Rows whose entry no longer exists in j, changed rows of
+// obsolete entries, duplicate rows and rows with more msgstr forms than the
+// entry are rejected.
+func ApplyTableToGettextJSON(j *GettextJSON, data []byte, sep rune) (*TableApplyResult, error) {
+	rows, err := readTable(data, sep)
+	if err != nil {
+		return nil, fmt.Errorf("read table: %w", err)
+	}
+	if len(rows) == 0 {
+		return &TableApplyResult{}, nil
+	}
+	columns := make(map[string]int)
+	msgstrCols := make(map[int]int)
+	for k, name := range rows[0] {
+		name = strings.TrimSpace(name)
+		columns[name] = k
+		if name == "msgstr" {
+			msgstrCols[0] = k
+		} else if strings.HasPrefix(name, "msgstr[") && strings.HasSuffix(name, "]") {
+			n, err := strconv.Atoi(name[len("msgstr[") : len(name)-1])
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("bad column name %q", name)
+			}
+			msgstrCols[n] = k
+		}
+	}
+	if _, ok := columns[tableColumnMsgid]; !ok {
+		return nil, fmt.Errorf("no %q column in table header", tableColumnMsgid)
+	}
+	if len(msgstrCols) == 0 {
+		return nil, fmt.Errorf("no msgstr column in table header")
+	}
+	cell := func(row []string, name string) (string, bool) {
+		k, ok := columns[name]
+		if !ok || k >= len(row) {
+			return "", false
+		}
+		return row[k], true
+	}
+
+	index := make(map[string]int, len(j.Entries))
+	for i, e := range j.Entries {
+		if k := entryKey(e); !e.Obsolete {
+			index[k] = i
+		} else if _, ok := index[k]; !ok {
+			index[k] = i
+		}
+	}
+	result := &TableApplyResult{}
+	seenRow := make(map[string]int)
+	for r, row := range rows[1:] {
+		rowNo := r + 2
+		var key GettextEntry
+		msgid, _ := cell(row, tableColumnMsgid)
+		key.MsgID = jsonDecodedToPoFormat(msgid)
+		if ctxt, ok := cell(row, tableColumnMsgctxt); ok && ctxt != "" {
+			ctxt = jsonDecodedToPoFormat(ctxt)
+			key.MsgCtxt = &ctxt
+		}
+		if plural, ok := cell(row, tableColumnMsgidPlural); ok {
+			key.MsgIDPlural = jsonDecodedToPoFormat(plural)
+		}
+		i, ok := index[entryKey(key)]
+		if !ok {
+			result.Rejected = append(result.Rejected,
+				fmt.Sprintf("row %d: msgid %q not found", rowNo, msgid))
+			continue
+		}
+		if prev, ok := seenRow[entryKey(key)]; ok {
+			result.Rejected = append(result.Rejected,
+				fmt.Sprintf("row %d: msgid %q already given in row %d", rowNo, msgid, prev))
+			continue
+		}
+		seenRow[entryKey(key)] = rowNo
+
+		e := &j.Entries[i]
+		msgstr := append([]string(nil), e.MsgStr...)
+		if len(msgstr) == 0 {
+			msgstr = []string{""}
+		}
+		extra := -1
+		for n, k := range msgstrCols {
+			if k >= len(row) {
+				continue
+			}
+			if n >= len(msgstr) {
+				if row[k] != "" && (extra < 0 || n < extra) {
+					extra = n
+				}
+				continue
+			}
+			msgstr[n] = jsonDecodedToPoFormat(row[k])
+		}
+		if extra >= 0 {
+			result.Rejected = append(result.Rejected,
+				fmt.Sprintf("row %d: msgid %q has only %d msgstr form(s), but msgstr[%d] is set",
+					rowNo, msgid, len(msgstr), extra))
+			continue
+		}
+		fuzzy := e.Fuzzy
+		if s, ok := cell(row, tableColumnFuzzy); ok {
+			fuzzy = parseTableFuzzy(s)
+		}
+		if equalStrings(msgstr, e.MsgStr) && fuzzy == e.Fuzzy {
+			continue
+		}
+		if e.Obsolete {
+			// Unchanged rows of obsolete entries (exported with the others) are fine.
+			result.Rejected = append(result.Rejected,
+				fmt.Sprintf("row %d: msgid %q is obsolete", rowNo, msgid))
+			continue
+		}
+		e.MsgStr = msgstr
+		e.Fuzzy = fuzzy
+		result.Updated++
+	}
+	return result, nil
+}
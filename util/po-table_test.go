@@ -0,0 +1,151 @@
+package util
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const poTableTestContent = `msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+#: a.c:1
+msgid ""
+"Line one\n"
+"Line two\twith tab, \"quote\" and back\\slash"
+msgstr ""
+"第1行\n"
+"第2行\t带制表符，\"引号\"和反\\斜线"
+
+msgctxt "verb"
+msgid "commit"
+msgstr "提交"
+
+#, fuzzy, c-format
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] "%d 个文件"
+msgstr[1] "%d 个文件"
+
+msgid "untranslated"
+msgstr ""
+
+#~ msgid "old"
+#~ msgstr "旧"
+`
+
+func TestWriteGettextJSONToTable(t *testing.T) {
+	po, err := ParsePoEntries([]byte(poTableTestContent))
+	if err != nil {
+		t.Fatal(err)
+	}
+	j := GettextJSONFromGettextPO(po)
+	var b bytes.Buffer
+	if err := WriteGettextJSONToTable(j, &b, '\t'); err != nil {
+		t.Fatal(err)
+	}
+	want := "index\tmsgctxt\tmsgid\tmsgid_plural\tmsgstr[0]\tmsgstr[1]\tfuzzy\tcomments\n" +
+		"1\t\tLine one\\nLine two\\twith tab, \"quote\" and back\\\\slash\t\t" +
+		"第1行\\n第2行\\t带制表符，\"引号\"和反\\\\斜线\t\t\t#: a.c:1\n" +
+		"2\tverb\tcommit\t\t提交\t\t\t\n" +
+		"3\t\t%d file\t%d files\t%d 个文件\t%d 个文件\tyes\t#, c-format\n" +
+		"4\t\tuntranslated\t\t\t\t\t\n" +
+		"5\t\told\t\t旧\t\t\t\n"
+	if b.String() != want {
+		t.Errorf("TSV output:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestApplyTableToGettextJSON_RoundTrip(t *testing.T) {
+	for _, sep := range []rune{',', '\t'} {
+		po, err := ParsePoEntries([]byte(poTableTestContent))
+		if err != nil {
+			t.Fatal(err)
+		}
+		j := GettextJSONFromGettextPO(po)
+		var table bytes.Buffer
+		if err := WriteGettextJSONToTable(j, &table, sep); err != nil {
+			t.Fatal(err)
+		}
+		result, err := ApplyTableToGettextJSON(j, table.Bytes(), sep)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Updated != 0 || len(result.Rejected) != 0 {
+			t.Errorf("sep %q: unchanged table gives %+v", sep, result)
+		}
+		var out bytes.Buffer
+		if err := WriteGettextJSONToPO(j, &out, false, false); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != poTableTestContent {
+			t.Errorf("sep %q: PO changed:\n%s", sep, out.String())
+		}
+	}
+}
+
+func TestApplyTableToGettextJSON(t *testing.T) {
+	// Reordered rows, no index and comments columns, "msgstr" for msgstr[0].
+	table := "msgid,msgctxt,msgid_plural,msgstr,msgstr[1],fuzzy\n" +
+		"%d file,,%d files,%d 个文件,%d 个文件们,\n" +
+		"commit,verb,,递交,,\n" +
+		"\"Line one\nLine two\twith tab, \"\"quote\"\" and back\\slash\",,,新译文,,TRUE\n" +
+		"commit,noun,,提交,,\n" +
+		"commit,verb,,重复,,\n" +
+		"untranslated,,,,多余,\n" +
+		"old,,,旧的,,\n"
+	po, err := ParsePoEntries([]byte(poTableTestContent))
+	if err != nil {
+		t.Fatal(err)
+	}
+	j := GettextJSONFromGettextPO(po)
+	result, err := ApplyTableToGettextJSON(j, []byte(table), ',')
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRejected := []string{
+		`row 5: msgid "commit" not found`,
+		`row 6: msgid "commit" already given in row 3`,
+		`row 7: msgid "untranslated" has only 1 msgstr form(s), but msgstr[1] is set`,
+		`row 8: msgid "old" is obsolete`,
+	}
+	if result.Updated != 3 || !equalStrings(result.Rejected, wantRejected) {
+		t.Errorf("result = %d updated, rejected:\n%s", result.Updated, strings.Join(result.Rejected, "\n"))
+	}
+	e := j.Entries[0]
+	if e.MsgStr[0] != "新译文" || !e.Fuzzy {
+		t.Errorf("entry 1 = %+v", e)
+	}
+	if e = j.Entries[1]; e.MsgStr[0] != "递交" {
+		t.Errorf("entry 2 = %+v", e)
+	}
+	if e = j.Entries[2]; e.Fuzzy || e.MsgStr[1] != "%d 个文件们" {
+		t.Errorf("entry 3 = %+v", e)
+	}
+	if e = j.Entries[4]; e.MsgStr[0] != "旧" {
+		t.Errorf("obsolete entry changed: %+v", e)
+	}
+
+	if _, err := ApplyTableToGettextJSON(j, []byte("index,msgstr[0]\n1,x\n"), ','); err == nil {
+		t.Error("table without msgid column: got nil error")
+	}
+}
+
+func TestTableSeparator(t *testing.T) {
+	tests := []struct {
+		path, data string
+		want       rune
+	}{
+		{"review.csv", "a\tb\tc\n", ','},
+		{"review.tsv", "a,b,c\n", '\t'},
+		{"review.txt", "index\tmsgctxt\tmsgid\n", '\t'},
+		{"review.txt", "index,msgctxt,msgid\n", ','},
+	}
+	for _, tt := range tests {
+		if got := TableSeparator(tt.path, []byte(tt.data)); got != tt.want {
+			t.Errorf("TableSeparator(%q, %q) = %q, want %q", tt.path, tt.data, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,403 @@
+// Package util provides a built-in PO validator, used when msgfmt is not available.
+package util
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// PoValidateOptions selects the optional checks of ValidatePoData. Syntax errors,
+// duplicate message definitions and misplaced plural sections are always reported.
+type PoValidateOptions struct {
+	// CheckHeader checks the header entry and Plural-Forms like "msgfmt --check-header".
+	CheckHeader bool
+	// CheckFormat checks format flags and leading/trailing newlines like "msgfmt --check-format".
+	CheckFormat bool
+	// NoCFormat skips the printf directives of c-format entries with CheckFormat,
+	// for callers which check them on their own, such as the c-format check.
+	NoCFormat bool
+}
+
+// PoValidateResult holds the diagnostics of ValidatePoData.
+type PoValidateResult struct {
+	// Messages are msgfmt style diagnostics, e.g. "po/zh_CN.po:25: end-of-line within string".
+	Messages []string
+	// Errors is the number of fatal errors in Messages.
+	Errors int
+	// Stats is nil when the file has syntax errors.
+	Stats *PoStats
+}
+
+// OK returns true when no fatal error was found.
+func (r *PoValidateResult) OK() bool {
+	return r.Errors == 0
+}
+
+// Summary returns "found N fatal error(s)" like msgfmt, or the statistics line
+// (msgfmt --statistics) when the file is valid.
+func (r *PoValidateResult) Summary() string {
+	if r.Errors == 1 {
+		return "found 1 fatal error"
+	} else if r.Errors > 1 {
+		return fmt.Sprintf("found %d fatal errors", r.Errors)
+	}
+	if r.Stats == nil {
+		return ""
+	}
+	return strings.TrimSuffix(FormatMsgfmtStatistics(r.Stats), "\n")
+}
+
+// addNote adds a diagnostic that is not counted as an error.
+func (r *PoValidateResult) addNote(filename string, lineNo int, format string, a ...interface{}) {
+	msg := fmt.Sprintf(format, a...)
+	if lineNo > 0 {
+		msg = fmt.Sprintf("%s:%d: %s", filename, lineNo, msg)
+	} else {
+		msg = fmt.Sprintf("%s: %s", filename, msg)
+	}
+	r.Messages = append(r.Messages, msg)
+}
+
+// addError adds a fatal diagnostic.
+func (r *PoValidateResult) addError(filename string, lineNo int, format string, a ...interface{}) {
+	r.addNote(filename, lineNo, format, a...)
+	r.Errors++
+}
+
+// poHeaderRequiredFields are the header fields checked by msgfmt --check-header,
+// with the initial values from the POT template that must be replaced.
+var poHeaderRequiredFields = []struct {
+	name, templateValue string
+}{
+	{"Project-Id-Version", "PACKAGE VERSION"},
+	{"PO-Revision-Date", "YEAR-MO-DA HO:MI+ZONE"},
+	{"Last-Translator", "FULL NAME <EMAIL@ADDRESS>"},
+	{"Language-Team", "LANGUAGE <LL@li.org>"},
+	{"MIME-Version", ""},
+	{"Content-Type", "text/plain; charset=CHARSET"},
+	{"Content-Transfer-Encoding", "ENCODING"},
+}
+
+// ValidatePoFileBuiltin reads poFile and validates it with ValidatePoData.
+func ValidatePoFileBuiltin(poFile string, opts PoValidateOptions) (*PoValidateResult, error) {
+	data, err := os.ReadFile(poFile)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", poFile, err)
+	}
+	return ValidatePoData(data, poFile, opts), nil
+}
+
+// ValidatePoData validates PO content without calling msgfmt. filename is only
+// used as the prefix of diagnostics. Semantic checks (duplicates, header, plural
+// forms, format flags) run on ParsePoEntries output and are skipped when the
+// syntax check fails, since the parser is lenient on broken input.
+func ValidatePoData(data []byte, filename string, opts PoValidateOptions) *PoValidateResult {
+	r := &PoValidateResult{}
+	validatePoSyntax(string(data), filename, r)
+	if !r.OK() {
+		return r
+	}
+	po, err := ParsePoEntries(data)
+	if err != nil {
+		r.addError(filename, 0, "%v", err)
+		return r
+	}
+
+	// Duplicate message definitions (obsolete entries are not messages).
+	firstDef := make(map[string]int)
+	if len(po.HeaderEntry.MsgStr) > 0 {
+		firstDef[entryKey(GettextEntry{})] = -1
+	}
+	for _, e := range po.Entries {
+		if e.Obsolete {
+			continue
+		}
+		k := entryKey(e)
+		if line, ok := firstDef[k]; ok {
+			r.addError(filename, e.EntryLocation, "duplicate message definition...")
+			if line > 0 {
+				r.addNote(filename, line, "...this is the location of the first definition")
+			}
+			continue
+		}
+		firstDef[k] = e.EntryLocation
+	}
+
+	if opts.CheckHeader {
+		validatePoHeader(po, filename, r)
+	}
+	if opts.CheckFormat {
+		pf, err := po.PluralForms()
+		if err != nil {
+			pf = nil
+		}
+		for i := range po.Entries {
+			e := &po.Entries[i]
+			if e.Obsolete || e.Fuzzy {
+				continue
+			}
+			for _, msg := range checkEntryFormat(e, pf, !opts.NoCFormat) {
+				r.addError(filename, e.EntryLocation, "%s", msg)
+			}
+		}
+	}
+	if r.OK() {
+		r.Stats = getPoStatsFromGettextJSON(GettextJSONFromGettextPO(po))
+	}
+	return r
+}
+
+// validatePoHeader checks the header fields and the Plural-Forms header.
+func validatePoHeader(po *GettextPO, filename string, r *PoValidateResult) {
+	// Like msgfmt, incomplete header fields are warnings, not fatal errors.
+	if len(po.HeaderEntry.MsgStr) == 0 {
+		r.addNote(filename, 0, "warning: PO file header missing")
+		return
+	}
+	for _, field := range poHeaderRequiredFields {
+		value := po.GetMeta(field.name)
+		if value == "" {
+			r.addNote(filename, 0, "warning: header field '%s' missing in header", field.name)
+		} else if field.templateValue != "" && value == field.templateValue {
+			r.addNote(filename, 0, "warning: header field '%s' still has the initial default value", field.name)
+		}
+	}
+	errs, ok := checkPoPluralForms(po)
+	if !ok {
+		for _, msg := range errs {
+			r.addError(filename, 0, "%s", msg)
+		}
+	}
+}
+
+// checkEntryFormat checks leading/trailing newlines of every msgstr form against
+// the msgid, conflicting format flags, and, if cFormat is true, the printf directives
+// of c-format entries (see checkEntryCFormat; pf may be nil).
+func checkEntryFormat(e *GettextEntry, pf *PluralForms, cFormat bool) []string {
+	var msgs []string
+	flags := entryFlags(e)
+	for f := range flags {
+		if strings.HasSuffix(f, "-format") && !strings.HasPrefix(f, "no-") && flags["no-"+f] {
+			msgs = append(msgs, fmt.Sprintf("conflicting flags '%s' and 'no-%s'", f, f))
+		}
+	}
+	for i, s := range e.MsgStr {
+		if s == "" {
+			continue
+		}
+		msgid, keyword := e.MsgID, "msgstr"
+		if e.MsgIDPlural != "" {
+			keyword = "msgstr[" + strconv.Itoa(i) + "]"
+			if i > 0 {
+				msgid = e.MsgIDPlural
+			}
+		}
+		if strings.HasPrefix(msgid, `\n`) != strings.HasPrefix(s, `\n`) {
+			msgs = append(msgs, fmt.Sprintf("'msgid' and '%s' entries do not both begin with '\\n'", keyword))
+		}
+		if strings.HasSuffix(msgid, `\n`) != strings.HasSuffix(s, `\n`) {
+			msgs = append(msgs, fmt.Sprintf("'msgid' and '%s' entries do not both end with '\\n'", keyword))
+		}
+	}
+	if cFormat && flags["c-format"] {
+		msgs = append(msgs, checkEntryCFormat(e, pf)...)
+	}
+	return msgs
+}
+
+// entryFlags returns the set of flags from the "#," comment lines of e
+// (without "fuzzy").
+func entryFlags(e *GettextEntry) map[string]bool {
+	flags := make(map[string]bool)
+	for _, f := range e.ParsedComments().Flags {
+		flags[f] = true
+	}
+	return flags
+}
+
+// poSyntaxEntry tracks the keywords seen for one entry during validatePoSyntax.
+type poSyntaxEntry struct {
+	msgidLine   int
+	hasPlural   bool
+	hasMsgstr   bool
+	nextPlural  int
+	hasMsgstrN  bool
+	lastKeyword string
+}
+
+// validatePoSyntax is a line-based lexer for PO syntax: keywords, string
+// literals and escape sequences, and the order of msgid/msgid_plural/msgstr sections.
+func validatePoSyntax(data, filename string, r *PoValidateResult) {
+	var cur *poSyntaxEntry
+	finish := func() {
+		if cur == nil {
+			return
+		}
+		if cur.msgidLine == 0 {
+			r.addError(filename, 0, "missing 'msgid' section")
+		} else if !cur.hasMsgstr && !cur.hasMsgstrN {
+			r.addError(filename, cur.msgidLine, "missing 'msgstr' section")
+		}
+		cur = nil
+	}
+
+	for i, line := range strings.Split(data, "\n") {
+		lineNo := i + 1
+		line = strings.TrimRight(line, "\r")
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#~") && !strings.HasPrefix(trimmed, "#~|") {
+			trimmed = strings.TrimSpace(trimmed[2:])
+			if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+				continue
+			}
+		} else if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		keyword := trimmed
+		rest := ""
+		if idx := strings.IndexAny(trimmed, " \t\""); idx >= 0 {
+			keyword, rest = trimmed[:idx], trimmed[idx:]
+		}
+		if strings.HasPrefix(trimmed, `"`) {
+			keyword, rest = "", trimmed
+		}
+		// A keyword with a bad string is still seen, so that its entry is
+		// not also reported as missing the section, as msgfmt does.
+		litErr := checkPoStringLiterals(rest)
+		if litErr != "" {
+			r.addError(filename, lineNo, "%s", litErr)
+			if keyword == "" {
+				continue
+			}
+		}
+
+		switch {
+		case keyword == "":
+			if cur == nil || cur.lastKeyword == "" {
+				r.addError(filename, lineNo, "syntax error")
+			}
+		case keyword == "msgctxt":
+			if cur != nil {
+				finish()
+			}
+			cur = &poSyntaxEntry{}
+			cur.lastKeyword = keyword
+		case keyword == "msgid":
+			if cur != nil && cur.msgidLine > 0 {
+				finish()
+			}
+			if cur == nil {
+				cur = &poSyntaxEntry{}
+			}
+			cur.msgidLine = lineNo
+			cur.lastKeyword = keyword
+		case keyword == "msgid_plural":
+			if cur == nil || cur.lastKeyword != "msgid" {
+				r.addError(filename, lineNo, "syntax error")
+				continue
+			}
+			cur.hasPlural = true
+			cur.lastKeyword = keyword
+		case keyword == "msgstr":
+			if cur == nil || cur.msgidLine == 0 || cur.hasMsgstr || cur.hasMsgstrN {
+				r.addError(filename, lineNo, "syntax error")
+				continue
+			}
+			if cur.hasPlural {
+				r.addError(filename, lineNo, "missing 'msgstr[]' section")
+			}
+			cur.hasMsgstr = true
+			cur.lastKeyword = keyword
+		case strings.HasPrefix(keyword, "msgstr["):
+			n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(keyword, "msgstr["), "]"))
+			if err != nil || !strings.HasSuffix(keyword, "]") {
+				r.addError(filename, lineNo, "syntax error")
+				continue
+			}
+			if cur == nil || cur.msgidLine == 0 || cur.hasMsgstr {
+				r.addError(filename, lineNo, "syntax error")
+				continue
+			}
+			if !cur.hasPlural {
+				r.addError(filename, lineNo, "missing 'msgid_plural' section")
+			} else if n != cur.nextPlural {
+				r.addError(filename, lineNo, "plural form has wrong index")
+			}
+			cur.nextPlural = n + 1
+			cur.hasMsgstrN = true
+			cur.lastKeyword = keyword
+		default:
+			if litErr == "" {
+				r.addError(filename, lineNo, "keyword \"%s\" unknown", keyword)
+			}
+			continue
+		}
+		if litErr == "" && strings.TrimSpace(rest) == "" {
+			r.addError(filename, lineNo, "syntax error")
+		}
+	}
+	finish()
+}
+
+// checkPoStringLiterals checks that s (after the keyword) is a sequence of
+// quoted strings with valid escape sequences. Returns a message or "".
+func checkPoStringLiterals(s string) string {
+	i := 0
+	for {
+		for i < len(s) && (s[i] == ' ' || s[i] == '\t') {
+			i++
+		}
+		if i >= len(s) {
+			return ""
+		}
+		if s[i] != '"' {
+			return "syntax error"
+		}
+		i++
+		closed := false
+		for i < len(s) {
+			c := s[i]
+			if c == '"' {
+				closed = true
+				i++
+				break
+			}
+			if c == '\\' {
+				if i+1 >= len(s) {
+					break
+				}
+				switch e := s[i+1]; {
+				case strings.IndexByte(`ntrbfva\"'?`, e) >= 0:
+					i += 2
+				case e >= '0' && e <= '7':
+					i += 2
+					for k := 0; k < 2 && i < len(s) && s[i] >= '0' && s[i] <= '7'; k++ {
+						i++
+					}
+				case e == 'x':
+					i += 2
+					if i >= len(s) || !isHexDigit(s[i]) {
+						return "invalid control sequence"
+					}
+					for i < len(s) && isHexDigit(s[i]) {
+						i++
+					}
+				default:
+					return "invalid control sequence"
+				}
+				continue
+			}
+			i++
+		}
+		if !closed {
+			return "end-of-line within string"
+		}
+	}
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
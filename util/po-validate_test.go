@@ -0,0 +1,168 @@
+package util
+
+import (
+	"strings"
+	"testing"
+)
+
+const validateTestHeader = `msgid ""
+msgstr ""
+"Project-Id-Version: git\n"
+"PO-Revision-Date: 2024-01-01 00:00+0800\n"
+"Last-Translator: A <a@example.com>\n"
+"Language-Team: zh_CN\n"
+"MIME-Version: 1.0\n"
+"Content-Type: text/plain; charset=UTF-8\n"
+"Content-Transfer-Encoding: 8bit\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+`
+
+func TestValidatePoData(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		opts     PoValidateOptions
+		wantOK   bool
+		wantMsgs []string
+	}{
+		{
+			name:   "valid file",
+			body:   "#, c-format\nmsgid \"%d file\"\nmsgid_plural \"%d files\"\nmsgstr[0] \"%d 文件\"\nmsgstr[1] \"%d 文件\"\n",
+			opts:   PoValidateOptions{CheckHeader: true, CheckFormat: true},
+			wantOK: true,
+		},
+		{
+			name:     "end-of-line within string",
+			body:     "msgid \"a\"\nmsgstr \"b\"\"\n",
+			wantMsgs: []string{"test.po:13: end-of-line within string"},
+		},
+		{
+			name:     "invalid escape",
+			body:     "msgid \"a\"\nmsgstr \"\\q\"\n",
+			wantMsgs: []string{"test.po:13: invalid control sequence"},
+		},
+		{
+			name:     "unknown keyword",
+			body:     "msgid \"a\"\nmsgstring \"b\"\n",
+			wantMsgs: []string{"test.po:13: keyword \"msgstring\" unknown", "test.po:12: missing 'msgstr' section"},
+		},
+		{
+			name:     "msgstr[] without msgid_plural",
+			body:     "msgid \"a\"\nmsgstr[0] \"b\"\n",
+			wantMsgs: []string{"test.po:13: missing 'msgid_plural' section"},
+		},
+		{
+			name:     "msgstr after msgid_plural",
+			body:     "msgid \"a\"\nmsgid_plural \"as\"\nmsgstr \"b\"\n",
+			wantMsgs: []string{"test.po:14: missing 'msgstr[]' section"},
+		},
+		{
+			name:     "wrong plural index",
+			body:     "msgid \"a\"\nmsgid_plural \"as\"\nmsgstr[0] \"b\"\nmsgstr[2] \"c\"\n",
+			wantMsgs: []string{"test.po:15: plural form has wrong index"},
+		},
+		{
+			name: "duplicate message",
+			body: "msgid \"a\"\nmsgstr \"b\"\n\nmsgid \"a\"\nmsgstr \"c\"\n",
+			wantMsgs: []string{
+				"test.po:15: duplicate message definition...",
+				"test.po:12: ...this is the location of the first definition",
+			},
+		},
+		{
+			name:   "same msgid in different context is not duplicate",
+			body:   "msgctxt \"x\"\nmsgid \"a\"\nmsgstr \"b\"\n\nmsgid \"a\"\nmsgstr \"c\"\n",
+			wantOK: true,
+		},
+		{
+			name:     "wrong plural count with header check",
+			body:     "msgid \"a\"\nmsgid_plural \"as\"\nmsgstr[0] \"b\"\n",
+			opts:     PoValidateOptions{CheckHeader: true},
+			wantMsgs: []string{"has 1 msgstr[] forms"},
+		},
+		{
+			name:     "trailing newline mismatch",
+			body:     "msgid \"a\\n\"\nmsgstr \"b\"\n",
+			opts:     PoValidateOptions{CheckFormat: true},
+			wantMsgs: []string{"test.po:12: 'msgid' and 'msgstr' entries do not both end with '\\n'"},
+		},
+		{
+			name:     "c-format mismatch",
+			body:     "#, c-format\nmsgid \"%s: %d\"\nmsgstr \"%d\"\n",
+			opts:     PoValidateOptions{CheckFormat: true},
+			wantMsgs: []string{"format specifications in 'msgid' and 'msgstr' for argument 1 are not the same: '%s' (char *) vs '%d' (int)"},
+		},
+		{
+			name:   "c-format mismatch is ignored with NoCFormat",
+			body:   "#, c-format\nmsgid \"%s: %d\"\nmsgstr \"%d\"\n",
+			opts:   PoValidateOptions{CheckFormat: true, NoCFormat: true},
+			wantOK: true,
+		},
+		{
+			name:   "c-format mismatch in fuzzy entry is ignored",
+			body:   "#, fuzzy, c-format\nmsgid \"%s: %d\"\nmsgstr \"%d\"\n",
+			opts:   PoValidateOptions{CheckFormat: true},
+			wantOK: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := ValidatePoData([]byte(validateTestHeader+tt.body), "test.po", tt.opts)
+			if r.OK() != tt.wantOK {
+				t.Fatalf("OK() = %v, want %v; messages: %v", r.OK(), tt.wantOK, r.Messages)
+			}
+			all := strings.Join(r.Messages, "\n")
+			for _, want := range tt.wantMsgs {
+				if !strings.Contains(all, want) {
+					t.Errorf("messages %q do not contain %q", r.Messages, want)
+				}
+			}
+			if tt.wantOK && r.Stats == nil {
+				t.Errorf("Stats is nil for valid file")
+			}
+		})
+	}
+}
+
+func TestValidatePoData_StringErrorKeepsKeyword(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		body string
+		want []string
+	}{
+		{"msgstr", "msgid \"a\"\nmsgstr \"b\"\"\n",
+			[]string{"test.po:13: end-of-line within string"}},
+		{"msgstr[]", "msgid \"a\"\nmsgid_plural \"as\"\nmsgstr[0] \"b\nmsgstr[1] \"c\"\n",
+			[]string{"test.po:14: end-of-line within string"}},
+		{"msgid", "msgid \"a\\q\"\nmsgstr \"b\"\n",
+			[]string{"test.po:12: invalid control sequence"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := ValidatePoData([]byte(validateTestHeader+tc.body), "test.po", PoValidateOptions{})
+			if strings.Join(r.Messages, "\n") != strings.Join(tc.want, "\n") {
+				t.Errorf("got %q, want %q", r.Messages, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidatePoData_HeaderWarnings(t *testing.T) {
+	po := "msgid \"\"\nmsgstr \"\"\n\"Project-Id-Version: PACKAGE VERSION\\n\"\n\nmsgid \"a\"\nmsgstr \"b\"\n"
+	r := ValidatePoData([]byte(po), "test.po", PoValidateOptions{CheckHeader: true})
+	if !r.OK() {
+		t.Fatalf("incomplete header must only warn, got errors: %v", r.Messages)
+	}
+	all := strings.Join(r.Messages, "\n")
+	for _, want := range []string{
+		"warning: header field 'Project-Id-Version' still has the initial default value",
+		"warning: header field 'Last-Translator' missing in header",
+	} {
+		if !strings.Contains(all, want) {
+			t.Errorf("messages %q do not contain %q", r.Messages, want)
+		}
+	}
+	if got := r.Summary(); got != "1 translated message." {
+		t.Errorf("Summary() = %q", got)
+	}
+}
@@ -0,0 +1,229 @@
+// Package util provides msgcat-compatible line wrapping and layout preservation for PO output.
+package util
+
+import (
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/git-l10n/git-po-helper/flag"
+	"github.com/mattn/go-runewidth"
+)
+
+// poWrapRunewidth measures screen columns like msgcat: East Asian wide
+// characters take two columns, ambiguous ones take one.
+var poWrapRunewidth = &runewidth.Condition{EastAsianWidth: false}
+
+// poToken is one output unit of an escaped PO string: an escape sequence such as
+// `\n` or `\"` (r is the character it stands for), or a single character.
+type poToken struct {
+	text string
+	r    rune
+}
+
+// tokenizePoString splits a PO-escaped string (output of poEscape) into tokens.
+func tokenizePoString(escaped string) []poToken {
+	var tokens []poToken
+	for i := 0; i < len(escaped); {
+		if escaped[i] == '\\' && i+1 < len(escaped) {
+			r := rune(escaped[i+1])
+			switch r {
+			case 'n':
+				r = '\n'
+			case 't':
+				r = '\t'
+			case 'r':
+				r = '\r'
+			}
+			tokens = append(tokens, poToken{text: escaped[i : i+2], r: r})
+			i += 2
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(escaped[i:])
+		tokens = append(tokens, poToken{text: escaped[i : i+size], r: r})
+		i += size
+	}
+	return tokens
+}
+
+func poTokensWidth(tokens []poToken) int {
+	width := 0
+	for _, t := range tokens {
+		width += poWrapRunewidth.StringWidth(t.text)
+	}
+	return width
+}
+
+func poTokensText(tokens []poToken) string {
+	var b strings.Builder
+	for _, t := range tokens {
+		b.WriteString(t.text)
+	}
+	return b.String()
+}
+
+// isWideRune reports whether r is an East Asian wide character (CJK ideographs,
+// kana, full-width forms), around which lines may be broken like in msgcat.
+func isWideRune(r rune) bool {
+	return poWrapRunewidth.RuneWidth(r) == 2
+}
+
+// poNoBreakBefore lists characters that must not start a line (closing
+// punctuation, quotes, newline), a subset of the Unicode line breaking rules.
+const poNoBreakBefore = ",.:;!?)]}\"'\n，。、；：？！）」』》〉】〕”’"
+
+// poNoBreakAfter lists characters that must not end a line (opening punctuation).
+const poNoBreakAfter = "([{\"'（「『《〈【〔“‘"
+
+// poBreakAllowed reports whether a line may be broken between tokens[i-1] and tokens[i].
+func poBreakAllowed(tokens []poToken, i int) bool {
+	prev, next := tokens[i-1].r, tokens[i].r
+	if strings.ContainsRune(poNoBreakBefore, next) || strings.ContainsRune(poNoBreakAfter, prev) {
+		return false
+	}
+	switch {
+	case prev == ' ' || prev == '\t':
+		return next != ' ' && next != '\t'
+	case prev == '-':
+		// Break inside hyphenated words ("non-fast-forward"), not after "--".
+		return i >= 2 && isWordRune(tokens[i-2].r) && isWordRune(next) && !(next >= '0' && next <= '9')
+	case isWideRune(prev) || isWideRune(next):
+		return next != ' ' && next != '\t'
+	}
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r >= 0x80 && !isWideRune(r)
+}
+
+// wrapPoTokens breaks one newline-terminated portion into lines of at most width
+// columns, at allowed break opportunities. Units longer than width are not split.
+func wrapPoTokens(tokens []poToken, width int) []string {
+	var (
+		lines     []string
+		line      []poToken
+		lineWidth int
+		start     int
+	)
+	flushUnit := func(end int) {
+		unit := tokens[start:end]
+		unitWidth := poTokensWidth(unit)
+		if len(line) > 0 && lineWidth+unitWidth > width {
+			lines = append(lines, poTokensText(line))
+			line, lineWidth = nil, 0
+		}
+		line = append(line, unit...)
+		lineWidth += unitWidth
+		start = end
+	}
+	for i := 1; i < len(tokens); i++ {
+		if poBreakAllowed(tokens, i) {
+			flushUnit(i)
+		}
+	}
+	if start < len(tokens) {
+		flushUnit(len(tokens))
+	}
+	if len(line) > 0 {
+		lines = append(lines, poTokensText(line))
+	}
+	return lines
+}
+
+// writeWrappedPoString writes keyword and value (PO format) like msgcat with
+// --width=width: on one line when it fits and has no embedded newline, otherwise
+// as keyword "" followed by lines broken after each \n and at word boundaries.
+func writeWrappedPoString(w io.Writer, prefix, keyword, value string, width int) error {
+	tokens := tokenizePoString(poEscape(value))
+	var portions [][]poToken
+	start := 0
+	for i, t := range tokens {
+		if t.text == `\n` {
+			portions = append(portions, tokens[start:i+1])
+			start = i + 1
+		}
+	}
+	if start < len(tokens) || len(portions) == 0 {
+		portions = append(portions, tokens[start:])
+	}
+
+	prefixWidth := poWrapRunewidth.StringWidth(prefix)
+	if len(portions) == 1 &&
+		prefixWidth+len(keyword)+1+2+poTokensWidth(portions[0]) <= width {
+		_, err := io.WriteString(w, prefix+keyword+" \""+poTokensText(portions[0])+"\"\n")
+		return err
+	}
+	if _, err := io.WriteString(w, prefix+keyword+" \"\"\n"); err != nil {
+		return err
+	}
+	for _, portion := range portions {
+		for _, line := range wrapPoTokens(portion, width-prefixWidth-2) {
+			if _, err := io.WriteString(w, prefix+"\""+line+"\"\n"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeRawLinesIfUnchanged writes the original lines of entry when --keep-layout
+// is set and the entry was not modified since it was read. Returns false when
+// the entry must be regenerated from its fields.
+func writeRawLinesIfUnchanged(w io.Writer, entry *GettextEntry) (bool, error) {
+	if !flag.PoKeepLayout() || len(entry.RawLines) == 0 || !entryMatchesRawLines(entry) {
+		return false, nil
+	}
+	for _, line := range entry.RawLines {
+		if _, err := io.WriteString(w, line+"\n"); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// entryMatchesRawLines reports whether the fields of e are still those parsed from
+// e.RawLines. The fuzzy flag is compared through e.Fuzzy, since gettext JSON keeps
+// it out of comments.
+func entryMatchesRawLines(e *GettextEntry) bool {
+	st := &poParseState{hasSeenHeaderBlock: true, currentPluralIndex: -1}
+	var entries []*GettextEntry
+	for i, line := range e.RawLines {
+		st.parseLine(line, i+1, &entries)
+	}
+	finishCurrentEntry(st, &entries)
+	if len(entries) != 1 {
+		return false
+	}
+	orig := entries[0]
+	if orig.MsgID != e.MsgID || orig.MsgIDPlural != e.MsgIDPlural ||
+		orig.Fuzzy != e.Fuzzy || orig.Obsolete != e.Obsolete ||
+		(orig.MsgCtxt == nil) != (e.MsgCtxt == nil) ||
+		(orig.MsgCtxt != nil && *orig.MsgCtxt != *e.MsgCtxt) ||
+		!equalStrings(orig.MsgStr, e.MsgStr) {
+		return false
+	}
+	return equalStrings(commentsWithoutFuzzy(orig.Comments), commentsWithoutFuzzy(e.Comments))
+}
+
+func commentsWithoutFuzzy(comments []string) []string {
+	var out []string
+	for _, c := range comments {
+		if stripped := StripFuzzyFromCommentLine(c); stripped != "" {
+			out = append(out, stripped)
+		}
+	}
+	return out
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
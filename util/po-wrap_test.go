@@ -0,0 +1,140 @@
+package util
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestWriteWrappedPoString(t *testing.T) {
+	tests := []struct {
+		name    string
+		prefix  string
+		keyword string
+		value   string
+		width   int
+		want    string
+	}{
+		{
+			name:    "fits on one line",
+			keyword: "msgid",
+			value:   `Hello, world\n`,
+			width:   79,
+			want:    "msgid \"Hello, world\\n\"\n",
+		},
+		{
+			name:    "embedded newline",
+			keyword: "msgid",
+			value:   `line one\nline two`,
+			width:   79,
+			want:    "msgid \"\"\n\"line one\\n\"\n\"line two\"\n",
+		},
+		{
+			name:    "wrap at spaces",
+			keyword: "msgid",
+			value:   "aaaa bbbb cccc dddd",
+			width:   14,
+			want:    "msgid \"\"\n\"aaaa bbbb \"\n\"cccc dddd\"\n",
+		},
+		{
+			name:    "no break before closing punctuation or after --",
+			keyword: "msgid",
+			value:   "use --force, please",
+			width:   12,
+			want:    "msgid \"\"\n\"use \"\n\"--force, \"\n\"please\"\n",
+		},
+		{
+			name:    "hyphenated word",
+			keyword: "msgid",
+			value:   "non-fast-forward",
+			width:   12,
+			want:    "msgid \"\"\n\"non-fast-\"\n\"forward\"\n",
+		},
+		{
+			name:    "CJK breaks between characters, not before full-width comma",
+			keyword: "msgstr",
+			value:   "一二三四，五六七",
+			width:   12,
+			want:    "msgstr \"\"\n\"一二三四，\"\n\"五六七\"\n",
+		},
+		{
+			name:    "obsolete prefix counts",
+			prefix:  "#~ ",
+			keyword: "msgid",
+			value:   "aaaa bbbb",
+			width:   13,
+			want:    "#~ msgid \"\"\n#~ \"aaaa \"\n#~ \"bbbb\"\n",
+		},
+		{
+			name:    "overlong word is not split",
+			keyword: "msgid",
+			value:   "abcdefghijklmnop qr",
+			width:   10,
+			want:    "msgid \"\"\n\"abcdefghijklmnop \"\n\"qr\"\n",
+		},
+		{
+			name:    "escapes are not split",
+			keyword: "msgid",
+			value:   `say \"hi\" now`,
+			width:   12,
+			want:    "msgid \"\"\n\"say \\\"hi\\\" \"\n\"now\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := writeWrappedPoString(&b, tt.prefix, tt.keyword, tt.value, tt.width); err != nil {
+				t.Fatal(err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteGettextJSONToPO_KeepLayout(t *testing.T) {
+	content := `msgid ""
+msgstr ""
+"Project-Id-Version: git\n"
+
+#: a.c:1
+msgid ""
+"hand "
+"wrapped"
+msgstr "von Hand"
+
+#, fuzzy
+msgid "two"
+msgstr ""
+"zwei"
+`
+	po, err := ParsePoEntries([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	j := GettextJSONFromGettextPO(po)
+
+	viper.Set("msg-cat--keep-layout", true)
+	defer viper.Set("msg-cat--keep-layout", false)
+
+	var b bytes.Buffer
+	if err := WriteGettextJSONToPO(j, &b, false, false); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != content {
+		t.Errorf("unmodified entries not kept:\n%s", b.String())
+	}
+
+	// Modified entries are regenerated, untouched ones keep their lines.
+	ClearFuzzyTagFromGettextJSON(j)
+	b.Reset()
+	if err := WriteGettextJSONToPO(j, &b, true, false); err != nil {
+		t.Fatal(err)
+	}
+	want := "#: a.c:1\nmsgid \"\"\n\"hand \"\n\"wrapped\"\nmsgstr \"von Hand\"\n\nmsgid \"two\"\nmsgstr \"zwei\"\n"
+	if b.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
@@ -1,9 +1,10 @@
 package util
 
 import (
-	"errors"
 	"fmt"
 	"os/exec"
+
+	log "github.com/sirupsen/logrus"
 )
 
 // CheckPrereq checks prerequisites for po-helper.
@@ -23,8 +24,11 @@ func CheckPrereq() error {
 		}
 	}
 
+	// gettext is optional: check-po, compile and update fall back to the
+	// built-in validator, .mo writer and msgmerge; commands that need msgcat
+	// report the missing program when they run it.
 	if _, err := exec.LookPath("msgfmt"); err != nil {
-		return errors.New("gettext is not installed")
+		log.Debugf("gettext is not installed, using built-in PO validator")
 	}
 
 	return nil
@@ -0,0 +1,258 @@
+// Package util provides the JSON and SARIF reports of the findings of check-po and check-commits.
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/git-l10n/git-po-helper/version"
+	log "github.com/sirupsen/logrus"
+)
+
+// Output formats of check-po and check-commits (--output-format).
+const (
+	ReportFormatText  = "text"
+	ReportFormatJSON  = "json"
+	ReportFormatSARIF = "sarif"
+)
+
+// ReportFinding is an error or a warning reported by ReportSection, as
+// written by --output-format=json.
+type ReportFinding struct {
+	// RuleID identifies the check, e.g. "c-format" or "commit-subject".
+	RuleID string `json:"rule"`
+	// Section is the title of the section of the text report.
+	Section string `json:"section"`
+	// Level is "error" or "warning".
+	Level   string `json:"level"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Commit  string `json:"commit,omitempty"`
+	Message string `json:"message"`
+}
+
+// reportFindings collects the findings, see CollectReportFindings.
+var reportFindings *[]ReportFinding
+
+// reportFindingsKeepText is true to print the sections while collecting the
+// findings.
+var reportFindingsKeepText bool
+
+// reportTarget is the file and the commit the findings are about, see
+// setReportTarget.
+var reportTarget struct {
+	file   string
+	commit string
+}
+
+var (
+	// reportLinePatterns find the line number of a finding in its message:
+	// "entry 3@L42 (msgid ...)" of our checks, or "po/zh_CN.po:42: ..." of msgfmt.
+	reportLinePatterns = []*regexp.Regexp{
+		regexp.MustCompile(`@L(\d+)\b`),
+		regexp.MustCompile(`^\S+\.pot?:(\d+):`),
+	}
+	// reportCommitPattern finds the commit of a finding of check-commits.
+	reportCommitPattern = regexp.MustCompile(`^commit ([0-9a-f]{7,}):`)
+	reportRuleIDPattern = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// CollectReportFindings makes ReportSection collect its errors and warnings,
+// until WriteReportFindings or WriteGitHubAnnotations is called. The sections
+// are not printed unless keepText is true.
+func CollectReportFindings(keepText bool) {
+	reportFindings = &[]ReportFinding{}
+	reportFindingsKeepText = keepText
+}
+
+// takeReportFindings returns the findings collected, and stops collecting.
+func takeReportFindings() []ReportFinding {
+	findings := []ReportFinding{}
+	if reportFindings != nil {
+		findings = *reportFindings
+	}
+	reportFindings = nil
+	reportFindingsKeepText = false
+	return findings
+}
+
+// setReportTarget sets the file and the commit of the findings reported
+// until the returned function restores the previous ones. Use "" for
+// unknown.
+func setReportTarget(file, commit string) func() {
+	saved := reportTarget
+	reportTarget.file = file
+	reportTarget.commit = commit
+	return func() {
+		reportTarget = saved
+	}
+}
+
+// reportRuleID returns the rule id of a section without a check name, e.g.
+// "commit-subject" for "Commit subject".
+func reportRuleID(title string) string {
+	return strings.Trim(reportRuleIDPattern.ReplaceAllString(strings.ToLower(title), "-"), "-")
+}
+
+// collectReportFindings adds the messages of a section, one finding for each
+// message. Informational messages are not findings.
+func collectReportFindings(ruleID, title string, level log.Level, errs []string) {
+	var levelName string
+	switch level {
+	case log.ErrorLevel:
+		levelName = "error"
+	case log.WarnLevel:
+		levelName = "warning"
+	default:
+		return
+	}
+	for _, msg := range errs {
+		if strings.TrimSpace(msg) == "" {
+			continue
+		}
+		finding := ReportFinding{
+			RuleID:  ruleID,
+			Section: title,
+			Level:   levelName,
+			File:    reportTarget.file,
+			Commit:  reportTarget.commit,
+			Message: strings.TrimRight(msg, "\n"),
+		}
+		for _, re := range reportLinePatterns {
+			if m := re.FindStringSubmatch(msg); m != nil {
+				finding.Line, _ = strconv.Atoi(m[1])
+				break
+			}
+		}
+		if finding.Commit == "" {
+			if m := reportCommitPattern.FindStringSubmatch(msg); m != nil {
+				finding.Commit = m[1]
+			}
+		}
+		*reportFindings = append(*reportFindings, finding)
+	}
+}
+
+// WriteReportFindings writes the findings collected since
+// CollectReportFindings to w in format (ReportFormatJSON or
+// ReportFormatSARIF), and stops collecting. ok is the result of the checks.
+func WriteReportFindings(w io.Writer, format string, ok bool) error {
+	findings := takeReportFindings()
+
+	var v interface{}
+	switch format {
+	case ReportFormatJSON:
+		v = struct {
+			OK       bool            `json:"ok"`
+			Findings []ReportFinding `json:"findings"`
+		}{ok, findings}
+	case ReportFormatSARIF:
+		v = newSarifLog(findings)
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(v)
+}
+
+// SARIF 2.1.0, as read by GitHub code scanning.
+type (
+	sarifLog struct {
+		Schema  string     `json:"$schema"`
+		Version string     `json:"version"`
+		Runs    []sarifRun `json:"runs"`
+	}
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		Name           string      `json:"name"`
+		Version        string      `json:"version,omitempty"`
+		InformationURI string      `json:"informationUri"`
+		Rules          []sarifRule `json:"rules"`
+	}
+	sarifRule struct {
+		ID               string       `json:"id"`
+		ShortDescription sarifMessage `json:"shortDescription"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifResult struct {
+		RuleID     string            `json:"ruleId"`
+		RuleIndex  int               `json:"ruleIndex"`
+		Level      string            `json:"level"`
+		Message    sarifMessage      `json:"message"`
+		Locations  []sarifLocation   `json:"locations,omitempty"`
+		Properties map[string]string `json:"properties,omitempty"`
+	}
+	sarifLocation struct {
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	}
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+		Region           *sarifRegion          `json:"region,omitempty"`
+	}
+	sarifArtifactLocation struct {
+		URI string `json:"uri"`
+	}
+	sarifRegion struct {
+		StartLine int `json:"startLine"`
+	}
+)
+
+func newSarifLog(findings []ReportFinding) *sarifLog {
+	driver := sarifDriver{
+		Name:           "git-po-helper",
+		Version:        version.Version,
+		InformationURI: "https://github.com/git-l10n/git-po-helper",
+		Rules:          []sarifRule{},
+	}
+	ruleIndex := make(map[string]int)
+	results := []sarifResult{}
+	for _, f := range findings {
+		idx, ok := ruleIndex[f.RuleID]
+		if !ok {
+			idx = len(driver.Rules)
+			ruleIndex[f.RuleID] = idx
+			driver.Rules = append(driver.Rules, sarifRule{
+				ID:               f.RuleID,
+				ShortDescription: sarifMessage{Text: f.Section},
+			})
+		}
+		result := sarifResult{
+			RuleID:    f.RuleID,
+			RuleIndex: idx,
+			Level:     f.Level,
+			Message:   sarifMessage{Text: f.Message},
+		}
+		if f.File != "" {
+			loc := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: f.File},
+			}}
+			if f.Line > 0 {
+				loc.PhysicalLocation.Region = &sarifRegion{StartLine: f.Line}
+			}
+			result.Locations = []sarifLocation{loc}
+		}
+		if f.Commit != "" {
+			result.Properties = map[string]string{"commit": f.Commit}
+		}
+		results = append(results, result)
+	}
+	return &sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}
+}
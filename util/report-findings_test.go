@@ -0,0 +1,101 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func TestReportFindings(t *testing.T) {
+	CollectReportFindings(false)
+	func() {
+		defer setReportTarget("po/zh_CN.po", "")()
+		reportRuleSection("c-format", "C format strings", false, log.InfoLevel, "[zh_CN.po]",
+			`entry 3@L42 (msgid "%s"): bad format`,
+			"",
+			"po/zh_CN.po:7: msgfmt error")
+		ReportSection("Syntax check with msgfmt", true, log.InfoLevel, "[zh_CN.po]", "1 translated message.")
+	}()
+	ReportSection("Commit subject", true, log.WarnLevel, "", "commit 1234567: subject length 60 > 50")
+
+	var buf bytes.Buffer
+	if err := WriteReportFindings(&buf, ReportFormatJSON, false); err != nil {
+		t.Fatal(err)
+	}
+	if reportFindings != nil {
+		t.Error("still collecting findings")
+	}
+	var out struct {
+		OK       bool            `json:"ok"`
+		Findings []ReportFinding `json:"findings"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	want := []ReportFinding{
+		{RuleID: "c-format", Section: "C format strings", Level: "error", File: "po/zh_CN.po", Line: 42,
+			Message: `entry 3@L42 (msgid "%s"): bad format`},
+		{RuleID: "c-format", Section: "C format strings", Level: "error", File: "po/zh_CN.po", Line: 7,
+			Message: "po/zh_CN.po:7: msgfmt error"},
+		{RuleID: "commit-subject", Section: "Commit subject", Level: "warning", Commit: "1234567",
+			Message: "commit 1234567: subject length 60 > 50"},
+	}
+	if out.OK || !reflect.DeepEqual(out.Findings, want) {
+		t.Errorf("got %+v, want %+v", out, want)
+	}
+}
+
+func TestReportFindingsSARIF(t *testing.T) {
+	CollectReportFindings(false)
+	func() {
+		defer setReportTarget("po/fr.po", "abcdef0")()
+		reportRuleSection("obsolete", "Obsolete #~ entries", false, log.InfoLevel, "", "1 obsolete entry")
+		reportRuleSection("c-format", "C format strings", false, log.InfoLevel, "", "entry 1@L9: bad")
+		reportRuleSection("obsolete", "Obsolete #~ entries", false, log.InfoLevel, "", "again")
+	}()
+
+	var buf bytes.Buffer
+	if err := WriteReportFindings(&buf, ReportFormatSARIF, false); err != nil {
+		t.Fatal(err)
+	}
+	var sarif sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &sarif); err != nil {
+		t.Fatal(err)
+	}
+	if sarif.Version != "2.1.0" || len(sarif.Runs) != 1 {
+		t.Fatalf("bad SARIF log: %s", buf.String())
+	}
+	run := sarif.Runs[0]
+	if len(run.Tool.Driver.Rules) != 2 || run.Tool.Driver.Rules[1].ID != "c-format" {
+		t.Errorf("bad rules: %+v", run.Tool.Driver.Rules)
+	}
+	if len(run.Results) != 3 {
+		t.Fatalf("got %d results, want 3", len(run.Results))
+	}
+	r := run.Results[1]
+	if r.RuleID != "c-format" || r.RuleIndex != 1 || r.Level != "error" ||
+		r.Locations[0].PhysicalLocation.ArtifactLocation.URI != "po/fr.po" ||
+		r.Locations[0].PhysicalLocation.Region.StartLine != 9 ||
+		r.Properties["commit"] != "abcdef0" {
+		t.Errorf("bad result: %+v", r)
+	}
+	if run.Results[2].RuleIndex != 0 {
+		t.Errorf("bad rule index of result: %+v", run.Results[2])
+	}
+}
+
+func TestReportRuleID(t *testing.T) {
+	for title, id := range map[string]string{
+		"Commit subject":                "commit-subject",
+		"Changes outside po/":           "changes-outside-po",
+		"CamelCase config variables":    "camelcase-config-variables",
+		"Incomplete translations found": "incomplete-translations-found",
+	} {
+		if got := reportRuleID(title); got != id {
+			t.Errorf("reportRuleID(%q) = %q, want %q", title, got, id)
+		}
+	}
+}
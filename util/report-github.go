@@ -0,0 +1,121 @@
+// Package util provides GitHub Actions annotations of the findings of check-po and check-commits.
+package util
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// githubEscapeData escapes the message of a workflow command.
+func githubEscapeData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
+
+// githubEscapeProperty escapes a property (file, title) of a workflow command.
+func githubEscapeProperty(s string) string {
+	s = githubEscapeData(s)
+	s = strings.ReplaceAll(s, ":", "%3A")
+	return strings.ReplaceAll(s, ",", "%2C")
+}
+
+// githubAnnotation returns the workflow command "::error file=...,line=...::"
+// or "::warning ...::" of f, shown inline on the diff of a pull request.
+func githubAnnotation(f ReportFinding) string {
+	title := f.Section
+	if f.Commit != "" {
+		title += fmt.Sprintf(" (commit %s)", AbbrevCommit(f.Commit))
+	}
+	var props []string
+	if f.File != "" {
+		props = append(props, "file="+githubEscapeProperty(f.File))
+		if f.Line > 0 {
+			props = append(props, fmt.Sprintf("line=%d", f.Line))
+		}
+	}
+	props = append(props, "title="+githubEscapeProperty(title))
+	return fmt.Sprintf("::%s %s::%s", f.Level, strings.Join(props, ","), githubEscapeData(f.Message))
+}
+
+// githubSummaryCell returns s for a cell of a Markdown table.
+func githubSummaryCell(s string) string {
+	s = strings.NewReplacer("|", `\|`, "<", "&lt;", ">", "&gt;").Replace(s)
+	return strings.ReplaceAll(strings.TrimRight(s, "\n"), "\n", "<br>")
+}
+
+// writeGitHubStepSummary writes the findings of command as a Markdown job
+// summary.
+func writeGitHubStepSummary(w io.Writer, command string, findings []ReportFinding, ok bool) error {
+	var nErrors, nWarnings int
+	for _, f := range findings {
+		if f.Level == "error" {
+			nErrors++
+		} else {
+			nWarnings++
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "## git-po-helper %s\n\n", command)
+	switch {
+	case len(findings) == 0:
+		b.WriteString(":white_check_mark: No problems found.\n")
+	case ok:
+		fmt.Fprintf(&b, ":warning: %d warning(s).\n", nWarnings)
+	default:
+		fmt.Fprintf(&b, ":x: %d error(s), %d warning(s).\n", nErrors, nWarnings)
+	}
+	if len(findings) > 0 {
+		b.WriteString("\n| Level | Check | File | Commit | Message |\n")
+		b.WriteString("|-------|-------|------|--------|---------|\n")
+		for _, f := range findings {
+			file := f.File
+			if file != "" && f.Line > 0 {
+				file = fmt.Sprintf("%s:%d", file, f.Line)
+			}
+			commit := ""
+			if f.Commit != "" {
+				commit = AbbrevCommit(f.Commit)
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n",
+				f.Level,
+				githubSummaryCell(f.Section),
+				githubSummaryCell(file),
+				commit,
+				githubSummaryCell(f.Message))
+		}
+	}
+	b.WriteString("\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteGitHubAnnotations writes the findings collected since
+// CollectReportFindings to w as workflow commands of GitHub Actions, and
+// appends a job summary of command to $GITHUB_STEP_SUMMARY if set. It stops
+// collecting. ok is the result of the checks.
+func WriteGitHubAnnotations(w io.Writer, command string, ok bool) error {
+	findings := takeReportFindings()
+	for _, f := range findings {
+		if _, err := fmt.Fprintln(w, githubAnnotation(f)); err != nil {
+			return err
+		}
+	}
+
+	summaryFile := os.Getenv("GITHUB_STEP_SUMMARY")
+	if summaryFile == "" {
+		return nil
+	}
+	f, err := os.OpenFile(summaryFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if err := writeGitHubStepSummary(f, command, findings, ok); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
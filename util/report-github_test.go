@@ -0,0 +1,72 @@
+package util
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func TestGitHubAnnotation(t *testing.T) {
+	for _, tc := range []struct {
+		finding ReportFinding
+		want    string
+	}{
+		{
+			ReportFinding{Section: "C format strings", Level: "error", File: "po/zh_CN.po", Line: 42,
+				Message: `entry 3@L42 (msgid "100%s"): bad`},
+			`::error file=po/zh_CN.po,line=42,title=C format strings::entry 3@L42 (msgid "100%25s"): bad`,
+		},
+		{
+			ReportFinding{Section: "Changes outside po/", Level: "warning",
+				Commit: "0123456789abcdef0123456789abcdef01234567", Message: "line 1\nline 2"},
+			`::warning title=Changes outside po/ (commit 0123456)::line 1%0Aline 2`,
+		},
+		{
+			ReportFinding{Section: "Obsolete #~ entries", Level: "error", File: "a,b:c.po", Message: "x"},
+			`::error file=a%2Cb%3Ac.po,title=Obsolete #~ entries::x`,
+		},
+	} {
+		if got := githubAnnotation(tc.finding); got != tc.want {
+			t.Errorf("got:\n%s\nwant:\n%s", got, tc.want)
+		}
+	}
+}
+
+func TestWriteGitHubAnnotations(t *testing.T) {
+	summary := filepath.Join(t.TempDir(), "summary.md")
+	os.Setenv("GITHUB_STEP_SUMMARY", summary)
+	defer os.Unsetenv("GITHUB_STEP_SUMMARY")
+
+	CollectReportFindings(false)
+	func() {
+		defer setReportTarget("po/fr.po", "")()
+		reportRuleSection("obsolete", "Obsolete #~ entries", false, log.InfoLevel, "", "a | b\n<c>")
+		ReportSection("Commit subject", true, log.WarnLevel, "", "commit 1234567: too long")
+	}()
+	var buf bytes.Buffer
+	if err := WriteGitHubAnnotations(&buf, "check-po", false); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 2 {
+		t.Errorf("got %d annotations, want 2:\n%s", n, buf.String())
+	}
+
+	data, err := os.ReadFile(summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"## git-po-helper check-po\n",
+		":x: 1 error(s), 1 warning(s).\n",
+		"| error | Obsolete #~ entries | po/fr.po |  | a \\| b<br>&lt;c&gt; |\n",
+		"| warning | Commit subject | po/fr.po | 1234567 | commit 1234567: too long |\n",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("summary does not contain %q:\n%s", want, data)
+		}
+	}
+}
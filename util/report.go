@@ -182,6 +182,12 @@ func reportSectionStart(level log.Level, title string) {
 // If ok is false, lines use ERROR; if ok, successLevel is INFO or WARN (else treated as WARN).
 // Example: ReportSection("Locale name", false, log.InfoLevel, prompt, err.Error()).
 func ReportSection(sectionTitle string, ok bool, successLevel log.Level, prompt string, errs ...string) {
+	reportRuleSection(reportRuleID(sectionTitle), sectionTitle, ok, successLevel, prompt, errs...)
+}
+
+// reportRuleSection is ReportSection for the rule ruleID of structured output
+// (see CollectReportFindings).
+func reportRuleSection(ruleID, sectionTitle string, ok bool, successLevel log.Level, prompt string, errs ...string) {
 	sl := successLevel
 	if sl != log.InfoLevel && sl != log.WarnLevel {
 		sl = log.WarnLevel
@@ -190,6 +196,12 @@ func ReportSection(sectionTitle string, ok bool, successLevel log.Level, prompt
 	if ok {
 		level = sl
 	}
+	if reportFindings != nil {
+		collectReportFindings(ruleID, sectionTitle, level, errs)
+		if !reportFindingsKeepText {
+			return
+		}
+	}
 	reportResultMessages(sectionTitle, level, prompt, errs, true)
 }
 
@@ -2,6 +2,7 @@
 package util
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -11,11 +12,10 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-func PrepareReviewData(oldCommit, oldFile, newCommit, newFile, outputFile string, noHeader, useJSON, msgidOnly bool) error {
-	var (
-		err                    error
-		relOldFile, relNewFile string
-	)
+// loadReviewFiles loads oldFile at oldCommit and newFile at newCommit (the
+// worktree when the commit is empty). A file missing in its commit loads as
+// an empty catalog. It returns the files relative to the worktree too.
+func loadReviewFiles(oldCommit, oldFile, newCommit, newFile string) (oldJ, newJ *GettextJSON, relOldFile, relNewFile string, err error) {
 	if oldCommit != "" || newCommit != "" {
 		repository.AssertRepositoryNotNil() // assert repo when using revisions
 	}
@@ -23,7 +23,7 @@ func PrepareReviewData(oldCommit, oldFile, newCommit, newFile, outputFile string
 	// Use temp files for orig and new; they are deleted when the function returns
 	oldTmpFile, err := os.CreateTemp("", "review-old-*.po")
 	if err != nil {
-		return fmt.Errorf("failed to create temp old file: %w", err)
+		return nil, nil, "", "", fmt.Errorf("failed to create temp old file: %w", err)
 	}
 	oldTmpFile.Close()
 	defer func() {
@@ -32,15 +32,15 @@ func PrepareReviewData(oldCommit, oldFile, newCommit, newFile, outputFile string
 
 	newTmpFile, err := os.CreateTemp("", "review-new-*.po")
 	if err != nil {
-		return fmt.Errorf("failed to create temp new file: %w", err)
+		return nil, nil, "", "", fmt.Errorf("failed to create temp new file: %w", err)
 	}
 	newTmpFile.Close()
 	defer func() {
 		os.Remove(newTmpFile.Name())
 	}()
 
-	log.Debugf("preparing review data: orig=%s, new=%s, review-input=%s",
-		oldTmpFile.Name(), newTmpFile.Name(), outputFile)
+	log.Debugf("preparing review data: orig=%s, new=%s",
+		oldTmpFile.Name(), newTmpFile.Name())
 
 	// Get original file (from git when revision set, else from worktree)
 	log.Infof("getting old file from commit: %s", oldCommit)
@@ -49,7 +49,7 @@ func PrepareReviewData(oldCommit, oldFile, newCommit, newFile, outputFile string
 		if filepath.IsAbs(oldFile) {
 			relOldFile, err = filepath.Rel(workDir, oldFile)
 			if err != nil {
-				return fmt.Errorf("failed to convert PO file path to relative: %w", err)
+				return nil, nil, "", "", fmt.Errorf("failed to convert PO file path to relative: %w", err)
 			}
 		} else {
 			relOldFile = oldFile
@@ -69,11 +69,11 @@ func PrepareReviewData(oldCommit, oldFile, newCommit, newFile, outputFile string
 			// If file doesn't exist in that commit, create empty file
 			log.Infof("file %s not found in commit %s, using empty file as original", relOldFile, oldCommit)
 			if err := os.WriteFile(oldFileRevision.Tmpfile, []byte{}, 0644); err != nil {
-				return fmt.Errorf("failed to create empty orig file: %w", err)
+				return nil, nil, "", "", fmt.Errorf("failed to create empty orig file: %w", err)
 			}
 		} else {
 			// For other errors, return them
-			return fmt.Errorf("failed to get original file from commit %s: %w", oldCommit, err)
+			return nil, nil, "", "", fmt.Errorf("failed to get original file from commit %s: %w", oldCommit, err)
 		}
 	}
 
@@ -83,7 +83,7 @@ func PrepareReviewData(oldCommit, oldFile, newCommit, newFile, outputFile string
 		if filepath.IsAbs(newFile) {
 			relNewFile, err = filepath.Rel(workDir, newFile)
 			if err != nil {
-				return fmt.Errorf("failed to convert PO file path to relative: %w", err)
+				return nil, nil, "", "", fmt.Errorf("failed to convert PO file path to relative: %w", err)
 			}
 		} else {
 			relNewFile = newFile
@@ -103,28 +103,37 @@ func PrepareReviewData(oldCommit, oldFile, newCommit, newFile, outputFile string
 			// If file doesn't exist in that commit, create empty file
 			log.Infof("file %s not found in commit %s, using empty file as original", relNewFile, newCommit)
 			if err := os.WriteFile(newFileRevision.Tmpfile, []byte{}, 0644); err != nil {
-				return fmt.Errorf("failed to create empty new file: %w", err)
+				return nil, nil, "", "", fmt.Errorf("failed to create empty new file: %w", err)
 			}
 		} else {
 			// For other errors, return them
-			return fmt.Errorf("failed to get new file from commit %s: %w", newCommit, err)
+			return nil, nil, "", "", fmt.Errorf("failed to get new file from commit %s: %w", newCommit, err)
 		}
 	}
 
 	origData, err := os.ReadFile(oldFileRevision.Tmpfile)
 	if err != nil {
-		return fmt.Errorf("failed to read orig file: %w", err)
+		return nil, nil, "", "", fmt.Errorf("failed to read orig file: %w", err)
 	}
 	newData, err := os.ReadFile(newFileRevision.Tmpfile)
 	if err != nil {
-		return fmt.Errorf("failed to read new file: %w", err)
+		return nil, nil, "", "", fmt.Errorf("failed to read new file: %w", err)
 	}
 
-	oldJ, err := LoadFileToGettextJSON(origData, relOldFile)
-	if err != nil {
-		return err
+	if oldJ, err = LoadFileToGettextJSON(origData, relOldFile); err != nil {
+		return nil, nil, "", "", err
+	}
+	if newJ, err = LoadFileToGettextJSON(newData, relNewFile); err != nil {
+		return nil, nil, "", "", err
 	}
-	newJ, err := LoadFileToGettextJSON(newData, relNewFile)
+	return oldJ, newJ, relOldFile, relNewFile, nil
+}
+
+// PrepareReviewData writes the new or changed entries of newFile at newCommit
+// compared to oldFile at oldCommit to outputFile, as PO or as JSON (useJSON).
+// Nothing is written when there are no such entries.
+func PrepareReviewData(oldCommit, oldFile, newCommit, newFile, outputFile string, noHeader, useJSON, msgidOnly bool) error {
+	oldJ, newJ, _, _, err := loadReviewFiles(oldCommit, oldFile, newCommit, newFile)
 	if err != nil {
 		return err
 	}
@@ -158,6 +167,21 @@ func PrepareReviewData(oldCommit, oldFile, newCommit, newFile, outputFile string
 	return WriteFile(outputFile, poData)
 }
 
+// PrepareReviewDiff is like PrepareReviewData, but writes the differences of
+// the new or changed entries in format (see WriteCompareDiff).
+func PrepareReviewDiff(oldCommit, oldFile, newCommit, newFile, outputFile, format string, msgidOnly, color bool) error {
+	oldJ, newJ, relOldFile, relNewFile, err := loadReviewFiles(oldCommit, oldFile, newCommit, newFile)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	diffs := CompareEntryDiffs(oldJ, newJ, msgidOnly)
+	if err := WriteCompareDiff(&buf, diffs, format, relOldFile, relNewFile, color); err != nil {
+		return err
+	}
+	return WriteFile(outputFile, buf.Bytes())
+}
+
 func writeGettextJSONToPath(outputFile string, j *GettextJSON) error {
 	if outputFile == "-" || outputFile == "" {
 		return WriteGettextJSONToJSON(j, os.Stdout)
@@ -0,0 +1,357 @@
+// Package util provides translation progress history across git revisions.
+package util
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/git-l10n/git-po-helper/repository"
+	log "github.com/sirupsen/logrus"
+)
+
+// StatHistoryPoint is the statistics of all PO files at one commit.
+type StatHistoryPoint struct {
+	Commit  string // full commit ID
+	Date    string // committer date, strict ISO 8601
+	Tags    []string
+	Reports []*StatReport
+}
+
+// StatHistorySeries is the statistics of one language over the commits of
+// the history, in chronological order.
+type StatHistorySeries struct {
+	Locale   string
+	Language string
+	Points   []StatHistorySample
+}
+
+// StatHistorySample is the statistics of a language at one commit.
+type StatHistorySample struct {
+	Commit string
+	Date   string
+	Tags   []string
+	Report *StatReport
+}
+
+// gitOutput runs git in the worktree and returns its output.
+func gitOutput(args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repository.WorkDir()
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s failed: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// tagsByCommit returns the names of the tags of each commit.
+func tagsByCommit() (map[string][]string, error) {
+	out, err := gitOutput("for-each-ref",
+		"--format=%(objectname) %(*objectname) %(refname:short)", "refs/tags")
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[string][]string)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		switch len(fields) {
+		case 2: // lightweight tag
+			tags[fields[0]] = append(tags[fields[0]], fields[1])
+		case 3: // annotated tag, use the peeled commit
+			tags[fields[1]] = append(tags[fields[1]], fields[2])
+		}
+	}
+	return tags, scanner.Err()
+}
+
+// historyCommits returns the commits of revRange in chronological order with
+// their committer dates. With tagsOnly, only tagged commits are returned,
+// otherwise the first-parent commits that changed the po/ directory.
+func historyCommits(revRange string, tagsOnly bool, tags map[string][]string) ([][2]string, error) {
+	args := []string{"log", "--reverse", "--format=%H %cI"}
+	if tagsOnly {
+		args = append(args, "--date-order", revRange)
+	} else {
+		args = append(args, "--first-parent", revRange, "--", PoDir)
+	}
+	out, err := gitOutput(args...)
+	if err != nil {
+		return nil, err
+	}
+	var commits [][2]string
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		if tagsOnly && len(tags[fields[0]]) == 0 {
+			continue
+		}
+		commits = append(commits, [2]string{fields[0], fields[1]})
+	}
+	return commits, scanner.Err()
+}
+
+// poBlobs returns the blob IDs of the files in the po/ directory of commit,
+// by file name.
+func poBlobs(commit string) (map[string]string, error) {
+	out, err := gitOutput("ls-tree", commit, PoDir+"/")
+	if err != nil {
+		return nil, err
+	}
+	blobs := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		// <mode> SP <type> SP <object> TAB <file>
+		line := scanner.Text()
+		tab := strings.IndexByte(line, '\t')
+		if tab < 0 {
+			continue
+		}
+		fields := strings.Fields(line[:tab])
+		if len(fields) == 3 && fields[1] == "blob" {
+			blobs[line[tab+1:]] = fields[2]
+		}
+	}
+	return blobs, scanner.Err()
+}
+
+// statHistoryReader reads files of commits with CheckoutTmpfile, and caches
+// the results by blob ID, since most files do not change between commits.
+type statHistoryReader struct {
+	potKeys map[string]map[string]bool // by blob ID of the POT
+	reports map[string]*StatReport     // by blob ID of the PO and of the POT
+}
+
+// readRevision checks out file at commit to a temporary file and calls f
+// with its name.
+func readRevision(commit, file string, f func(tmpfile string) error) error {
+	rev := FileRevision{Revision: commit, File: file}
+	defer func() {
+		if rev.Tmpfile != "" {
+			os.Remove(rev.Tmpfile)
+		}
+	}()
+	if err := CheckoutTmpfile(&rev); err != nil {
+		return err
+	}
+	return f(rev.Tmpfile)
+}
+
+func (h *statHistoryReader) loadPotKeys(commit, file, blob string) (map[string]bool, error) {
+	if keys, ok := h.potKeys[blob]; ok {
+		return keys, nil
+	}
+	var keys map[string]bool
+	err := readRevision(commit, file, func(tmpfile string) (err error) {
+		keys, err = LoadPotKeys(tmpfile)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	h.potKeys[blob] = keys
+	return keys, nil
+}
+
+func (h *statHistoryReader) report(commit, file, blob, potBlob string, potKeys map[string]bool) (*StatReport, error) {
+	key := blob + " " + potBlob
+	if r, ok := h.reports[key]; ok {
+		return r, nil
+	}
+	var r *StatReport
+	err := readRevision(commit, file, func(tmpfile string) (err error) {
+		r, err = getStatReport(tmpfile, file, potKeys)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	h.reports[key] = r
+	return r, nil
+}
+
+// GetStatHistory returns the statistics of the PO files of each commit of
+// revRange, read from git without checking out the commits. Commits are
+// the first-parent commits that changed the po/ directory, or with tagsOnly
+// the tagged commits, e.g. the releases. When po/git.pot is in a commit, the
+// statistics are against it (see StatReport).
+func GetStatHistory(revRange string, tagsOnly bool) ([]StatHistoryPoint, error) {
+	if err := repository.RequireOpened(); err != nil {
+		return nil, fmt.Errorf("stat --history requires a repository: %w", err)
+	}
+	tags, err := tagsByCommit()
+	if err != nil {
+		return nil, err
+	}
+	commits, err := historyCommits(revRange, tagsOnly, tags)
+	if err != nil {
+		return nil, err
+	}
+
+	h := statHistoryReader{
+		potKeys: make(map[string]map[string]bool),
+		reports: make(map[string]*StatReport),
+	}
+	potFile := path.Join(PoDir, GitPot)
+	var points []StatHistoryPoint
+	for _, c := range commits {
+		commit := c[0]
+		log.Debugf("stat history: reading %s at %s", PoDir, AbbrevCommit(commit))
+		blobs, err := poBlobs(commit)
+		if err != nil {
+			return nil, err
+		}
+		var potKeys map[string]bool
+		potBlob := blobs[potFile]
+		if potBlob != "" {
+			if potKeys, err = h.loadPotKeys(commit, potFile, potBlob); err != nil {
+				return nil, err
+			}
+		}
+		var files []string
+		for file := range blobs {
+			if path.Ext(file) == ".po" {
+				files = append(files, file)
+			}
+		}
+		sort.Strings(files)
+		point := StatHistoryPoint{Commit: commit, Date: c[1], Tags: tags[commit]}
+		for _, file := range files {
+			r, err := h.report(commit, file, blobs[file], potBlob, potKeys)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%s: %w", AbbrevCommit(commit), file, err)
+			}
+			point.Reports = append(point.Reports, r)
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+// StatHistoryByLanguage regroups points into one series per language,
+// sorted by locale.
+func StatHistoryByLanguage(points []StatHistoryPoint) []StatHistorySeries {
+	index := make(map[string]int)
+	var series []StatHistorySeries
+	for _, p := range points {
+		for _, r := range p.Reports {
+			i, ok := index[r.Locale]
+			if !ok {
+				i = len(series)
+				index[r.Locale] = i
+				series = append(series, StatHistorySeries{Locale: r.Locale, Language: r.Language})
+			}
+			series[i].Points = append(series[i].Points, StatHistorySample{
+				Commit: p.Commit,
+				Date:   p.Date,
+				Tags:   p.Tags,
+				Report: r,
+			})
+		}
+	}
+	sort.SliceStable(series, func(i, j int) bool { return series[i].Locale < series[j].Locale })
+	return series
+}
+
+// statHistorySampleJSON is the JSON form of StatHistorySample.
+type statHistorySampleJSON struct {
+	Commit            string   `json:"commit"`
+	Date              string   `json:"date"`
+	Tags              []string `json:"tags,omitempty"`
+	Translated        int      `json:"translated"`
+	Fuzzy             int      `json:"fuzzy"`
+	Untranslated      int      `json:"untranslated"`
+	Total             int      `json:"total"`
+	TranslatedPercent float64  `json:"translated_percent"`
+}
+
+// statHistorySeriesJSON is the JSON form of StatHistorySeries.
+type statHistorySeriesJSON struct {
+	Locale   string                  `json:"locale"`
+	Language string                  `json:"language,omitempty"`
+	History  []statHistorySampleJSON `json:"history"`
+}
+
+// WriteStatHistory writes the statistics of points as one time series per
+// language, in format: StatFormatText, StatFormatJSON or StatFormatCSV.
+func WriteStatHistory(w io.Writer, points []StatHistoryPoint, format string) error {
+	series := StatHistoryByLanguage(points)
+	switch format {
+	case StatFormatText, "":
+		for i, s := range series {
+			if i > 0 {
+				if _, err := fmt.Fprintln(w); err != nil {
+					return err
+				}
+			}
+			title := s.Locale
+			if s.Language != "" {
+				title = fmt.Sprintf("%s (%s)", s.Language, s.Locale)
+			}
+			if _, err := fmt.Fprintln(w, title+":"); err != nil {
+				return err
+			}
+			for _, p := range s.Points {
+				rev := AbbrevCommit(p.Commit)
+				if len(p.Tags) > 0 {
+					rev = strings.Join(p.Tags, ",")
+				}
+				if _, err := fmt.Fprintf(w, "  %s %s %5s%%  %s",
+					p.Date[:10], rev, formatStatPercent(p.Report.TranslatedPercent()),
+					FormatStatLine(&p.Report.PoStats)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	case StatFormatJSON:
+		out := make([]statHistorySeriesJSON, 0, len(series))
+		for _, s := range series {
+			js := statHistorySeriesJSON{Locale: s.Locale, Language: s.Language}
+			for _, p := range s.Points {
+				js.History = append(js.History, statHistorySampleJSON{
+					Commit:            p.Commit,
+					Date:              p.Date,
+					Tags:              p.Tags,
+					Translated:        p.Report.Translated,
+					Fuzzy:             p.Report.Fuzzy,
+					Untranslated:      p.Report.Untranslated,
+					Total:             p.Report.Total,
+					TranslatedPercent: p.Report.TranslatedPercent(),
+				})
+			}
+			out = append(out, js)
+		}
+		return newGettextJSONEncoder(w, "").Encode(out)
+	case StatFormatCSV:
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"locale", "language", "commit", "date", "tags",
+			"translated", "fuzzy", "untranslated", "total", "translated_percent"})
+		for _, s := range series {
+			for _, p := range s.Points {
+				_ = cw.Write([]string{s.Locale, s.Language, p.Commit, p.Date,
+					strings.Join(p.Tags, " "),
+					strconv.Itoa(p.Report.Translated), strconv.Itoa(p.Report.Fuzzy),
+					strconv.Itoa(p.Report.Untranslated), strconv.Itoa(p.Report.Total),
+					formatStatPercent(p.Report.TranslatedPercent())})
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	return fmt.Errorf("stat history cannot be written as %s", format)
+}
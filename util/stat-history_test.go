@@ -0,0 +1,133 @@
+package util
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/git-l10n/git-po-helper/repository"
+)
+
+func TestGetStatHistory(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.Unsetenv("GIT_DIR")
+	os.Unsetenv("GIT_WORK_TREE")
+	os.Unsetenv("GIT_INDEX_FILE")
+	os.Unsetenv("GIT_COMMON_DIR")
+	origWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd: %v", err)
+	}
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatalf("Chdir %s: %v", tmpDir, err)
+	}
+	defer func() {
+		_ = os.Chdir(origWd)
+		repository.OpenRepository(origWd)
+	}()
+
+	gitEnv := append(gitTestEnv(),
+		"GIT_AUTHOR_DATE=2024-01-01T00:00:00Z", "GIT_COMMITTER_DATE=2024-01-01T00:00:00Z")
+	runGit := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmpDir
+		cmd.Env = gitEnv
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s failed: %v\n%s", strings.Join(args, " "), err, string(output))
+		}
+	}
+	writePo := func(name string, entries ...string) {
+		content := "msgid \"\"\nmsgstr \"\"\n\"Content-Type: text/plain; charset=UTF-8\\n\"\n"
+		for i := 0; i+1 < len(entries); i += 2 {
+			content += "\nmsgid \"" + entries[i] + "\"\nmsgstr \"" + entries[i+1] + "\"\n"
+		}
+		if err := os.WriteFile(filepath.Join(tmpDir, "po", name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runGit("init")
+	runGit("config", "user.email", "test@test.com")
+	runGit("config", "user.name", "Test")
+	if err := os.MkdirAll(filepath.Join(tmpDir, "po"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writePo("git.pot", "a", "", "b", "")
+	writePo("zh_CN.po", "a", "A", "b", "")
+	runGit("add", "po/")
+	runGit("commit", "--no-verify", "-m", "initial")
+	runGit("tag", "v1")
+
+	writePo("zh_CN.po", "a", "A", "b", "B")
+	writePo("fr.po", "a", "A")
+	runGit("add", "po/")
+	runGit("commit", "--no-verify", "-m", "update")
+	if err := os.WriteFile(filepath.Join(tmpDir, "README"), []byte("x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit("add", "README")
+	runGit("commit", "--no-verify", "-m", "no po changes")
+	runGit("tag", "-a", "-m", "v2", "v2")
+	// The worktree is not used.
+	writePo("zh_CN.po")
+
+	repository.OpenRepository(tmpDir)
+
+	points, err := GetStatHistory("HEAD", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 2 {
+		t.Fatalf("got %d commits, want the 2 that changed po/", len(points))
+	}
+	if !equalStrings(points[0].Tags, []string{"v1"}) || len(points[1].Tags) != 0 {
+		t.Errorf("tags: %q, %q", points[0].Tags, points[1].Tags)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteStatHistory(&buf, points, StatFormatCSV); err != nil {
+		t.Fatal(err)
+	}
+	c1, c2 := points[0].Commit, points[1].Commit
+	want := `locale,language,commit,date,tags,translated,fuzzy,untranslated,total,translated_percent
+fr,French,` + c2 + `,2024-01-01T00:00:00+00:00,,1,0,1,2,50.0
+zh_CN,Chinese - China,` + c1 + `,2024-01-01T00:00:00+00:00,v1,1,0,1,2,50.0
+zh_CN,Chinese - China,` + c2 + `,2024-01-01T00:00:00+00:00,,2,0,0,2,100.0
+`
+	if got := buf.String(); got != want {
+		t.Errorf("csv:\n%s\nwant:\n%s", got, want)
+	}
+
+	points, err = GetStatHistory("HEAD", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 2 || !equalStrings(points[0].Tags, []string{"v1"}) || !equalStrings(points[1].Tags, []string{"v2"}) {
+		t.Fatalf("tagged commits: %+v", points)
+	}
+	if n := len(points[1].Reports); n != 2 {
+		t.Errorf("v2: got %d languages, want 2", n)
+	}
+
+	buf.Reset()
+	if err := WriteStatHistory(&buf, points, StatFormatText); err != nil {
+		t.Fatal(err)
+	}
+	want = `French (fr):
+  2024-01-01 v2  50.0%  1 translated message, 1 untranslated message.
+
+Chinese - China (zh_CN):
+  2024-01-01 v1  50.0%  1 translated message, 1 untranslated message.
+  2024-01-01 v2 100.0%  2 translated messages.
+`
+	if got := buf.String(); got != want {
+		t.Errorf("text:\n%s\nwant:\n%s", got, want)
+	}
+
+	if _, err := GetStatHistory("no-such-rev", false); err == nil {
+		t.Error("expected an error for a bad revision")
+	}
+}
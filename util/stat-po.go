@@ -2,7 +2,9 @@
 package util
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 )
@@ -27,55 +29,107 @@ func getPoStatsFromGettextJSON(j *GettextJSON) *PoStats {
 	if j == nil {
 		return stats
 	}
-	for _, e := range j.Entries {
-		if e.MsgID == "" {
-			continue
-		}
-		if e.Obsolete {
-			stats.Obsolete++
-			continue
-		}
-		hasTranslation := false
-		msgstrValue := ""
-		for _, s := range e.MsgStr {
-			if s != "" {
-				hasTranslation = true
-				break
-			}
-		}
-		if len(e.MsgStr) > 0 {
-			msgstrValue = e.MsgStr[0]
-		}
-		if e.Fuzzy {
-			stats.Fuzzy++
-			continue
-		}
-		if !hasTranslation {
-			stats.Untranslated++
-			continue
-		}
-		if msgstrValue == e.MsgID {
-			stats.Same++
-			stats.Translated++
-			continue
-		}
-		stats.Translated++
+	for i := range j.Entries {
+		stats.add(&j.Entries[i])
 	}
 	return stats
 }
 
-// GetPoStats reads a PO or gettext JSON file and returns entry statistics.
-// Uses LoadFileToGettextJSON for unified loading (same interface as msg-select, msg-cat, compare).
+// add counts one entry; entries with empty msgid (header) are ignored.
+func (s *PoStats) add(e *GettextEntry) {
+	if e.MsgID == "" {
+		return
+	}
+	if e.Obsolete {
+		s.Obsolete++
+		return
+	}
+	hasTranslation := false
+	msgstrValue := ""
+	for _, str := range e.MsgStr {
+		if str != "" {
+			hasTranslation = true
+			break
+		}
+	}
+	if len(e.MsgStr) > 0 {
+		msgstrValue = e.MsgStr[0]
+	}
+	if e.Fuzzy {
+		s.Fuzzy++
+		return
+	}
+	if !hasTranslation {
+		s.Untranslated++
+		return
+	}
+	if msgstrValue == e.MsgID {
+		s.Same++
+		s.Translated++
+		return
+	}
+	s.Translated++
+}
+
+// GetPoStats returns statistics for a PO/POT file, a gettext JSON file or a .mo file.
+// PO files are streamed entry by entry, so large compendia are counted in constant memory.
 func GetPoStats(file string) (*PoStats, error) {
-	data, err := os.ReadFile(file)
+	f, err := os.Open(file)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read %s: %w", file, err)
 	}
-	j, err := LoadFileToGettextJSON(data, file)
-	if err != nil {
-		return nil, err
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	magic, _ := r.Peek(4)
+	if isJSON, err := peekGettextJSON(r); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file, err)
+	} else if isJSON || IsMoData(magic) {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		j, err := LoadFileToGettextJSON(data, file)
+		if err != nil {
+			return nil, err
+		}
+		return getPoStatsFromGettextJSON(j), nil
+	}
+
+	stats := &PoStats{}
+	pr := NewPoReader(r)
+	for {
+		e, err := pr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse PO %s: %w", file, err)
+		}
+		stats.add(e)
+	}
+	return stats, nil
+}
+
+// peekGettextJSON reports whether r starts with gettext JSON (see IsGettextJSONData)
+// without consuming input.
+func peekGettextJSON(r *bufio.Reader) (bool, error) {
+	for n := 1; ; n++ {
+		buf, err := r.Peek(n)
+		if len(buf) == n {
+			switch buf[n-1] {
+			case ' ', '\t', '\r', '\n':
+				continue
+			case '{':
+				return true, nil
+			}
+			return false, nil
+		}
+		if err == io.EOF || err == bufio.ErrBufferFull {
+			return false, nil
+		}
+		return false, err
 	}
-	return getPoStatsFromGettextJSON(j), nil
 }
 
 // FormatMsgfmtStatistics formats stats to match msgfmt --statistics output.
@@ -0,0 +1,244 @@
+// Package util provides machine-readable statistics reports.
+package util
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Output formats of stat.
+const (
+	StatFormatText     = "text"
+	StatFormatJSON     = "json"
+	StatFormatCSV      = "csv"
+	StatFormatMarkdown = "markdown"
+)
+
+// StatReport is the statistics of one file for the stat reports. When a POT
+// file is given, the counts are limited to the messages of the POT: messages
+// missing in the file count as untranslated, and Total is the number of
+// messages in the POT. Otherwise Total is PoStats.Total of the file.
+type StatReport struct {
+	File     string
+	Locale   string
+	Language string
+	PoStats
+	Total int
+}
+
+// statPercent returns n of total in percent, rounded to one decimal. Only
+// n == total is 100% and only n == 0 is 0%.
+func statPercent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	p := math.Round(float64(n)*1000/float64(total)) / 10
+	if p == 100 && n < total {
+		p = 99.9
+	} else if p == 0 && n > 0 {
+		p = 0.1
+	}
+	return p
+}
+
+// TranslatedPercent returns the translated messages in percent of Total.
+func (r *StatReport) TranslatedPercent() float64 {
+	return statPercent(r.Translated, r.Total)
+}
+
+// FuzzyPercent returns the fuzzy messages in percent of Total.
+func (r *StatReport) FuzzyPercent() float64 {
+	return statPercent(r.Fuzzy, r.Total)
+}
+
+// UntranslatedPercent returns the untranslated messages in percent of Total.
+func (r *StatReport) UntranslatedPercent() float64 {
+	return statPercent(r.Untranslated, r.Total)
+}
+
+// LoadPotKeys returns the keys (msgctxt, msgid and msgid_plural) of the
+// messages of potFile.
+func LoadPotKeys(potFile string) (map[string]bool, error) {
+	potJ, err := ReadFileToGettextJSON(potFile)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]bool)
+	for _, e := range filterObsolete(potJ.Entries) {
+		if e.MsgID != "" {
+			keys[entryKey(e)] = true
+		}
+	}
+	return keys, nil
+}
+
+// GetStatReport returns the statistics of file. When potKeys is not nil,
+// they are computed against the messages of the POT (see StatReport).
+func GetStatReport(file string, potKeys map[string]bool) (*StatReport, error) {
+	return getStatReport(file, file, potKeys)
+}
+
+// getStatReport is GetStatReport for the content of file, reported as name.
+func getStatReport(file, name string, potKeys map[string]bool) (*StatReport, error) {
+	locale := strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	r := &StatReport{
+		File:     name,
+		Locale:   locale,
+		Language: FormatLocaleName(locale),
+	}
+	if potKeys == nil {
+		stats, err := GetPoStats(file)
+		if err != nil {
+			return nil, err
+		}
+		r.PoStats = *stats
+		r.Total = stats.Total()
+		return r, nil
+	}
+
+	j, err := ReadFileToGettextJSON(file)
+	if err != nil {
+		return nil, err
+	}
+	for i := range j.Entries {
+		e := &j.Entries[i]
+		if !e.Obsolete && !potKeys[entryKey(*e)] {
+			continue
+		}
+		r.add(e)
+	}
+	r.Total = len(potKeys)
+	r.Untranslated = r.Total - r.Translated - r.Fuzzy
+	return r, nil
+}
+
+// ListPoFiles returns the .po files in dir, sorted by name.
+func ListPoFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && filepath.Ext(e.Name()) == ".po" {
+			files = append(files, filepath.Join(dir, e.Name()))
+		}
+	}
+	return files, nil
+}
+
+// SortStatReports sorts reports by completion: translated percent first,
+// then fewer fuzzy messages, then locale.
+func SortStatReports(reports []*StatReport) {
+	sort.SliceStable(reports, func(i, j int) bool {
+		a, b := reports[i], reports[j]
+		if pa, pb := a.TranslatedPercent(), b.TranslatedPercent(); pa != pb {
+			return pa > pb
+		}
+		if a.Translated != b.Translated {
+			return a.Translated > b.Translated
+		}
+		if a.Fuzzy != b.Fuzzy {
+			return a.Fuzzy < b.Fuzzy
+		}
+		return a.Locale < b.Locale
+	})
+}
+
+// statReportJSON is the JSON form of StatReport.
+type statReportJSON struct {
+	File                string  `json:"file"`
+	Locale              string  `json:"locale"`
+	Language            string  `json:"language,omitempty"`
+	Translated          int     `json:"translated"`
+	Fuzzy               int     `json:"fuzzy"`
+	Untranslated        int     `json:"untranslated"`
+	Same                int     `json:"same"`
+	Obsolete            int     `json:"obsolete"`
+	Total               int     `json:"total"`
+	TranslatedPercent   float64 `json:"translated_percent"`
+	FuzzyPercent        float64 `json:"fuzzy_percent"`
+	UntranslatedPercent float64 `json:"untranslated_percent"`
+}
+
+// WriteStatReports writes reports in format: one of StatFormatText,
+// StatFormatJSON, StatFormatCSV and StatFormatMarkdown.
+func WriteStatReports(w io.Writer, reports []*StatReport, format string) error {
+	switch format {
+	case StatFormatText, "":
+		for _, r := range reports {
+			if _, err := fmt.Fprintf(w, "%s: %s", r.File, FormatStatLine(&r.PoStats)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case StatFormatJSON:
+		out := make([]statReportJSON, 0, len(reports))
+		for _, r := range reports {
+			out = append(out, statReportJSON{
+				File:                r.File,
+				Locale:              r.Locale,
+				Language:            r.Language,
+				Translated:          r.Translated,
+				Fuzzy:               r.Fuzzy,
+				Untranslated:        r.Untranslated,
+				Same:                r.Same,
+				Obsolete:            r.Obsolete,
+				Total:               r.Total,
+				TranslatedPercent:   r.TranslatedPercent(),
+				FuzzyPercent:        r.FuzzyPercent(),
+				UntranslatedPercent: r.UntranslatedPercent(),
+			})
+		}
+		return newGettextJSONEncoder(w, "").Encode(out)
+	case StatFormatCSV:
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"file", "locale", "language", "translated", "fuzzy",
+			"untranslated", "same", "obsolete", "total",
+			"translated_percent", "fuzzy_percent", "untranslated_percent"})
+		for _, r := range reports {
+			_ = cw.Write([]string{r.File, r.Locale, r.Language,
+				strconv.Itoa(r.Translated), strconv.Itoa(r.Fuzzy),
+				strconv.Itoa(r.Untranslated), strconv.Itoa(r.Same),
+				strconv.Itoa(r.Obsolete), strconv.Itoa(r.Total),
+				formatStatPercent(r.TranslatedPercent()),
+				formatStatPercent(r.FuzzyPercent()),
+				formatStatPercent(r.UntranslatedPercent())})
+		}
+		cw.Flush()
+		return cw.Error()
+	case StatFormatMarkdown:
+		var b strings.Builder
+		b.WriteString("| Language | File | Translated | Fuzzy | Untranslated | Completion |\n")
+		b.WriteString("|----------|------|-----------:|------:|-------------:|-----------:|\n")
+		for _, r := range reports {
+			lang := r.Locale
+			if r.Language != "" {
+				lang = fmt.Sprintf("%s (%s)", r.Language, r.Locale)
+			}
+			fmt.Fprintf(&b, "| %s | %s | %d | %d | %d | %s%% |\n",
+				markdownCell(lang), markdownCell(r.File),
+				r.Translated, r.Fuzzy, r.Untranslated,
+				formatStatPercent(r.TranslatedPercent()))
+		}
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	return fmt.Errorf("unknown stat format %q", format)
+}
+
+func formatStatPercent(p float64) string {
+	return strconv.FormatFloat(p, 'f', 1, 64)
+}
+
+// markdownCell escapes the characters of s that break a Markdown table cell.
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
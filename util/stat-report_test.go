@@ -0,0 +1,175 @@
+package util
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStatReports(t *testing.T) {
+	dir := t.TempDir()
+	pot := `msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+
+msgid "a"
+msgstr ""
+
+msgid "b"
+msgstr ""
+
+msgid "c"
+msgstr ""
+
+msgid "d"
+msgstr ""
+`
+	zhCN := `msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+
+msgid "a"
+msgstr "A"
+
+msgid "b"
+msgstr "B"
+
+#, fuzzy
+msgid "c"
+msgstr "C"
+
+msgid "z"
+msgstr "Z"
+
+#~ msgid "old"
+#~ msgstr "OLD"
+`
+	fr := `msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+
+msgid "a"
+msgstr "A"
+
+msgid "b"
+msgstr "B"
+
+msgid "c"
+msgstr "C"
+
+msgid "d"
+msgstr "d"
+`
+	for name, content := range map[string]string{"git.pot": pot, "zh_CN.po": zhCN, "fr.po": fr} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files, err := ListPoFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || filepath.Base(files[0]) != "fr.po" || filepath.Base(files[1]) != "zh_CN.po" {
+		t.Fatalf("ListPoFiles: %q", files)
+	}
+
+	// Without POT: counts of the file itself.
+	r, err := GetStatReport(files[1], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Translated != 3 || r.Fuzzy != 1 || r.Untranslated != 0 || r.Obsolete != 1 || r.Total != 4 {
+		t.Errorf("zh_CN without POT: %+v", r)
+	}
+	if r.TranslatedPercent() != 75 {
+		t.Errorf("zh_CN without POT: translated %v%%, want 75%%", r.TranslatedPercent())
+	}
+
+	potKeys, err := LoadPotKeys(filepath.Join(dir, "git.pot"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reports []*StatReport
+	for _, f := range []string{files[1], files[0]} {
+		r, err := GetStatReport(f, potKeys)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.File = filepath.Join("po", filepath.Base(f))
+		reports = append(reports, r)
+	}
+	SortStatReports(reports)
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{StatFormatText, `po/fr.po: 4 translated messages, 1 same message.
+po/zh_CN.po: 2 translated messages, 1 fuzzy translation, 1 untranslated message, 1 obsolete entry.
+`},
+		{StatFormatCSV, `file,locale,language,translated,fuzzy,untranslated,same,obsolete,total,translated_percent,fuzzy_percent,untranslated_percent
+po/fr.po,fr,French,4,0,0,1,0,4,100.0,0.0,0.0
+po/zh_CN.po,zh_CN,Chinese - China,2,1,1,0,1,4,50.0,25.0,25.0
+`},
+		{StatFormatMarkdown, `| Language | File | Translated | Fuzzy | Untranslated | Completion |
+|----------|------|-----------:|------:|-------------:|-----------:|
+| French (fr) | po/fr.po | 4 | 0 | 0 | 100.0% |
+| Chinese - China (zh_CN) | po/zh_CN.po | 2 | 1 | 1 | 50.0% |
+`},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := WriteStatReports(&buf, reports, tt.format); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%s:\n%s\nwant:\n%s", tt.format, got, tt.want)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteStatReports(&buf, reports[1:], StatFormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	want := `[
+  {
+    "file": "po/zh_CN.po",
+    "locale": "zh_CN",
+    "language": "Chinese - China",
+    "translated": 2,
+    "fuzzy": 1,
+    "untranslated": 1,
+    "same": 0,
+    "obsolete": 1,
+    "total": 4,
+    "translated_percent": 50,
+    "fuzzy_percent": 25,
+    "untranslated_percent": 25
+  }
+]
+`
+	if got := buf.String(); got != want {
+		t.Errorf("json:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestStatPercent(t *testing.T) {
+	tests := []struct {
+		n, total int
+		want     float64
+	}{
+		{0, 0, 0},
+		{1, 3, 33.3},
+		{2, 3, 66.7},
+		{5103, 5104, 99.9},
+		{5104, 5104, 100},
+		{1, 5104, 0.1},
+		{5099, 5104, 99.9},
+	}
+	for _, tt := range tests {
+		if got := statPercent(tt.n, tt.total); got != tt.want {
+			t.Errorf("statPercent(%d, %d) = %v, want %v", tt.n, tt.total, got, tt.want)
+		}
+	}
+}
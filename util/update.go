@@ -1,6 +1,7 @@
 package util
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -46,6 +47,7 @@ func CmdUpdate(fileName string) bool {
 		ok              bool
 		optNoLineNumber = viper.GetBool("no-line-number")
 		optNoLocation   = viper.GetBool("no-location")
+		optNativeMerge  = viper.GetBool("update--native-merge")
 	)
 
 	locale = strings.TrimSuffix(filepath.Base(fileName), ".po")
@@ -104,23 +106,42 @@ func CmdUpdate(fileName string) bool {
 		return false
 	}
 
-	cmdArgs = []string{"msgmerge"}
-	if optNoLocation {
-		cmdArgs = append(cmdArgs, "--no-location")
-	} else if optNoLineNumber {
-		cmdArgs = append(cmdArgs, "--add-location=file")
-	}
-	cmdArgs = append(cmdArgs,
-		"-o", tmpFile,
-		poFile,
-		poTemplate,
-	)
-	log.Infof(`run msgmerge for "%s": %s`, localeFullName, strings.Join(cmdArgs, " "))
-	cmd = exec.Command(cmdArgs[0], cmdArgs[1:]...)
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		log.Errorf(`msgmerge failed for "%s": %s`, poFile, err)
-		return false
+	if _, err := exec.LookPath("msgmerge"); err != nil && !optNativeMerge {
+		log.Debugf("msgmerge not found, update %s with built-in merge", poFile)
+		optNativeMerge = true
+	}
+	if optNativeMerge {
+		log.Infof(`run built-in msgmerge for "%s": %s + %s`, localeFullName, poFile, poTemplate)
+		result, err := msgMergeToFile(poFile, poTemplate, tmpFile, MsgMergeOptions{
+			NoLocation:   optNoLocation,
+			NoLineNumber: optNoLineNumber,
+		})
+		if err != nil {
+			log.Errorf(`msgmerge failed for "%s": %s`, poFile, err)
+			return false
+		}
+		for _, line := range result.Summary() {
+			log.Info(line)
+		}
+	} else {
+		cmdArgs = []string{"msgmerge"}
+		if optNoLocation {
+			cmdArgs = append(cmdArgs, "--no-location")
+		} else if optNoLineNumber {
+			cmdArgs = append(cmdArgs, "--add-location=file")
+		}
+		cmdArgs = append(cmdArgs,
+			"-o", tmpFile,
+			poFile,
+			poTemplate,
+		)
+		log.Infof(`run msgmerge for "%s": %s`, localeFullName, strings.Join(cmdArgs, " "))
+		cmd = exec.Command(cmdArgs[0], cmdArgs[1:]...)
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			log.Errorf(`msgmerge failed for "%s": %s`, poFile, err)
+			return false
+		}
 	}
 
 	if err := os.Rename(tmpFile, poFile); err != nil {
@@ -138,3 +159,26 @@ func CmdUpdate(fileName string) bool {
 	viper.Set("check--allow-obsolete", true)
 	return CheckPoFile(locale, poFile, true)
 }
+
+// msgMergeToFile merges poFile with the template potFile like msgmerge and
+// writes the result to outFile, which may be poFile. Lines are wrapped at 79
+// columns, the default page width of msgmerge.
+func msgMergeToFile(poFile, potFile, outFile string, opts MsgMergeOptions) (*MsgMergeResult, error) {
+	j, result, err := MsgMergeFile(poFile, potFile, opts)
+	if err != nil {
+		return nil, err
+	}
+	prevWidth := viper.GetInt("msg-merge--width")
+	defer viper.Set("msg-merge--width", prevWidth)
+	viper.Set("msg-merge--width", 79)
+
+	f, err := os.Create(outFile)
+	if err != nil {
+		return nil, fmt.Errorf("fail to create %s: %w", outFile, err)
+	}
+	defer f.Close()
+	if err := WriteGettextJSONToPO(j, f, false, false); err != nil {
+		return nil, err
+	}
+	return result, f.Close()
+}
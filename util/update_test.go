@@ -80,3 +80,28 @@ func assertZHpoStillTranslated(t *testing.T, path string) {
 		t.Errorf("expected Git Project-Id-Version in %s", path)
 	}
 }
+
+func TestCmdUpdate_nativeMerge(t *testing.T) {
+	cmdUpdateTestMu.Lock()
+	defer cmdUpdateTestMu.Unlock()
+
+	root := t.TempDir()
+	utiltest.MaterializeCmdUpdateTree(t, root)
+	utiltest.SetGitCeilingDirectories(t, root)
+
+	potPath := filepath.Join(root, "po", "git.pot")
+	defer func() {
+		viper.Set("pot-file", "auto")
+		viper.Set("check--report-typos", "")
+		viper.Set("update--native-merge", false)
+	}()
+	viper.Set("pot-file", potPath)
+	viper.Set("check--report-typos", "none")
+	viper.Set("update--native-merge", true)
+
+	utiltest.Chdir(t, root)
+	if !CmdUpdate("po/zh_CN.po") {
+		t.Fatal("CmdUpdate(po/zh_CN.po) with native merge failed")
+	}
+	assertZHpoStillTranslated(t, filepath.Join(root, "po", "zh_CN.po"))
+}
@@ -0,0 +1,654 @@
+// Package util provides XLIFF 1.2 and 2.0 import and export of gettext entries.
+package util
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Supported XLIFF versions for WriteGettextJSONToXLIFF.
+const (
+	XLIFFVersion12 = "1.2"
+	XLIFFVersion20 = "2.0"
+)
+
+const (
+	xliff12Namespace = "urn:oasis:names:tc:xliff:document:1.2"
+	xliff20Namespace = "urn:oasis:names:tc:xliff:document:2.0"
+
+	// xliff12PluralsRestype marks a <group> holding the forms of a plural entry,
+	// as written by Okapi and the Translate Toolkit (po2xliff).
+	xliff12PluralsRestype = "x-gettext-plurals"
+	// xliff20PluralsType is the XLIFF 2.0 counterpart of xliff12PluralsRestype.
+	xliff20PluralsType = "po:plurals"
+)
+
+// XLIFF note categories ("from" in 1.2, "category" in 2.0) for the PO header and
+// for comment lines. Each comment line is one note, so that the original order
+// of comments is kept. Notes with other categories, added in CAT tools, are
+// imported as translator comments.
+const (
+	xliffNoteHeaderComment = "po-header-comment"
+	xliffNoteHeader        = "po-header"
+	xliffNoteMsgidPlural   = "po-msgid-plural"
+	xliffNoteTranslator    = "translator"
+	xliffNoteExtracted     = "developer"
+	xliffNoteReference     = "po-reference"
+	xliffNoteFlags         = "po-flags"
+	xliffNotePrevious      = "po-previous"
+	xliffNoteComment       = "po-comment"
+)
+
+// xliffCommentPrefixes maps note categories to the prefix of PO comment lines.
+// Lines without the canonical "prefix + space" form are kept verbatim (xliffNoteComment).
+var xliffCommentPrefixes = []struct {
+	category, prefix string
+}{
+	{xliffNoteExtracted, "#. "},
+	{xliffNoteReference, "#: "},
+	{xliffNoteFlags, "#, "},
+	{xliffNotePrevious, "#| "},
+	{xliffNoteTranslator, "# "},
+}
+
+// xliffNoteFromComment returns the note category and text for a PO comment line.
+func xliffNoteFromComment(line string) (category, text string) {
+	line = strings.TrimSuffix(line, "\n")
+	if line == "#" {
+		return xliffNoteTranslator, ""
+	}
+	for _, p := range xliffCommentPrefixes {
+		if strings.HasPrefix(line, p.prefix) {
+			return p.category, line[len(p.prefix):]
+		}
+	}
+	return xliffNoteComment, line
+}
+
+// xliffCommentsFromNote returns the PO comment lines for a note. Multi-line
+// notes of unknown category become one translator comment per line.
+func xliffCommentsFromNote(category, text string) []string {
+	if category == xliffNoteComment {
+		return []string{text}
+	}
+	for _, p := range xliffCommentPrefixes {
+		if p.category == category && p.category != xliffNoteTranslator {
+			return []string{p.prefix + text}
+		}
+	}
+	var comments []string
+	for _, line := range strings.Split(text, "\n") {
+		if line == "" {
+			comments = append(comments, "#")
+		} else {
+			comments = append(comments, "# "+line)
+		}
+	}
+	return comments
+}
+
+// IsXLIFFData returns true if data looks like an XLIFF document (an XML document
+// whose root element is <xliff>).
+func IsXLIFFData(data []byte) bool {
+	trimmed := bytes.TrimLeft(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")), " \t\r\n")
+	if len(trimmed) == 0 || trimmed[0] != '<' {
+		return false
+	}
+	if len(trimmed) > 1024 {
+		trimmed = trimmed[:1024]
+	}
+	return bytes.Contains(trimmed, []byte("<xliff"))
+}
+
+// xliffWriter writes XLIFF markup with two-space indentation.
+type xliffWriter struct {
+	b       strings.Builder
+	version string
+	// cp numbers the <x/> placeholders of control characters in XLIFF 1.2.
+	cp int
+}
+
+func (xw *xliffWriter) line(depth int, s string) {
+	xw.b.WriteString(strings.Repeat("  ", depth))
+	xw.b.WriteString(s)
+	xw.b.WriteByte('\n')
+}
+
+// xliffAttr returns ` name="value"` with value escaped.
+func xliffAttr(name, value string) string {
+	return " " + name + "=\"" + xliffEscape(value, true) + "\""
+}
+
+// xliffEscape escapes s for XML character data (or attribute values when attr
+// is true). Carriage returns are written as character references, otherwise
+// XML parsers would normalize them to newlines.
+func xliffEscape(s string, attr bool) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '&':
+			b.WriteString("&amp;")
+		case '<':
+			b.WriteString("&lt;")
+		case '>':
+			b.WriteString("&gt;")
+		case '"':
+			if attr {
+				b.WriteString("&quot;")
+			} else {
+				b.WriteRune(r)
+			}
+		case '\r':
+			b.WriteString("&#13;")
+		case '\n', '\t':
+			if attr {
+				fmt.Fprintf(&b, "&#%d;", r)
+			} else {
+				b.WriteRune(r)
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// inline returns the XLIFF inline content for s (real characters, not PO format).
+// Characters not allowed in XML 1.0 are written as <cp hex="..."/> in XLIFF 2.0
+// and as <x ctype="x-char-..."/> placeholders in XLIFF 1.2.
+func (xw *xliffWriter) inline(s string) string {
+	var b strings.Builder
+	start := 0
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			// Invalid UTF-8: keep the byte value.
+			r = rune(s[i])
+		} else if isXMLChar(r) {
+			i += size
+			continue
+		}
+		b.WriteString(xliffEscape(s[start:i], false))
+		if xw.version == XLIFFVersion12 {
+			xw.cp++
+			fmt.Fprintf(&b, `<x id="cp%d" ctype="x-char-%04X"/>`, xw.cp, r)
+		} else {
+			fmt.Fprintf(&b, `<cp hex="%04X"/>`, r)
+		}
+		i += size
+		start = i
+	}
+	b.WriteString(xliffEscape(s[start:], false))
+	return b.String()
+}
+
+// isXMLChar reports whether r may appear in an XML 1.0 document.
+func isXMLChar(r rune) bool {
+	return r == '\t' || r == '\n' || r == '\r' ||
+		r >= 0x20 && r <= 0xD7FF ||
+		r >= 0xE000 && r <= 0xFFFD ||
+		r >= 0x10000 && r <= 0x10FFFF
+}
+
+// notes writes one note per comment line (and extra notes) at depth.
+func (xw *xliffWriter) notes(depth int, comments []string, extra ...[2]string) {
+	var notes [][2]string
+	for _, c := range comments {
+		category, text := xliffNoteFromComment(c)
+		notes = append(notes, [2]string{category, text})
+	}
+	notes = append(notes, extra...)
+	if len(notes) == 0 {
+		return
+	}
+	attr := "from"
+	if xw.version == XLIFFVersion20 {
+		attr = "category"
+		xw.line(depth, "<notes>")
+		depth++
+	}
+	for _, n := range notes {
+		xw.line(depth, "<note"+xliffAttr(attr, n[0])+">"+xw.inline(n[1])+"</note>")
+	}
+	if xw.version == XLIFFVersion20 {
+		xw.line(depth-1, "</notes>")
+	}
+}
+
+// unit writes one translation unit: source is msgid (or msgid_plural), target
+// is msgstr; both in PO format.
+func (xw *xliffWriter) unit(depth int, id string, ctxt *string, e *GettextEntry, source, target string, comments []string) {
+	hasTarget := target != "" || e.Fuzzy
+	attrs := xliffAttr("id", id)
+	if ctxt != nil {
+		if xw.version == XLIFFVersion12 {
+			attrs += xliffAttr("resname", poUnescape(*ctxt))
+		} else {
+			attrs += xliffAttr("name", poUnescape(*ctxt))
+		}
+	}
+	if e.Obsolete {
+		attrs += xliffAttr("translate", "no")
+	}
+	source = xw.inline(poUnescape(source))
+	target = xw.inline(poUnescape(target))
+
+	if xw.version == XLIFFVersion12 {
+		xw.line(depth, "<trans-unit"+attrs+` xml:space="preserve">`)
+		xw.line(depth+1, "<source>"+source+"</source>")
+		if hasTarget {
+			state := "translated"
+			if e.Fuzzy {
+				state = "needs-review-translation"
+			}
+			xw.line(depth+1, "<target"+xliffAttr("state", state)+">"+target+"</target>")
+		}
+		xw.notes(depth+1, comments)
+		xw.line(depth, "</trans-unit>")
+		return
+	}
+	xw.line(depth, "<unit"+attrs+">")
+	xw.notes(depth+1, comments)
+	switch {
+	case !hasTarget:
+		xw.line(depth+1, "<segment>")
+	case e.Fuzzy:
+		xw.line(depth+1, `<segment state="initial">`)
+	default:
+		xw.line(depth+1, `<segment state="translated">`)
+	}
+	xw.line(depth+2, "<source>"+source+"</source>")
+	if hasTarget {
+		xw.line(depth+2, "<target>"+target+"</target>")
+	}
+	xw.line(depth+1, "</segment>")
+	xw.line(depth, "</unit>")
+}
+
+// entry writes entry number n. Plural entries become a group with one unit per
+// msgstr[] form; the source of form 0 is msgid and of other forms msgid_plural.
+func (xw *xliffWriter) entry(depth, n int, e *GettextEntry) {
+	id := strconv.Itoa(n)
+	if e.MsgIDPlural == "" {
+		xw.unit(depth, id, e.MsgCtxt, e, e.MsgID, e.MsgStrSingle(), e.Comments)
+		return
+	}
+	attrs := xliffAttr("id", id)
+	if e.MsgCtxt != nil {
+		if xw.version == XLIFFVersion12 {
+			attrs += xliffAttr("resname", poUnescape(*e.MsgCtxt))
+		} else {
+			attrs += xliffAttr("name", poUnescape(*e.MsgCtxt))
+		}
+	}
+	if xw.version == XLIFFVersion12 {
+		attrs += xliffAttr("restype", xliff12PluralsRestype)
+	} else {
+		attrs += xliffAttr("type", xliff20PluralsType)
+	}
+	if e.Obsolete {
+		attrs += xliffAttr("translate", "no")
+	}
+	xw.line(depth, "<group"+attrs+">")
+	var extra [][2]string
+	if len(e.MsgStr) < 2 {
+		// msgid_plural is not the source of any unit (e.g. nplurals=1).
+		extra = append(extra, [2]string{xliffNoteMsgidPlural, poUnescape(e.MsgIDPlural)})
+	}
+	xw.notes(depth+1, e.Comments, extra...)
+	msgstr := e.MsgStr
+	if len(msgstr) == 0 {
+		msgstr = []string{""}
+	}
+	for i, s := range msgstr {
+		source := e.MsgID
+		if i > 0 {
+			source = e.MsgIDPlural
+		}
+		unitID := fmt.Sprintf("%d[%d]", n, i)
+		if xw.version == XLIFFVersion20 {
+			// XLIFF 2.0 ids are NMTOKENs.
+			unitID = fmt.Sprintf("%d-%d", n, i)
+		}
+		xw.unit(depth+1, unitID, nil, e, source, s, nil)
+	}
+	xw.line(depth, "</group>")
+}
+
+// WriteGettextJSONToXLIFF writes j as an XLIFF document of the given version
+// (XLIFFVersion12 or XLIFFVersion20) to w. original names the catalog in the
+// <file> element. The PO header is kept in file-level notes, comments in notes,
+// msgctxt in resname (1.2) or name (2.0), plural forms in a group of units, fuzzy
+// in the target state and obsolete entries as translate="no", so that
+// ParseXLIFF gives back the same entries.
+func WriteGettextJSONToXLIFF(j *GettextJSON, w io.Writer, version, original string) error {
+	if version == "" {
+		version = XLIFFVersion12
+	}
+	if version != XLIFFVersion12 && version != XLIFFVersion20 {
+		return fmt.Errorf("unsupported XLIFF version %q (use %s or %s)", version, XLIFFVersion12, XLIFFVersion20)
+	}
+	if j == nil {
+		j = &GettextJSON{}
+	}
+	if original == "" {
+		original = "messages.po"
+	}
+	lang := (&GettextPO{HeaderEntry: GettextEntry{MsgStr: []string{j.HeaderMeta}}}).GetMeta("Language")
+
+	xw := &xliffWriter{version: version}
+	xw.line(0, `<?xml version="1.0" encoding="UTF-8"?>`)
+	var header [][2]string
+	if j.HeaderComment != "" {
+		header = append(header, [2]string{xliffNoteHeaderComment, j.HeaderComment})
+	}
+	if j.HeaderMeta != "" {
+		header = append(header, [2]string{xliffNoteHeader, poUnescape(j.HeaderMeta)})
+	}
+	if version == XLIFFVersion12 {
+		xw.line(0, "<xliff"+xliffAttr("version", version)+xliffAttr("xmlns", xliff12Namespace)+">")
+		attrs := xliffAttr("original", original) + xliffAttr("source-language", "en")
+		if lang != "" {
+			attrs += xliffAttr("target-language", lang)
+		}
+		xw.line(1, "<file"+attrs+xliffAttr("datatype", "po")+">")
+		if len(header) > 0 {
+			xw.line(2, "<header>")
+			xw.notes(3, nil, header...)
+			xw.line(2, "</header>")
+		}
+		xw.line(2, "<body>")
+		for i := range j.Entries {
+			xw.entry(3, i+1, &j.Entries[i])
+		}
+		xw.line(2, "</body>")
+	} else {
+		attrs := xliffAttr("version", version) + xliffAttr("xmlns", xliff20Namespace) + xliffAttr("srcLang", "en")
+		if lang != "" {
+			attrs += xliffAttr("trgLang", lang)
+		}
+		xw.line(0, "<xliff"+attrs+">")
+		xw.line(1, "<file"+xliffAttr("id", "f1")+xliffAttr("original", original)+` xml:space="preserve">`)
+		xw.notes(2, nil, header...)
+		for i := range j.Entries {
+			xw.entry(2, i+1, &j.Entries[i])
+		}
+	}
+	xw.line(1, "</file>")
+	xw.line(0, "</xliff>")
+	_, err := io.WriteString(w, xw.b.String())
+	return err
+}
+
+// xmlNode is an element of a parsed XML document. Character data and child
+// elements are kept in document order, since XLIFF source and target mix both.
+type xmlNode struct {
+	name     string
+	attrs    map[string]string
+	children []*xmlNode
+	// text is the character data of a text node (name is empty).
+	text string
+}
+
+func (n *xmlNode) attr(name string) (string, bool) {
+	v, ok := n.attrs[name]
+	return v, ok
+}
+
+func (n *xmlNode) elements(name string) []*xmlNode {
+	var out []*xmlNode
+	for _, c := range n.children {
+		if c.name == name {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func (n *xmlNode) element(name string) *xmlNode {
+	for _, c := range n.children {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// content returns the text of an XLIFF inline content element: character data
+// of the element and its descendants, with <cp/> and x-char placeholders
+// decoded. Other inline codes are reduced to their text.
+func (n *xmlNode) content() string {
+	var b strings.Builder
+	var walk func(*xmlNode)
+	walk = func(n *xmlNode) {
+		for _, c := range n.children {
+			switch c.name {
+			case "":
+				b.WriteString(c.text)
+				continue
+			case "cp":
+				if v, err := strconv.ParseUint(c.attrs["hex"], 16, 32); err == nil {
+					b.WriteRune(rune(v))
+				}
+				continue
+			case "x":
+				if hex := strings.TrimPrefix(c.attrs["ctype"], "x-char-"); hex != c.attrs["ctype"] {
+					if v, err := strconv.ParseUint(hex, 16, 32); err == nil {
+						b.WriteRune(rune(v))
+					}
+					continue
+				}
+			}
+			walk(c)
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+// parseXMLTree parses data into a tree of xmlNode and returns the root element.
+// Namespaces are ignored: elements and attributes are keyed by local name.
+func parseXMLTree(data []byte) (*xmlNode, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = true
+	var (
+		root  *xmlNode
+		stack []*xmlNode
+	)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &xmlNode{name: t.Name.Local, attrs: make(map[string]string, len(t.Attr))}
+			for _, a := range t.Attr {
+				n.attrs[a.Name.Local] = a.Value
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+			} else if root == nil {
+				root = n
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, &xmlNode{text: string(t)})
+			}
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("no root element")
+	}
+	return root, nil
+}
+
+// xliffNotes returns the notes of n (1.2: <note from>, 2.0: <notes><note category>).
+func xliffNotes(n *xmlNode, version string) [][2]string {
+	var out [][2]string
+	parent, attr := n, "from"
+	if version != XLIFFVersion12 {
+		if parent = n.element("notes"); parent == nil {
+			return nil
+		}
+		attr = "category"
+	}
+	for _, note := range parent.elements("note") {
+		out = append(out, [2]string{note.attrs[attr], note.content()})
+	}
+	return out
+}
+
+// xliffEntryNotes sets the comments of e from notes, and msgid_plural from a
+// po-msgid-plural note.
+func xliffEntryNotes(e *GettextEntry, notes [][2]string) {
+	e.Comments = []string{}
+	for _, n := range notes {
+		if n[0] == xliffNoteMsgidPlural {
+			e.MsgIDPlural = jsonDecodedToPoFormat(n[1])
+			continue
+		}
+		e.Comments = append(e.Comments, xliffCommentsFromNote(n[0], n[1])...)
+	}
+}
+
+// xliffUnitTarget returns source, target and fuzzy state of a 1.2 trans-unit or
+// 2.0 unit. Fuzzy is a 1.2 target in a needs-* state (or "new" with text), or a
+// 2.0 target in a segment with the "initial" state.
+func xliffUnitTarget(u *xmlNode, version string) (source, target string, fuzzy bool) {
+	if version == XLIFFVersion12 {
+		if s := u.element("source"); s != nil {
+			source = s.content()
+		}
+		t := u.element("target")
+		if t == nil {
+			return source, "", false
+		}
+		target = t.content()
+		switch state := t.attrs["state"]; state {
+		case "", "translated", "final", "signed-off":
+		case "new", "needs-translation":
+			fuzzy = target != ""
+		default:
+			fuzzy = true
+		}
+		return source, target, fuzzy
+	}
+	// XLIFF 2.0: the unit text is the concatenation of its segments.
+	hasTarget := false
+	for _, c := range u.children {
+		if c.name != "segment" && c.name != "ignorable" {
+			continue
+		}
+		if s := c.element("source"); s != nil {
+			source += s.content()
+		}
+		if t := c.element("target"); t != nil {
+			hasTarget = true
+			target += t.content()
+			if state := c.attrs["state"]; c.name == "segment" && (state == "" || state == "initial") {
+				fuzzy = true
+			}
+		}
+	}
+	return source, target, fuzzy && hasTarget
+}
+
+// xliffEntries converts the units and groups below n into entries.
+func xliffEntries(n *xmlNode, version string, entries *[]GettextEntry) {
+	ctxtAttr, unitName, pluralAttr, pluralValue := "resname", "trans-unit", "restype", xliff12PluralsRestype
+	if version != XLIFFVersion12 {
+		ctxtAttr, unitName, pluralAttr, pluralValue = "name", "unit", "type", xliff20PluralsType
+	}
+	for _, c := range n.children {
+		switch {
+		case c.name == unitName:
+			source, target, fuzzy := xliffUnitTarget(c, version)
+			e := GettextEntry{
+				MsgID:    jsonDecodedToPoFormat(source),
+				MsgStr:   []string{jsonDecodedToPoFormat(target)},
+				Fuzzy:    fuzzy,
+				Obsolete: c.attrs["translate"] == "no",
+			}
+			if ctxt, ok := c.attr(ctxtAttr); ok {
+				ctxt = jsonDecodedToPoFormat(ctxt)
+				e.MsgCtxt = &ctxt
+			}
+			xliffEntryNotes(&e, xliffNotes(c, version))
+			*entries = append(*entries, e)
+		case c.name == "group" && c.attrs[pluralAttr] == pluralValue:
+			e := GettextEntry{Obsolete: c.attrs["translate"] == "no"}
+			if ctxt, ok := c.attr(ctxtAttr); ok {
+				ctxt = jsonDecodedToPoFormat(ctxt)
+				e.MsgCtxt = &ctxt
+			}
+			xliffEntryNotes(&e, xliffNotes(c, version))
+			for i, u := range c.elements(unitName) {
+				source, target, fuzzy := xliffUnitTarget(u, version)
+				switch i {
+				case 0:
+					e.MsgID = jsonDecodedToPoFormat(source)
+				case 1:
+					e.MsgIDPlural = jsonDecodedToPoFormat(source)
+				}
+				e.MsgStr = append(e.MsgStr, jsonDecodedToPoFormat(target))
+				e.Fuzzy = e.Fuzzy || fuzzy
+			}
+			*entries = append(*entries, e)
+		case c.name == "group" || c.name == "body":
+			xliffEntries(c, version, entries)
+		}
+	}
+}
+
+// ParseXLIFF decodes an XLIFF 1.2 or 2.0 document into GettextJSON (strings in
+// PO format). The header comes from the po-header notes of the first <file>.
+func ParseXLIFF(data []byte) (*GettextJSON, error) {
+	root, err := parseXMLTree(data)
+	if err != nil {
+		return nil, err
+	}
+	if root.name != "xliff" {
+		return nil, fmt.Errorf("root element is <%s>, not <xliff>", root.name)
+	}
+	version := XLIFFVersion12
+	if v := root.attrs["version"]; strings.HasPrefix(v, "2.") {
+		version = XLIFFVersion20
+	} else if v != "" && !strings.HasPrefix(v, "1.") {
+		return nil, fmt.Errorf("unsupported XLIFF version %q", v)
+	}
+	j := &GettextJSON{Entries: []GettextEntry{}}
+	for i, file := range root.elements("file") {
+		if i == 0 {
+			headerNode := file
+			if version == XLIFFVersion12 {
+				headerNode = file.element("header")
+			}
+			if headerNode != nil {
+				for _, n := range xliffNotes(headerNode, version) {
+					switch n[0] {
+					case xliffNoteHeaderComment:
+						j.HeaderComment = n[1]
+					case xliffNoteHeader:
+						j.HeaderMeta = jsonDecodedToPoFormat(n[1])
+					}
+				}
+			}
+		}
+		xliffEntries(file, version, &j.Entries)
+	}
+	return j, nil
+}
@@ -0,0 +1,223 @@
+package util
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const xliffTestPO = `# Chinese translations for Git package
+# Copyright (C) 2024 THE Git'S COPYRIGHT HOLDER
+#
+msgid ""
+msgstr ""
+"Project-Id-Version: git\n"
+"Language: zh_CN\n"
+"Content-Type: text/plain; charset=UTF-8\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+# Translator note
+#. TRANSLATORS: keep <tags> & "quotes"
+#: src/a.c:1 src/b.c:2
+#, c-format
+msgid ""
+"Line one\n"
+"Line two\twith tab\r\n"
+"a \"quote\" and a back\\slash <b>&amp;</b> {BEL}"
+msgstr ""
+"第1行\n"
+"第2行\t带制表符\r\n"
+"一个\"引号\"和反\\斜线 <b>&amp;</b> {BEL}"
+
+#
+#.no-space comment
+msgctxt "verb"
+msgid "commit"
+msgstr "提交"
+
+msgctxt ""
+msgid "empty context"
+msgstr ""
+
+#, fuzzy, c-format
+#| msgid "%d old file"
+#| msgid_plural "%d old files"
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] "%d 个文件"
+msgstr[1] "%d 个文件们"
+
+#, fuzzy
+msgid "fuzzy and empty"
+msgstr ""
+
+#~ msgid "old"
+#~ msgstr "旧"
+
+#~ msgctxt "ctx"
+#~ msgid "old %d plural"
+#~ msgid_plural "old %d plurals"
+#~ msgstr[0] "旧"
+#~ msgstr[1] "旧们"
+`
+
+func TestXLIFFRoundTrip(t *testing.T) {
+	content := strings.ReplaceAll(xliffTestPO, "{BEL}", "\a")
+	po, err := ParsePoEntries([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	j := GettextJSONFromGettextPO(po)
+	var wantJSON bytes.Buffer
+	if err := WriteGettextJSONToJSON(j, &wantJSON); err != nil {
+		t.Fatal(err)
+	}
+	for _, version := range []string{XLIFFVersion12, XLIFFVersion20} {
+		t.Run(version, func(t *testing.T) {
+			var xlf bytes.Buffer
+			if err := WriteGettextJSONToXLIFF(j, &xlf, version, "zh_CN.po"); err != nil {
+				t.Fatal(err)
+			}
+			if !IsXLIFFData(xlf.Bytes()) {
+				t.Fatalf("output not detected as XLIFF:\n%s", xlf.String())
+			}
+			back, err := LoadFileToGettextJSON(xlf.Bytes(), "zh_CN.xlf")
+			if err != nil {
+				t.Fatalf("%v\n%s", err, xlf.String())
+			}
+			var gotPO bytes.Buffer
+			if err := WriteGettextJSONToPO(back, &gotPO, false, false); err != nil {
+				t.Fatal(err)
+			}
+			if gotPO.String() != content {
+				t.Errorf("PO -> XLIFF -> PO differs:\n%s\nXLIFF:\n%s", gotPO.String(), xlf.String())
+			}
+			var gotJSON bytes.Buffer
+			if err := WriteGettextJSONToJSON(back, &gotJSON); err != nil {
+				t.Fatal(err)
+			}
+			if gotJSON.String() != wantJSON.String() {
+				t.Errorf("PO -> XLIFF -> JSON differs:\n%s\nwant:\n%s", gotJSON.String(), wantJSON.String())
+			}
+		})
+	}
+}
+
+func TestXLIFFSinglePluralForm(t *testing.T) {
+	j := &GettextJSON{Entries: []GettextEntry{{
+		MsgID:       "%d file",
+		MsgIDPlural: "%d files",
+		MsgStr:      []string{"%d 个文件"},
+		Comments:    []string{},
+	}}}
+	for _, version := range []string{XLIFFVersion12, XLIFFVersion20} {
+		var xlf bytes.Buffer
+		if err := WriteGettextJSONToXLIFF(j, &xlf, version, ""); err != nil {
+			t.Fatal(err)
+		}
+		back, err := ParseXLIFF(xlf.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(back.Entries) != 1 || back.Entries[0].MsgIDPlural != "%d files" ||
+			!equalStrings(back.Entries[0].MsgStr, []string{"%d 个文件"}) || len(back.Entries[0].Comments) != 0 {
+			t.Errorf("XLIFF %s: got %+v\n%s", version, back.Entries, xlf.String())
+		}
+	}
+}
+
+func TestParseXLIFF_CATTool(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []GettextEntry
+	}{
+		{
+			name: "XLIFF 1.2 with segmentation markup and states",
+			data: `<?xml version="1.0" encoding="UTF-8"?>
+<xliff version="1.2" xmlns="urn:oasis:names:tc:xliff:document:1.2">
+  <file original="de.po" source-language="en" target-language="de" datatype="po">
+    <body>
+      <group id="g1">
+        <trans-unit id="a">
+          <source>Hello</source>
+          <seg-source><mrk mtype="seg" mid="1">Hello</mrk></seg-source>
+          <target state="translated"><mrk mtype="seg" mid="1">Hallo</mrk></target>
+          <note>Checked
+by reviewer</note>
+        </trans-unit>
+      </group>
+      <trans-unit id="b">
+        <source>Bye</source>
+        <target state="needs-translation">Tschüss</target>
+      </trans-unit>
+      <trans-unit id="c">
+        <source>Open</source>
+        <target state="new"></target>
+      </trans-unit>
+    </body>
+  </file>
+</xliff>
+`,
+			want: []GettextEntry{
+				{MsgID: "Hello", MsgStr: []string{"Hallo"}, Comments: []string{"# Checked", "# by reviewer"}},
+				{MsgID: "Bye", MsgStr: []string{"Tschüss"}, Fuzzy: true, Comments: []string{}},
+				{MsgID: "Open", MsgStr: []string{""}, Comments: []string{}},
+			},
+		},
+		{
+			name: "XLIFF 2.0 with several segments and states",
+			data: `<?xml version="1.0" encoding="UTF-8"?>
+<xliff version="2.0" xmlns="urn:oasis:names:tc:xliff:document:2.0" srcLang="en" trgLang="de">
+  <file id="f1">
+    <unit id="u1">
+      <segment state="reviewed"><source>One. </source><target>Eins. </target></segment>
+      <segment state="final"><source>Two.</source><target>Zwei.</target></segment>
+    </unit>
+    <unit id="u2">
+      <segment><source>Draft<cp hex="0007"/></source><target>Entwurf<cp hex="0007"/></target></segment>
+    </unit>
+  </file>
+</xliff>
+`,
+			want: []GettextEntry{
+				{MsgID: "One. Two.", MsgStr: []string{"Eins. Zwei."}, Comments: []string{}},
+				{MsgID: "Draft\a", MsgStr: []string{"Entwurf\a"}, Fuzzy: true, Comments: []string{}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j, err := ParseXLIFF([]byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(j.Entries) != len(tt.want) {
+				t.Fatalf("got %d entries, want %d: %+v", len(j.Entries), len(tt.want), j.Entries)
+			}
+			for i, want := range tt.want {
+				got := j.Entries[i]
+				if got.MsgID != want.MsgID || !equalStrings(got.MsgStr, want.MsgStr) ||
+					got.Fuzzy != want.Fuzzy || !equalStrings(got.Comments, want.Comments) {
+					t.Errorf("entry %d = %+v, want %+v", i+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseXLIFF_Errors(t *testing.T) {
+	for _, data := range []string{
+		`<xliff version="1.2"><file><body><trans-unit id="1"><source>a</source></body></file></xliff>`,
+		`<?xml version="1.0"?><html></html>`,
+		`<xliff version="3.0"></xliff>`,
+	} {
+		if _, err := ParseXLIFF([]byte(data)); err == nil {
+			t.Errorf("ParseXLIFF(%q) = nil error", data)
+		}
+	}
+	if err := WriteGettextJSONToXLIFF(nil, &bytes.Buffer{}, "1.1", ""); err == nil ||
+		!strings.Contains(err.Error(), "unsupported XLIFF version") {
+		t.Errorf("WriteGettextJSONToXLIFF(version 1.1) = %v", err)
+	}
+}